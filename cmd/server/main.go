@@ -1,6 +1,7 @@
 package main
 
 import (
+	"awesomeProject/internal/models"
 	"awesomeProject/internal/router"
 	"awesomeProject/internal/services"
 	"context"
@@ -12,6 +13,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"awesomeProject/internal/handlers"
@@ -93,17 +95,262 @@ func main() {
 	restaurantsCollectionName := "restaurants"
 
 	// Инициализация сервисов
-	userService := services.NewEntityService(client, "food", usersCollectionName)
-	restaurantService := services.NewEntityService(client, "food", restaurantsCollectionName)
 	redisService := services.NewRedisService()
+	eventBus := services.NewEventBus(client, "food", redisService)
+	jobRunner := services.NewJobRunner(client, "food", redisService)
+	userService := services.NewEntityService(client, "food", usersCollectionName, eventBus)
+	restaurantService := services.NewEntityService(client, "food", restaurantsCollectionName, eventBus)
+	reviewService := services.NewReviewService(client, "food", services.ModerationRulesFromEnv(), eventBus)
+	moderationService := services.NewModerationService(client, "food", reviewService)
+	geocoder, err := services.NewGeocoderFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize geocoder: %v", err)
+	}
+	geoService := services.NewGeoService(client, "food", geocoder)
+	searchIndex := services.NewMongoSearchIndex(client, "food")
+	searchService := services.NewSearchService(client, "food", searchIndex)
+	notificationChannels, err := services.NewNotificationChannelsFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize notification channels: %v", err)
+	}
+	notifier := services.NewNotifier(client, "food", notificationChannels)
+	notificationService := services.NewNotificationService(client, "food", notifier)
+	passwordResetService := services.NewPasswordResetService(client, "food", notifier)
+	friendService := services.NewFriendService(client, "food", notificationService)
+	meetupService := services.NewMeetupService(client, "food", friendService, notificationService)
+	pollService := services.NewPollService(client, "food", friendService, meetupService, notificationService, jobRunner)
+	orderEvents := services.NewOrderEvents(redisService)
+	webhookService := services.NewWebhookService(client, "food")
+	orderService := services.NewOrderService(client, "food", orderEvents, notificationService, webhookService)
+	groupOrderService := services.NewGroupOrderService(client, "food", notificationService, eventBus)
+	kitchenService := services.NewKitchenService(client, "food", orderService)
+	deliveryService := services.NewDeliveryService(client, "food", geoService, orderService)
+	addressService := services.NewAddressService(client, "food")
+	reservationService := services.NewReservationService(client, "food", notificationService)
+	waitlistService := services.NewWaitlistService(client, "food", notificationService)
+	activityService := services.NewActivityService(client, "food", friendService, redisService)
+	recommendationService := services.NewRecommendationService(client, "food", friendService)
+	favoriteListService := services.NewFavoriteListService(client, "food", friendService)
+	chatService := services.NewChatService(client, "food", friendService, meetupService, services.NewChatHub())
+	blobStore, err := services.NewBlobStoreFromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize media store: %v", err)
+	}
+	mediaService := services.NewMediaService(client, "food", blobStore, redisService)
+	galleryService := services.NewGalleryService(client, "food", mediaService, reviewService)
+
+	// Создание индексов
+	if err := eventBus.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create domain event indexes: %v", err)
+	}
+	if err := jobRunner.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create job indexes: %v", err)
+	}
+	if err := eventBus.DetectTransactions(context.Background()); err != nil {
+		log.Fatalf("Failed to check MongoDB transactions support: %v", err)
+	}
+	if err := reviewService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create review indexes: %v", err)
+	}
+	if err := moderationService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create moderation indexes: %v", err)
+	}
+	if err := restaurantService.EnsureRestaurantIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create restaurant indexes: %v", err)
+	}
+	if err := userService.EnsureEntityIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create entity indexes: %v", err)
+	}
+	// Роль администратора выдается только здесь, ее нельзя получить через API
+	if emails := strings.Fields(strings.ReplaceAll(os.Getenv("ADMIN_EMAILS"), ",", " ")); len(emails) > 0 {
+		granted, err := userService.GrantAdminRoles(context.Background(), emails)
+		if err != nil {
+			log.Fatalf("Failed to grant admin roles: %v", err)
+		}
+		log.Printf("Admin role granted to %d of %d ADMIN_EMAILS users", granted, len(emails))
+	}
+	if err := geoService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create geo indexes: %v", err)
+	}
+	if err := searchIndex.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create search indexes: %v", err)
+	}
+	if err := friendService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create friendship indexes: %v", err)
+	}
+	if err := notificationService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create notification indexes: %v", err)
+	}
+	if err := notifier.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create notification outbox indexes: %v", err)
+	}
+	if err := passwordResetService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create password reset indexes: %v", err)
+	}
+	if err := meetupService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create meetup indexes: %v", err)
+	}
+	if err := pollService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create poll indexes: %v", err)
+	}
+	if err := groupOrderService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create group order indexes: %v", err)
+	}
+	if err := reservationService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create reservation indexes: %v", err)
+	}
+	if err := waitlistService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create waitlist indexes: %v", err)
+	}
+	if err := activityService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create activity indexes: %v", err)
+	}
+	if err := favoriteListService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create favorite list indexes: %v", err)
+	}
+	if err := chatService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create chat indexes: %v", err)
+	}
+	if err := webhookService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create webhook indexes: %v", err)
+	}
+	if err := orderService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create order indexes: %v", err)
+	}
+	if err := kitchenService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create kitchen indexes: %v", err)
+	}
+	if err := deliveryService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create delivery indexes: %v", err)
+	}
+	if err := galleryService.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("Failed to create gallery indexes: %v", err)
+	}
+
+	// Подписчики доменных событий и их публикация из исходящей очереди
+	eventBus.Subscribe(models.DomainEventRestaurantRegistered, "search_index", searchService.HandleRestaurantRegistered)
+	eventBus.Subscribe(models.DomainEventReviewPosted, "activity", activityService.HandleReviewPosted)
+	eventBus.Subscribe(models.DomainEventReviewDeleted, "gallery", galleryService.HandleReviewDeleted)
+	eventBus.Subscribe(models.DomainEventOrderPlaced, "order_events", orderService.PublishOrderPlaced)
+	eventBus.Subscribe(models.DomainEventOrderPlaced, "webhooks", orderService.EnqueueOrderPlacedWebhooks)
+	relayInterval, err := time.ParseDuration(os.Getenv("EVENT_RELAY_INTERVAL"))
+	if err != nil || relayInterval <= 0 {
+		relayInterval = time.Second
+	}
+	go eventBus.RunRelay(context.Background(), relayInterval)
+
+	// Фоновые задачи: обработчики и расписания
+	jobRunner.Register(services.JobPollClose, pollService.HandleCloseJob, services.JobOptions{})
+	jobRunner.Register(services.JobSearchReindex, searchService.HandleReindexJob, services.JobOptions{
+		Timeout:   30 * time.Minute,
+		Exclusive: true,
+	})
+	jobRunner.Register(services.JobRecomputeRatings, reviewService.HandleRecomputeRatingsJob, services.JobOptions{
+		Timeout:   30 * time.Minute,
+		Exclusive: true,
+	})
+	for _, schedule := range []struct{ name, env, spec string }{
+		{services.JobSearchReindex, "SEARCH_REINDEX_CRON", "*/10 * * * *"},
+		{services.JobRecomputeRatings, "RATINGS_RECOMPUTE_CRON", "30 4 * * *"},
+	} {
+		spec := os.Getenv(schedule.env)
+		if spec == "" {
+			spec = schedule.spec
+		}
+		if err := jobRunner.Schedule(schedule.name, spec, schedule.name, nil); err != nil {
+			log.Fatalf("Failed to schedule %s: %v", schedule.name, err)
+		}
+	}
+	jobsInterval, err := time.ParseDuration(os.Getenv("JOBS_POLL_INTERVAL"))
+	if err != nil || jobsInterval <= 0 {
+		jobsInterval = time.Second
+	}
+	go jobRunner.Run(context.Background(), jobsInterval)
+
+	// Отправка уведомлений из очереди
+	outboxInterval, err := time.ParseDuration(os.Getenv("NOTIFY_OUTBOX_INTERVAL"))
+	if err != nil || outboxInterval <= 0 {
+		outboxInterval = 5 * time.Second
+	}
+	go notifier.RunOutbox(context.Background(), outboxInterval)
+
+	// Доставка вебхуков ресторанов
+	webhookInterval, err := time.ParseDuration(os.Getenv("WEBHOOK_DELIVERY_INTERVAL"))
+	if err != nil || webhookInterval <= 0 {
+		webhookInterval = 2 * time.Second
+	}
+	go webhookService.RunDeliveries(context.Background(), webhookInterval)
+
+	// Симуляция курьеров для локального запуска доставки
+	if os.Getenv("COURIER_SIMULATION") == "true" {
+		simulationInterval, err := time.ParseDuration(os.Getenv("COURIER_SIMULATION_INTERVAL"))
+		if err != nil || simulationInterval <= 0 {
+			simulationInterval = 2 * time.Second
+		}
+		go deliveryService.RunSimulation(context.Background(), simulationInterval)
+	}
 
 	// Инициализация обработчиков
-	userHandler := handlers.NewEntityHandler(userService, redisService)
-	restaurantHandler := handlers.NewEntityHandler(restaurantService, redisService)
+	userHandler := handlers.NewEntityHandler(userService, redisService, activityService, mediaService)
+	restaurantHandler := handlers.NewEntityHandler(restaurantService, redisService, activityService, mediaService)
 	authHandler := handlers.NewAuthHandler(userService, []byte(secretKey), refreshTokenSecret)
+	reviewHandler := handlers.NewReviewHandler(reviewService, galleryService)
+	moderationHandler := handlers.NewModerationHandler(moderationService)
+	geoHandler := handlers.NewGeoHandler(geoService, mediaService)
+	searchHandler := handlers.NewSearchHandler(searchService)
+	friendHandler := handlers.NewFriendHandler(friendService, mediaService)
+	meetupHandler := handlers.NewMeetupHandler(meetupService, activityService, chatService)
+	notificationHandler := handlers.NewNotificationHandler(notificationService, notifier)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	pollHandler := handlers.NewPollHandler(pollService)
+	groupOrderHandler := handlers.NewGroupOrderHandler(groupOrderService)
+	reservationHandler := handlers.NewReservationHandler(reservationService)
+	waitlistHandler := handlers.NewWaitlistHandler(waitlistService)
+	activityHandler := handlers.NewActivityHandler(activityService, mediaService)
+	recommendationHandler := handlers.NewRecommendationHandler(recommendationService, mediaService)
+	favoriteListHandler := handlers.NewFavoriteListHandler(favoriteListService, mediaService)
+	chatHandler := handlers.NewChatHandler(chatService)
+	orderHandler := handlers.NewOrderHandler(orderService, orderEvents)
+	kitchenHandler := handlers.NewKitchenHandler(kitchenService)
+	deliveryHandler := handlers.NewDeliveryHandler(deliveryService, addressService)
+	addressHandler := handlers.NewAddressHandler(addressService)
+	eventHandler := handlers.NewEventHandler(eventBus)
+	jobHandler := handlers.NewJobHandler(jobRunner)
+	mediaHandler := handlers.NewMediaHandler(mediaService)
+	galleryHandler := handlers.NewGalleryHandler(galleryService, mediaService)
 
 	// Настройка роутинга
-	r := router.InitializeRouter(userHandler, authHandler, restaurantHandler)
+	r := router.InitializeRouter(router.Handlers{
+		User:           userHandler,
+		Auth:           authHandler,
+		Restaurant:     restaurantHandler,
+		Review:         reviewHandler,
+		Gallery:        galleryHandler,
+		Moderation:     moderationHandler,
+		Geo:            geoHandler,
+		Search:         searchHandler,
+		Friend:         friendHandler,
+		Meetup:         meetupHandler,
+		Notification:   notificationHandler,
+		Poll:           pollHandler,
+		GroupOrder:     groupOrderHandler,
+		Reservation:    reservationHandler,
+		Waitlist:       waitlistHandler,
+		Activity:       activityHandler,
+		Recommendation: recommendationHandler,
+		FavoriteList:   favoriteListHandler,
+		Chat:           chatHandler,
+		Order:          orderHandler,
+		Kitchen:        kitchenHandler,
+		Delivery:       deliveryHandler,
+		Address:        addressHandler,
+		PasswordReset:  passwordResetHandler,
+		Webhook:        webhookHandler,
+		Event:          eventHandler,
+		Job:            jobHandler,
+		Media:          mediaHandler,
+	})
 	// Добавление маршрута для документации Swagger
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 	// Настройка и запуск HTTP сервера
@@ -29,12 +29,33 @@ func AuthMiddleware(secretKey []byte) func(http.Handler) http.Handler {
 	}
 }
 
-// extractToken извлекает токен JWT из заголовка Authorization
+// extractToken извлекает токен JWT из заголовка Authorization.
+// Браузеры не позволяют задать заголовки при открытии WebSocket и EventSource, поэтому
+// для таких запросов токен принимается и из параметра access_token.
 func extractToken(r *http.Request) string {
 	bearToken := r.Header.Get("Authorization")
 	strArr := strings.Split(bearToken, " ")
 	if len(strArr) == 2 {
 		return strArr[1]
 	}
+	if strings.EqualFold(r.Header.Get("Upgrade"), "websocket") ||
+		strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return r.URL.Query().Get("access_token")
+	}
 	return ""
 }
+
+// RequireRole создает промежуточное ПО, пропускающее только сущности с указанной ролью.
+// Должно подключаться после AuthMiddleware.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := r.Context().Value("userClaims").(*JWTClaims)
+			if !ok || claims == nil || claims.Roles != role {
+				http.Error(w, "Forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ActivityHandler структура для обработчиков ленты активности друзей
+type ActivityHandler struct {
+	activityService *services.ActivityService
+	mediaService    *services.MediaService
+}
+
+// NewActivityHandler создает новый экземпляр ActivityHandler
+func NewActivityHandler(activityService *services.ActivityService, mediaService *services.MediaService) *ActivityHandler {
+	return &ActivityHandler{
+		activityService: activityService,
+		mediaService:    mediaService,
+	}
+}
+
+// FeedHandler обрабатывает получение ленты действий друзей.
+// Следующая страница запрашивается параметром cursor из ответа.
+func (h *ActivityHandler) FeedHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	_, limit := parsePagination(r)
+	page, err := h.activityService.Feed(r.Context(), claims.UserID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), activityErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(page)
+	writeJSON(w, http.StatusOK, page)
+}
+
+// GetPrivacyHandler обрабатывает получение настроек видимости действий пользователя
+func (h *ActivityHandler) GetPrivacyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	privacy, err := h.activityService.GetPrivacy(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), activityErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, privacy)
+}
+
+// SetPrivacyHandler обрабатывает изменение видимости типов действий.
+// Тело запроса: {"check_in": "private", "review_posted": "friends"}.
+func (h *ActivityHandler) SetPrivacyHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req models.ActivityPrivacy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	privacy, err := h.activityService.SetPrivacy(r.Context(), claims.UserID, req)
+	if err != nil {
+		http.Error(w, err.Error(), activityErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, privacy)
+}
+
+// CheckInHandler обрабатывает отметку пользователя в ресторане
+func (h *ActivityHandler) CheckInHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	activity, err := h.activityService.CheckIn(r.Context(), claims.UserID, restaurantID)
+	if err != nil {
+		http.Error(w, err.Error(), activityErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, activity)
+}
+
+// activityErrorStatus сопоставляет ошибку сервиса активности с HTTP статусом
+func activityErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrUserNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidActivityType, services.ErrInvalidVisibility, services.ErrInvalidCursor:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// AddressHandler структура для обработчиков адресной книги пользователя
+type AddressHandler struct {
+	addressService *services.AddressService
+}
+
+// AddressRequest тело запроса на создание или изменение адреса.
+// Координаты передаются парой lat и lng или не передаются вовсе.
+type AddressRequest struct {
+	Label     string   `json:"label"`
+	City      string   `json:"city"`
+	Street    string   `json:"street"`
+	House     string   `json:"house"`
+	Apartment string   `json:"apartment"`
+	Entrance  string   `json:"entrance"`
+	Floor     string   `json:"floor"`
+	Intercom  string   `json:"intercom"`
+	Comment   string   `json:"comment"`
+	Lat       *float64 `json:"lat"`
+	Lng       *float64 `json:"lng"`
+	IsDefault bool     `json:"is_default"`
+}
+
+// NewAddressHandler создает новый экземпляр AddressHandler
+func NewAddressHandler(addressService *services.AddressService) *AddressHandler {
+	return &AddressHandler{
+		addressService: addressService,
+	}
+}
+
+// ListAddressesHandler обрабатывает получение адресов текущего пользователя
+func (h *AddressHandler) ListAddressesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	addresses, err := h.addressService.ListAddresses(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, addresses)
+}
+
+// CreateAddressHandler обрабатывает добавление адреса
+func (h *AddressHandler) CreateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	address, ok := decodeAddress(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.addressService.CreateAddress(r.Context(), claims.UserID, address)
+	if err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetAddressHandler обрабатывает получение адреса
+func (h *AddressHandler) GetAddressHandler(w http.ResponseWriter, r *http.Request) {
+	userID, addressID, ok := h.parseAddress(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := h.addressService.GetAddress(r.Context(), userID, addressID)
+	if err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, address)
+}
+
+// UpdateAddressHandler обрабатывает изменение адреса
+func (h *AddressHandler) UpdateAddressHandler(w http.ResponseWriter, r *http.Request) {
+	userID, addressID, ok := h.parseAddress(w, r)
+	if !ok {
+		return
+	}
+
+	address, ok := decodeAddress(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.addressService.UpdateAddress(r.Context(), userID, addressID, address)
+	if err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteAddressHandler обрабатывает удаление адреса
+func (h *AddressHandler) DeleteAddressHandler(w http.ResponseWriter, r *http.Request) {
+	userID, addressID, ok := h.parseAddress(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.addressService.DeleteAddress(r.Context(), userID, addressID); err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SetDefaultHandler обрабатывает выбор адреса по умолчанию
+func (h *AddressHandler) SetDefaultHandler(w http.ResponseWriter, r *http.Request) {
+	userID, addressID, ok := h.parseAddress(w, r)
+	if !ok {
+		return
+	}
+
+	address, err := h.addressService.SetDefault(r.Context(), userID, addressID)
+	if err != nil {
+		http.Error(w, err.Error(), addressErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, address)
+}
+
+// parseAddress извлекает ID текущего пользователя и ID адреса из пути
+func (h *AddressHandler) parseAddress(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	addressID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid address ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, addressID, true
+}
+
+// decodeAddress читает адрес из тела запроса
+func decodeAddress(w http.ResponseWriter, r *http.Request) (*models.Address, bool) {
+	var req AddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	if (req.Lat == nil) != (req.Lng == nil) {
+		http.Error(w, "lat and lng must be passed together", http.StatusBadRequest)
+		return nil, false
+	}
+
+	address := &models.Address{
+		Label:     req.Label,
+		City:      req.City,
+		Street:    req.Street,
+		House:     req.House,
+		Apartment: req.Apartment,
+		Entrance:  req.Entrance,
+		Floor:     req.Floor,
+		Intercom:  req.Intercom,
+		Comment:   req.Comment,
+		IsDefault: req.IsDefault,
+	}
+	if req.Lat != nil {
+		point := models.NewGeoPoint(*req.Lat, *req.Lng)
+		address.Location = &point
+	}
+	return address, true
+}
+
+// addressErrorStatus сопоставляет ошибку сервиса адресов с HTTP статусом
+func addressErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrSavedAddressNotFound, services.ErrUserNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidCoordinates:
+		return http.StatusBadRequest
+	case services.ErrAddressBookFull:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
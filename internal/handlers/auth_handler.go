@@ -48,6 +48,10 @@ func (h *EntityHandler) RegisterHandler(w http.ResponseWriter, r *http.Request,
 	}
 
 	entityID, err := h.entityService.Register(r.Context(), authEntity)
+	if err == services.ErrEmailTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/net/websocket"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// chatCommandTimeout ограничивает время обработки одной команды, пришедшей по WebSocket
+const chatCommandTimeout = 10 * time.Second
+
+// ChatHandler структура для обработчиков бесед и сообщений
+type ChatHandler struct {
+	chatService *services.ChatService
+}
+
+// DirectConversationRequest тело запроса на открытие личной беседы
+type DirectConversationRequest struct {
+	UserID primitive.ObjectID `json:"user_id"`
+}
+
+// GroupConversationRequest тело запроса на создание групповой беседы
+type GroupConversationRequest struct {
+	Title     string               `json:"title"`
+	MemberIDs []primitive.ObjectID `json:"member_ids"`
+}
+
+// AddMembersRequest тело запроса на добавление участников в беседу
+type AddMembersRequest struct {
+	UserIDs []primitive.ObjectID `json:"user_ids"`
+}
+
+// SendMessageRequest тело запроса на отправку сообщения
+type SendMessageRequest struct {
+	Text string `json:"text"`
+}
+
+// MarkReadMessageRequest тело запроса на отметку беседы прочитанной
+type MarkReadMessageRequest struct {
+	MessageID primitive.ObjectID `json:"message_id"`
+}
+
+// ChatCommand команда клиента, отправленная по WebSocket: send или read
+type ChatCommand struct {
+	Type           string             `json:"type"`
+	ConversationID primitive.ObjectID `json:"conversation_id"`
+	Text           string             `json:"text"`
+	MessageID      primitive.ObjectID `json:"message_id"`
+}
+
+// NewChatHandler создает новый экземпляр ChatHandler
+func NewChatHandler(chatService *services.ChatService) *ChatHandler {
+	return &ChatHandler{
+		chatService: chatService,
+	}
+}
+
+// DirectConversationHandler обрабатывает открытие личной беседы с другом
+func (h *ChatHandler) DirectConversationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req DirectConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	conversation, err := h.chatService.GetOrCreateDirect(r.Context(), claims.UserID, req.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversation)
+}
+
+// CreateGroupHandler обрабатывает создание групповой беседы
+func (h *ChatHandler) CreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req GroupConversationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	conversation, err := h.chatService.CreateGroup(r.Context(), claims.UserID, req.Title, req.MemberIDs)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, conversation)
+}
+
+// ListConversationsHandler обрабатывает получение бесед пользователя
+func (h *ChatHandler) ListConversationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	conversations, err := h.chatService.ListConversations(r.Context(), claims.UserID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get conversations", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversations)
+}
+
+// GetConversationHandler обрабатывает получение беседы
+func (h *ChatHandler) GetConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.chatService.GetConversation(r.Context(), userID, conversationID)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversation)
+}
+
+// AddMembersHandler обрабатывает добавление друзей в групповую беседу
+func (h *ChatHandler) AddMembersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	var req AddMembersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.UserIDs) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	conversation, err := h.chatService.AddMembers(r.Context(), userID, conversationID, req.UserIDs)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversation)
+}
+
+// LeaveHandler обрабатывает выход из групповой беседы
+func (h *ChatHandler) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.chatService.Leave(r.Context(), userID, conversationID); err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListMessagesHandler обрабатывает получение истории сообщений.
+// Более старые сообщения запрашиваются параметром before из ответа.
+func (h *ChatHandler) ListMessagesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	_, limit := parsePagination(r)
+	page, err := h.chatService.ListMessages(r.Context(), userID, conversationID, r.URL.Query().Get("before"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// SendMessageHandler обрабатывает отправку сообщения без WebSocket
+func (h *ChatHandler) SendMessageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	var req SendMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	message, err := h.chatService.SendMessage(r.Context(), userID, conversationID, req.Text)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, message)
+}
+
+// MarkReadHandler обрабатывает отметку беседы прочитанной до указанного сообщения
+func (h *ChatHandler) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, conversationID, ok := h.parseConversation(w, r)
+	if !ok {
+		return
+	}
+
+	var req MarkReadMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := h.chatService.MarkRead(r.Context(), userID, conversationID, req.MessageID)
+	if err != nil {
+		http.Error(w, err.Error(), chatErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, receipt)
+}
+
+// WebSocketHandler открывает WebSocket-соединение для обмена сообщениями в реальном времени.
+// Сервер присылает события ChatEvent, клиент отправляет команды ChatCommand.
+// Токен проверяется AuthMiddleware при установке соединения.
+func (h *ChatHandler) WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	server := websocket.Server{
+		// Доступ определяется токеном, а не источником страницы
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			h.serveConnection(conn, claims.UserID)
+		},
+	}
+	server.ServeHTTP(w, r)
+}
+
+// serveConnection пересылает события пользователю и выполняет его команды до закрытия соединения
+func (h *ChatHandler) serveConnection(conn *websocket.Conn, userID primitive.ObjectID) {
+	defer conn.Close()
+	// Таймауты HTTP-сервера не должны обрывать долгоживущее соединение
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return
+	}
+
+	events, unsubscribe := h.chatService.Subscribe(userID)
+	defer unsubscribe()
+
+	// Запись в соединение выполняется только из этой горутины
+	outgoing := make(chan models.ChatEvent, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var event models.ChatEvent
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				event = e
+			case e, ok := <-outgoing:
+				if !ok {
+					return
+				}
+				event = e
+			}
+			if err := websocket.JSON.Send(conn, event); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		var command ChatCommand
+		if err := websocket.JSON.Receive(conn, &command); err != nil {
+			break
+		}
+		if err := h.execute(userID, command); err != nil {
+			select {
+			case outgoing <- models.ChatEvent{Type: models.ChatEventError, ConversationID: command.ConversationID, Error: err.Error()}:
+			case <-done:
+			}
+		}
+	}
+	close(outgoing)
+	<-done
+}
+
+// execute выполняет команду клиента; результат придет подписчикам как событие
+func (h *ChatHandler) execute(userID primitive.ObjectID, command ChatCommand) error {
+	ctx, cancel := context.WithTimeout(context.Background(), chatCommandTimeout)
+	defer cancel()
+
+	var err error
+	switch command.Type {
+	case "send":
+		_, err = h.chatService.SendMessage(ctx, userID, command.ConversationID, command.Text)
+	case models.ChatEventRead:
+		_, err = h.chatService.MarkRead(ctx, userID, command.ConversationID, command.MessageID)
+	default:
+		return errors.New("unknown command type")
+	}
+	if err != nil && chatErrorStatus(err) == http.StatusInternalServerError {
+		log.Printf("Failed to execute chat command: %v", err)
+		return errors.New("internal error")
+	}
+	return err
+}
+
+// parseConversation извлекает ID текущего пользователя и ID беседы из пути
+func (h *ChatHandler) parseConversation(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	conversationID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid conversation ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, conversationID, true
+}
+
+// chatErrorStatus сопоставляет ошибку сервиса чата с HTTP статусом
+func chatErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrConversationNotFound, services.ErrMessageNotFound:
+		return http.StatusNotFound
+	case services.ErrNotFriends, services.ErrUserBlocked:
+		return http.StatusForbidden
+	case services.ErrSelfFriendship, services.ErrEmptyConversation, services.ErrInvalidCursor:
+		return http.StatusBadRequest
+	case services.ErrConversationFull, services.ErrNotGroupConversation:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,471 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// DeliveryHandler структура для обработчиков доставки и курьеров
+type DeliveryHandler struct {
+	deliveryService *services.DeliveryService
+	addressService  *services.AddressService
+}
+
+// DeliveryRequest тело запроса на доставку заказа.
+// Если передан address_id, берется сохраненный адрес пользователя.
+// Если координаты не переданы, они определяются по адресу.
+type DeliveryRequest struct {
+	AddressID string   `json:"address_id"`
+	Address   string   `json:"address"`
+	Lat       *float64 `json:"lat"`
+	Lng       *float64 `json:"lng"`
+}
+
+// AssignCourierRequest тело запроса на назначение курьера.
+// Без courier_id назначается ближайший свободный курьер.
+type AssignCourierRequest struct {
+	CourierID *primitive.ObjectID `json:"courier_id"`
+}
+
+// CourierStatusRequest тело запроса на смену статуса курьера
+type CourierStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// CourierLocationRequest тело запроса на обновление положения курьера
+type CourierLocationRequest struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+// SimulatedCouriersRequest тело запроса на создание симулируемых курьеров вокруг точки
+type SimulatedCouriersRequest struct {
+	Count   int     `json:"count"`
+	Lat     float64 `json:"lat"`
+	Lng     float64 `json:"lng"`
+	RadiusM float64 `json:"radius_m"`
+}
+
+// NewDeliveryHandler создает новый экземпляр DeliveryHandler
+func NewDeliveryHandler(deliveryService *services.DeliveryService, addressService *services.AddressService) *DeliveryHandler {
+	return &DeliveryHandler{
+		deliveryService: deliveryService,
+		addressService:  addressService,
+	}
+}
+
+// QuoteHandler обрабатывает расчет стоимости доставки из ресторана по координатам или адресу
+func (h *DeliveryHandler) QuoteHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	point, err := parsePoint(r, "lat", "lng")
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+	if point == nil {
+		address := r.URL.Query().Get("address")
+		if address == "" {
+			http.Error(w, "lat and lng or address are required", http.StatusBadRequest)
+			return
+		}
+		point, err = h.deliveryService.Geocode(r.Context(), address)
+		if err != nil {
+			http.Error(w, err.Error(), deliveryErrorStatus(err))
+			return
+		}
+	}
+
+	quote, err := h.deliveryService.Quote(r.Context(), restaurantID, *point)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, quote)
+}
+
+// RequestDeliveryHandler обрабатывает оформление доставки заказа пользователем
+func (h *DeliveryHandler) RequestDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req DeliveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	var point *models.GeoPoint
+	if req.Lat != nil && req.Lng != nil {
+		p := models.NewGeoPoint(*req.Lat, *req.Lng)
+		point = &p
+	}
+	if req.AddressID != "" {
+		addressID, err := primitive.ObjectIDFromHex(req.AddressID)
+		if err != nil {
+			http.Error(w, "Invalid address ID", http.StatusBadRequest)
+			return
+		}
+		address, err := h.addressService.GetAddress(r.Context(), claims.UserID, addressID)
+		if err != nil {
+			http.Error(w, err.Error(), addressErrorStatus(err))
+			return
+		}
+		req.Address = address.Line()
+		point = address.Location
+	}
+
+	delivery, err := h.deliveryService.RequestDelivery(r.Context(), claims.UserID, mux.Vars(r)["id"], req.Address, point)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// TrackingHandler обрабатывает отслеживание доставки заказа пользователем или рестораном
+func (h *DeliveryHandler) TrackingHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tracking, err := h.deliveryService.Tracking(r.Context(), claims.EntityType, claims.UserID, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tracking)
+}
+
+// GetFeesHandler обрабатывает получение тарифов доставки ресторана
+func (h *DeliveryHandler) GetFeesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	fees, err := h.deliveryService.GetFees(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fees)
+}
+
+// SetFeesHandler обрабатывает сохранение тарифов доставки по расстоянию
+func (h *DeliveryHandler) SetFeesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var fees models.DeliveryFeeSettings
+	if err := json.NewDecoder(r.Body).Decode(&fees); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deliveryService.SetFees(r.Context(), claims.UserID, &fees); err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, fees)
+}
+
+// ListRestaurantDeliveriesHandler обрабатывает получение доставок ресторана
+func (h *DeliveryHandler) ListRestaurantDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	deliveries, err := h.deliveryService.ListRestaurantDeliveries(r.Context(), claims.UserID, r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get deliveries", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// NearbyCouriersHandler обрабатывает получение свободных курьеров рядом с рестораном
+func (h *DeliveryHandler) NearbyCouriersHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	_, limit := parsePagination(r)
+	couriers, err := h.deliveryService.NearbyCouriers(r.Context(), claims.UserID, limit)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, couriers)
+}
+
+// AssignHandler обрабатывает назначение курьера на доставку заказа ресторана
+func (h *DeliveryHandler) AssignHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req AssignCourierRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	delivery, err := h.deliveryService.Assign(r.Context(), claims.UserID, mux.Vars(r)["order_id"], req.CourierID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// CourierProfileHandler обрабатывает получение профиля текущего курьера
+func (h *DeliveryHandler) CourierProfileHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := h.courierID(w, r)
+	if !ok {
+		return
+	}
+
+	courier, err := h.deliveryService.GetCourier(r.Context(), courierID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, courier)
+}
+
+// CourierStatusHandler обрабатывает выход курьера на линию и уход с нее
+func (h *DeliveryHandler) CourierStatusHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := h.courierID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CourierStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	courier, err := h.deliveryService.SetCourierStatus(r.Context(), courierID, req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, courier)
+}
+
+// CourierLocationHandler обрабатывает обновление положения курьера
+func (h *DeliveryHandler) CourierLocationHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := h.courierID(w, r)
+	if !ok {
+		return
+	}
+
+	var req CourierLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	courier, err := h.deliveryService.UpdateCourierLocation(r.Context(), courierID, models.NewGeoPoint(req.Lat, req.Lng))
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, courier)
+}
+
+// CurrentDeliveryHandler обрабатывает получение активной доставки курьера
+func (h *DeliveryHandler) CurrentDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, ok := h.courierID(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.deliveryService.CurrentDelivery(r.Context(), courierID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// PickUpHandler обрабатывает получение заказа курьером в ресторане
+func (h *DeliveryHandler) PickUpHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, deliveryID, ok := h.parseDelivery(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.deliveryService.PickUp(r.Context(), courierID, deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// DeliverHandler обрабатывает вручение заказа клиенту
+func (h *DeliveryHandler) DeliverHandler(w http.ResponseWriter, r *http.Request) {
+	courierID, deliveryID, ok := h.parseDelivery(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.deliveryService.Deliver(r.Context(), courierID, deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// ListCouriersHandler обрабатывает получение курьеров администратором
+func (h *DeliveryHandler) ListCouriersHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	couriers, err := h.deliveryService.ListCouriers(r.Context(), r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get couriers", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, couriers)
+}
+
+// GrantCourierHandler обрабатывает выдачу пользователю роли курьера
+func (h *DeliveryHandler) GrantCourierHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	courier, err := h.deliveryService.GrantCourier(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, courier)
+}
+
+// RevokeCourierHandler обрабатывает снятие с пользователя роли курьера
+func (h *DeliveryHandler) RevokeCourierHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.deliveryService.RevokeCourier(r.Context(), userID); err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SpawnSimulatedCouriersHandler обрабатывает создание симулируемых курьеров для локального запуска
+func (h *DeliveryHandler) SpawnSimulatedCouriersHandler(w http.ResponseWriter, r *http.Request) {
+	var req SimulatedCouriersRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RadiusM <= 0 {
+		req.RadiusM = 2000
+	}
+
+	couriers, err := h.deliveryService.SpawnSimulatedCouriers(r.Context(), req.Count, models.NewGeoPoint(req.Lat, req.Lng), req.RadiusM)
+	if err != nil {
+		http.Error(w, err.Error(), deliveryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, couriers)
+}
+
+// courierID возвращает ID текущего курьера. Роль проверяется промежуточным ПО маршрутов курьера.
+func (h *DeliveryHandler) courierID(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, false
+	}
+	return claims.UserID, true
+}
+
+// parseDelivery извлекает ID текущего курьера и ID доставки из пути
+func (h *DeliveryHandler) parseDelivery(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	courierID, ok := h.courierID(w, r)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return courierID, deliveryID, true
+}
+
+// deliveryErrorStatus сопоставляет ошибку сервиса доставки с HTTP статусом
+func deliveryErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrOrderNotFound, services.ErrDeliveryNotFound, services.ErrCourierNotFound,
+		services.ErrRestaurantNotFound, services.ErrUserNotFound, services.ErrAddressNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidCoordinates, services.ErrInvalidDeliveryFees, services.ErrInvalidCourierStatus:
+		return http.StatusBadRequest
+	case services.ErrOutOfDeliveryRange, services.ErrRestaurantLocationUnknown:
+		return http.StatusUnprocessableEntity
+	case services.ErrCourierUnavailable, services.ErrCourierBusy, services.ErrNoCourierAvailable,
+		services.ErrDeliveryAlreadyAssigned, services.ErrInvalidDeliveryTransition, services.ErrOrderClosed,
+		services.ErrRoleConflict:
+		return http.StatusConflict
+	case services.ErrGeocoderRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EventHandler структура для обработчиков очереди доменных событий
+type EventHandler struct {
+	eventBus *services.EventBus
+}
+
+// NewEventHandler создает новый экземпляр EventHandler
+func NewEventHandler(eventBus *services.EventBus) *EventHandler {
+	return &EventHandler{
+		eventBus: eventBus,
+	}
+}
+
+// ListEventsHandler обрабатывает просмотр очереди событий администратором.
+// Параметры status и type фильтруют события.
+func (h *EventHandler) ListEventsHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	query := r.URL.Query()
+	events, err := h.eventBus.ListEvents(r.Context(), query.Get("status"), query.Get("type"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), eventErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, events)
+}
+
+// RetryEventHandler обрабатывает повторную публикацию проваленного события
+func (h *EventHandler) RetryEventHandler(w http.ResponseWriter, r *http.Request) {
+	eventID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid event ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.eventBus.RetryEvent(r.Context(), eventID); err != nil {
+		http.Error(w, err.Error(), eventErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "event requeued"})
+}
+
+// eventErrorStatus сопоставляет ошибку шины событий с HTTP статусом
+func eventErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrDomainEventNotFound:
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,396 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// FavoriteListHandler структура для обработчиков именованных списков избранного
+type FavoriteListHandler struct {
+	favoriteListService *services.FavoriteListService
+	mediaService        *services.MediaService
+}
+
+// FavoriteListRequest тело запроса на создание и изменение списка
+type FavoriteListRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Visibility  *string `json:"visibility"`
+}
+
+// FavoriteListEntryRequest тело запроса на добавление ресторана и изменение заметки
+type FavoriteListEntryRequest struct {
+	RestaurantID primitive.ObjectID `json:"restaurant_id"`
+	Note         string             `json:"note"`
+}
+
+// ReorderFavoriteListRequest тело запроса на изменение порядка ресторанов
+type ReorderFavoriteListRequest struct {
+	RestaurantIDs []primitive.ObjectID `json:"restaurant_ids"`
+}
+
+// NewFavoriteListHandler создает новый экземпляр FavoriteListHandler
+func NewFavoriteListHandler(favoriteListService *services.FavoriteListService, mediaService *services.MediaService) *FavoriteListHandler {
+	return &FavoriteListHandler{
+		favoriteListService: favoriteListService,
+		mediaService:        mediaService,
+	}
+}
+
+// CreateListHandler обрабатывает создание списка избранного
+func (h *FavoriteListHandler) CreateListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req FavoriteListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list := &models.FavoriteList{OwnerID: claims.UserID}
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+	if req.Description != nil {
+		list.Description = *req.Description
+	}
+	if req.Visibility != nil {
+		list.Visibility = *req.Visibility
+	}
+	if err := h.favoriteListService.CreateList(r.Context(), list); err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusCreated, list)
+}
+
+// ListOwnListsHandler обрабатывает получение своих списков избранного
+func (h *FavoriteListHandler) ListOwnListsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	lists, err := h.favoriteListService.ListOwnLists(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "Failed to get favorite lists", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(lists)
+	writeJSON(w, http.StatusOK, lists)
+}
+
+// ListUserListsHandler обрабатывает получение доступных списков другого пользователя
+func (h *FavoriteListHandler) ListUserListsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	ownerID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	lists, err := h.favoriteListService.ListUserLists(r.Context(), claims.UserID, ownerID)
+	if err != nil {
+		http.Error(w, "Failed to get favorite lists", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(lists)
+	writeJSON(w, http.StatusOK, lists)
+}
+
+// GetListHandler обрабатывает получение списка избранного
+func (h *FavoriteListHandler) GetListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.GetList(r.Context(), userID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// UpdateListHandler обрабатывает изменение названия, описания и видимости списка
+func (h *FavoriteListHandler) UpdateListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	var req FavoriteListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.favoriteListService.UpdateList(r.Context(), userID, listID, services.FavoriteListUpdate{
+		Name:        req.Name,
+		Description: req.Description,
+		Visibility:  req.Visibility,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// DeleteListHandler обрабатывает удаление списка избранного
+func (h *FavoriteListHandler) DeleteListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.favoriteListService.DeleteList(r.Context(), userID, listID); err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddEntryHandler обрабатывает добавление ресторана в список
+func (h *FavoriteListHandler) AddEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	var req FavoriteListEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.favoriteListService.AddEntry(r.Context(), userID, listID, req.RestaurantID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// UpdateEntryHandler обрабатывает изменение заметки к ресторану в списке
+func (h *FavoriteListHandler) UpdateEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, restaurantID, ok := h.parseEntry(w, r)
+	if !ok {
+		return
+	}
+
+	var req FavoriteListEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.favoriteListService.UpdateEntryNote(r.Context(), userID, listID, restaurantID, req.Note)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// RemoveEntryHandler обрабатывает удаление ресторана из списка
+func (h *FavoriteListHandler) RemoveEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, restaurantID, ok := h.parseEntry(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.RemoveEntry(r.Context(), userID, listID, restaurantID)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// ReorderHandler обрабатывает изменение порядка ресторанов в списке
+func (h *FavoriteListHandler) ReorderHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	var req ReorderFavoriteListRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	list, err := h.favoriteListService.Reorder(r.Context(), userID, listID, req.RestaurantIDs)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// CreateShareLinkHandler обрабатывает создание ссылки на список; токен возвращается в share_token
+func (h *FavoriteListHandler) CreateShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.CreateShareLink(r.Context(), userID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// RevokeShareLinkHandler обрабатывает отключение ссылки на список
+func (h *FavoriteListHandler) RevokeShareLinkHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.RevokeShareLink(r.Context(), userID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// CopyListHandler обрабатывает копирование доступного списка в свои списки
+func (h *FavoriteListHandler) CopyListHandler(w http.ResponseWriter, r *http.Request) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.CopyList(r.Context(), userID, listID)
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusCreated, list)
+}
+
+// GetSharedListHandler обрабатывает просмотр списка по ссылке
+func (h *FavoriteListHandler) GetSharedListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.GetSharedList(r.Context(), claims.UserID, mux.Vars(r)["token"])
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusOK, list)
+}
+
+// CopySharedListHandler обрабатывает копирование списка, открытого по ссылке
+func (h *FavoriteListHandler) CopySharedListHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	list, err := h.favoriteListService.CopySharedList(r.Context(), claims.UserID, mux.Vars(r)["token"])
+	if err != nil {
+		http.Error(w, err.Error(), favoriteListErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(list)
+	writeJSON(w, http.StatusCreated, list)
+}
+
+// parseList извлекает ID текущего пользователя и ID списка из пути
+func (h *FavoriteListHandler) parseList(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	listID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid favorite list ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, listID, true
+}
+
+// parseEntry дополнительно к parseList извлекает ID ресторана из пути
+func (h *FavoriteListHandler) parseEntry(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, listID, ok := h.parseList(w, r)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["restaurant_id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return userID, listID, restaurantID, true
+}
+
+// favoriteListErrorStatus сопоставляет ошибку сервиса списков избранного с HTTP статусом
+func favoriteListErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrFavoriteListNotFound, services.ErrEntryNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrFavoriteListForbidden:
+		return http.StatusForbidden
+	case services.ErrInvalidOrder:
+		return http.StatusBadRequest
+	case services.ErrFavoriteListLimit, services.ErrFavoriteListFull, services.ErrEntryExists, services.ErrFavoriteListConflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
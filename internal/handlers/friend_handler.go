@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// FriendHandler структура для обработчиков дружбы и блокировок
+type FriendHandler struct {
+	friendService *services.FriendService
+	mediaService  *services.MediaService
+}
+
+// NewFriendHandler создает новый экземпляр FriendHandler
+func NewFriendHandler(friendService *services.FriendService, mediaService *services.MediaService) *FriendHandler {
+	return &FriendHandler{
+		friendService: friendService,
+		mediaService:  mediaService,
+	}
+}
+
+// SendRequestHandler обрабатывает отправку заявки в друзья
+func (h *FriendHandler) SendRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	friendship, err := h.friendService.SendRequest(r.Context(), userID, otherID)
+	if err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, friendship)
+}
+
+// AcceptRequestHandler обрабатывает принятие входящей заявки
+func (h *FriendHandler) AcceptRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	friendship, err := h.friendService.AcceptRequest(r.Context(), userID, otherID)
+	if err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, friendship)
+}
+
+// DeclineRequestHandler обрабатывает отклонение входящей заявки
+func (h *FriendHandler) DeclineRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.DeclineRequest(r.Context(), userID, otherID); err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "friend request declined"})
+}
+
+// CancelRequestHandler обрабатывает отмену исходящей заявки
+func (h *FriendHandler) CancelRequestHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.CancelRequest(r.Context(), userID, otherID); err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "friend request cancelled"})
+}
+
+// ListRequestsHandler обрабатывает получение входящих (direction=incoming) или исходящих заявок
+func (h *FriendHandler) ListRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	incoming := r.URL.Query().Get("direction") != "outgoing"
+	page, limit := parsePagination(r)
+	requests, err := h.friendService.ListRequests(r.Context(), claims.UserID, incoming, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get friend requests", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(requests)
+	writeJSON(w, http.StatusOK, requests)
+}
+
+// ListFriendsHandler обрабатывает получение списка друзей
+func (h *FriendHandler) ListFriendsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	friends, err := h.friendService.ListFriends(r.Context(), claims.UserID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get friends", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(friends)
+	writeJSON(w, http.StatusOK, friends)
+}
+
+// UnfriendHandler обрабатывает удаление из друзей
+func (h *FriendHandler) UnfriendHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Unfriend(r.Context(), userID, otherID); err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "friend removed"})
+}
+
+// GetProfileHandler обрабатывает получение профиля пользователя с учетом дружбы и блокировок
+func (h *FriendHandler) GetProfileHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	profile, err := h.friendService.GetProfile(r.Context(), userID, otherID)
+	if err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(profile)
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// BlockHandler обрабатывает блокировку пользователя
+func (h *FriendHandler) BlockHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Block(r.Context(), userID, otherID); err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "user blocked"})
+}
+
+// UnblockHandler обрабатывает снятие блокировки
+func (h *FriendHandler) UnblockHandler(w http.ResponseWriter, r *http.Request) {
+	userID, otherID, ok := h.parsePair(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.friendService.Unblock(r.Context(), userID, otherID); err != nil {
+		http.Error(w, err.Error(), friendErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "user unblocked"})
+}
+
+// ListBlockedHandler обрабатывает получение списка заблокированных пользователей
+func (h *FriendHandler) ListBlockedHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	users, err := h.friendService.ListBlocked(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "Failed to get blocked users", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(users)
+	writeJSON(w, http.StatusOK, users)
+}
+
+// parsePair извлекает ID текущего пользователя и ID пользователя из пути
+func (h *FriendHandler) parsePair(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	otherID, err := primitive.ObjectIDFromHex(mux.Vars(r)["user_id"])
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, otherID, true
+}
+
+// friendErrorStatus сопоставляет ошибку сервиса дружбы с HTTP статусом
+func friendErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrUserNotFound, services.ErrFriendRequestNotFound, services.ErrFriendshipNotFound, services.ErrBlockNotFound:
+		return http.StatusNotFound
+	case services.ErrSelfFriendship:
+		return http.StatusBadRequest
+	case services.ErrAlreadyFriends, services.ErrFriendRequestExists:
+		return http.StatusConflict
+	case services.ErrUserBlocked:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,317 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// GalleryHandler структура для обработчиков галереи ресторана
+type GalleryHandler struct {
+	galleryService *services.GalleryService
+	mediaService   *services.MediaService
+}
+
+// PhotoDetailsRequest тело запроса на изменение подписи и тегов фото
+type PhotoDetailsRequest struct {
+	Caption *string  `json:"caption"`
+	Tags    []string `json:"tags"`
+}
+
+// ReorderPhotosRequest тело запроса на изменение порядка фото ресторана
+type ReorderPhotosRequest struct {
+	PhotoIDs []primitive.ObjectID `json:"photo_ids"`
+}
+
+// SetCoverRequest тело запроса на выбор обложки ресторана
+type SetCoverRequest struct {
+	PhotoID primitive.ObjectID `json:"photo_id"`
+}
+
+// BulkPhotoModerationRequest тело запроса пакетной модерации фото из отзывов
+type BulkPhotoModerationRequest struct {
+	PhotoIDs []primitive.ObjectID `json:"photo_ids"`
+	Action   string               `json:"action"` // approve, reject
+	Reason   string               `json:"reason"`
+}
+
+// NewGalleryHandler создает новый экземпляр GalleryHandler
+func NewGalleryHandler(galleryService *services.GalleryService, mediaService *services.MediaService) *GalleryHandler {
+	return &GalleryHandler{
+		galleryService: galleryService,
+		mediaService:   mediaService,
+	}
+}
+
+// GetGalleryHandler обрабатывает получение публичной галереи ресторана
+func (h *GalleryHandler) GetGalleryHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	page, limit := parsePagination(r)
+	gallery, err := h.galleryService.Gallery(r.Context(), restaurantID, r.URL.Query().Get("tag"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, gallery)
+}
+
+// UploadRestaurantPhotoHandler обрабатывает загрузку фото в галерею текущего ресторана.
+// Кроме файла форма может содержать поля caption и tags.
+func (h *GalleryHandler) UploadRestaurantPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	upload, ok := readImageUpload(w, r, h.mediaService.MaxUploadBytes())
+	if !ok {
+		return
+	}
+
+	photo, err := h.galleryService.AddRestaurantPhoto(r.Context(), claims.UserID, upload.Data, uploadPhotoDetails(upload))
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, photo)
+}
+
+// UpdateRestaurantPhotoHandler обрабатывает изменение подписи и тегов фото ресторана
+func (h *GalleryHandler) UpdateRestaurantPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	photoID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	var req PhotoDetailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	details := services.PhotoDetails{Caption: req.Caption, Tags: req.Tags}
+	photo, err := h.galleryService.UpdatePhoto(r.Context(), claims.UserID, photoID, details)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photo)
+}
+
+// DeleteRestaurantPhotoHandler обрабатывает удаление фото из галереи текущего ресторана
+func (h *GalleryHandler) DeleteRestaurantPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	photoID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.galleryService.DeleteRestaurantPhoto(r.Context(), claims.UserID, photoID); err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ReorderPhotosHandler обрабатывает изменение порядка фото в галерее текущего ресторана
+func (h *GalleryHandler) ReorderPhotosHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req ReorderPhotosRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	photos, err := h.galleryService.Reorder(r.Context(), claims.UserID, req.PhotoIDs)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photos)
+}
+
+// SetCoverHandler обрабатывает выбор обложки текущего ресторана
+func (h *GalleryHandler) SetCoverHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req SetCoverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.PhotoID.IsZero() {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	photo, err := h.galleryService.SetCover(r.Context(), claims.UserID, req.PhotoID)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, photo)
+}
+
+// ClearCoverHandler обрабатывает сброс выбранной обложки текущего ресторана
+func (h *GalleryHandler) ClearCoverHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	if err := h.galleryService.ClearCover(r.Context(), claims.UserID); err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadReviewPhotoHandler обрабатывает загрузку фото к своему отзыву; фото будет показано после модерации
+func (h *GalleryHandler) UploadReviewPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+	reviewID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	upload, ok := readImageUpload(w, r, h.mediaService.MaxUploadBytes())
+	if !ok {
+		return
+	}
+
+	photo, err := h.galleryService.AddReviewPhoto(r.Context(), claims.UserID, reviewID, upload.Data, uploadPhotoDetails(upload))
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, photo)
+}
+
+// DeleteReviewPhotoHandler обрабатывает удаление фото из своего отзыва
+func (h *GalleryHandler) DeleteReviewPhotoHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	reviewID, err := primitive.ObjectIDFromHex(vars["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+	photoID, err := primitive.ObjectIDFromHex(vars["photo_id"])
+	if err != nil {
+		http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.galleryService.DeleteReviewPhoto(r.Context(), claims.UserID, reviewID, photoID); err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListModerationQueueHandler обрабатывает получение очереди модерации фото из отзывов
+func (h *GalleryHandler) ListModerationQueueHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	queue, err := h.galleryService.ListModerationQueue(r.Context(), r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get photo moderation queue", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, queue)
+}
+
+// BulkModerateHandler обрабатывает пакетное одобрение или отклонение фото из отзывов
+func (h *GalleryHandler) BulkModerateHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BulkPhotoModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.PhotoIDs) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.galleryService.BulkModerate(r.Context(), adminID, req.PhotoIDs, req.Action, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), galleryErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// uploadPhotoDetails извлекает подпись и теги из полей формы загрузки.
+// Теги можно передать несколькими полями tags или через запятую.
+func uploadPhotoDetails(upload *imageUpload) services.PhotoDetails {
+	var details services.PhotoDetails
+	if values, ok := upload.Fields["caption"]; ok {
+		caption := values[0]
+		details.Caption = &caption
+	}
+	if values, ok := upload.Fields["tags"]; ok {
+		details.Tags = []string{}
+		for _, value := range values {
+			details.Tags = append(details.Tags, splitQueryList(value)...)
+		}
+	}
+	return details
+}
+
+// galleryErrorStatus сопоставляет ошибку сервиса галереи с HTTP статусом
+func galleryErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrPhotoNotFound, services.ErrRestaurantNotFound, services.ErrReviewNotFound:
+		return http.StatusNotFound
+	case services.ErrPhotoForbidden, services.ErrReviewForbidden:
+		return http.StatusForbidden
+	case services.ErrPhotoLimitReached, services.ErrReviewNotVisible:
+		return http.StatusConflict
+	case services.ErrInvalidPhotoOrder, services.ErrInvalidCoverPhoto, services.ErrInvalidModerationAction:
+		return http.StatusBadRequest
+	default:
+		return mediaErrorStatus(err)
+	}
+}
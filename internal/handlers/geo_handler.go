@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GeoHandler структура для обработчиков геопоиска ресторанов
+type GeoHandler struct {
+	geoService   *services.GeoService
+	mediaService *services.MediaService
+}
+
+// LocationRequest тело запроса на установку координат ресторана.
+// Если координаты не переданы, они определяются по адресу.
+type LocationRequest struct {
+	Address string   `json:"address"`
+	Lat     *float64 `json:"lat"`
+	Lng     *float64 `json:"lng"`
+}
+
+// DeliveryZoneRequest тело запроса на установку зон доставки.
+// Каждая зона - внешний контур из точек [долгота, широта].
+type DeliveryZoneRequest struct {
+	Zones [][][2]float64 `json:"zones"`
+}
+
+// NewGeoHandler создает новый экземпляр GeoHandler
+func NewGeoHandler(geoService *services.GeoService, mediaService *services.MediaService) *GeoHandler {
+	return &GeoHandler{
+		geoService:   geoService,
+		mediaService: mediaService,
+	}
+}
+
+// NearbyHandler обрабатывает поиск ресторанов рядом с точкой или адресом
+func (h *GeoHandler) NearbyHandler(w http.ResponseWriter, r *http.Request) {
+	point, err := h.resolvePoint(r, "lat", "lng")
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	radius, _ := strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
+	_, limit := parsePagination(r)
+
+	restaurants, err := h.geoService.Nearby(r.Context(), *point, radius, limit)
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(restaurants)
+	writeJSON(w, http.StatusOK, restaurants)
+}
+
+// WithinBoxHandler обрабатывает поиск ресторанов в видимой области карты
+func (h *GeoHandler) WithinBoxHandler(w http.ResponseWriter, r *http.Request) {
+	southWest, err := parsePoint(r, "sw_lat", "sw_lng")
+	if err != nil || southWest == nil {
+		http.Error(w, "sw_lat and sw_lng are required", http.StatusBadRequest)
+		return
+	}
+	northEast, err := parsePoint(r, "ne_lat", "ne_lng")
+	if err != nil || northEast == nil {
+		http.Error(w, "ne_lat and ne_lng are required", http.StatusBadRequest)
+		return
+	}
+	origin, err := parsePoint(r, "lat", "lng")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, limit := parsePagination(r)
+
+	restaurants, err := h.geoService.WithinBox(r.Context(), *southWest, *northEast, origin, limit)
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	h.mediaService.SignEntity(restaurants)
+	writeJSON(w, http.StatusOK, restaurants)
+}
+
+// ServiceableHandler обрабатывает проверку, доставляет ли ресторан по адресу пользователя
+func (h *GeoHandler) ServiceableHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	point, err := h.resolvePoint(r, "lat", "lng")
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	result, err := h.geoService.CheckServiceable(r.Context(), restaurantID, *point)
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// SetLocationHandler обрабатывает установку координат ресторана
+func (h *GeoHandler) SetLocationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil || claims.EntityType != services.EntityTypeRestaurant {
+		http.Error(w, "Only restaurants can set location", http.StatusForbidden)
+		return
+	}
+
+	var req LocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var point *models.GeoPoint
+	if req.Lat != nil && req.Lng != nil {
+		p := models.NewGeoPoint(*req.Lat, *req.Lng)
+		point = &p
+	}
+
+	location, err := h.geoService.SetRestaurantLocation(r.Context(), claims.UserID, req.Address, point)
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, location)
+}
+
+// SetDeliveryZoneHandler обрабатывает установку зон доставки ресторана
+func (h *GeoHandler) SetDeliveryZoneHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil || claims.EntityType != services.EntityTypeRestaurant {
+		http.Error(w, "Only restaurants can set delivery zones", http.StatusForbidden)
+		return
+	}
+
+	var req DeliveryZoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	zone, err := h.geoService.SetDeliveryZone(r.Context(), claims.UserID, req.Zones)
+	if err != nil {
+		http.Error(w, err.Error(), geoErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"delivery_zone": zone})
+}
+
+// resolvePoint возвращает точку из параметров координат либо геокодирует параметр address
+func (h *GeoHandler) resolvePoint(r *http.Request, latKey, lngKey string) (*models.GeoPoint, error) {
+	point, err := parsePoint(r, latKey, lngKey)
+	if err != nil || point != nil {
+		return point, err
+	}
+
+	address := r.URL.Query().Get("address")
+	if address == "" {
+		return nil, services.ErrInvalidCoordinates
+	}
+	return h.geoService.Geocode(r.Context(), address)
+}
+
+// parsePoint извлекает точку из параметров запроса; возвращает nil, если параметры не переданы
+func parsePoint(r *http.Request, latKey, lngKey string) (*models.GeoPoint, error) {
+	latStr, lngStr := r.URL.Query().Get(latKey), r.URL.Query().Get(lngKey)
+	if latStr == "" && lngStr == "" {
+		return nil, nil
+	}
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil {
+		return nil, services.ErrInvalidCoordinates
+	}
+	lng, err := strconv.ParseFloat(lngStr, 64)
+	if err != nil {
+		return nil, services.ErrInvalidCoordinates
+	}
+	point := models.NewGeoPoint(lat, lng)
+	return &point, nil
+}
+
+// geoErrorStatus сопоставляет ошибку геосервиса с HTTP статусом
+func geoErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrInvalidCoordinates, services.ErrInvalidDeliveryZone:
+		return http.StatusBadRequest
+	case services.ErrAddressNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrGeocoderRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GroupOrderHandler структура для обработчиков групповых заказов
+type GroupOrderHandler struct {
+	groupOrderService *services.GroupOrderService
+}
+
+// CreateGroupOrderRequest тело запроса на создание группового заказа
+type CreateGroupOrderRequest struct {
+	RestaurantID primitive.ObjectID `json:"restaurant_id"`
+	SplitMode    string             `json:"split_mode"`
+}
+
+// GroupOrderItemsRequest тело запроса с блюдами участника
+type GroupOrderItemsRequest struct {
+	Items []models.OrderItem `json:"items"`
+}
+
+// SplitRequest тело запроса на изменение способа разделения счета
+type SplitRequest struct {
+	Mode         string             `json:"mode"`
+	CustomShares map[string]float64 `json:"custom_shares"`
+}
+
+// MarkPaidRequest тело запроса на отметку оплаты; без user_id отмечается своя доля
+type MarkPaidRequest struct {
+	UserID *primitive.ObjectID `json:"user_id"`
+}
+
+// NewGroupOrderHandler создает новый экземпляр GroupOrderHandler
+func NewGroupOrderHandler(groupOrderService *services.GroupOrderService) *GroupOrderHandler {
+	return &GroupOrderHandler{
+		groupOrderService: groupOrderService,
+	}
+}
+
+// CreateGroupOrderHandler обрабатывает создание группового заказа
+func (h *GroupOrderHandler) CreateGroupOrderHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req CreateGroupOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.groupOrderService.CreateGroupOrder(r.Context(), claims.UserID, req.RestaurantID, req.SplitMode)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, order)
+}
+
+// GetGroupOrderHandler обрабатывает получение группового заказа участником
+func (h *GroupOrderHandler) GetGroupOrderHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.groupOrderService.GetGroupOrder(r.Context(), userID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// JoinHandler обрабатывает присоединение к заказу по коду из ссылки-приглашения
+func (h *GroupOrderHandler) JoinHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	order, err := h.groupOrderService.Join(r.Context(), claims.UserID, mux.Vars(r)["code"])
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// LeaveHandler обрабатывает выход участника из заказа
+func (h *GroupOrderHandler) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.groupOrderService.Leave(r.Context(), userID, orderID); err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "left group order"})
+}
+
+// SetItemsHandler обрабатывает замену блюд участника
+func (h *GroupOrderHandler) SetItemsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var req GroupOrderItemsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.groupOrderService.SetItems(r.Context(), userID, orderID, req.Items)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// LockHandler обрабатывает фиксацию состава заказа хозяином
+func (h *GroupOrderHandler) LockHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.groupOrderService.Lock(r.Context(), userID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// UnlockHandler обрабатывает повторное открытие заказа для изменений
+func (h *GroupOrderHandler) UnlockHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.groupOrderService.Unlock(r.Context(), userID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// SetSplitHandler обрабатывает изменение способа разделения счета
+func (h *GroupOrderHandler) SetSplitHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var req SplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.groupOrderService.SetSplit(r.Context(), userID, orderID, req.Mode, req.CustomShares)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// SubmitHandler обрабатывает отправку заказа в ресторан
+func (h *GroupOrderHandler) SubmitHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := h.groupOrderService.Submit(r.Context(), userID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// MarkPaidHandler обрабатывает отметку оплаты доли участника
+func (h *GroupOrderHandler) MarkPaidHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	var req MarkPaidRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	payerID := userID
+	if req.UserID != nil {
+		payerID = *req.UserID
+	}
+
+	order, err := h.groupOrderService.MarkPaid(r.Context(), userID, orderID, payerID)
+	if err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// CancelHandler обрабатывает отмену заказа хозяином
+func (h *GroupOrderHandler) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	userID, orderID, ok := h.parseOrder(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.groupOrderService.Cancel(r.Context(), userID, orderID); err != nil {
+		http.Error(w, err.Error(), groupOrderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "group order cancelled"})
+}
+
+// parseOrder извлекает ID текущего пользователя и ID группового заказа из пути
+func (h *GroupOrderHandler) parseOrder(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	orderID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid group order ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, orderID, true
+}
+
+// groupOrderErrorStatus сопоставляет ошибку сервиса групповых заказов с HTTP статусом
+func groupOrderErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrGroupOrderNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrGroupOrderForbidden:
+		return http.StatusForbidden
+	case services.ErrInvalidOrderItem, services.ErrInvalidSplitMode, services.ErrSplitMismatch, services.ErrHostCannotLeave:
+		return http.StatusBadRequest
+	case services.ErrGroupOrderNotOpen, services.ErrGroupOrderNotLocked, services.ErrGroupOrderNotSubmitted,
+		services.ErrGroupOrderFull, services.ErrGroupOrderEmpty, services.ErrAlreadyParticipant, services.ErrShareAlreadyPaid:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"context"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobHandler структура для обработчиков администрирования фоновых задач
+type JobHandler struct {
+	jobRunner *services.JobRunner
+}
+
+// NewJobHandler создает новый экземпляр JobHandler
+func NewJobHandler(jobRunner *services.JobRunner) *JobHandler {
+	return &JobHandler{
+		jobRunner: jobRunner,
+	}
+}
+
+// ListJobsHandler обрабатывает просмотр задач. Параметры status и type фильтруют задачи.
+func (h *JobHandler) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	query := r.URL.Query()
+	jobs, err := h.jobRunner.ListJobs(r.Context(), query.Get("status"), query.Get("type"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// GetJobHandler обрабатывает получение задачи
+func (h *JobHandler) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	h.withJob(w, r, h.jobRunner.GetJob)
+}
+
+// RetryJobHandler обрабатывает повтор проваленной или отмененной задачи
+func (h *JobHandler) RetryJobHandler(w http.ResponseWriter, r *http.Request) {
+	h.withJob(w, r, h.jobRunner.RetryJob)
+}
+
+// CancelJobHandler обрабатывает отмену задачи, ожидающей выполнения
+func (h *JobHandler) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	h.withJob(w, r, h.jobRunner.CancelJob)
+}
+
+// ListSchedulesHandler обрабатывает просмотр периодических задач
+func (h *JobHandler) ListSchedulesHandler(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.jobRunner.ListSchedules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, schedules)
+}
+
+// TriggerScheduleHandler обрабатывает внеочередной запуск периодической задачи
+func (h *JobHandler) TriggerScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := h.jobRunner.TriggerSchedule(r.Context(), mux.Vars(r)["name"])
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// withJob выполняет действие над задачей из пути и возвращает ее новую версию
+func (h *JobHandler) withJob(w http.ResponseWriter, r *http.Request, action func(context.Context, primitive.ObjectID) (*models.Job, error)) {
+	jobID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid job ID", http.StatusBadRequest)
+		return
+	}
+
+	job, err := action(r.Context(), jobID)
+	if err != nil {
+		http.Error(w, err.Error(), jobErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// jobErrorStatus сопоставляет ошибку сервиса фоновых задач с HTTP статусом
+func jobErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrJobNotFound, services.ErrJobScheduleNotFound:
+		return http.StatusNotFound
+	case services.ErrJobDuplicate, services.ErrJobNotRetryable, services.ErrJobNotCancellable:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
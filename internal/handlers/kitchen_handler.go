@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// KitchenHandler структура для обработчиков кухонного экрана ресторана
+type KitchenHandler struct {
+	kitchenService *services.KitchenService
+}
+
+// NewKitchenHandler создает новый экземпляр KitchenHandler
+func NewKitchenHandler(kitchenService *services.KitchenService) *KitchenHandler {
+	return &KitchenHandler{
+		kitchenService: kitchenService,
+	}
+}
+
+// BoardHandler обрабатывает получение кухонного экрана. Параметр station оставляет одну станцию.
+func (h *KitchenHandler) BoardHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	board, err := h.kitchenService.Board(r.Context(), claims.UserID, r.URL.Query().Get("station"))
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, board)
+}
+
+// TicketsHandler обрабатывает получение активных заказов по обещанному времени
+// с фильтрами station и status
+func (h *KitchenHandler) TicketsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	status := query.Get("status")
+	if status != "" && status != models.OrderStatusPending && status != models.OrderStatusConfirmed && status != models.OrderStatusPreparing {
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+
+	tickets, err := h.kitchenService.Tickets(r.Context(), claims.UserID, query.Get("station"), status)
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, tickets)
+}
+
+// GetSettingsHandler обрабатывает получение нормативов приготовления
+func (h *KitchenHandler) GetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	settings, err := h.kitchenService.GetSettings(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// SetSettingsHandler обрабатывает сохранение нормативов приготовления по категориям блюд
+func (h *KitchenHandler) SetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var settings models.KitchenSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.kitchenService.SetSettings(r.Context(), claims.UserID, &settings); err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// StartItemHandler обрабатывает отметку позиции заказа начатой
+func (h *KitchenHandler) StartItemHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, orderID, index, ok := h.parseItem(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.kitchenService.StartItem(r.Context(), restaurantID, orderID, index)
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+// DoneItemHandler обрабатывает отметку позиции заказа готовой
+func (h *KitchenHandler) DoneItemHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, orderID, index, ok := h.parseItem(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.kitchenService.FinishItem(r.Context(), restaurantID, orderID, index)
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+// ResetItemHandler обрабатывает снятие ошибочных отметок с позиции заказа
+func (h *KitchenHandler) ResetItemHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, orderID, index, ok := h.parseItem(w, r)
+	if !ok {
+		return
+	}
+
+	ticket, err := h.kitchenService.ResetItem(r.Context(), restaurantID, orderID, index)
+	if err != nil {
+		http.Error(w, err.Error(), kitchenErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, ticket)
+}
+
+// parseItem извлекает ID ресторана, ID заказа и номер позиции из пути
+func (h *KitchenHandler) parseItem(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, string, int, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return primitive.NilObjectID, "", 0, false
+	}
+
+	vars := mux.Vars(r)
+	index, err := strconv.Atoi(vars["index"])
+	if err != nil {
+		http.Error(w, "Invalid item index", http.StatusBadRequest)
+		return primitive.NilObjectID, "", 0, false
+	}
+	return claims.UserID, vars["order_id"], index, true
+}
+
+// kitchenErrorStatus сопоставляет ошибку сервиса кухни с HTTP статусом
+func kitchenErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrRestaurantNotFound, services.ErrOrderNotFound, services.ErrOrderItemNotFound:
+		return http.StatusNotFound
+	case services.ErrOrderNotInKitchen:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
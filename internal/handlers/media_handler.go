@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// uploadFormField имя поля multipart-формы с файлом изображения
+const uploadFormField = "file"
+
+// multipartOverhead запас на заголовки и границы multipart-формы сверх размера файла
+const multipartOverhead = 64 << 10
+
+// maxUploadFieldBytes наибольший размер текстового поля формы загрузки
+const maxUploadFieldBytes = 4 << 10
+
+var errUploadMissing = errors.New("multipart field \"" + uploadFormField + "\" with image is required")
+
+// MediaHandler структура для обработчиков загрузки изображений
+type MediaHandler struct {
+	mediaService *services.MediaService
+}
+
+// NewMediaHandler создает новый экземпляр MediaHandler
+func NewMediaHandler(mediaService *services.MediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+// UploadUserAvatarHandler обрабатывает загрузку аватара текущего пользователя
+func (h *MediaHandler) UploadUserAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+	data, ok := h.readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := h.mediaService.SetUserAvatar(r.Context(), claims.UserID, data)
+	writeImage(w, image, err)
+}
+
+// DeleteUserAvatarHandler обрабатывает удаление аватара текущего пользователя
+func (h *MediaHandler) DeleteUserAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+	writeImageRemoved(w, h.mediaService.RemoveUserAvatar(r.Context(), claims.UserID))
+}
+
+// UploadRestaurantAvatarHandler обрабатывает загрузку аватара текущего ресторана
+func (h *MediaHandler) UploadRestaurantAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	data, ok := h.readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := h.mediaService.SetRestaurantAvatar(r.Context(), claims.UserID, data)
+	writeImage(w, image, err)
+}
+
+// DeleteRestaurantAvatarHandler обрабатывает удаление аватара текущего ресторана
+func (h *MediaHandler) DeleteRestaurantAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	writeImageRemoved(w, h.mediaService.RemoveRestaurantAvatar(r.Context(), claims.UserID))
+}
+
+// UploadMenuItemImageHandler обрабатывает загрузку фото блюда из меню текущего ресторана
+func (h *MediaHandler) UploadMenuItemImageHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	data, ok := h.readUpload(w, r)
+	if !ok {
+		return
+	}
+
+	image, err := h.mediaService.SetMenuItemImage(r.Context(), claims.UserID, mux.Vars(r)["item_id"], data)
+	writeImage(w, image, err)
+}
+
+// DeleteMenuItemImageHandler обрабатывает удаление фото блюда
+func (h *MediaHandler) DeleteMenuItemImageHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+	writeImageRemoved(w, h.mediaService.RemoveMenuItemImage(r.Context(), claims.UserID, mux.Vars(r)["item_id"]))
+}
+
+// ServeMediaHandler отдает файл локального хранилища по подписанной ссылке.
+// Для S3 ссылки ведут прямо в хранилище, и маршрут не используется.
+func (h *MediaHandler) ServeMediaHandler(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.mediaService.Store().(*services.LocalBlobStore)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	query := r.URL.Query()
+	file, err := store.Open(key, query.Get("expires"), query.Get("sig"))
+	if err != nil {
+		http.Error(w, err.Error(), mediaErrorStatus(err))
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusInternalServerError)
+		return
+	}
+	// Файлы неизменяемы: новое изображение всегда получает новый ключ
+	w.Header().Set("Cache-Control", "private, max-age=3600, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, path.Base(key), info.ModTime(), file)
+}
+
+// readUpload читает файл из поля file multipart-формы, ограничивая размер запроса.
+// При ошибке записывает ответ и возвращает false.
+func (h *MediaHandler) readUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	upload, ok := readImageUpload(w, r, h.mediaService.MaxUploadBytes())
+	if !ok {
+		return nil, false
+	}
+	return upload.Data, true
+}
+
+// imageUpload файл изображения и текстовые поля multipart-формы
+type imageUpload struct {
+	Data   []byte
+	Fields url.Values
+}
+
+// readImageUpload читает multipart-форму с файлом в поле file и небольшими текстовыми полями,
+// ограничивая размер запроса. При ошибке записывает ответ и возвращает false.
+func readImageUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) (*imageUpload, bool) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "Expected multipart/form-data request", http.StatusBadRequest)
+		return nil, false
+	}
+	upload := &imageUpload{Fields: url.Values{}}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, "Invalid multipart body", mediaErrorStatus(err))
+			return nil, false
+		}
+
+		if part.FileName() != "" && part.FormName() != uploadFormField {
+			part.Close()
+			continue
+		}
+		limit := int64(maxUploadFieldBytes)
+		if part.FormName() == uploadFormField {
+			limit = maxBytes
+		}
+		// Читаем на байт больше лимита, чтобы отличить значение ровно на лимите от слишком большого
+		data, err := io.ReadAll(io.LimitReader(part, limit+1))
+		part.Close()
+		if err != nil {
+			http.Error(w, "Failed to read upload", mediaErrorStatus(err))
+			return nil, false
+		}
+		if int64(len(data)) > limit {
+			if part.FormName() == uploadFormField {
+				http.Error(w, services.ErrImageTooLarge.Error(), http.StatusRequestEntityTooLarge)
+			} else {
+				http.Error(w, "Form field "+part.FormName()+" is too long", http.StatusBadRequest)
+			}
+			return nil, false
+		}
+
+		if part.FormName() == uploadFormField {
+			upload.Data = data
+		} else {
+			upload.Fields.Add(part.FormName(), string(data))
+		}
+	}
+
+	if upload.Data == nil {
+		http.Error(w, errUploadMissing.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	return upload, true
+}
+
+// writeImage записывает ответ с загруженным изображением
+func writeImage(w http.ResponseWriter, image *models.Image, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), mediaErrorStatus(err))
+		return
+	}
+	writeJSON(w, http.StatusOK, image)
+}
+
+// writeImageRemoved записывает ответ на удаление изображения
+func writeImageRemoved(w http.ResponseWriter, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), mediaErrorStatus(err))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mediaErrorStatus сопоставляет ошибку загрузки или хранилища с HTTP статусом
+func mediaErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	switch errors.Cause(err) {
+	case services.ErrImageOwnerNotFound, services.ErrMenuItemNotFound, services.ErrBlobNotFound, services.ErrInvalidBlobKey:
+		return http.StatusNotFound
+	case services.ErrUnsupportedImage:
+		return http.StatusUnsupportedMediaType
+	case services.ErrInvalidImage:
+		return http.StatusUnprocessableEntity
+	case services.ErrImageTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case services.ErrInvalidSignature:
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// restaurantMeetupsDefaultDays - горизонт планирования групповых визитов по умолчанию
+const restaurantMeetupsDefaultDays = 14
+
+// MeetupHandler структура для обработчиков совместных походов в ресторан
+type MeetupHandler struct {
+	meetupService   *services.MeetupService
+	activityService *services.ActivityService
+	chatService     *services.ChatService
+}
+
+// CreateMeetupRequest тело запроса на создание встречи
+type CreateMeetupRequest struct {
+	RestaurantID primitive.ObjectID   `json:"restaurant_id"`
+	Title        string               `json:"title"`
+	Description  string               `json:"description"`
+	StartsAt     time.Time            `json:"starts_at"`
+	Seats        int                  `json:"seats"`
+	InviteeIDs   []primitive.ObjectID `json:"invitee_ids"`
+}
+
+// UpdateMeetupRequest тело запроса на изменение встречи; отсутствующие поля не меняются
+type UpdateMeetupRequest struct {
+	RestaurantID *primitive.ObjectID `json:"restaurant_id"`
+	Title        *string             `json:"title"`
+	Description  *string             `json:"description"`
+	StartsAt     *time.Time          `json:"starts_at"`
+	Seats        *int                `json:"seats"`
+}
+
+// InviteRequest тело запроса на приглашение друзей
+type InviteRequest struct {
+	UserIDs []primitive.ObjectID `json:"user_ids"`
+}
+
+// RSVPRequest тело запроса с ответом на приглашение
+type RSVPRequest struct {
+	Status string `json:"status"`
+}
+
+// NewMeetupHandler создает новый экземпляр MeetupHandler
+func NewMeetupHandler(meetupService *services.MeetupService, activityService *services.ActivityService, chatService *services.ChatService) *MeetupHandler {
+	return &MeetupHandler{
+		meetupService:   meetupService,
+		activityService: activityService,
+		chatService:     chatService,
+	}
+}
+
+// CreateMeetupHandler обрабатывает создание встречи
+func (h *MeetupHandler) CreateMeetupHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req CreateMeetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meetup := &models.Meetup{
+		OrganizerID:  claims.UserID,
+		RestaurantID: req.RestaurantID,
+		Title:        req.Title,
+		Description:  req.Description,
+		StartsAt:     req.StartsAt,
+		Seats:        req.Seats,
+	}
+	if err := h.meetupService.CreateMeetup(r.Context(), meetup, req.InviteeIDs); err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+	h.syncConversation(r, meetup)
+
+	writeJSON(w, http.StatusCreated, meetup)
+}
+
+// ListMeetupsHandler обрабатывает получение встреч пользователя (scope=upcoming или all)
+func (h *MeetupHandler) ListMeetupsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	upcoming := r.URL.Query().Get("scope") != "all"
+	page, limit := parsePagination(r)
+	meetups, err := h.meetupService.ListUserMeetups(r.Context(), claims.UserID, upcoming, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get meetups", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, meetups)
+}
+
+// GetMeetupHandler обрабатывает получение встречи участником
+func (h *MeetupHandler) GetMeetupHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	meetup, err := h.meetupService.GetMeetup(r.Context(), userID, meetupID)
+	if err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, meetup)
+}
+
+// UpdateMeetupHandler обрабатывает изменение встречи организатором
+func (h *MeetupHandler) UpdateMeetupHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	var req UpdateMeetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meetup, err := h.meetupService.UpdateMeetup(r.Context(), userID, meetupID, services.MeetupUpdate{
+		RestaurantID: req.RestaurantID,
+		Title:        req.Title,
+		Description:  req.Description,
+		StartsAt:     req.StartsAt,
+		Seats:        req.Seats,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+	h.syncConversation(r, meetup)
+
+	writeJSON(w, http.StatusOK, meetup)
+}
+
+// CancelMeetupHandler обрабатывает отмену встречи организатором
+func (h *MeetupHandler) CancelMeetupHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.meetupService.CancelMeetup(r.Context(), userID, meetupID); err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "meetup cancelled"})
+}
+
+// InviteHandler обрабатывает приглашение друзей во встречу
+func (h *MeetupHandler) InviteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	var req InviteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.UserIDs) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meetup, err := h.meetupService.InviteFriends(r.Context(), userID, meetupID, req.UserIDs)
+	if err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+	h.syncConversation(r, meetup)
+
+	writeJSON(w, http.StatusOK, meetup)
+}
+
+// RSVPHandler обрабатывает ответ приглашенного (yes, no, maybe)
+func (h *MeetupHandler) RSVPHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	var req RSVPRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meetup, err := h.meetupService.RSVP(r.Context(), userID, meetupID, req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+
+	if req.Status == models.RSVPYes {
+		if err := h.activityService.RecordMeetupJoined(r.Context(), userID, meetup); err != nil {
+			log.Printf("Failed to record meetup activity: %v", err)
+		}
+	}
+
+	writeJSON(w, http.StatusOK, meetup)
+}
+
+// RestaurantMeetupsHandler обрабатывает получение предстоящих групповых визитов ресторана.
+// Интервал задается параметрами from и to в формате RFC3339.
+func (h *MeetupHandler) RestaurantMeetupsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	from, to := time.Now(), time.Now().AddDate(0, 0, restaurantMeetupsDefaultDays)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid from", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil || !parsed.After(from) {
+			http.Error(w, "Invalid to", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	meetups, err := h.meetupService.ListRestaurantMeetups(r.Context(), claims.UserID, from, to)
+	if err != nil {
+		http.Error(w, "Failed to get meetups", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, meetups)
+}
+
+// ConversationHandler обрабатывает получение беседы участников встречи
+func (h *MeetupHandler) ConversationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, meetupID, ok := h.parseMeetup(w, r)
+	if !ok {
+		return
+	}
+
+	conversation, err := h.chatService.MeetupConversation(r.Context(), userID, meetupID)
+	if err != nil {
+		http.Error(w, err.Error(), meetupErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, conversation)
+}
+
+// syncConversation приводит беседу встречи в соответствие с составом приглашенных
+func (h *MeetupHandler) syncConversation(r *http.Request, meetup *models.Meetup) {
+	if _, err := h.chatService.SyncMeetupConversation(r.Context(), meetup); err != nil {
+		log.Printf("Failed to sync meetup conversation: %v", err)
+	}
+}
+
+// parseMeetup извлекает ID текущего пользователя и ID встречи из пути
+func (h *MeetupHandler) parseMeetup(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	meetupID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid meetup ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, meetupID, true
+}
+
+// meetupErrorStatus сопоставляет ошибку сервиса встреч с HTTP статусом
+func meetupErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrMeetupNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrMeetupForbidden, services.ErrNotInvited, services.ErrInviteeNotFriend:
+		return http.StatusForbidden
+	case services.ErrMeetupInPast, services.ErrInvalidRSVP, services.ErrSeatsBelowGuests, services.ErrCannotInviteSelf:
+		return http.StatusBadRequest
+	case services.ErrMeetupFull, services.ErrMeetupNotActive, services.ErrAlreadyInvited, services.ErrMeetupConflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
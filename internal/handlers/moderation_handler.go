@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// ModerationHandler структура для обработчиков модерации отзывов
+type ModerationHandler struct {
+	moderationService *services.ModerationService
+}
+
+// ReportReviewRequest тело запроса жалобы на отзыв
+type ReportReviewRequest struct {
+	Reason string `json:"reason"`
+}
+
+// BulkModerationRequest тело запроса пакетной модерации
+type BulkModerationRequest struct {
+	ReviewIDs []primitive.ObjectID `json:"review_ids"`
+	Action    string               `json:"action"` // approve, reject
+	Reason    string               `json:"reason"`
+}
+
+// NewModerationHandler создает новый экземпляр ModerationHandler
+func NewModerationHandler(moderationService *services.ModerationService) *ModerationHandler {
+	return &ModerationHandler{
+		moderationService: moderationService,
+	}
+}
+
+// ReportReviewHandler обрабатывает жалобу пользователя или ресторана на отзыв
+func (h *ModerationHandler) ReportReviewHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reviewID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReportReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	report := &models.ReviewReport{
+		ReviewID:     reviewID,
+		ReporterID:   claims.UserID,
+		ReporterType: claims.EntityType,
+		Reason:       req.Reason,
+	}
+	if err := h.moderationService.ReportReview(r.Context(), report); err != nil {
+		http.Error(w, err.Error(), moderationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, report)
+}
+
+// ListQueueHandler обрабатывает получение очереди модерации
+func (h *ModerationHandler) ListQueueHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	queue, err := h.moderationService.ListQueue(r.Context(), r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get moderation queue", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, queue)
+}
+
+// BulkModerateHandler обрабатывает пакетное одобрение или отклонение отзывов
+func (h *ModerationHandler) BulkModerateHandler(w http.ResponseWriter, r *http.Request) {
+	adminID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req BulkModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.ReviewIDs) == 0 {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	results, err := h.moderationService.BulkModerate(r.Context(), adminID, req.ReviewIDs, req.Action, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), moderationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// ListAuditHandler обрабатывает получение журнала модерации
+func (h *ModerationHandler) ListAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var reviewID primitive.ObjectID
+	if hex := r.URL.Query().Get("review_id"); hex != "" {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			http.Error(w, "Invalid review ID", http.StatusBadRequest)
+			return
+		}
+		reviewID = id
+	}
+
+	page, limit := parsePagination(r)
+	entries, err := h.moderationService.ListAudit(r.Context(), reviewID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get moderation audit", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// moderationErrorStatus сопоставляет ошибку сервиса модерации с HTTP статусом
+func moderationErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrReviewNotFound:
+		return http.StatusNotFound
+	case services.ErrAlreadyReported:
+		return http.StatusConflict
+	case services.ErrInvalidModerationAction:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// NotificationHandler структура для обработчиков уведомлений
+type NotificationHandler struct {
+	notificationService *services.NotificationService
+	notifier            *services.Notifier
+}
+
+// MarkReadRequest тело запроса на отметку уведомлений прочитанными
+type MarkReadRequest struct {
+	IDs []primitive.ObjectID `json:"ids"`
+}
+
+// NewNotificationHandler создает новый экземпляр NotificationHandler
+func NewNotificationHandler(notificationService *services.NotificationService, notifier *services.Notifier) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		notifier:            notifier,
+	}
+}
+
+// ListNotificationsHandler обрабатывает получение уведомлений пользователя
+func (h *NotificationHandler) ListNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	page, limit := parsePagination(r)
+	unreadOnly := r.URL.Query().Get("unread") == "true"
+	notifications, err := h.notificationService.List(r.Context(), userID, unreadOnly, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get notifications", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, notifications)
+}
+
+// MarkReadHandler обрабатывает отметку уведомлений прочитанными
+func (h *NotificationHandler) MarkReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req MarkReadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notificationService.MarkRead(r.Context(), userID, req.IDs); err != nil {
+		http.Error(w, "Failed to mark notifications read", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "notifications marked read"})
+}
+
+// PushUnsubscribeRequest тело запроса на удаление push-подписки
+type PushUnsubscribeRequest struct {
+	Endpoint string `json:"endpoint"`
+}
+
+// GetPreferencesHandler обрабатывает получение настроек уведомлений
+func (h *NotificationHandler) GetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	prefs, err := h.notifier.GetPreferences(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, prefs)
+}
+
+// SetPreferencesHandler обрабатывает изменение каналов, языка и тихих часов
+func (h *NotificationHandler) SetPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var prefs models.NotificationPreferences
+	if err := json.NewDecoder(r.Body).Decode(&prefs); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	saved, err := h.notifier.SetPreferences(r.Context(), claims.UserID, &prefs)
+	if err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// PushKeyHandler обрабатывает получение ключа VAPID для подписки браузера
+func (h *NotificationHandler) PushKeyHandler(w http.ResponseWriter, r *http.Request) {
+	key, err := h.notifier.PushPublicKey()
+	if err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"public_key": key})
+}
+
+// SubscribePushHandler обрабатывает сохранение push-подписки браузера
+func (h *NotificationHandler) SubscribePushHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var subscription models.PushSubscription
+	if err := json.NewDecoder(r.Body).Decode(&subscription); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if subscription.UserAgent == "" {
+		subscription.UserAgent = r.UserAgent()
+	}
+
+	saved, err := h.notifier.SubscribePush(r.Context(), claims.UserID, &subscription)
+	if err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, saved)
+}
+
+// UnsubscribePushHandler обрабатывает удаление push-подписки
+func (h *NotificationHandler) UnsubscribePushHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req PushUnsubscribeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notifier.UnsubscribePush(r.Context(), claims.UserID, req.Endpoint); err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListOutboxHandler обрабатывает просмотр очереди отправки администратором
+func (h *NotificationHandler) ListOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	page, limit := parsePagination(r)
+	query := r.URL.Query()
+	messages, err := h.notifier.ListOutbox(r.Context(), query.Get("status"), query.Get("channel"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messages)
+}
+
+// RetryOutboxHandler обрабатывает повторную отправку проваленного сообщения
+func (h *NotificationHandler) RetryOutboxHandler(w http.ResponseWriter, r *http.Request) {
+	messageID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid message ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.notifier.RetryMessage(r.Context(), messageID); err != nil {
+		http.Error(w, err.Error(), notificationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "message requeued"})
+}
+
+// FakeSentHandler обрабатывает просмотр сообщений, принятых каналом-заглушкой
+func (h *NotificationHandler) FakeSentHandler(w http.ResponseWriter, r *http.Request) {
+	messages, ok := h.notifier.FakeSent(mux.Vars(r)["channel"])
+	if !ok {
+		http.Error(w, "Channel is not a fake", http.StatusNotFound)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, messages)
+}
+
+// notificationErrorStatus сопоставляет ошибку сервиса уведомлений с HTTP статусом
+func notificationErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrUserNotFound, services.ErrPushSubscriptionNotFound, services.ErrOutboxMessageNotFound,
+		services.ErrPushNotConfigured:
+		return http.StatusNotFound
+	case services.ErrInvalidQuietHours, services.ErrInvalidPushSubscription:
+		return http.StatusBadRequest
+	case services.ErrPushEndpointTaken:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+// orderStreamHeartbeat интервал комментариев-пингов, не дающих прокси закрыть простаивающий поток
+const orderStreamHeartbeat = 15 * time.Second
+
+// OrderHandler структура для обработчиков заказов и потоков их событий
+type OrderHandler struct {
+	orderService *services.OrderService
+	orderEvents  *services.OrderEvents
+}
+
+// UpdateOrderStatusRequest тело запроса на смену статуса заказа
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status"`
+}
+
+// NewOrderHandler создает новый экземпляр OrderHandler
+func NewOrderHandler(orderService *services.OrderService, orderEvents *services.OrderEvents) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+		orderEvents:  orderEvents,
+	}
+}
+
+// GetOrderHandler обрабатывает получение заказа пользователем или рестораном
+func (h *OrderHandler) GetOrderHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	order, err := h.orderService.GetOrder(r.Context(), claims.EntityType, claims.UserID, mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// UpdateStatusHandler обрабатывает смену статуса заказа рестораном
+func (h *OrderHandler) UpdateStatusHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	order, err := h.orderService.UpdateStatus(r.Context(), claims.UserID, mux.Vars(r)["id"], req.Status)
+	if err != nil {
+		http.Error(w, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// OrderEventsHandler открывает поток Server-Sent Events с изменениями одного заказа.
+// Доступен пользователю-участнику заказа и ресторану, которому заказ адресован.
+// Поток завершается после перехода заказа в конечный статус.
+func (h *OrderHandler) OrderEventsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	orderID := mux.Vars(r)["id"]
+	order, err := h.orderService.GetOrder(r.Context(), claims.EntityType, claims.UserID, orderID)
+	if err != nil {
+		http.Error(w, err.Error(), orderErrorStatus(err))
+		return
+	}
+
+	// Завершенный заказ больше не изменится: досылаем пропущенное и закрываем поток
+	h.stream(w, r, services.OrderStream(orderID), true, isFinalOrderStatus(order.Status))
+}
+
+// RestaurantStreamHandler открывает поток Server-Sent Events со всеми заказами ресторана
+func (h *OrderHandler) RestaurantStreamHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	h.stream(w, r, services.RestaurantOrdersStream(claims.UserID), false, false)
+}
+
+// stream пересылает события потока клиенту. Сначала оформляется подписка, затем
+// досылаются события из истории после Last-Event-ID, поэтому ничего не теряется
+// между переподключениями; повторы отбрасываются по номеру события.
+// При replayOnly отправляется только история, а при ее отсутствии возвращается 204,
+// что прекращает автоматические переподключения EventSource.
+func (h *OrderHandler) stream(w http.ResponseWriter, r *http.Request, stream string, closeOnFinal, replayOnly bool) {
+	ctx := r.Context()
+	controller := http.NewResponseController(w)
+	// Таймауты HTTP-сервера не должны обрывать долгоживущий поток
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	live, err := h.orderEvents.Subscribe(ctx, stream)
+	if err != nil {
+		log.Printf("Failed to subscribe to order events: %v", err)
+		http.Error(w, "Failed to subscribe to order events", http.StatusServiceUnavailable)
+		return
+	}
+
+	lastID := lastEventID(r)
+	history, err := h.orderEvents.History(ctx, stream, lastID)
+	if err != nil {
+		log.Printf("Failed to read order event history: %v", err)
+		http.Error(w, "Failed to read order events", http.StatusServiceUnavailable)
+		return
+	}
+	if replayOnly && len(history) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(event models.OrderEvent) bool {
+		if event.ID <= lastID {
+			return true
+		}
+		if err := writeOrderEvent(w, event); err != nil {
+			return false
+		}
+		lastID = event.ID
+		return !(closeOnFinal && event.Type == models.OrderEventStatusChanged && isFinalOrderStatus(event.Status))
+	}
+
+	for _, event := range history {
+		if !send(event) {
+			controller.Flush()
+			return
+		}
+	}
+	if err := controller.Flush(); err != nil || replayOnly {
+		return
+	}
+
+	heartbeat := time.NewTicker(orderStreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-live:
+			if !ok {
+				return
+			}
+			keepOpen := send(event)
+			if err := controller.Flush(); err != nil || !keepOpen {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeOrderEvent записывает событие в формате text/event-stream
+func writeOrderEvent(w http.ResponseWriter, event models.OrderEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "encoding order event failed")
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, payload)
+	return err
+}
+
+// lastEventID возвращает номер последнего полученного клиентом события.
+// EventSource передает его в заголовке при переподключении, параметр запроса
+// нужен для первого подключения после перезагрузки страницы.
+func lastEventID(r *http.Request) int64 {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+	return id
+}
+
+// isFinalOrderStatus сообщает, что заказ больше не будет менять статус
+func isFinalOrderStatus(status string) bool {
+	return status == models.OrderStatusDelivered || status == models.OrderStatusCancelled
+}
+
+// orderErrorStatus сопоставляет ошибку сервиса заказов с HTTP статусом
+func orderErrorStatus(err error) int {
+	switch errors.Cause(err) {
+	case services.ErrOrderNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidOrderTransition:
+		return http.StatusBadRequest
+	case services.ErrOrderConflict:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PasswordResetHandler структура для обработчиков восстановления пароля
+type PasswordResetHandler struct {
+	passwordResetService *services.PasswordResetService
+}
+
+// ForgotPasswordRequest тело запроса ссылки восстановления
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+// ResetPasswordRequest тело запроса на установку нового пароля
+type ResetPasswordRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// NewPasswordResetHandler создает новый экземпляр PasswordResetHandler
+func NewPasswordResetHandler(passwordResetService *services.PasswordResetService) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetService: passwordResetService,
+	}
+}
+
+// ForgotPasswordHandler обрабатывает запрос ссылки восстановления.
+// Ответ одинаков для известных и неизвестных адресов.
+func (h *PasswordResetHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Email) == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordResetService.RequestReset(r.Context(), strings.TrimSpace(req.Email)); err != nil {
+		http.Error(w, "Failed to request password reset", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusAccepted, map[string]string{"message": "if the email is registered, a reset link has been sent"})
+}
+
+// ResetPasswordHandler обрабатывает установку нового пароля по токену
+func (h *PasswordResetHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var req ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.passwordResetService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		switch errors.Cause(err) {
+		case services.ErrInvalidResetToken, services.ErrPasswordTooShort:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to reset password", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "password changed"})
+}
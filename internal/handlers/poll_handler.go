@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// PollHandler структура для обработчиков опросов о выборе ресторана
+type PollHandler struct {
+	pollService *services.PollService
+}
+
+// CreatePollRequest тело запроса на создание опроса
+type CreatePollRequest struct {
+	Title         string               `json:"title"`
+	RestaurantIDs []primitive.ObjectID `json:"restaurant_ids"`
+	VoterIDs      []primitive.ObjectID `json:"voter_ids"`
+	Deadline      time.Time            `json:"deadline"`
+}
+
+// VoteRequest тело запроса с бюллетенем: рестораны в порядке предпочтения
+type VoteRequest struct {
+	Ranking []primitive.ObjectID `json:"ranking"`
+}
+
+// PollMeetupRequest тело запроса на создание встречи по итогам опроса
+type PollMeetupRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	StartsAt    time.Time `json:"starts_at"`
+	Seats       int       `json:"seats"`
+}
+
+// NewPollHandler создает новый экземпляр PollHandler
+func NewPollHandler(pollService *services.PollService) *PollHandler {
+	return &PollHandler{
+		pollService: pollService,
+	}
+}
+
+// CreatePollHandler обрабатывает создание опроса
+func (h *PollHandler) CreatePollHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	var req CreatePollRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	poll, err := h.pollService.CreatePoll(r.Context(), claims.UserID, req.Title, req.RestaurantIDs, req.VoterIDs, req.Deadline)
+	if err != nil {
+		http.Error(w, err.Error(), pollErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, poll)
+}
+
+// ListPollsHandler обрабатывает получение опросов пользователя
+func (h *PollHandler) ListPollsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	polls, err := h.pollService.ListUserPolls(r.Context(), claims.UserID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get polls", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, polls)
+}
+
+// GetPollHandler обрабатывает получение опроса участником
+func (h *PollHandler) GetPollHandler(w http.ResponseWriter, r *http.Request) {
+	userID, pollID, ok := h.parsePoll(w, r)
+	if !ok {
+		return
+	}
+
+	poll, err := h.pollService.GetPoll(r.Context(), userID, pollID)
+	if err != nil {
+		http.Error(w, err.Error(), pollErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poll)
+}
+
+// VoteHandler обрабатывает голосование в опросе
+func (h *PollHandler) VoteHandler(w http.ResponseWriter, r *http.Request) {
+	userID, pollID, ok := h.parsePoll(w, r)
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	poll, err := h.pollService.Vote(r.Context(), userID, pollID, req.Ranking)
+	if err != nil {
+		http.Error(w, err.Error(), pollErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poll)
+}
+
+// ClosePollHandler обрабатывает досрочное закрытие опроса автором
+func (h *PollHandler) ClosePollHandler(w http.ResponseWriter, r *http.Request) {
+	userID, pollID, ok := h.parsePoll(w, r)
+	if !ok {
+		return
+	}
+
+	poll, err := h.pollService.ClosePoll(r.Context(), userID, pollID)
+	if err != nil {
+		http.Error(w, err.Error(), pollErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, poll)
+}
+
+// CreateMeetupHandler обрабатывает создание встречи в ресторане-победителе опроса
+func (h *PollHandler) CreateMeetupHandler(w http.ResponseWriter, r *http.Request) {
+	userID, pollID, ok := h.parsePoll(w, r)
+	if !ok {
+		return
+	}
+
+	var req PollMeetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	meetup, err := h.pollService.CreateMeetupFromPoll(r.Context(), userID, pollID, services.PollMeetupRequest{
+		Title:       req.Title,
+		Description: req.Description,
+		StartsAt:    req.StartsAt,
+		Seats:       req.Seats,
+	})
+	if err != nil {
+		status := pollErrorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = meetupErrorStatus(err)
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, meetup)
+}
+
+// parsePoll извлекает ID текущего пользователя и ID опроса из пути
+func (h *PollHandler) parsePoll(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	pollID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid poll ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, pollID, true
+}
+
+// pollErrorStatus сопоставляет ошибку сервиса опросов с HTTP статусом
+func pollErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrPollNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrPollForbidden, services.ErrPollVoterNotFound:
+		return http.StatusForbidden
+	case services.ErrInvalidBallot, services.ErrPollDeadlinePast:
+		return http.StatusBadRequest
+	case services.ErrPollClosed, services.ErrPollNotClosed, services.ErrPollNoWinner, services.ErrPollMeetupExists:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
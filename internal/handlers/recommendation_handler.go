@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// RecommendationHandler структура для обработчиков рекомендаций ресторанов
+type RecommendationHandler struct {
+	recommendationService *services.RecommendationService
+	mediaService          *services.MediaService
+}
+
+// NewRecommendationHandler создает новый экземпляр RecommendationHandler
+func NewRecommendationHandler(recommendationService *services.RecommendationService, mediaService *services.MediaService) *RecommendationHandler {
+	return &RecommendationHandler{
+		recommendationService: recommendationService,
+		mediaService:          mediaService,
+	}
+}
+
+// RecommendationsHandler обрабатывает получение персональных рекомендаций с пояснениями
+func (h *RecommendationHandler) RecommendationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	_, limit := parsePagination(r)
+	recommendations, err := h.recommendationService.Recommend(r.Context(), claims.UserID, limit)
+	if err != nil {
+		if errors.Cause(err) == services.ErrUserNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to get recommendations", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(recommendations)
+	writeJSON(w, http.StatusOK, recommendations)
+}
@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// ReservationHandler структура для обработчиков бронирования столиков
+type ReservationHandler struct {
+	reservationService *services.ReservationService
+}
+
+// CreateReservationRequest тело запроса на бронирование
+type CreateReservationRequest struct {
+	PartySize int       `json:"party_size"`
+	StartsAt  time.Time `json:"starts_at"`
+	Note      string    `json:"note"`
+}
+
+// DeclineReservationRequest тело запроса на отклонение бронирования
+type DeclineReservationRequest struct {
+	Reason string `json:"reason"`
+}
+
+// NewReservationHandler создает новый экземпляр ReservationHandler
+func NewReservationHandler(reservationService *services.ReservationService) *ReservationHandler {
+	return &ReservationHandler{
+		reservationService: reservationService,
+	}
+}
+
+// SetSettingsHandler обрабатывает сохранение настроек бронирования ресторана
+func (h *ReservationHandler) SetSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var settings models.ReservationSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reservationService.SetSettings(r.Context(), claims.UserID, &settings); err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// AvailabilityHandler обрабатывает получение свободных слотов ресторана на дату
+func (h *ReservationHandler) AvailabilityHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	partySize, err := strconv.Atoi(r.URL.Query().Get("party_size"))
+	if err != nil {
+		http.Error(w, "Invalid party_size", http.StatusBadRequest)
+		return
+	}
+
+	slots, err := h.reservationService.Availability(r.Context(), restaurantID, r.URL.Query().Get("date"), partySize)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, slots)
+}
+
+// CreateReservationHandler обрабатывает бронирование стола пользователем
+func (h *ReservationHandler) CreateReservationHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reservation := &models.Reservation{
+		RestaurantID: restaurantID,
+		UserID:       claims.UserID,
+		PartySize:    req.PartySize,
+		StartsAt:     req.StartsAt,
+		Note:         req.Note,
+	}
+	if err := h.reservationService.CreateReservation(r.Context(), reservation); err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, reservation)
+}
+
+// ListUserReservationsHandler обрабатывает получение бронирований пользователя
+func (h *ReservationHandler) ListUserReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	reservations, err := h.reservationService.ListUserReservations(r.Context(), claims.UserID, page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get reservations", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+// ListRestaurantReservationsHandler обрабатывает получение бронирований ресторана на дату
+func (h *ReservationHandler) ListRestaurantReservationsHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = time.Now().Format("2006-01-02")
+	}
+	reservations, err := h.reservationService.ListRestaurantReservations(r.Context(), claims.UserID, date, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservations)
+}
+
+// CancelReservationHandler обрабатывает отмену бронирования пользователем
+func (h *ReservationHandler) CancelReservationHandler(w http.ResponseWriter, r *http.Request) {
+	userID, reservationID, ok := h.parseReservation(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.CancelReservation(r.Context(), userID, reservationID)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// ConfirmReservationHandler обрабатывает подтверждение бронирования рестораном
+func (h *ReservationHandler) ConfirmReservationHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, reservationID, ok := h.parseReservation(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.ConfirmReservation(r.Context(), restaurantID, reservationID)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// DeclineReservationHandler обрабатывает отклонение бронирования рестораном
+func (h *ReservationHandler) DeclineReservationHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, reservationID, ok := h.parseReservation(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req DeclineReservationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reservation, err := h.reservationService.DeclineReservation(r.Context(), restaurantID, reservationID, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// NoShowHandler обрабатывает отметку неявки гостей
+func (h *ReservationHandler) NoShowHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, reservationID, ok := h.parseReservation(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.MarkNoShow(r.Context(), restaurantID, reservationID)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// CompleteHandler обрабатывает отметку состоявшегося визита
+func (h *ReservationHandler) CompleteHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, reservationID, ok := h.parseReservation(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.MarkCompleted(r.Context(), restaurantID, reservationID)
+	if err != nil {
+		http.Error(w, err.Error(), reservationErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// parseReservation извлекает ID текущей сущности заданного типа и ID бронирования из пути
+func (h *ReservationHandler) parseReservation(w http.ResponseWriter, r *http.Request, entityType string) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, entityType)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	reservationID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid reservation ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, reservationID, true
+}
+
+// reservationErrorStatus сопоставляет ошибку сервиса бронирования с HTTP статусом
+func reservationErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrReservationNotFound, services.ErrRestaurantNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidDate, services.ErrDuplicateTableID, services.ErrPartyTooLarge, services.ErrReservationOutsideWindow, services.ErrReservationClosed:
+		return http.StatusBadRequest
+	case services.ErrReservationsDisabled, services.ErrSlotUnavailable, services.ErrReservationState, services.ErrReservationNotStarted:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// SearchRestaurantsHandler обрабатывает поиск ресторанов с фильтрами, сортировкой и курсорной пагинацией
+func (h *EntityHandler) SearchRestaurantsHandler(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	_, limit := parsePagination(r)
+
+	query := services.RestaurantSearchQuery{
+		Categories:  splitQueryList(values.Get("category")),
+		DietaryTags: splitQueryList(values.Get("dietary")),
+		Sort:        values.Get("sort"),
+		Cursor:      values.Get("cursor"),
+		Limit:       limit,
+	}
+
+	var err error
+	if v := values.Get("min_price"); v != "" {
+		if query.MinPrice, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid min_price", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := values.Get("max_price"); v != "" {
+		if query.MaxPrice, err = strconv.Atoi(v); err != nil {
+			http.Error(w, "Invalid max_price", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := values.Get("min_rating"); v != "" {
+		if query.MinRating, err = strconv.ParseFloat(v, 64); err != nil {
+			http.Error(w, "Invalid min_rating", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := values.Get("open_now"); v != "" {
+		if query.OpenNow, err = strconv.ParseBool(v); err != nil {
+			http.Error(w, "Invalid open_now", http.StatusBadRequest)
+			return
+		}
+	}
+
+	page, err := h.entityService.SearchRestaurants(r.Context(), query)
+	if err != nil {
+		if errors.Cause(err) == services.ErrInvalidCursor {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to search restaurants", http.StatusInternalServerError)
+		return
+	}
+
+	h.mediaService.SignEntity(page)
+	writeJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// ReviewHandler структура для обработчиков отзывов
+type ReviewHandler struct {
+	reviewService  *services.ReviewService
+	galleryService *services.GalleryService
+}
+
+// ReviewRequest тело запроса на создание или изменение отзыва
+type ReviewRequest struct {
+	OrderID string `json:"order_id"`
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+// ReviewReplyRequest тело запроса на ответ ресторана
+type ReviewReplyRequest struct {
+	Text string `json:"text"`
+}
+
+// NewReviewHandler создает новый экземпляр ReviewHandler
+func NewReviewHandler(reviewService *services.ReviewService, galleryService *services.GalleryService) *ReviewHandler {
+	return &ReviewHandler{
+		reviewService:  reviewService,
+		galleryService: galleryService,
+	}
+}
+
+// CreateReviewHandler обрабатывает создание отзыва о ресторане
+func (h *ReviewHandler) CreateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil || claims.EntityType != services.EntityTypeUser {
+		http.Error(w, "Only users can post reviews", http.StatusForbidden)
+		return
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review := &models.Review{
+		RestaurantID: restaurantID,
+		UserID:       claims.UserID,
+		OrderID:      req.OrderID,
+		Rating:       req.Rating,
+		Comment:      req.Comment,
+	}
+	if err := h.reviewService.CreateReview(r.Context(), review); err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, review)
+}
+
+// ListRestaurantReviewsHandler обрабатывает получение страницы отзывов ресторана
+func (h *ReviewHandler) ListRestaurantReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	page, limit := parsePagination(r)
+	reviews, err := h.reviewService.ListRestaurantReviews(r.Context(), restaurantID, r.URL.Query().Get("sort"), page, limit)
+	if err != nil {
+		http.Error(w, "Failed to get reviews", http.StatusInternalServerError)
+		return
+	}
+	if err := h.galleryService.AttachReviewPhotos(r.Context(), reviews.Reviews); err != nil {
+		http.Error(w, "Failed to get review photos", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reviews)
+}
+
+// UpdateReviewHandler обрабатывает изменение отзыва его автором
+func (h *ReviewHandler) UpdateReviewHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reviewID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.reviewService.UpdateReview(r.Context(), userID, reviewID, req.Rating, req.Comment)
+	if err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, review)
+}
+
+// DeleteReviewHandler обрабатывает удаление отзыва его автором
+func (h *ReviewHandler) DeleteReviewHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	reviewID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.reviewService.DeleteReview(r.Context(), userID, reviewID); err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"message": "review deleted successfully"})
+}
+
+// ReplyToReviewHandler обрабатывает ответ владельца ресторана на отзыв
+func (h *ReviewHandler) ReplyToReviewHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil || claims.EntityType != services.EntityTypeRestaurant {
+		http.Error(w, "Only restaurants can reply to reviews", http.StatusForbidden)
+		return
+	}
+
+	reviewID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid review ID", http.StatusBadRequest)
+		return
+	}
+
+	var req ReviewReplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	review, err := h.reviewService.ReplyToReview(r.Context(), claims.UserID, reviewID, req.Text)
+	if err != nil {
+		http.Error(w, err.Error(), reviewErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, review)
+}
+
+// reviewErrorStatus сопоставляет ошибку сервиса отзывов с HTTP статусом
+func reviewErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrReviewNotFound:
+		return http.StatusNotFound
+	case services.ErrReviewForbidden, services.ErrReviewEditExpired, services.ErrOrderNotReviewable:
+		return http.StatusForbidden
+	case services.ErrReviewAlreadyExists, services.ErrReviewChanged:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"awesomeProject/internal/services"
+	"net/http"
+)
+
+// SearchHandler структура для обработчиков полнотекстового поиска
+type SearchHandler struct {
+	searchService *services.SearchService
+}
+
+// NewSearchHandler создает новый экземпляр SearchHandler
+func NewSearchHandler(searchService *services.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// FullTextSearchHandler обрабатывает поиск по названиям и описаниям ресторанов и блюд
+func (h *SearchHandler) FullTextSearchHandler(w http.ResponseWriter, r *http.Request) {
+	text := r.URL.Query().Get("q")
+	if text == "" {
+		http.Error(w, "Query parameter q is required", http.StatusBadRequest)
+		return
+	}
+
+	origin, err := parsePoint(r, "lat", "lng")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, limit := parsePagination(r)
+
+	hits, err := h.searchService.Search(r.Context(), services.SearchQuery{
+		Text:   text,
+		Kinds:  splitQueryList(r.URL.Query().Get("kind")),
+		Origin: origin,
+		Limit:  limit,
+	})
+	if err != nil {
+		http.Error(w, "Failed to search", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, hits)
+}
+
+// ReindexHandler обрабатывает полную перестройку поискового индекса
+func (h *SearchHandler) ReindexHandler(w http.ResponseWriter, r *http.Request) {
+	count, err := h.searchService.ReindexAll(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to rebuild search index: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"indexed": count})
+}
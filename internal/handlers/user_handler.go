@@ -19,8 +19,10 @@ import (
 
 // EntityHandler структура для обработчиков пользователей
 type EntityHandler struct {
-	entityService *services.EntityService
-	redisService  *services.RedisService
+	entityService   *services.EntityService
+	redisService    *services.RedisService
+	activityService *services.ActivityService
+	mediaService    *services.MediaService
 }
 
 type ChangePasswordRequest struct {
@@ -29,10 +31,12 @@ type ChangePasswordRequest struct {
 }
 
 // NewEntityHandler создает новый экземпляр EntityHandler
-func NewEntityHandler(userService *services.EntityService, redisService *services.RedisService) *EntityHandler {
+func NewEntityHandler(userService *services.EntityService, redisService *services.RedisService, activityService *services.ActivityService, mediaService *services.MediaService) *EntityHandler {
 	return &EntityHandler{
-		entityService: userService,
-		redisService:  redisService,
+		entityService:   userService,
+		redisService:    redisService,
+		activityService: activityService,
+		mediaService:    mediaService,
 	}
 }
 
@@ -92,6 +96,7 @@ func (h *EntityHandler) GetEntityById(w http.ResponseWriter, r *http.Request, en
 
 	err = h.redisService.GetCachedEntity(entityID.Hex(), &entity)
 	if err == nil {
+		h.mediaService.SignEntity(entity)
 		w.Header().Set("Content-Type", "application/json")
 		err = json.NewEncoder(w).Encode(entity)
 		if err != nil {
@@ -117,6 +122,9 @@ func (h *EntityHandler) GetEntityById(w http.ResponseWriter, r *http.Request, en
 		return
 	}
 
+	// Ссылки подписываются после кэширования, чтобы в кэш не попали ссылки с истекающим сроком
+	h.mediaService.SignEntity(entity)
+
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(entity)
 	if err != nil {
@@ -226,6 +234,7 @@ func (h *EntityHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error getting users", http.StatusInternalServerError)
 		return
 	}
+	h.mediaService.SignEntity(users)
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(users)
@@ -241,6 +250,7 @@ func (h *EntityHandler) GetAllRestaurants(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Error getting restaurants", http.StatusInternalServerError)
 		return
 	}
+	h.mediaService.SignEntity(restaurants)
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(restaurants)
@@ -270,6 +280,7 @@ func (h *EntityHandler) GetEntity(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, claims.EntityType+" not found", http.StatusNotFound)
 		return
 	}
+	h.mediaService.SignEntity(entity)
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(entity)
@@ -303,10 +314,41 @@ func (h *EntityHandler) AddFavoriteRestaurantHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Повторное добавление не создает нового события в ленте
+	if err := h.activityService.RecordFavorite(r.Context(), userID, restaurantID); err != nil {
+		log.Printf("Failed to record favorite activity: %v", err)
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Restaurant added to favorites"))
 }
 
+// RemoveFavoriteRestaurantHandler обрабатывает удаление ресторана из списка избранных
+func (h *EntityHandler) RemoveFavoriteRestaurantHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := getUserIDFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["restaurant_id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.entityService.RemoveFavoriteRestaurant(r.Context(), userID, restaurantID); err != nil {
+		http.Error(w, "Failed to remove favorite restaurant", http.StatusInternalServerError)
+		return
+	}
+
+	if err := h.activityService.RetractFavorite(r.Context(), userID, restaurantID); err != nil {
+		log.Printf("Failed to retract favorite activity: %v", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // GetFavoriteRestaurantsHandler обрабатывает получение списка избранных ресторанов пользователя
 func (h *EntityHandler) GetFavoriteRestaurantsHandler(w http.ResponseWriter, r *http.Request) {
 	// Извлечение ID пользователя из контекста запроса
@@ -322,6 +364,7 @@ func (h *EntityHandler) GetFavoriteRestaurantsHandler(w http.ResponseWriter, r *
 		http.Error(w, "Failed to get favorite restaurants", http.StatusInternalServerError)
 		return
 	}
+	h.mediaService.SignEntity(favoriteRestaurants)
 
 	w.Header().Set("Content-Type", "application/json")
 	err = json.NewEncoder(w).Encode(favoriteRestaurants)
@@ -344,3 +387,23 @@ func getUserIDFromContext(ctx context.Context) (primitive.ObjectID, error) {
 
 	return userID, nil
 }
+
+// getClaimsFromContext извлекает утверждения JWT из контекста
+func getClaimsFromContext(ctx context.Context) (*auth.JWTClaims, error) {
+	userClaims, ok := ctx.Value("userClaims").(*auth.JWTClaims)
+	if !ok || userClaims == nil {
+		return nil, errors.New("no user claims found in context")
+	}
+	return userClaims, nil
+}
+
+// requireEntity возвращает утверждения JWT, если запрос выполнен сущностью указанного типа,
+// иначе записывает ответ 403 и возвращает false
+func requireEntity(w http.ResponseWriter, r *http.Request, entityType string) (*auth.JWTClaims, bool) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil || claims.EntityType != entityType {
+		http.Error(w, "Forbidden for this account type", http.StatusForbidden)
+		return nil, false
+	}
+	return claims, true
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// WaitlistHandler структура для обработчиков листа ожидания
+type WaitlistHandler struct {
+	waitlistService *services.WaitlistService
+}
+
+// JoinWaitlistRequest тело запроса на постановку пользователя в очередь
+type JoinWaitlistRequest struct {
+	PartySize int `json:"party_size"`
+}
+
+// WalkInRequest тело запроса на добавление гостей персоналом
+type WalkInRequest struct {
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	PartySize int    `json:"party_size"`
+}
+
+// NewWaitlistHandler создает новый экземпляр WaitlistHandler
+func NewWaitlistHandler(waitlistService *services.WaitlistService) *WaitlistHandler {
+	return &WaitlistHandler{
+		waitlistService: waitlistService,
+	}
+}
+
+// JoinHandler обрабатывает постановку пользователя в очередь ресторана
+func (h *WaitlistHandler) JoinHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	restaurantID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid restaurant ID", http.StatusBadRequest)
+		return
+	}
+
+	var req JoinWaitlistRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.waitlistService.Join(r.Context(), claims.UserID, restaurantID, req.PartySize)
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// AddWalkInHandler обрабатывает добавление гостей в очередь персоналом ресторана
+func (h *WaitlistHandler) AddWalkInHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	var req WalkInRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.waitlistService.AddWalkIn(r.Context(), claims.UserID, req.Name, req.Phone, req.PartySize)
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, entry)
+}
+
+// QueueHandler обрабатывает получение текущей очереди ресторана
+func (h *WaitlistHandler) QueueHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	entries, err := h.waitlistService.Queue(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, "Failed to get waitlist", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// GetEntryHandler обрабатывает получение пользователем своей записи в очереди
+func (h *WaitlistHandler) GetEntryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, entryID, ok := h.parseEntry(w, r, services.EntityTypeUser)
+	if !ok {
+		return
+	}
+
+	entry, err := h.waitlistService.GetEntry(r.Context(), userID, entryID)
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// NotifyHandler обрабатывает приглашение гостей к готовому столу
+func (h *WaitlistHandler) NotifyHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, entryID, ok := h.parseEntry(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	entry, err := h.waitlistService.NotifyReady(r.Context(), restaurantID, entryID)
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// SeatHandler обрабатывает рассадку гостей
+func (h *WaitlistHandler) SeatHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, entryID, ok := h.parseEntry(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	entry, err := h.waitlistService.Seat(r.Context(), restaurantID, entryID)
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// LeaveHandler обрабатывает уход из очереди: пользователь покидает свою запись,
+// ресторан отмечает ушедших гостей
+func (h *WaitlistHandler) LeaveHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := getClaimsFromContext(r.Context())
+	if err != nil {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	entryID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var entry *models.WaitlistEntry
+	if claims.EntityType == services.EntityTypeRestaurant {
+		entry, err = h.waitlistService.MarkLeft(r.Context(), claims.UserID, entryID)
+	} else {
+		entry, err = h.waitlistService.Leave(r.Context(), claims.UserID, entryID)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), waitlistErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// parseEntry извлекает ID текущей сущности заданного типа и ID записи из пути
+func (h *WaitlistHandler) parseEntry(w http.ResponseWriter, r *http.Request, entityType string) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, entityType)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	entryID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid waitlist entry ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, entryID, true
+}
+
+// waitlistErrorStatus сопоставляет ошибку сервиса листа ожидания с HTTP статусом
+func waitlistErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrWaitlistEntryNotFound, services.ErrRestaurantNotFound, services.ErrUserNotFound:
+		return http.StatusNotFound
+	case services.ErrAlreadyInWaitlist, services.ErrWaitlistEntryState:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"awesomeProject/internal/models"
+	"awesomeProject/internal/services"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// WebhookHandler структура для обработчиков вебхуков ресторана
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+// WebhookRequest тело запроса на создание или изменение вебхука.
+// Секрет учитывается только при создании; active по умолчанию true.
+type WebhookRequest struct {
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// NewWebhookHandler создает новый экземпляр WebhookHandler
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// ListWebhooksHandler обрабатывает получение вебхуков ресторана
+func (h *WebhookHandler) ListWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookService.List(r.Context(), claims.UserID)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhooks)
+}
+
+// CreateWebhookHandler обрабатывает создание вебхука; секрет возвращается только в этом ответе
+func (h *WebhookHandler) CreateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	webhook, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	created, err := h.webhookService.Create(r.Context(), claims.UserID, webhook)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// GetWebhookHandler обрабатывает получение вебхука
+func (h *WebhookHandler) GetWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.Get(r.Context(), restaurantID, webhookID)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// UpdateWebhookHandler обрабатывает изменение вебхука
+func (h *WebhookHandler) UpdateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, ok := decodeWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.webhookService.Update(r.Context(), restaurantID, webhookID, webhook)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeleteWebhookHandler обрабатывает удаление вебхука
+func (h *WebhookHandler) DeleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.webhookService.Delete(r.Context(), restaurantID, webhookID); err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateSecretHandler обрабатывает смену секрета; новый секрет возвращается только в этом ответе
+func (h *WebhookHandler) RotateSecretHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookService.RotateSecret(r.Context(), restaurantID, webhookID)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, webhook)
+}
+
+// SendTestHandler обрабатывает отправку тестового события
+func (h *WebhookHandler) SendTestHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookService.SendTest(r.Context(), restaurantID, webhookID)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// WebhookDeliveriesHandler обрабатывает получение лога доставок вебхука. Параметр status фильтрует по статусу.
+func (h *WebhookHandler) WebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	restaurantID, webhookID, ok := parseWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	deliveries, err := h.webhookService.Deliveries(r.Context(), restaurantID, &webhookID, r.URL.Query().Get("status"), page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// DeadLettersHandler обрабатывает получение недоставленных событий по всем вебхукам ресторана
+func (h *WebhookHandler) DeadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	page, limit := parsePagination(r)
+	deliveries, err := h.webhookService.Deliveries(r.Context(), claims.UserID, nil, models.WebhookDeliveryDead, page, limit)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, deliveries)
+}
+
+// RedeliverHandler обрабатывает повторную отправку недоставленного события
+func (h *WebhookHandler) RedeliverHandler(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := primitive.ObjectIDFromHex(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		http.Error(w, "Invalid delivery ID", http.StatusBadRequest)
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(r.Context(), claims.UserID, deliveryID)
+	if err != nil {
+		http.Error(w, err.Error(), webhookErrorStatus(err))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, delivery)
+}
+
+// parseWebhook извлекает ID текущего ресторана и ID вебхука из пути
+func parseWebhook(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, primitive.ObjectID, bool) {
+	claims, ok := requireEntity(w, r, services.EntityTypeRestaurant)
+	if !ok {
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	webhookID, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid webhook ID", http.StatusBadRequest)
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+	return claims.UserID, webhookID, true
+}
+
+// decodeWebhook читает вебхук из тела запроса
+func decodeWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	var req WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+
+	webhook := &models.Webhook{
+		URL:         req.URL,
+		Events:      req.Events,
+		Secret:      req.Secret,
+		Description: req.Description,
+		Active:      true,
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+	return webhook, true
+}
+
+// webhookErrorStatus сопоставляет ошибку сервиса вебхуков с HTTP статусом
+func webhookErrorStatus(err error) int {
+	if _, ok := err.(validator.ValidationErrors); ok {
+		return http.StatusBadRequest
+	}
+	switch errors.Cause(err) {
+	case services.ErrWebhookNotFound, services.ErrWebhookDeliveryNotFound:
+		return http.StatusNotFound
+	case services.ErrInvalidWebhookURL:
+		return http.StatusBadRequest
+	case services.ErrWebhookLimitReached:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Типы действий в ленте активности
+const (
+	ActivityReviewPosted  = "review_posted"
+	ActivityFavoriteAdded = "favorite_added"
+	ActivityMeetupJoined  = "meetup_joined"
+	ActivityCheckIn       = "check_in"
+)
+
+// ActivityTypes перечисляет все типы действий ленты
+var ActivityTypes = []string{ActivityReviewPosted, ActivityFavoriteAdded, ActivityMeetupJoined, ActivityCheckIn}
+
+// Видимость действий пользователя
+const (
+	ActivityVisibilityFriends = "friends"
+	ActivityVisibilityPrivate = "private"
+)
+
+// Activity представляет событие в журнале действий пользователя.
+// Журнал только дополняется; лента друзей собирается из него при чтении.
+type Activity struct {
+	ID             primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ActorID        primitive.ObjectID  `json:"actor_id" bson:"actor_id"`
+	Type           string              `json:"type" bson:"type"`
+	RestaurantID   primitive.ObjectID  `json:"restaurant_id" bson:"restaurant_id"`
+	RefID          *primitive.ObjectID `json:"ref_id,omitempty" bson:"ref_id,omitempty"` // отзыв или встреча
+	Data           map[string]string   `json:"data,omitempty" bson:"data,omitempty"`
+	DedupKey       string              `json:"-" bson:"dedup_key,omitempty"`
+	CreatedAt      time.Time           `json:"created_at" bson:"created_at"`
+	Actor          *UserSummary        `json:"actor,omitempty" bson:"-"`
+	RestaurantName string              `json:"restaurant_name,omitempty" bson:"-"`
+}
+
+// ActivityPrivacy задает видимость каждого типа действий для друзей.
+// Отсутствующий тип считается видимым друзьям.
+type ActivityPrivacy map[string]string
+
+// Visible сообщает, видят ли друзья действия указанного типа
+func (p ActivityPrivacy) Visible(activityType string) bool {
+	return p[activityType] != ActivityVisibilityPrivate
+}
+
+// ActivityFeedPage представляет страницу ленты активности
+type ActivityFeedPage struct {
+	Activities []Activity `json:"activities"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
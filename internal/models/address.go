@@ -0,0 +1,52 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"strings"
+	"time"
+)
+
+// MaxUserAddresses ограничивает размер адресной книги пользователя
+const MaxUserAddresses = 20
+
+// Address представляет сохраненный адрес пользователя.
+// Координаты необязательны; без них адрес доставки геокодируется по строке адреса.
+type Address struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	Label     string             `json:"label" bson:"label" validate:"required,max=50"` // например "Дом", "Работа"
+	City      string             `json:"city" bson:"city" validate:"required,max=100"`
+	Street    string             `json:"street" bson:"street" validate:"required,max=200"`
+	House     string             `json:"house" bson:"house" validate:"required,max=20"`
+	Apartment string             `json:"apartment,omitempty" bson:"apartment,omitempty" validate:"max=20"`
+	Entrance  string             `json:"entrance,omitempty" bson:"entrance,omitempty" validate:"max=10"`
+	Floor     string             `json:"floor,omitempty" bson:"floor,omitempty" validate:"max=10"`
+	Intercom  string             `json:"intercom,omitempty" bson:"intercom,omitempty" validate:"max=20"`
+	Comment   string             `json:"comment,omitempty" bson:"comment,omitempty" validate:"max=500"`
+	Location  *GeoPoint          `json:"location,omitempty" bson:"location,omitempty"`
+	IsDefault bool               `json:"is_default" bson:"is_default"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate выполняет валидацию полей адреса
+func (a *Address) Validate() error {
+	validate := validator.New()
+	return validate.Struct(a)
+}
+
+// Normalize убирает лишние пробелы в полях адреса
+func (a *Address) Normalize() {
+	for _, field := range []*string{&a.Label, &a.City, &a.Street, &a.House, &a.Apartment, &a.Entrance, &a.Floor, &a.Intercom, &a.Comment} {
+		*field = strings.TrimSpace(*field)
+	}
+}
+
+// Line возвращает адрес одной строкой для геокодирования и курьера
+func (a *Address) Line() string {
+	parts := []string{a.City, a.Street, a.House}
+	if a.Apartment != "" {
+		parts = append(parts, "кв. "+a.Apartment)
+	}
+	return strings.Join(parts, ", ")
+}
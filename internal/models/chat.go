@@ -0,0 +1,92 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Типы бесед
+const (
+	ConversationDirect = "direct" // переписка двух пользователей
+	ConversationGroup  = "group"
+	ConversationMeetup = "meetup" // создается автоматически для встречи
+)
+
+// Типы событий чата, передаваемых по WebSocket
+const (
+	ChatEventMessage = "message"
+	ChatEventRead    = "read"
+	ChatEventError   = "error"
+)
+
+// Conversation представляет беседу пользователей.
+// Reads хранит последнее прочитанное сообщение каждого участника (ключ - hex ID пользователя).
+type Conversation struct {
+	ID          primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	Type        string                 `json:"type" bson:"type"`
+	Title       string                 `json:"title,omitempty" bson:"title,omitempty" validate:"max=100"`
+	MemberIDs   []primitive.ObjectID   `json:"member_ids" bson:"member_ids"`
+	CreatedBy   primitive.ObjectID     `json:"created_by" bson:"created_by"`
+	DirectKey   string                 `json:"-" bson:"direct_key,omitempty"`
+	MeetupID    *primitive.ObjectID    `json:"meetup_id,omitempty" bson:"meetup_id,omitempty"`
+	LastMessage *Message               `json:"last_message,omitempty" bson:"last_message,omitempty"`
+	Reads       map[string]ReadReceipt `json:"reads" bson:"reads"`
+	UnreadCount int64                  `json:"unread_count" bson:"-"`
+	CreatedAt   time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at" bson:"updated_at"`
+}
+
+// ReadReceipt отметка о прочтении беседы участником
+type ReadReceipt struct {
+	MessageID primitive.ObjectID `json:"message_id" bson:"message_id"`
+	ReadAt    time.Time          `json:"read_at" bson:"read_at"`
+}
+
+// Message представляет сообщение в беседе
+type Message struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ConversationID primitive.ObjectID `json:"conversation_id" bson:"conversation_id"`
+	SenderID       primitive.ObjectID `json:"sender_id" bson:"sender_id"`
+	Text           string             `json:"text" bson:"text" validate:"required,max=4000"`
+	CreatedAt      time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// MessagePage представляет страницу сообщений, новые первыми.
+// NextBefore передается в параметре before для получения более старых сообщений.
+type MessagePage struct {
+	Messages   []Message `json:"messages"`
+	NextBefore string    `json:"next_before,omitempty"`
+}
+
+// ChatEvent представляет событие, доставляемое участникам беседы в реальном времени
+type ChatEvent struct {
+	Type           string              `json:"type"`
+	ConversationID primitive.ObjectID  `json:"conversation_id,omitempty"`
+	Message        *Message            `json:"message,omitempty"`
+	UserID         *primitive.ObjectID `json:"user_id,omitempty"`
+	MessageID      *primitive.ObjectID `json:"message_id,omitempty"`
+	Error          string              `json:"error,omitempty"`
+}
+
+// IsMember проверяет, участвует ли пользователь в беседе
+func (c *Conversation) IsMember(userID primitive.ObjectID) bool {
+	for _, memberID := range c.MemberIDs {
+		if memberID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate выполняет валидацию полей беседы
+func (c *Conversation) Validate() error {
+	validate := validator.New()
+	return validate.Struct(c)
+}
+
+// Validate выполняет валидацию полей сообщения
+func (m *Message) Validate() error {
+	validate := validator.New()
+	return validate.Struct(m)
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// RoleCourier роль пользователя, доставляющего заказы
+const RoleCourier = "courier"
+
+// Статусы доставки
+const (
+	DeliveryStatusAwaitingCourier = "awaiting_courier"
+	DeliveryStatusAssigned        = "assigned"  // курьер едет в ресторан
+	DeliveryStatusPickedUp        = "picked_up" // курьер везет заказ клиенту
+	DeliveryStatusDelivered       = "delivered"
+	DeliveryStatusCancelled       = "cancelled"
+)
+
+// Статусы курьера
+const (
+	CourierStatusOffline   = "offline"
+	CourierStatusAvailable = "available"
+	CourierStatusBusy      = "busy"
+)
+
+// DeliveryFeeSettings представляет тарифы доставки ресторана.
+// Стоимость берется из первого правила, дальность которого не меньше расстояния;
+// дальше последнего правила ресторан не доставляет.
+type DeliveryFeeSettings struct {
+	Rules []DeliveryFeeRule `json:"rules" bson:"rules" validate:"required,min=1,max=20,dive"`
+}
+
+// DeliveryFeeRule представляет стоимость доставки до указанного расстояния
+type DeliveryFeeRule struct {
+	UpToMeters int     `json:"up_to_m" bson:"upToMeters" validate:"gte=1,lte=100000"`
+	Fee        float64 `json:"fee" bson:"fee" validate:"gte=0"`
+}
+
+// Validate выполняет валидацию тарифов доставки
+func (s *DeliveryFeeSettings) Validate() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+// OrderDelivery представляет адрес и стоимость доставки заказа
+type OrderDelivery struct {
+	Address   string   `json:"address" bson:"address"`
+	Location  GeoPoint `json:"location" bson:"location"`
+	DistanceM float64  `json:"distance_m" bson:"distance_m"`
+	Fee       float64  `json:"fee" bson:"fee"`
+}
+
+// DeliveryQuote представляет предварительный расчет доставки по адресу
+type DeliveryQuote struct {
+	Serviceable      bool    `json:"serviceable"`
+	DistanceM        float64 `json:"distance_m"`
+	Fee              float64 `json:"fee,omitempty"`
+	EstimatedMinutes int     `json:"estimated_minutes,omitempty"` // время в пути от ресторана
+}
+
+// Delivery представляет доставку заказа курьером
+type Delivery struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrderID       string             `json:"order_id" bson:"order_id"`
+	RestaurantID  primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Status        string             `json:"status" bson:"status"`
+	Pickup        GeoPoint           `json:"pickup" bson:"pickup"`
+	OrderDelivery `bson:",inline"`
+	CourierID     *primitive.ObjectID `json:"courier_id,omitempty" bson:"courier_id,omitempty"`
+	ETA           *time.Time          `json:"eta,omitempty" bson:"eta,omitempty"`
+	AssignedAt    *time.Time          `json:"assigned_at,omitempty" bson:"assigned_at,omitempty"`
+	PickedUpAt    *time.Time          `json:"picked_up_at,omitempty" bson:"picked_up_at,omitempty"`
+	DeliveredAt   *time.Time          `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+	CreatedAt     time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Courier представляет профиль курьера. ID совпадает с ID пользователя;
+// у симулируемых курьеров учетной записи нет.
+type Courier struct {
+	ID                primitive.ObjectID  `json:"id" bson:"_id"`
+	Name              string              `json:"name" bson:"name"`
+	Phone             string              `json:"phone,omitempty" bson:"phone,omitempty"`
+	Status            string              `json:"status" bson:"status"`
+	Location          *GeoPoint           `json:"location,omitempty" bson:"location,omitempty"`
+	LocationUpdatedAt *time.Time          `json:"location_updated_at,omitempty" bson:"location_updated_at,omitempty"`
+	ActiveDeliveryID  *primitive.ObjectID `json:"active_delivery_id,omitempty" bson:"active_delivery_id,omitempty"`
+	Simulated         bool                `json:"simulated" bson:"simulated"`
+	CreatedAt         time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt         time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// CourierPosition представляет публичные данные курьера для отслеживания доставки
+type CourierPosition struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Location  *GeoPoint          `json:"location,omitempty"`
+	UpdatedAt *time.Time         `json:"updated_at,omitempty"`
+}
+
+// DeliveryTracking представляет состояние доставки для клиента
+type DeliveryTracking struct {
+	Delivery        *Delivery        `json:"delivery"`
+	Courier         *CourierPosition `json:"courier,omitempty"`
+	RemainingMeters float64          `json:"remaining_m"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Типы доменных событий
+const (
+	DomainEventUserRegistered       = "user.registered"
+	DomainEventRestaurantRegistered = "restaurant.registered"
+	DomainEventOrderPlaced          = "order.placed"
+	DomainEventReviewPosted         = "review.posted"
+	DomainEventReviewDeleted        = "review.deleted"
+)
+
+// Статусы события в исходящей очереди
+const (
+	DomainEventPending    = "pending"
+	DomainEventPublishing = "publishing"
+	DomainEventPublished  = "published"
+	DomainEventFailed     = "failed" // попытки исчерпаны, событие ждет ручного повтора
+)
+
+// DomainEvent представляет событие предметной области. Событие записывается в исходящую
+// очередь в той же транзакции, что и изменение состояния, и затем рассылается подписчикам.
+type DomainEvent struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type          string             `json:"type" bson:"type"`
+	AggregateID   string             `json:"aggregate_id" bson:"aggregate_id"`
+	Payload       bson.M             `json:"payload" bson:"payload"`
+	OccurredAt    time.Time          `json:"occurred_at" bson:"occurred_at"`
+	Status        string             `json:"status" bson:"status"`
+	Handled       []string           `json:"handled" bson:"handled"`                         // подписчики, уже обработавшие событие
+	StreamID      string             `json:"stream_id,omitempty" bson:"stream_id,omitempty"` // ID записи в Redis Stream
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	PublishedAt   *time.Time         `json:"published_at,omitempty" bson:"published_at,omitempty"`
+}
+
+// DecodePayload раскладывает данные события в структуру соответствующего типа
+func (e *DomainEvent) DecodePayload(v interface{}) error {
+	return decodeDocument(e.Payload, v)
+}
+
+// decodeDocument раскладывает произвольный BSON-документ в структуру
+func decodeDocument(doc bson.M, v interface{}) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, v)
+}
+
+// EntityRegisteredPayload данные событий регистрации пользователя и ресторана
+type EntityRegisteredPayload struct {
+	EntityID   primitive.ObjectID `json:"entity_id" bson:"entity_id"`
+	EntityType string             `json:"entity_type" bson:"entity_type"`
+}
+
+// OrderPlacedPayload данные события о новом заказе
+type OrderPlacedPayload struct {
+	OrderID      string               `json:"order_id" bson:"order_id"`
+	RestaurantID primitive.ObjectID   `json:"restaurant_id" bson:"restaurant_id"`
+	UserIDs      []primitive.ObjectID `json:"user_ids" bson:"user_ids"`
+	GroupOrderID *primitive.ObjectID  `json:"group_order_id,omitempty" bson:"group_order_id,omitempty"`
+}
+
+// ReviewPostedPayload данные события о новом отзыве
+type ReviewPostedPayload struct {
+	ReviewID     primitive.ObjectID `json:"review_id" bson:"review_id"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Rating       int                `json:"rating" bson:"rating"`
+	Status       string             `json:"status" bson:"status"`
+}
+
+// ReviewDeletedPayload данные события об удаленном отзыве
+type ReviewDeletedPayload struct {
+	ReviewID     primitive.ObjectID `json:"review_id" bson:"review_id"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+}
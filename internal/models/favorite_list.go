@@ -0,0 +1,59 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Видимость списка избранного
+const (
+	ListVisibilityPublic  = "public"
+	ListVisibilityFriends = "friends"
+	ListVisibilityPrivate = "private"
+)
+
+// FavoriteList представляет именованный список ресторанов пользователя ("Для свиданий", "Бизнес-ланч").
+// Порядок ресторанов задается порядком Entries.
+type FavoriteList struct {
+	ID          primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	OwnerID     primitive.ObjectID  `json:"owner_id" bson:"owner_id"`
+	Name        string              `json:"name" bson:"name" validate:"required,max=100"`
+	Description string              `json:"description,omitempty" bson:"description,omitempty" validate:"max=500"`
+	Visibility  string              `json:"visibility" bson:"visibility" validate:"oneof=public friends private"`
+	Entries     []FavoriteListEntry `json:"entries" bson:"entries" validate:"dive"`
+	ShareToken  string              `json:"share_token,omitempty" bson:"share_token,omitempty"` // виден только владельцу
+	CopiedFrom  *primitive.ObjectID `json:"copied_from,omitempty" bson:"copied_from,omitempty"`
+	CreatedAt   time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// FavoriteListEntry представляет ресторан в списке с заметкой владельца
+type FavoriteListEntry struct {
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	Note         string             `json:"note,omitempty" bson:"note,omitempty" validate:"max=500"`
+	AddedAt      time.Time          `json:"added_at" bson:"added_at"`
+	Restaurant   *RestaurantSummary `json:"restaurant,omitempty" bson:"-"`
+}
+
+// Validate выполняет валидацию полей списка избранного
+func (l *FavoriteList) Validate() error {
+	validate := validator.New()
+	return validate.Struct(l)
+}
+
+// HasEntry сообщает, есть ли ресторан в списке
+func (l *FavoriteList) HasEntry(restaurantID primitive.ObjectID) bool {
+	for _, entry := range l.Entries {
+		if entry.RestaurantID == restaurantID {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate выполняет валидацию полей записи списка
+func (e *FavoriteListEntry) Validate() error {
+	validate := validator.New()
+	return validate.Struct(e)
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Статусы дружбы
+const (
+	FriendshipStatusPending  = "pending"
+	FriendshipStatusAccepted = "accepted"
+)
+
+// Friendship представляет связь между двумя пользователями.
+// Пара хранится упорядоченной (UserLow < UserHigh), чтобы на пару приходился один документ.
+type Friendship struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserLow     primitive.ObjectID `json:"-" bson:"user_low"`
+	UserHigh    primitive.ObjectID `json:"-" bson:"user_high"`
+	RequesterID primitive.ObjectID `json:"requester_id" bson:"requester_id"`
+	AddresseeID primitive.ObjectID `json:"addressee_id" bson:"addressee_id"`
+	Status      string             `json:"status" bson:"status"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	AcceptedAt  *time.Time         `json:"accepted_at,omitempty" bson:"accepted_at,omitempty"`
+}
+
+// UserBlock представляет блокировку одного пользователя другим.
+type UserBlock struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	BlockerID primitive.ObjectID `json:"blocker_id" bson:"blocker_id"`
+	BlockedID primitive.ObjectID `json:"blocked_id" bson:"blocked_id"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// UserSummary представляет публичные данные пользователя в списках.
+type UserSummary struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Name        string             `json:"name" bson:"name"`
+	Surname     string             `json:"surname" bson:"surname"`
+	Avatar      string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	AvatarImage *Image             `json:"avatar_image,omitempty" bson:"avatarImage,omitempty"`
+	MutualCount int                `json:"mutual_count" bson:"-"`
+	Since       *time.Time         `json:"since,omitempty" bson:"-"`
+}
+
+// UserProfile представляет профиль пользователя с точки зрения другого пользователя.
+type UserProfile struct {
+	UserSummary
+	Description      string `json:"description"`
+	Interests        string `json:"interests"`
+	FriendshipStatus string `json:"friendship_status"` // none, pending_outgoing, pending_incoming, friends
+}
+
+// UserSummaryPage представляет страницу списка пользователей.
+type UserSummaryPage struct {
+	Users []UserSummary `json:"users"`
+	Total int64         `json:"total"`
+	Page  int           `json:"page"`
+	Limit int           `json:"limit"`
+}
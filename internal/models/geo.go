@@ -0,0 +1,26 @@
+package models
+
+// GeoPoint представляет точку в формате GeoJSON.
+// Координаты хранятся в порядке [долгота, широта].
+type GeoPoint struct {
+	Type        string     `json:"type" bson:"type"`
+	Coordinates [2]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// GeoMultiPolygon представляет набор полигонов в формате GeoJSON.
+// Используется для зон доставки ресторана.
+type GeoMultiPolygon struct {
+	Type        string           `json:"type" bson:"type"`
+	Coordinates [][][][2]float64 `json:"coordinates" bson:"coordinates"`
+}
+
+// NewGeoPoint создает точку GeoJSON по широте и долготе
+func NewGeoPoint(lat, lng float64) GeoPoint {
+	return GeoPoint{Type: "Point", Coordinates: [2]float64{lng, lat}}
+}
+
+// Lat возвращает широту точки
+func (p GeoPoint) Lat() float64 { return p.Coordinates[1] }
+
+// Lng возвращает долготу точки
+func (p GeoPoint) Lng() float64 { return p.Coordinates[0] }
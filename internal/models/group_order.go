@@ -0,0 +1,79 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Статусы группового заказа
+const (
+	GroupOrderStatusOpen      = "open"      // участники добавляют блюда
+	GroupOrderStatusLocked    = "locked"    // хозяин зафиксировал состав заказа
+	GroupOrderStatusSubmitted = "submitted" // заказ отправлен в ресторан
+	GroupOrderStatusCancelled = "cancelled"
+)
+
+// Способы разделения счета
+const (
+	SplitByItem = "by_item" // каждый платит за свои блюда
+	SplitEvenly = "even"    // сумма делится поровну между участниками
+	SplitCustom = "custom"  // суммы задает хозяин заказа
+)
+
+// Статусы оплаты доли участника
+const (
+	PaymentStatusUnpaid = "unpaid"
+	PaymentStatusPaid   = "paid"
+)
+
+// GroupOrder представляет совместный заказ друзей в одном ресторане.
+// Участники присоединяются по коду приглашения, хозяин фиксирует и отправляет заказ.
+type GroupOrder struct {
+	ID           primitive.ObjectID      `json:"id" bson:"_id,omitempty"`
+	HostID       primitive.ObjectID      `json:"host_id" bson:"host_id"`
+	RestaurantID primitive.ObjectID      `json:"restaurant_id" bson:"restaurant_id"`
+	JoinCode     string                  `json:"join_code" bson:"join_code"`
+	Status       string                  `json:"status" bson:"status"`
+	SplitMode    string                  `json:"split_mode" bson:"split_mode"`
+	CustomShares map[string]float64      `json:"custom_shares,omitempty" bson:"custom_shares,omitempty"` // hex ID участника -> сумма
+	Participants []GroupOrderParticipant `json:"participants" bson:"participants"`
+	Total        float64                 `json:"total" bson:"total"`
+	Shares       []ParticipantShare      `json:"shares" bson:"shares,omitempty"`
+	OrderID      string                  `json:"order_id,omitempty" bson:"order_id,omitempty"`
+	CreatedAt    time.Time               `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time               `json:"updated_at" bson:"updated_at"`
+	SubmittedAt  *time.Time              `json:"submitted_at,omitempty" bson:"submitted_at,omitempty"`
+}
+
+// GroupOrderParticipant представляет участника группового заказа и выбранные им блюда
+type GroupOrderParticipant struct {
+	UserID   primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Items    []GroupOrderItem   `json:"items" bson:"items"`
+	JoinedAt time.Time          `json:"joined_at" bson:"joined_at"`
+}
+
+// GroupOrderItem представляет позицию участника с ценой на момент добавления
+type GroupOrderItem struct {
+	OrderItem `bson:",inline"`
+	Name      string  `json:"name" bson:"name"`
+	Price     float64 `json:"price" bson:"price"`
+}
+
+// ParticipantShare представляет долю участника в счете и статус ее оплаты
+type ParticipantShare struct {
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Subtotal      float64            `json:"subtotal" bson:"subtotal"` // стоимость собственных блюд
+	Amount        float64            `json:"amount" bson:"amount"`     // сумма к оплате
+	PaymentStatus string             `json:"payment_status" bson:"payment_status"`
+	PaidAt        *time.Time         `json:"paid_at,omitempty" bson:"paid_at,omitempty"`
+}
+
+// Participant возвращает участника заказа по ID пользователя
+func (o *GroupOrder) Participant(userID primitive.ObjectID) *GroupOrderParticipant {
+	for i := range o.Participants {
+		if o.Participants[i].UserID == userID {
+			return &o.Participants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Варианты размеров загруженного изображения
+const (
+	ImageVariantThumbnail = "thumbnail"
+	ImageVariantMedium    = "medium"
+	ImageVariantLarge     = "large"
+)
+
+// Image загруженное изображение, сохраненное в хранилище файлов в нескольких размерах.
+// В базе хранятся только ключи файлов; подписанные ссылки заполняются при выдаче ответа.
+type Image struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	Variants   []ImageVariant     `json:"variants" bson:"variants"`
+	UploadedAt time.Time          `json:"uploaded_at" bson:"uploadedAt"`
+}
+
+// ImageVariant один размер изображения
+type ImageVariant struct {
+	Name        string `json:"name" bson:"name"`
+	Key         string `json:"key" bson:"key"`
+	ContentType string `json:"content_type" bson:"contentType"`
+	Width       int    `json:"width" bson:"width"`
+	Height      int    `json:"height" bson:"height"`
+	Size        int    `json:"size" bson:"size"`
+	URL         string `json:"url,omitempty" bson:"-"` // подписанная ссылка с ограниченным сроком действия
+}
+
+// Variant возвращает вариант изображения по имени
+func (i *Image) Variant(name string) *ImageVariant {
+	for j := range i.Variants {
+		if i.Variants[j].Name == name {
+			return &i.Variants[j]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Статусы фоновой задачи
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed" // попытки исчерпаны, задача ждет ручного повтора
+	JobStatusCancelled = "cancelled"
+)
+
+// Job представляет фоновую задачу. Задача с UniqueKey не ставится повторно,
+// пока предыдущая задача с тем же ключом ожидает выполнения или выполняется.
+type Job struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Payload     bson.M             `json:"payload,omitempty" bson:"payload,omitempty"`
+	Status      string             `json:"status" bson:"status"`
+	UniqueKey   string             `json:"unique_key,omitempty" bson:"unique_key,omitempty"`
+	ActiveKey   string             `json:"-" bson:"active_key,omitempty"`                // совпадает с UniqueKey, пока задача не завершена
+	Schedule    string             `json:"schedule,omitempty" bson:"schedule,omitempty"` // расписание, создавшее задачу
+	Attempts    int                `json:"attempts" bson:"attempts"`
+	MaxAttempts int                `json:"max_attempts" bson:"max_attempts"`
+	RunAt       time.Time          `json:"run_at" bson:"run_at"`
+	LockedUntil *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	Worker      string             `json:"worker,omitempty" bson:"worker,omitempty"`
+	LastError   string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+}
+
+// DecodePayload раскладывает данные задачи в структуру соответствующего типа
+func (j *Job) DecodePayload(v interface{}) error {
+	return decodeDocument(j.Payload, v)
+}
+
+// JobSchedule представляет периодическую задачу в формате cron. Следующий запуск
+// хранится в базе, чтобы задачу по расписанию ставила только одна реплика.
+type JobSchedule struct {
+	Name      string              `json:"name" bson:"_id"`
+	Spec      string              `json:"spec" bson:"spec"`
+	JobType   string              `json:"job_type" bson:"job_type"`
+	NextRunAt time.Time           `json:"next_run_at" bson:"next_run_at"`
+	LastRunAt *time.Time          `json:"last_run_at,omitempty" bson:"last_run_at,omitempty"`
+	LastJobID *primitive.ObjectID `json:"last_job_id,omitempty" bson:"last_job_id,omitempty"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// PollCloseJobPayload данные задачи закрытия опроса по истечении срока
+type PollCloseJobPayload struct {
+	PollID primitive.ObjectID `json:"poll_id" bson:"poll_id"`
+}
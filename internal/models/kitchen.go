@@ -0,0 +1,91 @@
+package models
+
+import (
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Состояния позиции заказа на кухне
+const (
+	KitchenItemQueued  = "queued"
+	KitchenItemStarted = "started"
+	KitchenItemDone    = "done"
+)
+
+// KitchenStationOther станция для блюд без категории или удаленных из меню
+const KitchenStationOther = "other"
+
+// KitchenSettings представляет нормативы приготовления ресторана.
+// Станцией кухни считается категория блюда (MenuItem.Category).
+type KitchenSettings struct {
+	PrepTargets        map[string]int `json:"prep_targets" bson:"prepTargets" validate:"max=100,dive,keys,required,max=100,endkeys,gte=1,lte=240"` // минуты по категориям
+	DefaultPrepMinutes int            `json:"default_prep_minutes" bson:"defaultPrepMinutes" validate:"gte=1,lte=240"`
+}
+
+// Validate выполняет валидацию нормативов кухни
+func (s *KitchenSettings) Validate() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+// KitchenTicket представляет активный заказ на кухонном экране.
+// PromisedAt - время создания плюс самый долгий норматив среди позиций.
+type KitchenTicket struct {
+	OrderID     string              `json:"order_id"`
+	Status      string              `json:"status"`
+	Items       []KitchenTicketItem `json:"items"`
+	CreatedAt   time.Time           `json:"created_at"`
+	PromisedAt  time.Time           `json:"promised_at"`
+	Late        bool                `json:"late"`
+	LateMinutes int                 `json:"late_minutes,omitempty"`
+	Ready       bool                `json:"ready"` // все позиции приготовлены
+}
+
+// KitchenTicketItem представляет позицию заказа на кухонном экране.
+// Index - номер позиции в заказе, по нему позиция отмечается начатой или готовой.
+type KitchenTicketItem struct {
+	Index         int        `json:"index"`
+	MenuItemID    string     `json:"menu_item_id"`
+	Name          string     `json:"name"`
+	Station       string     `json:"station"`
+	Quantity      int        `json:"quantity"`
+	State         string     `json:"state"`
+	TargetMinutes int        `json:"target_minutes"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	DoneAt        *time.Time `json:"done_at,omitempty"`
+	Late          bool       `json:"late"`
+}
+
+// KitchenItemProgress хранит отметки кухни по позиции заказа
+type KitchenItemProgress struct {
+	StartedAt *time.Time `bson:"started_at,omitempty"`
+	DoneAt    *time.Time `bson:"done_at,omitempty"`
+}
+
+// KitchenStatusGroup объединяет билеты с одинаковым статусом заказа
+type KitchenStatusGroup struct {
+	Status  string          `json:"status"`
+	Tickets []KitchenTicket `json:"tickets"`
+}
+
+// KitchenStationItem представляет позицию в очереди станции
+type KitchenStationItem struct {
+	OrderID    string    `json:"order_id"`
+	PromisedAt time.Time `json:"promised_at"`
+	KitchenTicketItem
+}
+
+// KitchenStation представляет очередь неготовых позиций одной станции
+type KitchenStation struct {
+	Station string               `json:"station"`
+	Items   []KitchenStationItem `json:"items"`
+}
+
+// KitchenBoard представляет кухонный экран: заказы по статусам и позиции по станциям,
+// упорядоченные по обещанному времени
+type KitchenBoard struct {
+	GeneratedAt time.Time            `json:"generated_at"`
+	LateCount   int                  `json:"late_count"`
+	Statuses    []KitchenStatusGroup `json:"statuses"`
+	Stations    []KitchenStation     `json:"stations"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Статусы встречи
+const (
+	MeetupStatusScheduled = "scheduled"
+	MeetupStatusCancelled = "cancelled"
+)
+
+// Ответы на приглашение во встречу
+const (
+	RSVPInvited = "invited"
+	RSVPYes     = "yes"
+	RSVPNo      = "no"
+	RSVPMaybe   = "maybe"
+)
+
+// Meetup представляет совместный поход друзей в ресторан.
+// Seats - общее количество мест, включая организатора.
+type Meetup struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	OrganizerID  primitive.ObjectID `json:"organizer_id" bson:"organizer_id"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id" validate:"required"`
+	Title        string             `json:"title" bson:"title" validate:"required,max=200"`
+	Description  string             `json:"description" bson:"description" validate:"max=2000"`
+	StartsAt     time.Time          `json:"starts_at" bson:"starts_at" validate:"required"`
+	Seats        int                `json:"seats" bson:"seats" validate:"gte=2,lte=50"`
+	Status       string             `json:"status" bson:"status"`
+	Invitations  []MeetupInvitation `json:"invitations" bson:"invitations"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// MeetupInvitation представляет приглашение друга и его ответ.
+type MeetupInvitation struct {
+	UserID      primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Status      string             `json:"status" bson:"status"` // invited, yes, no, maybe
+	InvitedAt   time.Time          `json:"invited_at" bson:"invited_at"`
+	RespondedAt *time.Time         `json:"responded_at,omitempty" bson:"responded_at,omitempty"`
+}
+
+// RestaurantMeetupView представляет предстоящий групповой визит с точки зрения ресторана.
+// Не содержит данных участников, только количество гостей.
+type RestaurantMeetupView struct {
+	ID              primitive.ObjectID `json:"id"`
+	Title           string             `json:"title"`
+	StartsAt        time.Time          `json:"starts_at"`
+	Seats           int                `json:"seats"`
+	ConfirmedGuests int                `json:"confirmed_guests"` // организатор и ответившие "yes"
+	MaybeGuests     int                `json:"maybe_guests"`
+}
+
+// Validate выполняет валидацию полей встречи
+func (m *Meetup) Validate() error {
+	validate := validator.New()
+	return validate.Struct(m)
+}
+
+// ConfirmedCount возвращает количество подтвержденных участников, включая организатора
+func (m *Meetup) ConfirmedCount() int {
+	count := 1
+	for _, invitation := range m.Invitations {
+		if invitation.Status == RSVPYes {
+			count++
+		}
+	}
+	return count
+}
+
+// IsParticipant проверяет, является ли пользователь организатором или приглашенным
+func (m *Meetup) IsParticipant(userID primitive.ObjectID) bool {
+	if m.OrganizerID == userID {
+		return true
+	}
+	for _, invitation := range m.Invitations {
+		if invitation.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// ReviewReport представляет жалобу пользователя или ресторана на отзыв.
+type ReviewReport struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ReviewID     primitive.ObjectID `json:"review_id" bson:"review_id"`
+	ReporterID   primitive.ObjectID `json:"reporter_id" bson:"reporter_id"`
+	ReporterType string             `json:"reporter_type" bson:"reporter_type"` // users, restaurants
+	Reason       string             `json:"reason" bson:"reason" validate:"required,max=500"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Действия журнала модерации
+const (
+	ModerationActionAutoFlag = "auto_flag"
+	ModerationActionReported = "reported"
+	ModerationActionApprove  = "approve"
+	ModerationActionReject   = "reject"
+)
+
+// ModerationAuditEntry представляет запись журнала модерации.
+// ActorID пустой для автоматических действий системы. Для фото из отзыва заполнены PhotoID и ReviewID отзыва.
+type ModerationAuditEntry struct {
+	ID         primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ReviewID   primitive.ObjectID  `json:"review_id" bson:"review_id"`
+	PhotoID    *primitive.ObjectID `json:"photo_id,omitempty" bson:"photo_id,omitempty"`
+	ActorID    primitive.ObjectID  `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Action     string              `json:"action" bson:"action"`
+	FromStatus string              `json:"from_status" bson:"from_status"`
+	ToStatus   string              `json:"to_status" bson:"to_status"`
+	Reason     string              `json:"reason,omitempty" bson:"reason,omitempty"`
+	CreatedAt  time.Time           `json:"created_at" bson:"created_at"`
+}
+
+// BulkModerationResult представляет результат модерации одного отзыва в пакетной операции.
+type BulkModerationResult struct {
+	ReviewID primitive.ObjectID `json:"review_id"`
+	Status   string             `json:"status,omitempty"`
+	Error    string             `json:"error,omitempty"`
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Notification представляет уведомление во входящих пользователя.
+type Notification struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Type      string             `json:"type" bson:"type"`
+	Title     string             `json:"title" bson:"title"`
+	Data      map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	Read      bool               `json:"read" bson:"read"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// Каналы доставки уведомлений вне приложения
+const (
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+	NotificationChannelPush  = "push"
+)
+
+// Языки шаблонов уведомлений
+const (
+	LocaleRU = "ru"
+	LocaleEN = "en"
+)
+
+// Статусы сообщения в очереди отправки
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSending = "sending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed"
+)
+
+// NotificationPreferences представляет настройки уведомлений пользователя.
+// Channels включает каналы для всех типов; Types переопределяет их для отдельных типов,
+// пустой список в Types отключает внешние каналы для типа. Входящие сохраняются всегда.
+type NotificationPreferences struct {
+	Locale     string              `json:"locale" bson:"locale" validate:"omitempty,oneof=ru en"`
+	Channels   []string            `json:"channels" bson:"channels" validate:"max=3,dive,oneof=email sms push"`
+	Types      map[string][]string `json:"types,omitempty" bson:"types,omitempty" validate:"max=50,dive,keys,min=1,max=50,endkeys,max=3,dive,oneof=email sms push"`
+	QuietHours *QuietHours         `json:"quiet_hours,omitempty" bson:"quietHours,omitempty"`
+}
+
+// QuietHours представляет интервал, в который уведомления откладываются до его окончания.
+// Интервал может переходить через полночь, например с 22:00 до 08:00.
+type QuietHours struct {
+	Start    string `json:"start" bson:"start" validate:"required,len=5"` // ЧЧ:ММ
+	End      string `json:"end" bson:"end" validate:"required,len=5"`     // ЧЧ:ММ
+	Timezone string `json:"timezone,omitempty" bson:"timezone,omitempty" validate:"max=64"`
+}
+
+// Validate выполняет валидацию настроек уведомлений
+func (p *NotificationPreferences) Validate() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+// ChannelsFor возвращает каналы, включенные пользователем для типа уведомления
+func (p *NotificationPreferences) ChannelsFor(notificationType string) []string {
+	if channels, ok := p.Types[notificationType]; ok {
+		return channels
+	}
+	return p.Channels
+}
+
+// DefaultNotificationPreferences возвращает настройки для пользователя, который их не менял.
+// SMS платные для сервиса, поэтому включаются только явно.
+func DefaultNotificationPreferences() NotificationPreferences {
+	return NotificationPreferences{
+		Locale:   LocaleRU,
+		Channels: []string{NotificationChannelEmail, NotificationChannelPush},
+	}
+}
+
+// PushSubscription представляет подписку браузера на web-push
+type PushSubscription struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Endpoint  string             `json:"endpoint" bson:"endpoint" validate:"required,url,max=2000"`
+	Keys      PushKeys           `json:"keys" bson:"keys"`
+	UserAgent string             `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// PushKeys ключи шифрования подписки в base64url, как их отдает PushSubscription.toJSON()
+type PushKeys struct {
+	P256dh string `json:"p256dh" bson:"p256dh" validate:"required,max=200"`
+	Auth   string `json:"auth" bson:"auth" validate:"required,max=100"`
+}
+
+// Validate выполняет валидацию подписки
+func (s *PushSubscription) Validate() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+// OutboxMessage представляет сообщение в очереди отправки по внешнему каналу
+type OutboxMessage struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Type          string             `json:"type" bson:"type"`
+	Channel       string             `json:"channel" bson:"channel"`
+	To            string             `json:"to" bson:"to"` // email, телефон или endpoint подписки
+	PushKeys      *PushKeys          `json:"-" bson:"push_keys,omitempty"`
+	Subject       string             `json:"subject,omitempty" bson:"subject,omitempty"`
+	Body          string             `json:"body,omitempty" bson:"body,omitempty"`
+	Data          map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	Sensitive     bool               `json:"sensitive" bson:"sensitive"` // текст удаляется после отправки
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	SentAt        *time.Time         `json:"sent_at,omitempty" bson:"sent_at,omitempty"`
+	CompletedAt   *time.Time         `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+// Статусы заказа
+const (
+	OrderStatusPending   = "pending"
+	OrderStatusConfirmed = "confirmed"
+	OrderStatusPreparing = "preparing"
+	OrderStatusDelivered = "delivered"
+	OrderStatusCancelled = "cancelled"
+)
+
+// OrderTransitions перечисляет допустимые переходы между статусами заказа
+var OrderTransitions = map[string][]string{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusPreparing, OrderStatusCancelled},
+	OrderStatusPreparing: {OrderStatusDelivered},
+}
+
+// CanTransition сообщает, можно ли перевести заказ из статуса from в статус to
+func CanTransition(from, to string) bool {
+	for _, allowed := range OrderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Типы событий заказа
+const (
+	OrderEventCreated       = "order_created"
+	OrderEventStatusChanged = "order_status"
+	OrderEventDelivery      = "delivery_status" // Status содержит статус доставки
+)
+
+// OrderEvent представляет изменение заказа, рассылаемое подписчикам.
+// ID монотонно растет и используется клиентами SSE как Last-Event-ID.
+type OrderEvent struct {
+	ID             int64              `json:"id"`
+	Type           string             `json:"type"`
+	OrderID        string             `json:"order_id"`
+	RestaurantID   primitive.ObjectID `json:"restaurant_id"`
+	UserIDs        []string           `json:"user_ids,omitempty"`
+	Status         string             `json:"status"`
+	PreviousStatus string             `json:"previous_status,omitempty"`
+	At             time.Time          `json:"at"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// Теги фотографий галереи ресторана
+const (
+	PhotoTagInterior = "interior"
+	PhotoTagFood     = "food"
+	PhotoTagMenu     = "menu"
+)
+
+// Источники фотографий: загружены рестораном или приложены пользователем к отзыву
+const (
+	PhotoSourceRestaurant = "restaurant"
+	PhotoSourceReview     = "review"
+)
+
+// Статусы модерации фотографии. Фото ресторана публикуются сразу, фото из отзывов - после модерации.
+const (
+	PhotoStatusPending  = "pending"
+	PhotoStatusApproved = "approved"
+	PhotoStatusRejected = "rejected"
+)
+
+// RestaurantPhoto представляет фотографию в галерее ресторана.
+// Position задает порядок фото ресторана; фото из отзывов упорядочены по дате.
+type RestaurantPhoto struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	RestaurantID     primitive.ObjectID  `json:"restaurant_id" bson:"restaurant_id"`
+	Source           string              `json:"source" bson:"source"`
+	ReviewID         *primitive.ObjectID `json:"review_id,omitempty" bson:"review_id,omitempty"`
+	UserID           *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Image            Image               `json:"image" bson:"image"`
+	Caption          string              `json:"caption" bson:"caption" validate:"max=300"`
+	Tags             []string            `json:"tags" bson:"tags" validate:"max=3,dive,oneof=interior food menu"`
+	Position         int                 `json:"position" bson:"position"`
+	Status           string              `json:"status" bson:"status"`
+	ModerationReason string              `json:"moderation_reason,omitempty" bson:"moderation_reason,omitempty"`
+	ReviewHidden     bool                `json:"-" bson:"review_hidden,omitempty"` // отзыв фото снят с публикации
+	Cover            bool                `json:"cover,omitempty" bson:"-"`
+	CreatedAt        time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt        time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate выполняет валидацию подписи и тегов фотографии
+func (p *RestaurantPhoto) Validate() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+// RestaurantGallery представляет публичную галерею ресторана.
+// Cover - выбранная рестораном обложка или, если она не выбрана, первое фото ресторана.
+type RestaurantGallery struct {
+	RestaurantID   primitive.ObjectID `json:"restaurant_id"`
+	Cover          *RestaurantPhoto   `json:"cover,omitempty"`
+	Photos         []RestaurantPhoto  `json:"photos"`
+	UserPhotos     []RestaurantPhoto  `json:"user_photos"`
+	UserPhotoTotal int64              `json:"user_photo_total"`
+	Page           int                `json:"page"`
+	Limit          int                `json:"limit"`
+}
+
+// PhotoPage представляет страницу фотографий с общим количеством.
+type PhotoPage struct {
+	Photos []RestaurantPhoto `json:"photos"`
+	Total  int64             `json:"total"`
+	Page   int               `json:"page"`
+	Limit  int               `json:"limit"`
+}
+
+// PhotoModerationResult представляет результат модерации одной фотографии в пакетной операции.
+type PhotoModerationResult struct {
+	PhotoID primitive.ObjectID `json:"photo_id"`
+	Status  string             `json:"status,omitempty"`
+	Error   string             `json:"error,omitempty"`
+}
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Статусы опроса
+const (
+	PollStatusOpen   = "open"
+	PollStatusClosed = "closed"
+)
+
+// Poll представляет опрос друзей о выборе ресторана с ранжированным голосованием.
+// Бюллетени хранятся по hex ID пользователя и не раскрываются до закрытия опроса.
+type Poll struct {
+	ID          primitive.ObjectID    `json:"id" bson:"_id,omitempty"`
+	CreatorID   primitive.ObjectID    `json:"creator_id" bson:"creator_id"`
+	Title       string                `json:"title" bson:"title" validate:"required,max=200"`
+	Options     []PollOption          `json:"options" bson:"options" validate:"min=2,max=10"`
+	VoterIDs    []primitive.ObjectID  `json:"voter_ids" bson:"voter_ids"`
+	Ballots     map[string]PollBallot `json:"-" bson:"ballots"`
+	BallotCount int                   `json:"ballot_count" bson:"-"`
+	MyRanking   []primitive.ObjectID  `json:"my_ranking,omitempty" bson:"-"`
+	Deadline    time.Time             `json:"deadline" bson:"deadline" validate:"required"`
+	Status      string                `json:"status" bson:"status"`
+	WinnerID    *primitive.ObjectID   `json:"winner_id,omitempty" bson:"winner_id,omitempty"`
+	Rounds      []PollRound           `json:"rounds,omitempty" bson:"rounds,omitempty"`
+	MeetupID    *primitive.ObjectID   `json:"meetup_id,omitempty" bson:"meetup_id,omitempty"`
+	CreatedAt   time.Time             `json:"created_at" bson:"created_at"`
+	ClosedAt    *time.Time            `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+}
+
+// PollOption представляет ресторан-вариант опроса
+type PollOption struct {
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	Name         string             `json:"name" bson:"name"`
+}
+
+// PollBallot представляет бюллетень: рестораны в порядке предпочтения
+type PollBallot struct {
+	UserID  primitive.ObjectID   `json:"user_id" bson:"user_id"`
+	Ranking []primitive.ObjectID `json:"ranking" bson:"ranking"`
+	CastAt  time.Time            `json:"cast_at" bson:"cast_at"`
+}
+
+// PollRound представляет раунд подсчета голосов методом мгновенного второго тура
+type PollRound struct {
+	Round      int                 `json:"round" bson:"round"`
+	Counts     map[string]int      `json:"counts" bson:"counts"` // hex ID ресторана -> голоса
+	Eliminated *primitive.ObjectID `json:"eliminated,omitempty" bson:"eliminated,omitempty"`
+}
+
+// Validate выполняет валидацию полей опроса
+func (p *Poll) Validate() error {
+	validate := validator.New()
+	return validate.Struct(p)
+}
+
+// IsParticipant проверяет, является ли пользователь автором или приглашенным участником опроса
+func (p *Poll) IsParticipant(userID primitive.ObjectID) bool {
+	if p.CreatorID == userID {
+		return true
+	}
+	for _, voterID := range p.VoterIDs {
+		if voterID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// HasOption проверяет, является ли ресторан вариантом опроса
+func (p *Poll) HasOption(restaurantID primitive.ObjectID) bool {
+	for _, option := range p.Options {
+		if option.RestaurantID == restaurantID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,8 @@
+package models
+
+// Recommendation представляет рекомендованный пользователю ресторан с пояснениями
+type Recommendation struct {
+	Restaurant RestaurantSummary `json:"restaurant"`
+	Score      float64           `json:"score"`
+	Reasons    []string          `json:"reasons"`
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Статусы бронирования
+const (
+	ReservationStatusPending   = "pending"
+	ReservationStatusConfirmed = "confirmed"
+	ReservationStatusDeclined  = "declined"
+	ReservationStatusCancelled = "cancelled"
+	ReservationStatusNoShow    = "no_show"
+	ReservationStatusCompleted = "completed"
+)
+
+// ReservationSettings представляет настройки бронирования ресторана.
+// Если заданы столы, гости рассаживаются за столы; иначе учитывается общее число мест.
+type ReservationSettings struct {
+	Tables          []Table `json:"tables" bson:"tables" validate:"dive"`
+	SeatCapacity    int     `json:"seat_capacity" bson:"seatCapacity" validate:"gte=0,lte=2000"`
+	SlotMinutes     int     `json:"slot_minutes" bson:"slotMinutes" validate:"gte=15,lte=480"`        // длительность визита
+	IntervalMinutes int     `json:"interval_minutes" bson:"intervalMinutes" validate:"gte=5,lte=240"` // шаг сетки времени
+	MaxDaysAhead    int     `json:"max_days_ahead" bson:"maxDaysAhead" validate:"gte=1,lte=365"`      // горизонт бронирования
+	MaxPartySize    int     `json:"max_party_size" bson:"maxPartySize" validate:"gte=1,lte=100"`      // размер компании
+	AutoConfirm     bool    `json:"auto_confirm" bson:"autoConfirm"`                                  // подтверждать без участия ресторана
+}
+
+// Table представляет стол ресторана
+type Table struct {
+	ID    string `json:"id" bson:"id" validate:"required,max=50"`
+	Seats int    `json:"seats" bson:"seats" validate:"gte=1,lte=100"`
+}
+
+// Reservation представляет бронирование столика пользователем
+type Reservation struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RestaurantID  primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	UserID        primitive.ObjectID `json:"user_id" bson:"user_id"`
+	PartySize     int                `json:"party_size" bson:"party_size" validate:"gte=1,lte=100"`
+	StartsAt      time.Time          `json:"starts_at" bson:"starts_at" validate:"required"`
+	EndsAt        time.Time          `json:"ends_at" bson:"ends_at"`
+	TableIDs      []string           `json:"table_ids,omitempty" bson:"table_ids,omitempty"`
+	Status        string             `json:"status" bson:"status"`
+	Note          string             `json:"note,omitempty" bson:"note,omitempty" validate:"max=500"`
+	DeclineReason string             `json:"decline_reason,omitempty" bson:"decline_reason,omitempty"`
+	GuestStats    *ReservationStats  `json:"guest_stats,omitempty" bson:"-"` // заполняется для ресторана
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// ReservationStats представляет историю бронирований пользователя
+type ReservationStats struct {
+	Completed         int `json:"completed" bson:"completed"`
+	Cancellations     int `json:"cancellations" bson:"cancellations"`
+	LateCancellations int `json:"late_cancellations" bson:"lateCancellations"`
+	NoShows           int `json:"no_shows" bson:"noShows"`
+}
+
+// AvailabilitySlot представляет время начала, доступное для бронирования
+type AvailabilitySlot struct {
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// Validate выполняет валидацию настроек бронирования
+func (s *ReservationSettings) Validate() error {
+	validate := validator.New()
+	return validate.Struct(s)
+}
+
+// Validate выполняет валидацию полей бронирования
+func (r *Reservation) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
@@ -14,26 +14,71 @@ type RestaurantCredentials struct {
 
 // Restaurant структура, представляющая ресторан
 type Restaurant struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty"`
-	Email        string             `json:"email" bson:"email" validate:"required,email"`
-	Password     string             `json:"password" bson:"password" validate:"required,min=6"`
-	Name         string             `bson:"name" validate:"required"`
-	AveragePrice int                `bson:"averagePrice" validate:"required,gt=0"`
-	Description  string             `bson:"description" validate:"required"`
-	Category     string             `bson:"category" validate:"required"`
-	OGRN         string             `bson:"ogrn" validate:"required,len=13"` // Проверка длины
-	INN          string             `bson:"inn" validate:"required,len=10"`  // Проверка длины
-	Address      string             `bson:"address" validate:"required"`
-	Avatar       string             `bson:"avatar,omitempty"`
-	Phone        string             `bson:"phone" validate:"required,len=11"`
+	ID           primitive.ObjectID   `bson:"_id,omitempty"`
+	Email        string               `json:"email" bson:"email" validate:"required,email"`
+	Password     string               `json:"password" bson:"password" validate:"required,min=6"`
+	Name         string               `bson:"name" validate:"required"`
+	AveragePrice int                  `bson:"averagePrice" validate:"required,gt=0"`
+	Description  string               `bson:"description" validate:"required"`
+	Category     string               `bson:"category" validate:"required"`
+	OGRN         string               `bson:"ogrn" validate:"required,len=13"` // Проверка длины
+	INN          string               `bson:"inn" validate:"required,len=10"`  // Проверка длины
+	Address      string               `bson:"address" validate:"required"`
+	Location     *GeoPoint            `json:"location,omitempty" bson:"location,omitempty"`
+	DeliveryZone *GeoMultiPolygon     `json:"delivery_zone,omitempty" bson:"deliveryZone,omitempty"`
+	Avatar       string               `bson:"avatar,omitempty"`
+	AvatarImage  *Image               `json:"avatar_image,omitempty" bson:"avatarImage,omitempty"` // загруженный аватар, приоритетнее Avatar
+	CoverPhotoID *primitive.ObjectID  `json:"cover_photo_id,omitempty" bson:"coverPhotoId,omitempty"`
+	Phone        string               `bson:"phone" validate:"required,len=11"`
+	Hours        string               `json:"hours" bson:"hours"`
+	OpeningHours []OpeningHours       `json:"opening_hours" bson:"openingHours,omitempty" validate:"dive"`
+	DietaryTags  []string             `json:"dietary_tags" bson:"dietaryTags,omitempty"`
+	Banned       bool                 `bson:"banned,omitempty"`
+	BanReason    string               `bson:"banReason,omitempty"`
+	Roles        string               `json:"roles" bson:"roles,omitempty"`
+	RefreshToken string               `json:"-"`
+	Menu         []MenuItem           `json:"menu" bson:"menu"`
+	Orders       []Order              `json:"orders" bson:"orders"`
+	Rating       *RatingSummary       `json:"rating,omitempty" bson:"rating,omitempty"`
+	Reservations *ReservationSettings `json:"reservation_settings,omitempty" bson:"reservationSettings,omitempty"`
+	Kitchen      *KitchenSettings     `json:"kitchen_settings,omitempty" bson:"kitchenSettings,omitempty"`
+	DeliveryFees *DeliveryFeeSettings `json:"delivery_fees,omitempty" bson:"deliveryFees,omitempty"`
+}
+
+// OpeningHours представляет часы работы ресторана в один из дней недели.
+// Время указывается в формате "HH:MM" по местному времени ресторана; работа после полуночи
+// описывается отдельной записью следующего дня, например "00:00"-"02:00".
+type OpeningHours struct {
+	Day   int    `json:"day" bson:"day" validate:"gte=0,lte=6"` // 0 - воскресенье
+	Open  string `json:"open" bson:"open" validate:"required,len=5"`
+	Close string `json:"close" bson:"close" validate:"required,len=5"` // допускается "24:00"
+}
+
+// RestaurantSummary представляет публичные данные ресторана в результатах поиска.
+// Не содержит пароля, токенов, заказов и реквизитов.
+type RestaurantSummary struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id"`
+	Name         string             `json:"name" bson:"name"`
+	Description  string             `json:"description" bson:"description"`
+	Category     string             `json:"category" bson:"category"`
+	AveragePrice int                `json:"average_price" bson:"averagePrice"`
+	Address      string             `json:"address" bson:"address"`
+	Location     *GeoPoint          `json:"location,omitempty" bson:"location,omitempty"`
+	Distance     *float64           `json:"distance_m,omitempty" bson:"distance,omitempty"` // расстояние до точки запроса в метрах
+	Avatar       string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	AvatarImage  *Image             `json:"avatar_image,omitempty" bson:"avatarImage,omitempty"`
+	Phone        string             `json:"phone" bson:"phone"`
 	Hours        string             `json:"hours" bson:"hours"`
-	Banned       bool               `bson:"banned,omitempty"`
-	BanReason    string             `bson:"banReason,omitempty"`
-	Roles        string             `json:"roles" bson:"roles,omitempty"`
-	RefreshToken string             `json:"-"`
-	Menu         []MenuItem         `json:"menu" bson:"menu"`
-	Orders       []Order            `json:"orders" bson:"orders"`
-	Reviews      []Review           `json:"reviews" bson:"reviews"`
+	OpeningHours []OpeningHours     `json:"opening_hours,omitempty" bson:"openingHours,omitempty"`
+	DietaryTags  []string           `json:"dietary_tags,omitempty" bson:"dietaryTags,omitempty"`
+	Rating       *RatingSummary     `json:"rating,omitempty" bson:"rating,omitempty"`
+}
+
+// RestaurantSearchPage представляет страницу результатов поиска ресторанов.
+// NextCursor пустой, если страница последняя.
+type RestaurantSearchPage struct {
+	Restaurants []RestaurantSummary `json:"restaurants"`
+	NextCursor  string              `json:"next_cursor,omitempty"`
 }
 
 // MenuItem представляет информацию о блюде в меню ресторана.
@@ -45,18 +90,20 @@ type MenuItem struct {
 	Price        float64 `json:"price" bson:"price"`
 	Category     string  `json:"category" bson:"category"`
 	ImageURL     string  `json:"image_url" bson:"image_url"`
+	Image        *Image  `json:"image,omitempty" bson:"image,omitempty"` // загруженное фото блюда, приоритетнее ImageURL
 }
 
 // Order представляет информацию о заказе.
 type Order struct {
-	ID            string        `json:"id" bson:"_id,omitempty"`
-	UserID        string        `json:"user_id" bson:"user_id"`
-	RestaurantID  string        `json:"restaurant_id" bson:"restaurant_id"`
-	Items         []OrderItem   `json:"items" bson:"items"`
-	PaymentMethod PaymentMethod `json:"payment_method" bson:"payment_method"`
-	Status        string        `json:"status" bson:"status"` // pending, confirmed, preparing, delivered
-	CreatedAt     time.Time     `json:"created_at" bson:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at" bson:"updated_at"`
+	ID            string         `json:"id" bson:"_id,omitempty"`
+	UserID        string         `json:"user_id" bson:"user_id"`
+	RestaurantID  string         `json:"restaurant_id" bson:"restaurant_id"`
+	Items         []OrderItem    `json:"items" bson:"items"`
+	PaymentMethod PaymentMethod  `json:"payment_method" bson:"payment_method"`
+	Status        string         `json:"status" bson:"status"`                         // pending, confirmed, preparing, delivered
+	Delivery      *OrderDelivery `json:"delivery,omitempty" bson:"delivery,omitempty"` // nil для заказов без доставки
+	CreatedAt     time.Time      `json:"created_at" bson:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at" bson:"updated_at"`
 }
 
 // OrderItem представляет информацию о позиции заказа.
@@ -67,12 +114,50 @@ type OrderItem struct {
 
 // Review представляет отзыв о ресторане.
 type Review struct {
-	ID           string    `json:"id" bson:"_id,omitempty"`
-	RestaurantID string    `json:"restaurant_id" bson:"restaurant_id"`
-	UserID       string    `json:"user_id" bson:"user_id"`
-	Rating       int       `json:"rating" bson:"rating"`
-	Comment      string    `json:"comment" bson:"comment"`
-	CreatedAt    time.Time `json:"created_at" bson:"created_at"`
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	UserID       primitive.ObjectID `json:"user_id" bson:"user_id"`
+	OrderID      string             `json:"order_id" bson:"order_id" validate:"required"`
+	Rating       int                `json:"rating" bson:"rating" validate:"required,gte=1,lte=5"`
+	Comment      string             `json:"comment" bson:"comment" validate:"max=2000"`
+	Reply        *ReviewReply       `json:"reply,omitempty" bson:"reply,omitempty"`
+	Status       string             `json:"status" bson:"status"`                       // pending, approved, rejected
+	Flags        []string           `json:"flags,omitempty" bson:"flags,omitempty"`     // видны только модераторам
+	ReportCount  int                `json:"report_count,omitempty" bson:"report_count"` // видно только модераторам
+	Photos       []RestaurantPhoto  `json:"photos,omitempty" bson:"-"`                  // одобренные фото, приложенные к отзыву
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Статусы модерации отзыва
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusRejected = "rejected"
+)
+
+// ReviewReply представляет ответ ресторана на отзыв.
+type ReviewReply struct {
+	Text      string    `json:"text" bson:"text" validate:"required,max=2000"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+}
+
+// ReviewPage представляет страницу отзывов с общим количеством.
+type ReviewPage struct {
+	Reviews []Review `json:"reviews"`
+	Total   int64    `json:"total"`
+	Page    int      `json:"page"`
+	Limit   int      `json:"limit"`
+}
+
+// RatingSummary представляет агрегированный рейтинг ресторана.
+// Поддерживается инкрементально при добавлении, изменении и удалении отзывов.
+type RatingSummary struct {
+	Average   float64        `json:"average" bson:"average"`
+	Count     int            `json:"count" bson:"count"`
+	Sum       int            `json:"-" bson:"sum"`
+	Histogram map[string]int `json:"histogram" bson:"histogram"` // ключи "1".."5"
+	Version   int64          `json:"-" bson:"version"`           // растет с каждым изменением рейтинга
 }
 
 // ValidateRestaurant проводит валидацию полей ресторана
@@ -82,6 +167,12 @@ func ValidateRestaurant(restaurant *Restaurant) error {
 	return validate.Struct(restaurant)
 }
 
+// Validate выполняет валидацию полей отзыва
+func (r *Review) Validate() error {
+	validate := validator.New()
+	return validate.Struct(r)
+}
+
 func (r *Restaurant) GetEmail() string          { return r.Email }
 func (r *Restaurant) GetPassword() string       { return r.Password }
 func (r *Restaurant) GetID() primitive.ObjectID { return r.ID }
@@ -105,6 +196,8 @@ func (r *Restaurant) GetCustomData() map[string]interface{} {
 		"phone":        r.Phone,
 		"banned":       r.Banned,
 		"banReason":    r.BanReason,
+		"openingHours": r.OpeningHours,
+		"dietaryTags":  r.DietaryTags,
 		"roles":        r.GetRoles(), // Добавлено получение ролей
 	}
 }
@@ -0,0 +1,23 @@
+package models
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+// Типы документов поискового индекса
+const (
+	SearchKindRestaurant = "restaurant"
+	SearchKindDish       = "dish"
+)
+
+// SearchHit представляет найденный ресторан или блюдо.
+// Highlights содержит фрагменты полей с совпадениями, выделенными тегами <em>.
+type SearchHit struct {
+	Kind         string             `json:"kind"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id"`
+	MenuItemID   string             `json:"menu_item_id,omitempty"`
+	Name         string             `json:"name"`
+	Description  string             `json:"description,omitempty"`
+	Highlights   map[string]string  `json:"highlights,omitempty"`
+	Rating       float64            `json:"rating,omitempty"`
+	DistanceM    *float64           `json:"distance_m,omitempty"`
+	Score        float64            `json:"score"`
+}
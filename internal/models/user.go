@@ -5,6 +5,9 @@ import (
 	"gopkg.in/go-playground/validator.v9"
 )
 
+// RoleAdmin роль администратора. Выдается только вне API: через ADMIN_EMAILS при запуске сервера.
+const RoleAdmin = "admin"
+
 type UserCredentials struct {
 	Email        string `json:"email"`
 	Password     string `json:"password"`
@@ -13,23 +16,28 @@ type UserCredentials struct {
 
 // User структура, представляющая пользователя
 type User struct {
-	ID             primitive.ObjectID   `bson:"_id,omitempty"`
-	Email          string               `json:"email" bson:"email" validate:"required,email"`
-	Password       string               `json:"password" bson:"password" validate:"required,min=6"`
-	Surname        string               `json:"surname" bson:"surname" validate:"required"`
-	Name           string               `json:"name" bson:"name" validate:"required"`
-	Age            int                  `json:"age" bson:"age" validate:"required,gte=0,lte=130"`
-	Phone          string               `json:"phone" bson:"phone" validate:"required,len=11"`
-	Interests      string               `json:"interests" bson:"interests" validate:"max=1000"`
-	Description    string               `json:"description" bson:"description" validate:"max=1000"`
-	Avatar         string               `json:"avatar" bson:"avatar" validate:"max=1000"`
-	Banned         bool                 `json:"banned" bson:"banned,omitempty"`
-	BanReason      string               `json:"ban_reason" bson:"banReason,omitempty"`
-	Roles          string               `json:"roles" bson:"roles,omitempty"`
-	RefreshToken   string               `json:"-"`
-	Favorites      []primitive.ObjectID `json:"favorites" bson:"favorites,omitempty"`
-	PaymentMethods []PaymentMethod      `json:"payment_methods" bson:"payment_methods"`
-	Orders         []Order              `json:"orders" bson:"orders"`
+	ID             primitive.ObjectID       `bson:"_id,omitempty"`
+	Email          string                   `json:"email" bson:"email" validate:"required,email"`
+	Password       string                   `json:"password" bson:"password" validate:"required,min=6"`
+	Surname        string                   `json:"surname" bson:"surname" validate:"required"`
+	Name           string                   `json:"name" bson:"name" validate:"required"`
+	Age            int                      `json:"age" bson:"age" validate:"required,gte=0,lte=130"`
+	Phone          string                   `json:"phone" bson:"phone" validate:"required,len=11"`
+	Interests      string                   `json:"interests" bson:"interests" validate:"max=1000"`
+	Description    string                   `json:"description" bson:"description" validate:"max=1000"`
+	Avatar         string                   `json:"avatar" bson:"avatar" validate:"max=1000"`
+	AvatarImage    *Image                   `json:"avatar_image,omitempty" bson:"avatarImage,omitempty"` // загруженный аватар, приоритетнее Avatar
+	Banned         bool                     `json:"banned" bson:"banned,omitempty"`
+	BanReason      string                   `json:"ban_reason" bson:"banReason,omitempty"`
+	Roles          string                   `json:"roles" bson:"roles,omitempty"`
+	RefreshToken   string                   `json:"-"`
+	Favorites      []primitive.ObjectID     `json:"favorites" bson:"favorites,omitempty"`
+	PaymentMethods []PaymentMethod          `json:"payment_methods" bson:"payment_methods"`
+	Orders         []Order                  `json:"orders" bson:"orders"`
+	Addresses      []Address                `json:"-" bson:"addresses,omitempty"` // отдаются только владельцу через /users/me/addresses
+	Reservations   *ReservationStats        `json:"reservation_stats,omitempty" bson:"reservationStats,omitempty"`
+	Privacy        ActivityPrivacy          `json:"activity_privacy,omitempty" bson:"activityPrivacy,omitempty"`
+	Notifications  *NotificationPreferences `json:"-" bson:"notificationPreferences,omitempty"`
 }
 
 // PaymentMethod представляет информацию о способе оплаты пользователя.
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// Статусы записи в листе ожидания
+const (
+	WaitlistStatusWaiting  = "waiting"
+	WaitlistStatusNotified = "notified" // стол готов, гостей пригласили
+	WaitlistStatusSeated   = "seated"
+	WaitlistStatusLeft     = "left"
+)
+
+// WaitlistEntry представляет компанию в очереди ресторана.
+// UserID пустой, если гостей без аккаунта добавил персонал.
+type WaitlistEntry struct {
+	ID               primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	RestaurantID     primitive.ObjectID  `json:"restaurant_id" bson:"restaurant_id"`
+	UserID           *primitive.ObjectID `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Name             string              `json:"name" bson:"name" validate:"required,max=100"`
+	Phone            string              `json:"phone,omitempty" bson:"phone,omitempty" validate:"omitempty,max=20"`
+	PartySize        int                 `json:"party_size" bson:"party_size" validate:"gte=1,lte=50"`
+	Status           string              `json:"status" bson:"status"`
+	Active           bool                `json:"-" bson:"active"` // ожидает или приглашен к столу
+	PositionAtJoin   int                 `json:"-" bson:"position_at_join"`
+	Position         int                 `json:"position,omitempty" bson:"-"`
+	EstimatedWaitMin int                 `json:"estimated_wait_min" bson:"-"`
+	QuotedWaitMin    int                 `json:"quoted_wait_min" bson:"quoted_wait_min"` // оценка на момент постановки в очередь
+	CreatedAt        time.Time           `json:"created_at" bson:"created_at"`
+	NotifiedAt       *time.Time          `json:"notified_at,omitempty" bson:"notified_at,omitempty"`
+	SeatedAt         *time.Time          `json:"seated_at,omitempty" bson:"seated_at,omitempty"`
+	LeftAt           *time.Time          `json:"left_at,omitempty" bson:"left_at,omitempty"`
+}
+
+// Validate выполняет валидацию полей записи в листе ожидания
+func (e *WaitlistEntry) Validate() error {
+	validate := validator.New()
+	return validate.Struct(e)
+}
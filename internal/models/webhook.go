@@ -0,0 +1,97 @@
+package models
+
+import (
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/go-playground/validator.v9"
+	"time"
+)
+
+// WebhookEventTest тип тестового события, которое ресторан отправляет себе сам
+const WebhookEventTest = "webhook.test"
+
+// WebhookEventTypes события заказов, на которые можно подписать вебхук
+var WebhookEventTypes = []string{OrderEventCreated, OrderEventStatusChanged, OrderEventDelivery}
+
+// Статусы доставки вебхука
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySending   = "sending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // попытки исчерпаны, доставка в списке недоставленных
+)
+
+// Webhook представляет подписку ресторана на события заказов.
+// Секрет используется для подписи HMAC и показывается только при создании и смене.
+type Webhook struct {
+	ID           primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	RestaurantID primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	URL          string             `json:"url" bson:"url" validate:"required,url,max=2000"`
+	Events       []string           `json:"events" bson:"events" validate:"required,min=1,max=10,dive,oneof=order_created order_status delivery_status"`
+	Secret       string             `json:"secret,omitempty" bson:"secret" validate:"omitempty,min=16,max=200"`
+	Description  string             `json:"description,omitempty" bson:"description,omitempty" validate:"max=200"`
+	Active       bool               `json:"active" bson:"active"`
+	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// Validate выполняет валидацию вебхука
+func (w *Webhook) Validate() error {
+	validate := validator.New()
+	return validate.Struct(w)
+}
+
+// Subscribed проверяет, подписан ли вебхук на тип события
+func (w *Webhook) Subscribed(eventType string) bool {
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookEnvelope тело запроса, которое получает ресторан. ID события одинаков
+// во всех повторах и при повторной обработке того же события заказа, по нему получатель отбрасывает дубли.
+type WebhookEnvelope struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookOrderData данные события заказа в вебхуке
+type WebhookOrderData struct {
+	OrderID        string    `json:"order_id"`
+	Status         string    `json:"status"`
+	PreviousStatus string    `json:"previous_status,omitempty"`
+	At             time.Time `json:"at"`
+	Order          *Order    `json:"order,omitempty"`
+}
+
+// WebhookAttempt представляет одну попытку доставки
+type WebhookAttempt struct {
+	At         time.Time `json:"at" bson:"at"`
+	StatusCode int       `json:"status_code,omitempty" bson:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty" bson:"error,omitempty"`
+	Response   string    `json:"response,omitempty" bson:"response,omitempty"` // начало тела ответа
+	DurationMs int64     `json:"duration_ms" bson:"duration_ms"`
+}
+
+// WebhookDelivery представляет доставку одного события одному вебхуку
+type WebhookDelivery struct {
+	ID            primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	WebhookID     primitive.ObjectID `json:"webhook_id" bson:"webhook_id"`
+	RestaurantID  primitive.ObjectID `json:"restaurant_id" bson:"restaurant_id"`
+	EventID       string             `json:"event_id" bson:"event_id"`
+	EventType     string             `json:"event_type" bson:"event_type"`
+	Payload       string             `json:"payload" bson:"payload"`
+	Status        string             `json:"status" bson:"status"`
+	Attempts      int                `json:"attempts" bson:"attempts"`
+	NextAttemptAt time.Time          `json:"next_attempt_at" bson:"next_attempt_at"`
+	LockedUntil   *time.Time         `json:"-" bson:"locked_until,omitempty"`
+	LastError     string             `json:"last_error,omitempty" bson:"last_error,omitempty"`
+	History       []WebhookAttempt   `json:"history" bson:"history"`
+	CreatedAt     time.Time          `json:"created_at" bson:"created_at"`
+	DeliveredAt   *time.Time         `json:"delivered_at,omitempty" bson:"delivered_at,omitempty"`
+	DeadAt        *time.Time         `json:"dead_at,omitempty" bson:"dead_at,omitempty"`
+}
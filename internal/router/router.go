@@ -3,6 +3,7 @@ package router
 import (
 	"awesomeProject/internal/auth"
 	"awesomeProject/internal/handlers"
+	"awesomeProject/internal/models"
 	"github.com/gorilla/mux"
 	"github.com/joho/godotenv"
 	"log"
@@ -10,8 +11,42 @@ import (
 	"os"
 )
 
+// Handlers набор обработчиков, подключаемых к роутеру
+type Handlers struct {
+	User           *handlers.EntityHandler
+	Auth           *handlers.AuthHandler
+	Restaurant     *handlers.EntityHandler
+	Review         *handlers.ReviewHandler
+	Gallery        *handlers.GalleryHandler
+	Moderation     *handlers.ModerationHandler
+	Geo            *handlers.GeoHandler
+	Search         *handlers.SearchHandler
+	Friend         *handlers.FriendHandler
+	Meetup         *handlers.MeetupHandler
+	Notification   *handlers.NotificationHandler
+	Poll           *handlers.PollHandler
+	GroupOrder     *handlers.GroupOrderHandler
+	Reservation    *handlers.ReservationHandler
+	Waitlist       *handlers.WaitlistHandler
+	Activity       *handlers.ActivityHandler
+	Recommendation *handlers.RecommendationHandler
+	FavoriteList   *handlers.FavoriteListHandler
+	Chat           *handlers.ChatHandler
+	Order          *handlers.OrderHandler
+	Kitchen        *handlers.KitchenHandler
+	Delivery       *handlers.DeliveryHandler
+	Address        *handlers.AddressHandler
+	PasswordReset  *handlers.PasswordResetHandler
+	Webhook        *handlers.WebhookHandler
+	Event          *handlers.EventHandler
+	Job            *handlers.JobHandler
+	Media          *handlers.MediaHandler
+}
+
 // InitializeRouter настраивает и возвращает роутер
-func InitializeRouter(userHandler *handlers.EntityHandler, authHandler *handlers.AuthHandler, restaurantHandler *handlers.EntityHandler) *mux.Router {
+func InitializeRouter(h Handlers) *mux.Router {
+	userHandler, authHandler, restaurantHandler := h.User, h.Auth, h.Restaurant
+
 	if err := godotenv.Load(".env"); err != nil {
 		log.Fatal("Error loading .env file")
 	}
@@ -22,6 +57,9 @@ func InitializeRouter(userHandler *handlers.EntityHandler, authHandler *handlers
 	r := mux.NewRouter()
 	s := r.PathPrefix("/api").Subrouter()
 
+	// Файлы локального хранилища; доступ проверяется подписью ссылки, а не токеном
+	r.HandleFunc("/media/{key:.+}", h.Media.ServeMediaHandler).Methods("GET", "HEAD")
+
 	// Пользовательские маршруты
 	r.HandleFunc("/users/register", func(w http.ResponseWriter, r *http.Request) {
 		userHandler.RegisterHandler(w, r, "users")
@@ -39,14 +77,31 @@ func InitializeRouter(userHandler *handlers.EntityHandler, authHandler *handlers
 
 	}).Methods("POST")
 
-	r.HandleFunc("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
-		userHandler.GetEntityById(w, r, "users")
-	}).Methods("GET")
+	// Профиль пользователя доступен только авторизованным пользователям, чтобы блокировку
+	// нельзя было обойти анонимным запросом
+	r.Handle("/users/{user_id}", auth.AuthMiddleware([]byte(secretKey))(
+		http.HandlerFunc(h.Friend.GetProfileHandler),
+	)).Methods("GET")
+
+	// Восстановление пароля
+	r.HandleFunc("/users/password/forgot", h.PasswordReset.ForgotPasswordHandler).Methods("POST")
+	r.HandleFunc("/users/password/reset", h.PasswordReset.ResetPasswordHandler).Methods("POST")
+
+	r.HandleFunc("/search", h.Search.FullTextSearchHandler).Methods("GET")
+	r.HandleFunc("/restaurants", restaurantHandler.SearchRestaurantsHandler).Methods("GET")
+	r.HandleFunc("/restaurants/nearby", h.Geo.NearbyHandler).Methods("GET")
+	r.HandleFunc("/restaurants/within", h.Geo.WithinBoxHandler).Methods("GET")
 
 	r.HandleFunc("/restaurants/{id}", func(w http.ResponseWriter, r *http.Request) {
 		restaurantHandler.GetEntityById(w, r, "restaurants")
 	}).Methods("GET")
 
+	r.HandleFunc("/restaurants/{id}/reviews", h.Review.ListRestaurantReviewsHandler).Methods("GET")
+	r.HandleFunc("/restaurants/{id}/photos", h.Gallery.GetGalleryHandler).Methods("GET")
+	r.HandleFunc("/restaurants/{id}/serviceable", h.Geo.ServiceableHandler).Methods("GET")
+	r.HandleFunc("/restaurants/{id}/availability", h.Reservation.AvailabilityHandler).Methods("GET")
+	r.HandleFunc("/restaurants/{id}/delivery-quote", h.Delivery.QuoteHandler).Methods("GET")
+
 	// Secure rout
 
 	s.Use(auth.AuthMiddleware([]byte(secretKey)))
@@ -77,8 +132,228 @@ func InitializeRouter(userHandler *handlers.EntityHandler, authHandler *handlers
 
 	s.HandleFunc("/users/favorites/add/{restaurant_id}", userHandler.AddFavoriteRestaurantHandler).Methods("POST")
 	s.HandleFunc("/users/favorites/get", userHandler.GetFavoriteRestaurantsHandler).Methods("GET")
+	s.HandleFunc("/users/favorites/{restaurant_id}", userHandler.RemoveFavoriteRestaurantHandler).Methods("DELETE")
 
 	s.HandleFunc("/change-password/{id}", userHandler.ChangePasswordHandler).Methods("POST")
 
+	// Геоданные ресторана
+	s.HandleFunc("/restaurants/me/location", h.Geo.SetLocationHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/delivery-zone", h.Geo.SetDeliveryZoneHandler).Methods("PUT")
+
+	// Отзывы
+	s.HandleFunc("/restaurants/{id}/reviews", h.Review.CreateReviewHandler).Methods("POST")
+	s.HandleFunc("/reviews/{id}", h.Review.UpdateReviewHandler).Methods("PUT")
+	s.HandleFunc("/reviews/{id}", h.Review.DeleteReviewHandler).Methods("DELETE")
+	s.HandleFunc("/reviews/{id}/reply", h.Review.ReplyToReviewHandler).Methods("POST")
+	s.HandleFunc("/reviews/{id}/report", h.Moderation.ReportReviewHandler).Methods("POST")
+
+	// Друзья и блокировки
+	s.HandleFunc("/friends", h.Friend.ListFriendsHandler).Methods("GET")
+	s.HandleFunc("/friends/{user_id}", h.Friend.UnfriendHandler).Methods("DELETE")
+	s.HandleFunc("/friends/requests", h.Friend.ListRequestsHandler).Methods("GET")
+	s.HandleFunc("/friends/requests/{user_id}", h.Friend.SendRequestHandler).Methods("POST")
+	s.HandleFunc("/friends/requests/{user_id}", h.Friend.CancelRequestHandler).Methods("DELETE")
+	s.HandleFunc("/friends/requests/{user_id}/accept", h.Friend.AcceptRequestHandler).Methods("POST")
+	s.HandleFunc("/friends/requests/{user_id}/decline", h.Friend.DeclineRequestHandler).Methods("POST")
+	s.HandleFunc("/blocks", h.Friend.ListBlockedHandler).Methods("GET")
+	s.HandleFunc("/blocks/{user_id}", h.Friend.BlockHandler).Methods("POST")
+	s.HandleFunc("/blocks/{user_id}", h.Friend.UnblockHandler).Methods("DELETE")
+	s.HandleFunc("/users/{user_id}/profile", h.Friend.GetProfileHandler).Methods("GET")
+
+	// Совместные походы в ресторан
+	s.HandleFunc("/meetups", h.Meetup.CreateMeetupHandler).Methods("POST")
+	s.HandleFunc("/meetups", h.Meetup.ListMeetupsHandler).Methods("GET")
+	s.HandleFunc("/meetups/{id}", h.Meetup.GetMeetupHandler).Methods("GET")
+	s.HandleFunc("/meetups/{id}", h.Meetup.UpdateMeetupHandler).Methods("PUT")
+	s.HandleFunc("/meetups/{id}/cancel", h.Meetup.CancelMeetupHandler).Methods("POST")
+	s.HandleFunc("/meetups/{id}/invite", h.Meetup.InviteHandler).Methods("POST")
+	s.HandleFunc("/meetups/{id}/conversation", h.Meetup.ConversationHandler).Methods("GET")
+	s.HandleFunc("/meetups/{id}/rsvp", h.Meetup.RSVPHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/meetups", h.Meetup.RestaurantMeetupsHandler).Methods("GET")
+
+	// Опросы о выборе ресторана
+	s.HandleFunc("/polls", h.Poll.CreatePollHandler).Methods("POST")
+	s.HandleFunc("/polls", h.Poll.ListPollsHandler).Methods("GET")
+	s.HandleFunc("/polls/{id}", h.Poll.GetPollHandler).Methods("GET")
+	s.HandleFunc("/polls/{id}/vote", h.Poll.VoteHandler).Methods("POST")
+	s.HandleFunc("/polls/{id}/close", h.Poll.ClosePollHandler).Methods("POST")
+	s.HandleFunc("/polls/{id}/meetup", h.Poll.CreateMeetupHandler).Methods("POST")
+
+	// Групповые заказы
+	s.HandleFunc("/group-orders", h.GroupOrder.CreateGroupOrderHandler).Methods("POST")
+	s.HandleFunc("/group-orders/join/{code}", h.GroupOrder.JoinHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}", h.GroupOrder.GetGroupOrderHandler).Methods("GET")
+	s.HandleFunc("/group-orders/{id}/items", h.GroupOrder.SetItemsHandler).Methods("PUT")
+	s.HandleFunc("/group-orders/{id}/leave", h.GroupOrder.LeaveHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}/lock", h.GroupOrder.LockHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}/unlock", h.GroupOrder.UnlockHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}/split", h.GroupOrder.SetSplitHandler).Methods("PUT")
+	s.HandleFunc("/group-orders/{id}/submit", h.GroupOrder.SubmitHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}/pay", h.GroupOrder.MarkPaidHandler).Methods("POST")
+	s.HandleFunc("/group-orders/{id}/cancel", h.GroupOrder.CancelHandler).Methods("POST")
+
+	// Бронирование столиков
+	s.HandleFunc("/restaurants/me/reservation-settings", h.Reservation.SetSettingsHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/reservations", h.Reservation.ListRestaurantReservationsHandler).Methods("GET")
+	s.HandleFunc("/restaurants/{id}/reservations", h.Reservation.CreateReservationHandler).Methods("POST")
+	s.HandleFunc("/users/me/reservations", h.Reservation.ListUserReservationsHandler).Methods("GET")
+	s.HandleFunc("/reservations/{id}/cancel", h.Reservation.CancelReservationHandler).Methods("POST")
+	s.HandleFunc("/reservations/{id}/confirm", h.Reservation.ConfirmReservationHandler).Methods("POST")
+	s.HandleFunc("/reservations/{id}/decline", h.Reservation.DeclineReservationHandler).Methods("POST")
+	s.HandleFunc("/reservations/{id}/no-show", h.Reservation.NoShowHandler).Methods("POST")
+	s.HandleFunc("/reservations/{id}/complete", h.Reservation.CompleteHandler).Methods("POST")
+
+	// Лист ожидания
+	s.HandleFunc("/restaurants/me/waitlist", h.Waitlist.QueueHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/waitlist", h.Waitlist.AddWalkInHandler).Methods("POST")
+	s.HandleFunc("/restaurants/{id}/waitlist", h.Waitlist.JoinHandler).Methods("POST")
+	s.HandleFunc("/waitlist/{id}", h.Waitlist.GetEntryHandler).Methods("GET")
+	s.HandleFunc("/waitlist/{id}/notify", h.Waitlist.NotifyHandler).Methods("POST")
+	s.HandleFunc("/waitlist/{id}/seat", h.Waitlist.SeatHandler).Methods("POST")
+	s.HandleFunc("/waitlist/{id}/leave", h.Waitlist.LeaveHandler).Methods("POST")
+
+	// Лента активности друзей
+	s.HandleFunc("/feed", h.Activity.FeedHandler).Methods("GET")
+	s.HandleFunc("/users/me/activity-privacy", h.Activity.GetPrivacyHandler).Methods("GET")
+	s.HandleFunc("/users/me/activity-privacy", h.Activity.SetPrivacyHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/{id}/check-in", h.Activity.CheckInHandler).Methods("POST")
+
+	// Рекомендации
+	s.HandleFunc("/recommendations", h.Recommendation.RecommendationsHandler).Methods("GET")
+
+	// Списки избранного
+	s.HandleFunc("/favorite-lists", h.FavoriteList.CreateListHandler).Methods("POST")
+	s.HandleFunc("/favorite-lists", h.FavoriteList.ListOwnListsHandler).Methods("GET")
+	s.HandleFunc("/favorite-lists/shared/{token}", h.FavoriteList.GetSharedListHandler).Methods("GET")
+	s.HandleFunc("/favorite-lists/shared/{token}/copy", h.FavoriteList.CopySharedListHandler).Methods("POST")
+	s.HandleFunc("/favorite-lists/{id}", h.FavoriteList.GetListHandler).Methods("GET")
+	s.HandleFunc("/favorite-lists/{id}", h.FavoriteList.UpdateListHandler).Methods("PUT")
+	s.HandleFunc("/favorite-lists/{id}", h.FavoriteList.DeleteListHandler).Methods("DELETE")
+	s.HandleFunc("/favorite-lists/{id}/entries", h.FavoriteList.AddEntryHandler).Methods("POST")
+	s.HandleFunc("/favorite-lists/{id}/entries/{restaurant_id}", h.FavoriteList.UpdateEntryHandler).Methods("PUT")
+	s.HandleFunc("/favorite-lists/{id}/entries/{restaurant_id}", h.FavoriteList.RemoveEntryHandler).Methods("DELETE")
+	s.HandleFunc("/favorite-lists/{id}/order", h.FavoriteList.ReorderHandler).Methods("PUT")
+	s.HandleFunc("/favorite-lists/{id}/share", h.FavoriteList.CreateShareLinkHandler).Methods("POST")
+	s.HandleFunc("/favorite-lists/{id}/share", h.FavoriteList.RevokeShareLinkHandler).Methods("DELETE")
+	s.HandleFunc("/favorite-lists/{id}/copy", h.FavoriteList.CopyListHandler).Methods("POST")
+	s.HandleFunc("/users/{user_id}/favorite-lists", h.FavoriteList.ListUserListsHandler).Methods("GET")
+
+	// Чат
+	s.HandleFunc("/chat/ws", h.Chat.WebSocketHandler).Methods("GET")
+	s.HandleFunc("/conversations", h.Chat.ListConversationsHandler).Methods("GET")
+	s.HandleFunc("/conversations", h.Chat.CreateGroupHandler).Methods("POST")
+	s.HandleFunc("/conversations/direct", h.Chat.DirectConversationHandler).Methods("POST")
+	s.HandleFunc("/conversations/{id}", h.Chat.GetConversationHandler).Methods("GET")
+	s.HandleFunc("/conversations/{id}/members", h.Chat.AddMembersHandler).Methods("POST")
+	s.HandleFunc("/conversations/{id}/leave", h.Chat.LeaveHandler).Methods("POST")
+	s.HandleFunc("/conversations/{id}/messages", h.Chat.ListMessagesHandler).Methods("GET")
+	s.HandleFunc("/conversations/{id}/messages", h.Chat.SendMessageHandler).Methods("POST")
+	s.HandleFunc("/conversations/{id}/read", h.Chat.MarkReadHandler).Methods("POST")
+
+	// Заказы и потоки их событий
+	s.HandleFunc("/orders/{id}", h.Order.GetOrderHandler).Methods("GET")
+	s.HandleFunc("/orders/{id}/events", h.Order.OrderEventsHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/orders/stream", h.Order.RestaurantStreamHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/orders/{id}/status", h.Order.UpdateStatusHandler).Methods("PUT")
+
+	// Доставка
+	s.HandleFunc("/orders/{id}/delivery", h.Delivery.RequestDeliveryHandler).Methods("POST")
+	s.HandleFunc("/orders/{id}/tracking", h.Delivery.TrackingHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/delivery-fees", h.Delivery.GetFeesHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/delivery-fees", h.Delivery.SetFeesHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/deliveries", h.Delivery.ListRestaurantDeliveriesHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/deliveries/{order_id}/assign", h.Delivery.AssignHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/couriers", h.Delivery.NearbyCouriersHandler).Methods("GET")
+
+	// Адресная книга пользователя
+	s.HandleFunc("/users/me/addresses", h.Address.ListAddressesHandler).Methods("GET")
+	s.HandleFunc("/users/me/addresses", h.Address.CreateAddressHandler).Methods("POST")
+	s.HandleFunc("/users/me/addresses/{id}", h.Address.GetAddressHandler).Methods("GET")
+	s.HandleFunc("/users/me/addresses/{id}", h.Address.UpdateAddressHandler).Methods("PUT")
+	s.HandleFunc("/users/me/addresses/{id}", h.Address.DeleteAddressHandler).Methods("DELETE")
+	s.HandleFunc("/users/me/addresses/{id}/default", h.Address.SetDefaultHandler).Methods("POST")
+
+	// Загрузка изображений
+	s.HandleFunc("/users/me/avatar", h.Media.UploadUserAvatarHandler).Methods("PUT")
+	s.HandleFunc("/users/me/avatar", h.Media.DeleteUserAvatarHandler).Methods("DELETE")
+	s.HandleFunc("/restaurants/me/avatar", h.Media.UploadRestaurantAvatarHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/avatar", h.Media.DeleteRestaurantAvatarHandler).Methods("DELETE")
+	s.HandleFunc("/restaurants/me/menu/{item_id}/image", h.Media.UploadMenuItemImageHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/menu/{item_id}/image", h.Media.DeleteMenuItemImageHandler).Methods("DELETE")
+
+	// Галерея ресторана и фото из отзывов
+	s.HandleFunc("/restaurants/me/photos", h.Gallery.UploadRestaurantPhotoHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/photos/order", h.Gallery.ReorderPhotosHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/photos/{id}", h.Gallery.UpdateRestaurantPhotoHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/photos/{id}", h.Gallery.DeleteRestaurantPhotoHandler).Methods("DELETE")
+	s.HandleFunc("/restaurants/me/cover", h.Gallery.SetCoverHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/cover", h.Gallery.ClearCoverHandler).Methods("DELETE")
+	s.HandleFunc("/reviews/{id}/photos", h.Gallery.UploadReviewPhotoHandler).Methods("POST")
+	s.HandleFunc("/reviews/{id}/photos/{photo_id}", h.Gallery.DeleteReviewPhotoHandler).Methods("DELETE")
+
+	courier := s.PathPrefix("/courier").Subrouter()
+	courier.Use(auth.RequireRole(models.RoleCourier))
+	courier.HandleFunc("/me", h.Delivery.CourierProfileHandler).Methods("GET")
+	courier.HandleFunc("/status", h.Delivery.CourierStatusHandler).Methods("PUT")
+	courier.HandleFunc("/location", h.Delivery.CourierLocationHandler).Methods("PUT")
+	courier.HandleFunc("/delivery", h.Delivery.CurrentDeliveryHandler).Methods("GET")
+	courier.HandleFunc("/deliveries/{id}/pickup", h.Delivery.PickUpHandler).Methods("POST")
+	courier.HandleFunc("/deliveries/{id}/deliver", h.Delivery.DeliverHandler).Methods("POST")
+
+	// Вебхуки ресторана; маршруты без {id} регистрируются раньше
+	s.HandleFunc("/restaurants/me/webhooks", h.Webhook.ListWebhooksHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/webhooks", h.Webhook.CreateWebhookHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/webhooks/dead-letters", h.Webhook.DeadLettersHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/webhooks/deliveries/{delivery_id}/redeliver", h.Webhook.RedeliverHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/webhooks/{id}", h.Webhook.GetWebhookHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/webhooks/{id}", h.Webhook.UpdateWebhookHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/webhooks/{id}", h.Webhook.DeleteWebhookHandler).Methods("DELETE")
+	s.HandleFunc("/restaurants/me/webhooks/{id}/secret", h.Webhook.RotateSecretHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/webhooks/{id}/test", h.Webhook.SendTestHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/webhooks/{id}/deliveries", h.Webhook.WebhookDeliveriesHandler).Methods("GET")
+
+	// Кухонный экран ресторана
+	s.HandleFunc("/restaurants/me/kitchen", h.Kitchen.BoardHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/kitchen/settings", h.Kitchen.GetSettingsHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/kitchen/settings", h.Kitchen.SetSettingsHandler).Methods("PUT")
+	s.HandleFunc("/restaurants/me/kitchen/tickets", h.Kitchen.TicketsHandler).Methods("GET")
+	s.HandleFunc("/restaurants/me/kitchen/tickets/{order_id}/items/{index}/start", h.Kitchen.StartItemHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/kitchen/tickets/{order_id}/items/{index}/done", h.Kitchen.DoneItemHandler).Methods("POST")
+	s.HandleFunc("/restaurants/me/kitchen/tickets/{order_id}/items/{index}/reset", h.Kitchen.ResetItemHandler).Methods("POST")
+
+	// Уведомления
+	s.HandleFunc("/notifications", h.Notification.ListNotificationsHandler).Methods("GET")
+	s.HandleFunc("/notifications/read", h.Notification.MarkReadHandler).Methods("POST")
+	s.HandleFunc("/notifications/preferences", h.Notification.GetPreferencesHandler).Methods("GET")
+	s.HandleFunc("/notifications/preferences", h.Notification.SetPreferencesHandler).Methods("PUT")
+	s.HandleFunc("/notifications/push/key", h.Notification.PushKeyHandler).Methods("GET")
+	s.HandleFunc("/notifications/push/subscriptions", h.Notification.SubscribePushHandler).Methods("POST")
+	s.HandleFunc("/notifications/push/subscriptions", h.Notification.UnsubscribePushHandler).Methods("DELETE")
+
+	// Администрирование
+	admin := s.PathPrefix("/admin").Subrouter()
+	admin.Use(auth.RequireRole(models.RoleAdmin))
+	admin.HandleFunc("/reviews", h.Moderation.ListQueueHandler).Methods("GET")
+	admin.HandleFunc("/reviews/moderate", h.Moderation.BulkModerateHandler).Methods("POST")
+	admin.HandleFunc("/photos", h.Gallery.ListModerationQueueHandler).Methods("GET")
+	admin.HandleFunc("/photos/moderate", h.Gallery.BulkModerateHandler).Methods("POST")
+	admin.HandleFunc("/moderation/audit", h.Moderation.ListAuditHandler).Methods("GET")
+	admin.HandleFunc("/search/reindex", h.Search.ReindexHandler).Methods("POST")
+	admin.HandleFunc("/couriers", h.Delivery.ListCouriersHandler).Methods("GET")
+	admin.HandleFunc("/couriers/simulated", h.Delivery.SpawnSimulatedCouriersHandler).Methods("POST")
+	admin.HandleFunc("/couriers/{user_id}", h.Delivery.GrantCourierHandler).Methods("POST")
+	admin.HandleFunc("/couriers/{user_id}", h.Delivery.RevokeCourierHandler).Methods("DELETE")
+	admin.HandleFunc("/notifications/outbox", h.Notification.ListOutboxHandler).Methods("GET")
+	admin.HandleFunc("/notifications/outbox/{id}/retry", h.Notification.RetryOutboxHandler).Methods("POST")
+	admin.HandleFunc("/notifications/fake/{channel}", h.Notification.FakeSentHandler).Methods("GET")
+	admin.HandleFunc("/events", h.Event.ListEventsHandler).Methods("GET")
+	admin.HandleFunc("/events/{id}/retry", h.Event.RetryEventHandler).Methods("POST")
+	admin.HandleFunc("/jobs", h.Job.ListJobsHandler).Methods("GET")
+	admin.HandleFunc("/jobs/schedules", h.Job.ListSchedulesHandler).Methods("GET")
+	admin.HandleFunc("/jobs/schedules/{name}/run", h.Job.TriggerScheduleHandler).Methods("POST")
+	admin.HandleFunc("/jobs/{id}", h.Job.GetJobHandler).Methods("GET")
+	admin.HandleFunc("/jobs/{id}/retry", h.Job.RetryJobHandler).Methods("POST")
+	admin.HandleFunc("/jobs/{id}/cancel", h.Job.CancelJobHandler).Methods("POST")
+
 	return r
 }
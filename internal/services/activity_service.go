@@ -0,0 +1,496 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const activitiesCollectionName = "activities"
+
+var (
+	ErrInvalidActivityType = errors.New("invalid activity type")
+	ErrInvalidVisibility   = errors.New("visibility must be friends or private")
+)
+
+// ActivityService структура сервиса журнала действий и ленты друзей
+type ActivityService struct {
+	db            *mongo.Database
+	friendService *FriendService
+	redis         *RedisService
+	cacheTTL      time.Duration
+}
+
+// NewActivityService создает новый экземпляр ActivityService.
+// Первые страницы ленты кешируются в Redis на FEED_CACHE_TTL_SECONDS (по умолчанию 60).
+func NewActivityService(client *mongo.Client, dbName string, friendService *FriendService, redisService *RedisService) *ActivityService {
+	return &ActivityService{
+		db:            client.Database(dbName),
+		friendService: friendService,
+		redis:         redisService,
+		cacheTTL:      time.Duration(envInt("FEED_CACHE_TTL_SECONDS", 60)) * time.Second,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции действий
+func (s *ActivityService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(activitiesCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "actor_id", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{
+			// Повторное событие с тем же ключом не создает вторую запись
+			Keys: bson.D{{Key: "dedup_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"dedup_key": bson.M{"$exists": true},
+			}),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating activity indexes failed")
+	}
+	return nil
+}
+
+// Record добавляет событие в журнал действий и сбрасывает кеш лент друзей автора.
+// Событие с уже записанным DedupKey молча пропускается.
+func (s *ActivityService) Record(ctx context.Context, activity *models.Activity) error {
+	if !validActivityType(activity.Type) {
+		return ErrInvalidActivityType
+	}
+
+	activity.ID = primitive.NewObjectID()
+	activity.CreatedAt = time.Now().Truncate(time.Millisecond)
+	if _, err := s.db.Collection(activitiesCollectionName).InsertOne(ctx, activity); err != nil {
+		if activity.DedupKey != "" && mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return errors.Wrap(err, "inserting activity failed")
+	}
+
+	s.invalidateFriendFeeds(ctx, activity.ActorID)
+	return nil
+}
+
+// RecordReview записывает публикацию отзыва
+func (s *ActivityService) RecordReview(ctx context.Context, review *models.Review) error {
+	return s.Record(ctx, &models.Activity{
+		ActorID:      review.UserID,
+		Type:         models.ActivityReviewPosted,
+		RestaurantID: review.RestaurantID,
+		RefID:        &review.ID,
+		Data:         map[string]string{"rating": strconv.Itoa(review.Rating)},
+		DedupKey:     fmt.Sprintf("%s:%s", models.ActivityReviewPosted, review.ID.Hex()),
+	})
+}
+
+// HandleReviewPosted записывает в журнал отзыв из события review.posted.
+// Отзывы на модерации не попадают в ленту друзей.
+func (s *ActivityService) HandleReviewPosted(ctx context.Context, event *models.DomainEvent) error {
+	var payload models.ReviewPostedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return errors.Wrap(err, "decoding review event failed")
+	}
+	if payload.Status != models.ReviewStatusApproved {
+		return nil
+	}
+	return s.RecordReview(ctx, &models.Review{
+		ID:           payload.ReviewID,
+		RestaurantID: payload.RestaurantID,
+		UserID:       payload.UserID,
+		Rating:       payload.Rating,
+	})
+}
+
+// RecordFavorite записывает добавление ресторана в избранное
+func (s *ActivityService) RecordFavorite(ctx context.Context, userID, restaurantID primitive.ObjectID) error {
+	return s.Record(ctx, &models.Activity{
+		ActorID:      userID,
+		Type:         models.ActivityFavoriteAdded,
+		RestaurantID: restaurantID,
+		DedupKey:     fmt.Sprintf("%s:%s:%s", models.ActivityFavoriteAdded, userID.Hex(), restaurantID.Hex()),
+	})
+}
+
+// RetractFavorite удаляет из журнала событие добавления ресторана в избранное,
+// чтобы друзья не видели ресторан, который пользователь уже убрал
+func (s *ActivityService) RetractFavorite(ctx context.Context, userID, restaurantID primitive.ObjectID) error {
+	result, err := s.db.Collection(activitiesCollectionName).DeleteOne(ctx, bson.M{
+		"dedup_key": fmt.Sprintf("%s:%s:%s", models.ActivityFavoriteAdded, userID.Hex(), restaurantID.Hex()),
+	})
+	if err != nil {
+		return errors.Wrap(err, "deleting activity failed")
+	}
+	if result.DeletedCount > 0 {
+		s.invalidateFriendFeeds(ctx, userID)
+	}
+	return nil
+}
+
+// RecordMeetupJoined записывает согласие пользователя прийти на встречу
+func (s *ActivityService) RecordMeetupJoined(ctx context.Context, userID primitive.ObjectID, meetup *models.Meetup) error {
+	return s.Record(ctx, &models.Activity{
+		ActorID:      userID,
+		Type:         models.ActivityMeetupJoined,
+		RestaurantID: meetup.RestaurantID,
+		RefID:        &meetup.ID,
+		Data:         map[string]string{"starts_at": meetup.StartsAt.Format(time.RFC3339)},
+		DedupKey:     fmt.Sprintf("%s:%s:%s", models.ActivityMeetupJoined, meetup.ID.Hex(), userID.Hex()),
+	})
+}
+
+// CheckIn отмечает посещение ресторана пользователем; одна отметка на ресторан в день
+func (s *ActivityService) CheckIn(ctx context.Context, userID, restaurantID primitive.ObjectID) (*models.Activity, error) {
+	if err := ensureRestaurantExists(ctx, s.db, restaurantID); err != nil {
+		return nil, err
+	}
+
+	day := time.Now().In(restaurantLocation()).Format("2006-01-02")
+	activity := &models.Activity{
+		ActorID:      userID,
+		Type:         models.ActivityCheckIn,
+		RestaurantID: restaurantID,
+		DedupKey:     fmt.Sprintf("%s:%s:%s:%s", models.ActivityCheckIn, userID.Hex(), restaurantID.Hex(), day),
+	}
+	if err := s.Record(ctx, activity); err != nil {
+		return nil, err
+	}
+	return activity, nil
+}
+
+// GetPrivacy возвращает настройки видимости всех типов действий пользователя
+func (s *ActivityService) GetPrivacy(ctx context.Context, userID primitive.ObjectID) (models.ActivityPrivacy, error) {
+	var user struct {
+		Privacy models.ActivityPrivacy `bson:"activityPrivacy"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"activityPrivacy": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding user failed")
+	}
+
+	privacy := make(models.ActivityPrivacy, len(models.ActivityTypes))
+	for _, activityType := range models.ActivityTypes {
+		privacy[activityType] = models.ActivityVisibilityFriends
+		if !user.Privacy.Visible(activityType) {
+			privacy[activityType] = models.ActivityVisibilityPrivate
+		}
+	}
+	return privacy, nil
+}
+
+// SetPrivacy обновляет видимость указанных типов действий; остальные типы не меняются
+func (s *ActivityService) SetPrivacy(ctx context.Context, userID primitive.ObjectID, privacy models.ActivityPrivacy) (models.ActivityPrivacy, error) {
+	set := bson.M{}
+	for activityType, visibility := range privacy {
+		if !validActivityType(activityType) {
+			return nil, ErrInvalidActivityType
+		}
+		if visibility != models.ActivityVisibilityFriends && visibility != models.ActivityVisibilityPrivate {
+			return nil, ErrInvalidVisibility
+		}
+		set["activityPrivacy."+activityType] = visibility
+	}
+
+	if len(set) > 0 {
+		result, err := s.db.Collection(EntityTypeUser).UpdateOne(ctx, bson.M{"_id": userID}, bson.M{"$set": set})
+		if err != nil {
+			return nil, errors.Wrap(err, "updating activity privacy failed")
+		}
+		if result.MatchedCount == 0 {
+			return nil, ErrUserNotFound
+		}
+		s.invalidateFriendFeeds(ctx, userID)
+	}
+	return s.GetPrivacy(ctx, userID)
+}
+
+// Feed собирает ленту действий друзей пользователя, новые первыми.
+// Лента строится при чтении из журналов друзей с учетом их настроек видимости;
+// первая страница кешируется в Redis.
+func (s *ActivityService) Feed(ctx context.Context, userID primitive.ObjectID, cursor string, limit int) (*models.ActivityFeedPage, error) {
+	if cursor == "" {
+		if page, ok := s.cachedFeed(ctx, userID, limit); ok {
+			return page, nil
+		}
+	}
+
+	filter, err := s.feedFilter(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	page := &models.ActivityFeedPage{Activities: []models.Activity{}}
+	if filter == nil {
+		s.cacheFeed(ctx, userID, limit, cursor, page)
+		return page, nil
+	}
+	if cursor != "" {
+		after, err := activityCursorFilter(cursor)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	found, err := s.db.Collection(activitiesCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit+1)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding activities failed")
+	}
+	if err := found.All(ctx, &page.Activities); err != nil {
+		return nil, errors.Wrap(err, "decoding activities failed")
+	}
+
+	if len(page.Activities) > limit {
+		page.Activities = page.Activities[:limit]
+		page.NextCursor = encodeActivityCursor(page.Activities[limit-1])
+	}
+	if err := s.enrich(ctx, page.Activities); err != nil {
+		return nil, err
+	}
+
+	s.cacheFeed(ctx, userID, limit, cursor, page)
+	return page, nil
+}
+
+// feedFilter строит условие выборки действий друзей с учетом их настроек видимости.
+// Друзья с одинаковым набором скрытых типов объединяются в одно условие.
+// Возвращает nil, если видимых действий нет.
+func (s *ActivityService) feedFilter(ctx context.Context, userID primitive.ObjectID) (bson.M, error) {
+	friendIDs, err := s.friendService.FriendIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(friendIDs) == 0 {
+		return nil, nil
+	}
+
+	cursor, err := s.db.Collection(EntityTypeUser).Find(ctx, bson.M{"_id": bson.M{"$in": friendIDs}},
+		options.Find().SetProjection(bson.M{"activityPrivacy": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding friends failed")
+	}
+	var friends []struct {
+		ID      primitive.ObjectID     `bson:"_id"`
+		Privacy models.ActivityPrivacy `bson:"activityPrivacy"`
+	}
+	if err := cursor.All(ctx, &friends); err != nil {
+		return nil, errors.Wrap(err, "decoding friends failed")
+	}
+
+	groups := map[string][]primitive.ObjectID{}
+	hiddenByKey := map[string][]string{}
+	for _, friend := range friends {
+		var hidden []string
+		for _, activityType := range models.ActivityTypes {
+			if !friend.Privacy.Visible(activityType) {
+				hidden = append(hidden, activityType)
+			}
+		}
+		if len(hidden) == len(models.ActivityTypes) {
+			continue
+		}
+		key := strings.Join(hidden, ",")
+		groups[key] = append(groups[key], friend.ID)
+		hiddenByKey[key] = hidden
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	clauses := bson.A{}
+	for _, key := range keys {
+		clause := bson.M{"actor_id": bson.M{"$in": groups[key]}}
+		if hidden := hiddenByKey[key]; len(hidden) > 0 {
+			clause["type"] = bson.M{"$nin": hidden}
+		}
+		clauses = append(clauses, clause)
+	}
+	if len(clauses) == 1 {
+		return clauses[0].(bson.M), nil
+	}
+	return bson.M{"$or": clauses}, nil
+}
+
+// enrich дополняет действия данными авторов и названиями ресторанов
+func (s *ActivityService) enrich(ctx context.Context, activities []models.Activity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+
+	actorIDs := make([]primitive.ObjectID, 0, len(activities))
+	restaurantIDs := make([]primitive.ObjectID, 0, len(activities))
+	for _, activity := range activities {
+		actorIDs = append(actorIDs, activity.ActorID)
+		restaurantIDs = append(restaurantIDs, activity.RestaurantID)
+	}
+
+	actors, err := s.friendService.userSummaries(ctx, uniqueObjectIDs(actorIDs))
+	if err != nil {
+		return err
+	}
+	actorsByID := make(map[primitive.ObjectID]models.UserSummary, len(actors))
+	for _, actor := range actors {
+		actorsByID[actor.ID] = actor
+	}
+
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx,
+		bson.M{"_id": bson.M{"$in": uniqueObjectIDs(restaurantIDs)}},
+		options.Find().SetProjection(bson.M{"name": 1}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "finding restaurants failed")
+	}
+	var restaurants []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return errors.Wrap(err, "decoding restaurants failed")
+	}
+	names := make(map[primitive.ObjectID]string, len(restaurants))
+	for _, restaurant := range restaurants {
+		names[restaurant.ID] = restaurant.Name
+	}
+
+	for i := range activities {
+		if actor, ok := actorsByID[activities[i].ActorID]; ok {
+			activities[i].Actor = &actor
+		}
+		activities[i].RestaurantName = names[activities[i].RestaurantID]
+	}
+	return nil
+}
+
+// feedCacheKey возвращает ключ хеша Redis с кешированными первыми страницами ленты
+func feedCacheKey(userID primitive.ObjectID) string {
+	return "feed:" + userID.Hex()
+}
+
+// cachedFeed возвращает первую страницу ленты из кеша; ошибки Redis считаются промахом
+func (s *ActivityService) cachedFeed(ctx context.Context, userID primitive.ObjectID, limit int) (*models.ActivityFeedPage, bool) {
+	if s.redis == nil {
+		return nil, false
+	}
+	raw, err := s.redis.Client.HGet(ctx, feedCacheKey(userID), strconv.Itoa(limit)).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			log.Printf("Failed to read cached feed: %v", err)
+		}
+		return nil, false
+	}
+	var page models.ActivityFeedPage
+	if err := json.Unmarshal(raw, &page); err != nil {
+		return nil, false
+	}
+	return &page, true
+}
+
+// cacheFeed сохраняет первую страницу ленты в кеш; остальные страницы не кешируются
+func (s *ActivityService) cacheFeed(ctx context.Context, userID primitive.ObjectID, limit int, cursor string, page *models.ActivityFeedPage) {
+	if s.redis == nil || cursor != "" {
+		return
+	}
+	raw, err := json.Marshal(page)
+	if err != nil {
+		return
+	}
+	key := feedCacheKey(userID)
+	pipe := s.redis.Client.TxPipeline()
+	pipe.HSet(ctx, key, strconv.Itoa(limit), raw)
+	pipe.Expire(ctx, key, s.cacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to cache feed: %v", err)
+	}
+}
+
+// invalidateFriendFeeds сбрасывает кеш лент всех друзей пользователя
+func (s *ActivityService) invalidateFriendFeeds(ctx context.Context, userID primitive.ObjectID) {
+	if s.redis == nil {
+		return
+	}
+	friendIDs, err := s.friendService.FriendIDs(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to invalidate feeds: %v", err)
+		return
+	}
+	if len(friendIDs) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(friendIDs))
+	for _, friendID := range friendIDs {
+		keys = append(keys, feedCacheKey(friendID))
+	}
+	if err := s.redis.Client.Del(ctx, keys...).Err(); err != nil {
+		log.Printf("Failed to invalidate feeds: %v", err)
+	}
+}
+
+// activityCursor позиция в ленте: время создания в миллисекундах и ID действия
+type activityCursor struct {
+	CreatedAt int64  `json:"t"`
+	ID        string `json:"id"`
+}
+
+// encodeActivityCursor кодирует позицию действия в курсор
+func encodeActivityCursor(last models.Activity) string {
+	raw, _ := json.Marshal(activityCursor{CreatedAt: last.CreatedAt.UnixMilli(), ID: last.ID.Hex()})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// activityCursorFilter строит условие "после курсора" для сортировки по убыванию времени
+func activityCursorFilter(encoded string) (bson.M, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c activityCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	lastID, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt := time.UnixMilli(c.CreatedAt)
+	return bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$lt": createdAt}},
+		bson.M{"created_at": createdAt, "_id": bson.M{"$lt": lastID}},
+	}}, nil
+}
+
+// validActivityType проверяет, что тип действия известен
+func validActivityType(activityType string) bool {
+	for _, known := range models.ActivityTypes {
+		if known == activityType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestActivityFeedFilter(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+	open, alsoOpen, noReviews, hidden := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	friendship := func(other primitive.ObjectID) bson.D {
+		low, high := orderPair(userID, other)
+		return bson.D{{Key: "user_low", Value: low}, {Key: "user_high", Value: high}}
+	}
+	private := func(types ...string) bson.D {
+		privacy := bson.D{}
+		for _, activityType := range types {
+			privacy = append(privacy, bson.E{Key: activityType, Value: models.ActivityVisibilityPrivate})
+		}
+		return privacy
+	}
+
+	mt.Run("groups friends by hidden types", func(mt *mtest.T) {
+		s := &ActivityService{db: mt.DB, friendService: &FriendService{db: mt.DB}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch,
+				friendship(open), friendship(alsoOpen), friendship(noReviews), friendship(hidden)),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: open}},
+				bson.D{{Key: "_id", Value: noReviews}, {Key: "activityPrivacy", Value: private(models.ActivityReviewPosted)}},
+				bson.D{{Key: "_id", Value: alsoOpen}, {Key: "activityPrivacy", Value: bson.D{{Key: models.ActivityCheckIn, Value: models.ActivityVisibilityFriends}}}},
+				bson.D{{Key: "_id", Value: hidden}, {Key: "activityPrivacy", Value: private(models.ActivityTypes...)}},
+			),
+		)
+
+		got, err := s.feedFilter(context.Background(), userID)
+		if err != nil {
+			mt.Fatalf("feedFilter() error = %v", err)
+		}
+		want := bson.M{"$or": bson.A{
+			bson.M{"actor_id": bson.M{"$in": []primitive.ObjectID{open, alsoOpen}}},
+			bson.M{"actor_id": bson.M{"$in": []primitive.ObjectID{noReviews}}, "type": bson.M{"$nin": []string{models.ActivityReviewPosted}}},
+		}}
+		if !reflect.DeepEqual(got, want) {
+			mt.Errorf("feedFilter() = %v, want %v", got, want)
+		}
+	})
+
+	mt.Run("everything hidden", func(mt *mtest.T) {
+		s := &ActivityService{db: mt.DB, friendService: &FriendService{db: mt.DB}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch, friendship(hidden)),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch,
+				bson.D{{Key: "_id", Value: hidden}, {Key: "activityPrivacy", Value: private(models.ActivityTypes...)}}),
+		)
+
+		got, err := s.feedFilter(context.Background(), userID)
+		if err != nil || got != nil {
+			mt.Errorf("feedFilter() = %v, %v; want no filter", got, err)
+		}
+	})
+}
+
+func TestActivityCursorRoundTrip(t *testing.T) {
+	last := models.Activity{ID: primitive.NewObjectID(), CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC)}
+	createdAt := time.UnixMilli(last.CreatedAt.UnixMilli())
+
+	got, err := activityCursorFilter(encodeActivityCursor(last))
+	if err != nil {
+		t.Fatalf("activityCursorFilter() error = %v", err)
+	}
+	want := bson.M{"$or": bson.A{
+		bson.M{"created_at": bson.M{"$lt": createdAt}},
+		bson.M{"created_at": createdAt, "_id": bson.M{"$lt": last.ID}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("activityCursorFilter() = %v, want %v", got, want)
+	}
+
+	if _, err := activityCursorFilter("not a cursor"); err != ErrInvalidCursor {
+		t.Errorf("activityCursorFilter() error = %v, want %v", err, ErrInvalidCursor)
+	}
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrSavedAddressNotFound = errors.New("saved address not found")
+	ErrAddressBookFull      = errors.New("address book is full")
+)
+
+// AddressService структура сервиса адресной книги пользователей.
+// Адреса хранятся в документе пользователя; в непустой адресной книге ровно один адрес по умолчанию.
+type AddressService struct {
+	db *mongo.Database
+}
+
+// NewAddressService создает новый экземпляр AddressService
+func NewAddressService(client *mongo.Client, dbName string) *AddressService {
+	return &AddressService{
+		db: client.Database(dbName),
+	}
+}
+
+// ListAddresses возвращает адреса пользователя; адрес по умолчанию идет первым
+func (s *AddressService) ListAddresses(ctx context.Context, userID primitive.ObjectID) ([]models.Address, error) {
+	addresses, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.Address, 0, len(addresses))
+	for _, address := range addresses {
+		if address.IsDefault {
+			result = append([]models.Address{address}, result...)
+		} else {
+			result = append(result, address)
+		}
+	}
+	return result, nil
+}
+
+// GetAddress возвращает адрес пользователя
+func (s *AddressService) GetAddress(ctx context.Context, userID, addressID primitive.ObjectID) (*models.Address, error) {
+	addresses, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addresses {
+		if addresses[i].ID == addressID {
+			return &addresses[i], nil
+		}
+	}
+	return nil, ErrSavedAddressNotFound
+}
+
+// DefaultAddress возвращает адрес пользователя по умолчанию
+func (s *AddressService) DefaultAddress(ctx context.Context, userID primitive.ObjectID) (*models.Address, error) {
+	addresses, err := s.load(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addresses {
+		if addresses[i].IsDefault {
+			return &addresses[i], nil
+		}
+	}
+	return nil, ErrSavedAddressNotFound
+}
+
+// CreateAddress добавляет адрес. Первый адрес пользователя становится адресом по умолчанию.
+func (s *AddressService) CreateAddress(ctx context.Context, userID primitive.ObjectID, address *models.Address) (*models.Address, error) {
+	if err := s.prepare(address); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	makeDefault := address.IsDefault
+	address.ID = primitive.NewObjectID()
+	address.IsDefault = false
+	address.CreatedAt = now
+	address.UpdatedAt = now
+
+	var user struct {
+		Addresses []models.Address `bson:"addresses"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOneAndUpdate(ctx,
+		bson.M{
+			"_id":   userID,
+			"$expr": bson.M{"$lt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$addresses", bson.A{}}}}, models.MaxUserAddresses}},
+		},
+		bson.M{"$push": bson.M{"addresses": address}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"addresses": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.load(ctx, userID); err != nil {
+			return nil, err
+		}
+		return nil, ErrAddressBookFull
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "saving address failed")
+	}
+
+	if makeDefault || len(user.Addresses) == 1 {
+		return s.SetDefault(ctx, userID, address.ID)
+	}
+	return address, nil
+}
+
+// UpdateAddress заменяет поля адреса. Флаг по умолчанию меняется только через SetDefault
+// или установкой is_default в true.
+func (s *AddressService) UpdateAddress(ctx context.Context, userID, addressID primitive.ObjectID, address *models.Address) (*models.Address, error) {
+	if err := s.prepare(address); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"addresses.$.label":      address.Label,
+		"addresses.$.city":       address.City,
+		"addresses.$.street":     address.Street,
+		"addresses.$.house":      address.House,
+		"addresses.$.apartment":  address.Apartment,
+		"addresses.$.entrance":   address.Entrance,
+		"addresses.$.floor":      address.Floor,
+		"addresses.$.intercom":   address.Intercom,
+		"addresses.$.comment":    address.Comment,
+		"addresses.$.updated_at": time.Now(),
+	}
+	update := bson.M{"$set": set}
+	if address.Location != nil {
+		set["addresses.$.location"] = address.Location
+	} else {
+		update["$unset"] = bson.M{"addresses.$.location": ""}
+	}
+
+	result, err := s.db.Collection(EntityTypeUser).UpdateOne(ctx,
+		bson.M{"_id": userID, "addresses._id": addressID}, update)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating address failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrSavedAddressNotFound
+	}
+
+	if address.IsDefault {
+		return s.SetDefault(ctx, userID, addressID)
+	}
+	return s.GetAddress(ctx, userID, addressID)
+}
+
+// DeleteAddress удаляет адрес. Если удален адрес по умолчанию, им становится самый старый из оставшихся.
+func (s *AddressService) DeleteAddress(ctx context.Context, userID, addressID primitive.ObjectID) error {
+	var user struct {
+		Addresses []models.Address `bson:"addresses"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOneAndUpdate(ctx,
+		bson.M{"_id": userID, "addresses._id": addressID},
+		bson.M{"$pull": bson.M{"addresses": bson.M{"_id": addressID}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After).SetProjection(bson.M{"addresses": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return ErrSavedAddressNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "deleting address failed")
+	}
+
+	if len(user.Addresses) == 0 {
+		return nil
+	}
+	oldest := user.Addresses[0]
+	for _, address := range user.Addresses {
+		if address.IsDefault {
+			return nil
+		}
+		if address.CreatedAt.Before(oldest.CreatedAt) {
+			oldest = address
+		}
+	}
+	_, err = s.SetDefault(ctx, userID, oldest.ID)
+	return err
+}
+
+// SetDefault делает адрес адресом по умолчанию и снимает флаг с остальных одним обновлением
+func (s *AddressService) SetDefault(ctx context.Context, userID, addressID primitive.ObjectID) (*models.Address, error) {
+	result, err := s.db.Collection(EntityTypeUser).UpdateOne(ctx,
+		bson.M{"_id": userID, "addresses._id": addressID},
+		bson.M{"$set": bson.M{
+			"addresses.$[other].is_default":  false,
+			"addresses.$[chosen].is_default": true,
+		}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{
+			bson.M{"other._id": bson.M{"$ne": addressID}},
+			bson.M{"chosen._id": addressID},
+		}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting default address failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrSavedAddressNotFound
+	}
+	return s.GetAddress(ctx, userID, addressID)
+}
+
+// prepare нормализует и проверяет адрес перед сохранением
+func (s *AddressService) prepare(address *models.Address) error {
+	address.Normalize()
+	if err := address.Validate(); err != nil {
+		return err
+	}
+	if address.Location != nil {
+		if !validPoint(*address.Location) {
+			return ErrInvalidCoordinates
+		}
+		address.Location.Type = "Point"
+	}
+	return nil
+}
+
+// load возвращает адреса пользователя
+func (s *AddressService) load(ctx context.Context, userID primitive.ObjectID) ([]models.Address, error) {
+	var user struct {
+		Addresses []models.Address `bson:"addresses"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"addresses": 1}),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "finding addresses failed")
+	}
+	return user.Addresses, nil
+}
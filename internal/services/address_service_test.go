@@ -0,0 +1,117 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestListAddressesPutsDefaultFirst(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	home, work, dacha := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	mt.Run("default first", func(mt *mtest.T) {
+		s := &AddressService{db: mt.DB}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: primitive.NewObjectID()},
+			{Key: "addresses", Value: bson.A{
+				bson.D{{Key: "_id", Value: home}},
+				bson.D{{Key: "_id", Value: work}, {Key: "is_default", Value: true}},
+				bson.D{{Key: "_id", Value: dacha}},
+			}},
+		}))
+
+		got, err := s.ListAddresses(context.Background(), primitive.NewObjectID())
+		if err != nil {
+			mt.Fatalf("ListAddresses() error = %v", err)
+		}
+		if len(got) != 3 || got[0].ID != work || got[1].ID != home || got[2].ID != dacha {
+			mt.Errorf("ListAddresses() = %v, want work, home, dacha", got)
+		}
+	})
+}
+
+func TestCreateAddressRejectsFullBook(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+
+	mt.Run("full", func(mt *mtest.T) {
+		s := &AddressService{db: mt.DB}
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: nil}},
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "_id", Value: userID}}),
+		)
+
+		address := &models.Address{Label: "Дом", City: "Москва", Street: "Тверская", House: "1"}
+		if _, err := s.CreateAddress(context.Background(), userID, address); err != ErrAddressBookFull {
+			mt.Fatalf("CreateAddress() error = %v, want %v", err, ErrAddressBookFull)
+		}
+	})
+
+	mt.Run("invalid location", func(mt *mtest.T) {
+		s := &AddressService{db: mt.DB}
+		location := models.NewGeoPoint(91, 0)
+		address := &models.Address{Label: " Дом ", City: "Москва", Street: "Тверская", House: "1", Location: &location}
+
+		if _, err := s.CreateAddress(context.Background(), userID, address); err != ErrInvalidCoordinates {
+			mt.Fatalf("CreateAddress() error = %v, want %v", err, ErrInvalidCoordinates)
+		}
+		if address.Label != "Дом" {
+			mt.Errorf("Label = %q, want trimmed", address.Label)
+		}
+	})
+}
+
+func TestDeleteDefaultAddressPromotesOldest(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID, newer, older := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	mt.Run("promotes oldest", func(mt *mtest.T) {
+		s := &AddressService{db: mt.DB}
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: userID},
+				{Key: "addresses", Value: bson.A{
+					bson.D{{Key: "_id", Value: newer}, {Key: "created_at", Value: created.Add(time.Hour)}},
+					bson.D{{Key: "_id", Value: older}, {Key: "created_at", Value: created}},
+				}},
+			}}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: userID},
+				{Key: "addresses", Value: bson.A{bson.D{{Key: "_id", Value: older}, {Key: "is_default", Value: true}}}},
+			}),
+		)
+
+		if err := s.DeleteAddress(context.Background(), userID, primitive.NewObjectID()); err != nil {
+			mt.Fatalf("DeleteAddress() error = %v", err)
+		}
+
+		mt.GetStartedEvent() // удаление адреса
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		if got := update.Lookup("q", "addresses._id").ObjectID(); got != older {
+			mt.Errorf("new default = %s, want the oldest address %s", got.Hex(), older.Hex())
+		}
+	})
+
+	mt.Run("default kept", func(mt *mtest.T) {
+		s := &AddressService{db: mt.DB}
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+			{Key: "_id", Value: userID},
+			{Key: "addresses", Value: bson.A{bson.D{{Key: "_id", Value: newer}, {Key: "is_default", Value: true}}}},
+		}}})
+
+		if err := s.DeleteAddress(context.Background(), userID, older); err != nil {
+			mt.Fatalf("DeleteAddress() error = %v", err)
+		}
+		if events := len(mt.GetAllStartedEvents()); events != 1 {
+			mt.Errorf("commands = %d, want only the delete", events)
+		}
+	})
+}
@@ -11,25 +11,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/crypto/bcrypt"
 	"log"
 	"os"
 )
 
-// Register регистрирует нового пользователя в системе
+// ErrEmailTaken возвращается при регистрации с уже занятым email
+var ErrEmailTaken = errors.New("этот email уже зарегистрирован в системе")
+
+// EnsureEntityIndexes создает уникальные индексы email пользователей и ресторанов.
+// Только индекс защищает от одновременной регистрации с одним email; сущности без email в него не попадают.
+func (s *EntityService) EnsureEntityIndexes(ctx context.Context) error {
+	for _, collectionName := range []string{EntityTypeUser, EntityTypeRestaurant} {
+		_, err := s.db.Collection(collectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "email", Value: 1}},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}}),
+		})
+		if err != nil {
+			return errors.Wrapf(err, "creating %s email index failed", collectionName)
+		}
+	}
+	return nil
+}
+
+// GrantAdminRoles выдает роль администратора пользователям с указанными email
+// и возвращает число обновленных пользователей. Пользователи с другой ролью не затрагиваются.
+func (s *EntityService) GrantAdminRoles(ctx context.Context, emails []string) (int64, error) {
+	if len(emails) == 0 {
+		return 0, nil
+	}
+	result, err := s.db.Collection(EntityTypeUser).UpdateMany(ctx,
+		bson.M{"email": bson.M{"$in": emails}, "roles": bson.M{"$in": bson.A{"", nil}}},
+		bson.M{"$set": bson.M{"roles": models.RoleAdmin}},
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "granting admin role failed")
+	}
+	return result.ModifiedCount, nil
+}
+
+// Register регистрирует нового пользователя в системе. Сущность и событие о регистрации
+// записываются в одной транзакции; уникальность email обеспечивает индекс из EnsureEntityIndexes.
 func (s *EntityService) Register(ctx context.Context, auth auth.Authenticatable) (string, error) {
 	collectionName := auth.GetCollectionName() // Получение имени коллекции
 	collection := s.db.Collection(collectionName)
 	userData := auth.GetCustomData()
-	//Проверка на уникальность email
-	count, err := collection.CountDocuments(ctx, bson.M{"email": auth.GetEmail()})
-
-	if err != nil {
-		return "", errors.Wrap(err, "checking email failed")
-	}
-	if count > 0 {
-		return "", errors.New("этот email уже зарегистрирован в системе")
-	}
+	// Роли выдаются только сервером, а не при самостоятельной регистрации
+	delete(userData, "roles")
 
 	// Хэширование пароля пользователя
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(auth.GetPassword()), bcrypt.DefaultCost)
@@ -38,16 +69,33 @@ func (s *EntityService) Register(ctx context.Context, auth auth.Authenticatable)
 	}
 	userData["password"] = string(hashedPassword) // Добавляем хэшированный пароль
 
-	// Добавление пользователя в базу данных
+	eventType := models.DomainEventUserRegistered
+	if collectionName == EntityTypeRestaurant {
+		eventType = models.DomainEventRestaurantRegistered
+	}
+
+	var entityID primitive.ObjectID
+	err = s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		// Добавление пользователя в базу данных; занятый email отклоняет уникальный индекс
+		result, err := collection.InsertOne(ctx, bson.M(userData))
+		if err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrEmailTaken
+			}
+			return errors.Wrap(err, "inserting user failed")
+		}
+		entityID = result.InsertedID.(primitive.ObjectID)
 
-	result, err := collection.InsertOne(ctx, bson.M(userData))
+		return s.eventBus.Record(ctx, eventType, entityID.Hex(), models.EntityRegisteredPayload{
+			EntityID:   entityID,
+			EntityType: collectionName,
+		})
+	})
 	if err != nil {
-		return "", errors.Wrap(err, "inserting user failed")
+		return "", err
 	}
 
-	// Получение ID вставленного пользователя
-	userID := result.InsertedID.(primitive.ObjectID).Hex()
-	return userID, nil
+	return entityID.Hex(), nil
 }
 
 // Authenticate проверяет учетные данные пользователя и возвращает токен, если успешно
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"awesomeProject/internal/auth"
+	"awesomeProject/internal/models"
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRegisterRejectsTakenEmail(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wantErr   error
+	}{
+		{
+			name: "new email",
+			responses: []bson.D{
+				mtest.CreateSuccessResponse(), // пользователь
+				mtest.CreateSuccessResponse(), // событие user.registered
+			},
+		},
+		{
+			name: "email taken",
+			responses: []bson.D{
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}),
+			},
+			wantErr: ErrEmailTaken,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			bus := &EventBus{client: mt.Client, db: mt.DB}
+			service := &EntityService{db: mt.DB, entityCollName: EntityTypeUser, eventBus: bus}
+			mt.AddMockResponses(tt.responses...)
+
+			id, err := service.Register(context.Background(), &models.User{Email: "user@example.com", Password: "secret123"})
+			if err != tt.wantErr {
+				mt.Fatalf("Register() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && id == "" {
+				mt.Errorf("Register() returned empty id")
+			}
+			// Занятость email проверяет уникальный индекс, а не отдельный запрос перед вставкой
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "count" || event.CommandName == "aggregate" {
+					mt.Errorf("unexpected %s before insert", event.CommandName)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterIgnoresRoles(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name   string
+		entity auth.Authenticatable
+	}{
+		{name: "user claims admin", entity: &models.User{Email: "user@example.com", Password: "secret123", Roles: models.RoleAdmin}},
+		{name: "user claims courier", entity: &models.User{Email: "user@example.com", Password: "secret123", Roles: models.RoleCourier}},
+		{name: "restaurant claims admin", entity: &models.Restaurant{Email: "cafe@example.com", Password: "secret123", Roles: models.RoleAdmin}},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			bus := &EventBus{client: mt.Client, db: mt.DB}
+			service := &EntityService{db: mt.DB, eventBus: bus}
+			mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+			if _, err := service.Register(context.Background(), tt.entity); err != nil {
+				mt.Fatalf("Register() error = %v", err)
+			}
+
+			inserted := mt.GetStartedEvent().Command.Lookup("documents").Array().Index(0).Value().Document()
+			if roles, err := inserted.LookupErr("roles"); err == nil {
+				mt.Errorf("registered entity has roles %v", roles)
+			}
+		})
+	}
+}
+
+func TestUpdateEntityIgnoresRoles(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("update", func(mt *mtest.T) {
+		service := &EntityService{db: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+		)
+
+		user := &models.User{Email: "user@example.com", Name: "Ivan", Roles: models.RoleAdmin}
+		if err := service.UpdateEntity(context.Background(), primitive.NewObjectID().Hex(), user, "users"); err != nil {
+			mt.Fatalf("UpdateEntity() error = %v", err)
+		}
+
+		mt.GetStartedEvent() // проверка занятости email
+		set := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set").Document()
+		if roles, err := set.LookupErr("roles"); err == nil {
+			mt.Errorf("update sets roles %v", roles)
+		}
+		if got := set.Lookup("name").StringValue(); got != "Ivan" {
+			mt.Errorf("update name = %q, want Ivan", got)
+		}
+	})
+}
+
+func TestGrantAdminRoles(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("only users without a role", func(mt *mtest.T) {
+		service := &EntityService{db: mt.DB}
+		mt.AddMockResponses(bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 2}, {Key: "nModified", Value: 1}})
+
+		granted, err := service.GrantAdminRoles(context.Background(), []string{"a@example.com", "b@example.com"})
+		if err != nil {
+			mt.Fatalf("GrantAdminRoles() error = %v", err)
+		}
+		if granted != 1 {
+			mt.Errorf("granted = %d, want 1", granted)
+		}
+
+		update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+		if got := update.Lookup("q", "roles", "$in").Array().Index(0).Value().StringValue(); got != "" {
+			mt.Errorf("filter roles = %q, want users without a role", got)
+		}
+		if got := update.Lookup("u", "$set", "roles").StringValue(); got != models.RoleAdmin {
+			mt.Errorf("granted role = %q, want %q", got, models.RoleAdmin)
+		}
+	})
+
+	mt.Run("no emails", func(mt *mtest.T) {
+		service := &EntityService{db: mt.DB}
+		if _, err := service.GrantAdminRoles(context.Background(), nil); err != nil {
+			mt.Fatalf("GrantAdminRoles() error = %v", err)
+		}
+		if len(mt.GetAllStartedEvents()) != 0 {
+			mt.Error("unexpected database call without emails")
+		}
+	})
+}
+
+func TestGetRestaurantIgnoresLegacyEmbeddedReviews(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("legacy reviews", func(mt *mtest.T) {
+		id := primitive.NewObjectID()
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.restaurant", mtest.FirstBatch, bson.D{
+			{Key: "_id", Value: id},
+			{Key: "name", Value: "Пельменная"},
+			{Key: "reviews", Value: bson.A{bson.D{{Key: "_id", Value: "legacy-review"}, {Key: "rating", Value: 5}}}},
+		}))
+		service := &EntityService{db: mt.DB, entityCollName: EntityTypeRestaurant}
+
+		entity, err := service.GetEntity(context.Background(), id.Hex(), EntityTypeRestaurant)
+		if err != nil {
+			t.Fatalf("GetEntity() error = %v", err)
+		}
+		if restaurant := entity.(*models.Restaurant); restaurant.Name != "Пельменная" {
+			t.Fatalf("Name = %q, want %q", restaurant.Name, "Пельменная")
+		}
+	})
+}
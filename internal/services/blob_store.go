@@ -0,0 +1,205 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/hkdf"
+)
+
+// mediaSigningKeyLabel метка HKDF для ключа подписи ссылок на медиа
+const mediaSigningKeyLabel = "awesomeProject media url signing v1"
+
+var (
+	ErrBlobNotFound     = errors.New("blob not found")
+	ErrInvalidBlobKey   = errors.New("invalid blob key")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+)
+
+// BlobStore хранилище загруженных файлов. Файлы не публичны:
+// клиенты получают к ним доступ только по подписанным ссылкам с ограниченным сроком действия.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	SignedURL(key string, ttl time.Duration) (string, error)
+}
+
+// NewBlobStoreFromEnv создает хранилище по переменной окружения MEDIA_STORE.
+// "s3" использует S3-совместимое хранилище из S3_*, иначе файлы хранятся на диске в MEDIA_LOCAL_DIR,
+// а ссылки подписываются MEDIA_SIGNING_KEY или ключом, выведенным из SECRET_KEY.
+func NewBlobStoreFromEnv() (BlobStore, error) {
+	switch os.Getenv("MEDIA_STORE") {
+	case "s3":
+		return NewS3BlobStore(S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+			VirtualHosted:   os.Getenv("S3_VIRTUAL_HOSTED") == "true",
+		})
+	default:
+		dir := os.Getenv("MEDIA_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		baseURL := os.Getenv("MEDIA_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080/media"
+		}
+		signingKey := []byte(os.Getenv("MEDIA_SIGNING_KEY"))
+		if len(signingKey) == 0 {
+			var err error
+			if signingKey, err = deriveMediaSigningKey(SecretKey); err != nil {
+				return nil, err
+			}
+		}
+		return NewLocalBlobStore(dir, baseURL, signingKey)
+	}
+}
+
+// deriveMediaSigningKey выводит ключ подписи ссылок из секрета через HKDF с отдельной меткой,
+// чтобы подписи ссылок на медиа и JWT никогда не вычислялись одним ключом
+func deriveMediaSigningKey(secret []byte) ([]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("MEDIA_SIGNING_KEY or SECRET_KEY is required for local blob store")
+	}
+	key := make([]byte, sha256.Size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, nil, []byte(mediaSigningKeyLabel)), key); err != nil {
+		return nil, errors.Wrap(err, "deriving media signing key failed")
+	}
+	return key, nil
+}
+
+// validBlobKey проверяет, что ключ является относительным путем без выхода за пределы хранилища
+func validBlobKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// LocalBlobStore хранит файлы в каталоге на диске и отдает их через обработчик /media
+// по ссылкам, подписанным HMAC-SHA256 от ключа и срока действия
+type LocalBlobStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewLocalBlobStore создает хранилище в каталоге dir; ссылки строятся от baseURL
+func NewLocalBlobStore(dir, baseURL string, signingKey []byte) (*LocalBlobStore, error) {
+	if len(signingKey) == 0 {
+		return nil, errors.New("signing key is required for local blob store")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrap(err, "creating media directory failed")
+	}
+	return &LocalBlobStore{
+		dir:        dir,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		signingKey: signingKey,
+	}, nil
+}
+
+// path возвращает путь к файлу на диске
+func (s *LocalBlobStore) path(key string) (string, error) {
+	if !validBlobKey(key) {
+		return "", ErrInvalidBlobKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put сохраняет файл; запись идет во временный файл, чтобы читатели не увидели его частично
+func (s *LocalBlobStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, "creating blob directory failed")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return errors.Wrap(err, "creating blob file failed")
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, bytes.NewReader(data)); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "writing blob failed")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "writing blob failed")
+	}
+	return errors.Wrap(os.Rename(tmp.Name(), path), "saving blob failed")
+}
+
+// Delete удаляет файл; отсутствие файла не считается ошибкой
+func (s *LocalBlobStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "deleting blob failed")
+	}
+	return nil
+}
+
+// SignedURL возвращает ссылку на файл, действующую ttl
+func (s *LocalBlobStore) SignedURL(key string, ttl time.Duration) (string, error) {
+	if !validBlobKey(key) {
+		return "", ErrInvalidBlobKey
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("sig", s.sign(key, expires))
+	return s.baseURL + "/" + key + "?" + query.Encode(), nil
+}
+
+// Open проверяет подпись ссылки и открывает файл для отдачи
+func (s *LocalBlobStore) Open(key, expires, signature string) (*os.File, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return nil, ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(signature), []byte(s.sign(key, expires))) {
+		return nil, ErrInvalidSignature
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "opening blob failed")
+	}
+	return file, nil
+}
+
+// sign вычисляет подпись ключа и срока действия ссылки
+func (s *LocalBlobStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestDeriveMediaSigningKey(t *testing.T) {
+	key, err := deriveMediaSigningKey([]byte("jwt-secret"))
+	if err != nil {
+		t.Fatalf("deriveMediaSigningKey() error = %v", err)
+	}
+	again, _ := deriveMediaSigningKey([]byte("jwt-secret"))
+	other, _ := deriveMediaSigningKey([]byte("other-secret"))
+
+	switch {
+	case len(key) != 32:
+		t.Errorf("len(key) = %d, want 32", len(key))
+	case !bytes.Equal(key, again):
+		t.Error("key is not deterministic")
+	case bytes.Equal(key, other):
+		t.Error("different secrets give the same key")
+	case bytes.Contains(key, []byte("jwt-secret")):
+		t.Error("key contains the secret")
+	}
+
+	if _, err := deriveMediaSigningKey(nil); err == nil {
+		t.Error("deriveMediaSigningKey(nil) error = nil, want error")
+	}
+}
+
+func TestLocalBlobStoreRejectsJWTSecretSignature(t *testing.T) {
+	secret := []byte("jwt-secret")
+	key, err := deriveMediaSigningKey(secret)
+	if err != nil {
+		t.Fatalf("deriveMediaSigningKey() error = %v", err)
+	}
+	store, err := NewLocalBlobStore(t.TempDir(), "http://localhost/media", key)
+	if err != nil {
+		t.Fatalf("NewLocalBlobStore() error = %v", err)
+	}
+	// Ссылка, подписанная самим секретом JWT, не должна открывать файлы
+	forger := &LocalBlobStore{signingKey: secret, baseURL: "http://localhost/media"}
+
+	tests := []struct {
+		name    string
+		signer  *LocalBlobStore
+		wantErr error
+	}{
+		{name: "own signature", signer: store, wantErr: ErrBlobNotFound},
+		{name: "jwt secret signature", signer: forger, wantErr: ErrInvalidSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link, err := tt.signer.SignedURL("photos/a.jpg", time.Minute)
+			if err != nil {
+				t.Fatalf("SignedURL() error = %v", err)
+			}
+			parsed, _ := url.Parse(link)
+			_, err = store.Open(strings.TrimPrefix(parsed.Path, "/media/"), parsed.Query().Get("expires"), parsed.Query().Get("sig"))
+			if errors.Cause(err) != tt.wantErr {
+				t.Errorf("Open() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
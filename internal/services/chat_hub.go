@@ -0,0 +1,70 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// chatSubscriberBuffer размер очереди событий одного подключения
+const chatSubscriberBuffer = 64
+
+// ChatHub рассылает события чата открытым подключениям пользователей этого экземпляра сервера.
+// У одного пользователя может быть несколько подключений (телефон и браузер).
+type ChatHub struct {
+	mu          sync.RWMutex
+	subscribers map[primitive.ObjectID]map[chan models.ChatEvent]bool
+}
+
+// NewChatHub создает новый экземпляр ChatHub
+func NewChatHub() *ChatHub {
+	return &ChatHub{
+		subscribers: map[primitive.ObjectID]map[chan models.ChatEvent]bool{},
+	}
+}
+
+// Subscribe регистрирует подключение пользователя. Возвращает канал событий
+// и функцию отписки, которую нужно вызвать при закрытии подключения.
+func (h *ChatHub) Subscribe(userID primitive.ObjectID) (<-chan models.ChatEvent, func()) {
+	events := make(chan models.ChatEvent, chatSubscriberBuffer)
+
+	h.mu.Lock()
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = map[chan models.ChatEvent]bool{}
+	}
+	h.subscribers[userID][events] = true
+	h.mu.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers[userID], events)
+			if len(h.subscribers[userID]) == 0 {
+				delete(h.subscribers, userID)
+			}
+			h.mu.Unlock()
+			close(events)
+		})
+	}
+}
+
+// Publish отправляет событие всем подключениям указанных пользователей.
+// Если очередь подключения переполнена, событие для него пропускается:
+// клиент догрузит пропущенные сообщения через историю беседы.
+func (h *ChatHub) Publish(userIDs []primitive.ObjectID, event models.ChatEvent) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, userID := range userIDs {
+		for events := range h.subscribers[userID] {
+			select {
+			case events <- event:
+			default:
+				log.Printf("Dropping chat event for slow connection of user %s", userID.Hex())
+			}
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestChatHubPublish(t *testing.T) {
+	hub := NewChatHub()
+	alice, bob, carol := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	phone, closePhone := hub.Subscribe(alice)
+	browser, closeBrowser := hub.Subscribe(alice)
+	bobEvents, closeBob := hub.Subscribe(bob)
+	carolEvents, closeCarol := hub.Subscribe(carol)
+	defer closePhone()
+	defer closeBrowser()
+	defer closeBob()
+	defer closeCarol()
+
+	hub.Publish([]primitive.ObjectID{alice, bob}, models.ChatEvent{Type: "message"})
+
+	for name, events := range map[string]<-chan models.ChatEvent{"phone": phone, "browser": browser, "bob": bobEvents} {
+		select {
+		case event := <-events:
+			if event.Type != "message" {
+				t.Errorf("%s got %q, want message", name, event.Type)
+			}
+		default:
+			t.Errorf("%s got no event", name)
+		}
+	}
+	select {
+	case event := <-carolEvents:
+		t.Errorf("carol got %q, want nothing", event.Type)
+	default:
+	}
+}
+
+func TestChatHubDropsEventsForSlowConnections(t *testing.T) {
+	hub := NewChatHub()
+	userID := primitive.NewObjectID()
+	events, unsubscribe := hub.Subscribe(userID)
+	defer unsubscribe()
+
+	// Publish не должен блокироваться, когда очередь подключения заполнена
+	for i := 0; i < chatSubscriberBuffer+10; i++ {
+		hub.Publish([]primitive.ObjectID{userID}, models.ChatEvent{Type: "typing"})
+	}
+	if len(events) != chatSubscriberBuffer {
+		t.Errorf("queued events = %d, want %d", len(events), chatSubscriberBuffer)
+	}
+}
+
+func TestChatHubUnsubscribe(t *testing.T) {
+	hub := NewChatHub()
+	userID := primitive.NewObjectID()
+	events, unsubscribe := hub.Subscribe(userID)
+
+	unsubscribe()
+	unsubscribe() // повторный вызов при закрытии подключения безопасен
+
+	if _, open := <-events; open {
+		t.Errorf("events channel is still open after unsubscribe")
+	}
+	hub.Publish([]primitive.ObjectID{userID}, models.ChatEvent{Type: "message"})
+	if len(hub.subscribers) != 0 {
+		t.Errorf("subscribers = %d, want none", len(hub.subscribers))
+	}
+}
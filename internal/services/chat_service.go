@@ -0,0 +1,470 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	conversationsCollectionName = "conversations"
+	messagesCollectionName      = "messages"
+)
+
+// maxConversationMembers ограничивает размер групповой беседы
+const maxConversationMembers = 50
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrNotFriends           = errors.New("users must be friends")
+	ErrConversationFull     = errors.New("conversation has too many members")
+	ErrNotGroupConversation = errors.New("only group conversations can be changed")
+	ErrMessageNotFound      = errors.New("message not found")
+	ErrEmptyConversation    = errors.New("group conversation needs at least one other member")
+)
+
+// ChatService структура сервиса бесед и сообщений
+type ChatService struct {
+	db            *mongo.Database
+	friendService *FriendService
+	meetupService *MeetupService
+	hub           *ChatHub
+}
+
+// NewChatService создает новый экземпляр ChatService
+func NewChatService(client *mongo.Client, dbName string, friendService *FriendService, meetupService *MeetupService, hub *ChatHub) *ChatService {
+	return &ChatService{
+		db:            client.Database(dbName),
+		friendService: friendService,
+		meetupService: meetupService,
+		hub:           hub,
+	}
+}
+
+// EnsureIndexes создает индексы коллекций бесед и сообщений
+func (s *ChatService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(conversationsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "member_ids", Value: 1}, {Key: "updated_at", Value: -1}}},
+		{
+			// Одна личная беседа на пару пользователей
+			Keys: bson.D{{Key: "direct_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"direct_key": bson.M{"$exists": true},
+			}),
+		},
+		{
+			// Одна беседа на встречу
+			Keys: bson.D{{Key: "meetup_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"meetup_id": bson.M{"$exists": true},
+			}),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating conversation indexes failed")
+	}
+
+	_, err = s.db.Collection(messagesCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "conversation_id", Value: 1}, {Key: "_id", Value: -1}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating message indexes failed")
+	}
+	return nil
+}
+
+// GetOrCreateDirect возвращает личную беседу двух друзей, создавая ее при первом обращении
+func (s *ChatService) GetOrCreateDirect(ctx context.Context, userID, otherID primitive.ObjectID) (*models.Conversation, error) {
+	if userID == otherID {
+		return nil, ErrSelfFriendship
+	}
+	friends, err := s.friendService.AreFriends(ctx, userID, otherID)
+	if err != nil {
+		return nil, err
+	}
+	if !friends {
+		return nil, ErrNotFriends
+	}
+
+	low, high := orderPair(userID, otherID)
+	now := time.Now()
+	var conversation models.Conversation
+	err = s.db.Collection(conversationsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"direct_key": low.Hex() + ":" + high.Hex()},
+		bson.M{"$setOnInsert": bson.M{
+			"type":       models.ConversationDirect,
+			"member_ids": []primitive.ObjectID{low, high},
+			"created_by": userID,
+			"reads":      bson.M{},
+			"created_at": now,
+			"updated_at": now,
+		}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&conversation)
+	if err != nil {
+		return nil, errors.Wrap(err, "upserting direct conversation failed")
+	}
+	return &conversation, nil
+}
+
+// CreateGroup создает групповую беседу создателя с его друзьями
+func (s *ChatService) CreateGroup(ctx context.Context, creatorID primitive.ObjectID, title string, memberIDs []primitive.ObjectID) (*models.Conversation, error) {
+	members, err := s.friendMembers(ctx, creatorID, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, ErrEmptyConversation
+	}
+	members = append([]primitive.ObjectID{creatorID}, members...)
+	if len(members) > maxConversationMembers {
+		return nil, ErrConversationFull
+	}
+
+	now := time.Now()
+	conversation := &models.Conversation{
+		ID:        primitive.NewObjectID(),
+		Type:      models.ConversationGroup,
+		Title:     title,
+		MemberIDs: members,
+		CreatedBy: creatorID,
+		Reads:     map[string]models.ReadReceipt{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := conversation.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.Collection(conversationsCollectionName).InsertOne(ctx, conversation); err != nil {
+		return nil, errors.Wrap(err, "inserting conversation failed")
+	}
+	return conversation, nil
+}
+
+// AddMembers добавляет друзей участника в групповую беседу
+func (s *ChatService) AddMembers(ctx context.Context, userID, conversationID primitive.ObjectID, memberIDs []primitive.ObjectID) (*models.Conversation, error) {
+	conversation, err := s.memberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.Type != models.ConversationGroup {
+		return nil, ErrNotGroupConversation
+	}
+
+	members, err := s.friendMembers(ctx, userID, memberIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return conversation, nil
+	}
+
+	// Условие на размер массива не дает превысить лимит при одновременном добавлении
+	var updated models.Conversation
+	err = s.db.Collection(conversationsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{
+			"_id": conversationID,
+			"$expr": bson.M{"$lte": bson.A{
+				bson.M{"$size": bson.M{"$setUnion": bson.A{"$member_ids", members}}},
+				maxConversationMembers,
+			}},
+		},
+		bson.M{
+			"$addToSet": bson.M{"member_ids": bson.M{"$each": members}},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConversationFull
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "adding members failed")
+	}
+	return &updated, nil
+}
+
+// Leave удаляет пользователя из групповой беседы
+func (s *ChatService) Leave(ctx context.Context, userID, conversationID primitive.ObjectID) error {
+	conversation, err := s.memberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return err
+	}
+	if conversation.Type != models.ConversationGroup {
+		return ErrNotGroupConversation
+	}
+
+	_, err = s.db.Collection(conversationsCollectionName).UpdateOne(ctx, bson.M{"_id": conversationID}, bson.M{
+		"$pull":  bson.M{"member_ids": userID},
+		"$unset": bson.M{"reads." + userID.Hex(): ""},
+	})
+	if err != nil {
+		return errors.Wrap(err, "leaving conversation failed")
+	}
+	return nil
+}
+
+// SyncMeetupConversation создает или обновляет беседу встречи:
+// участники - организатор и все приглашенные, название совпадает с названием встречи
+func (s *ChatService) SyncMeetupConversation(ctx context.Context, meetup *models.Meetup) (*models.Conversation, error) {
+	members := []primitive.ObjectID{meetup.OrganizerID}
+	for _, invitation := range meetup.Invitations {
+		members = append(members, invitation.UserID)
+	}
+
+	now := time.Now()
+	var conversation models.Conversation
+	err := s.db.Collection(conversationsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"meetup_id": meetup.ID},
+		bson.M{
+			"$set": bson.M{"title": meetup.Title, "member_ids": uniqueObjectIDs(members)},
+			"$setOnInsert": bson.M{
+				"type":       models.ConversationMeetup,
+				"created_by": meetup.OrganizerID,
+				"reads":      bson.M{},
+				"created_at": now,
+				"updated_at": now,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&conversation)
+	if err != nil {
+		return nil, errors.Wrap(err, "syncing meetup conversation failed")
+	}
+	return &conversation, nil
+}
+
+// MeetupConversation возвращает беседу встречи, в которой участвует пользователь
+func (s *ChatService) MeetupConversation(ctx context.Context, userID, meetupID primitive.ObjectID) (*models.Conversation, error) {
+	meetup, err := s.meetupService.GetMeetup(ctx, userID, meetupID)
+	if err != nil {
+		return nil, err
+	}
+	return s.SyncMeetupConversation(ctx, meetup)
+}
+
+// GetConversation возвращает беседу участника с количеством непрочитанных сообщений
+func (s *ChatService) GetConversation(ctx context.Context, userID, conversationID primitive.ObjectID) (*models.Conversation, error) {
+	conversation, err := s.memberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.countUnread(ctx, userID, conversation); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// ListConversations возвращает беседы пользователя, недавно активные первыми
+func (s *ChatService) ListConversations(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]models.Conversation, error) {
+	cursor, err := s.db.Collection(conversationsCollectionName).Find(ctx, bson.M{"member_ids": userID}, options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding conversations failed")
+	}
+	conversations := []models.Conversation{}
+	if err := cursor.All(ctx, &conversations); err != nil {
+		return nil, errors.Wrap(err, "decoding conversations failed")
+	}
+	for i := range conversations {
+		if err := s.countUnread(ctx, userID, &conversations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return conversations, nil
+}
+
+// SendMessage сохраняет сообщение и доставляет его участникам, подключенным по WebSocket
+func (s *ChatService) SendMessage(ctx context.Context, userID, conversationID primitive.ObjectID, text string) (*models.Message, error) {
+	conversation, err := s.memberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation.Type == models.ConversationDirect {
+		for _, memberID := range conversation.MemberIDs {
+			if memberID == userID {
+				continue
+			}
+			blocked, err := s.friendService.IsBlocked(ctx, userID, memberID)
+			if err != nil {
+				return nil, err
+			}
+			if blocked {
+				return nil, ErrUserBlocked
+			}
+		}
+	}
+
+	message := &models.Message{
+		ID:             primitive.NewObjectID(),
+		ConversationID: conversationID,
+		SenderID:       userID,
+		Text:           text,
+		CreatedAt:      time.Now(),
+	}
+	if err := message.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := s.db.Collection(messagesCollectionName).InsertOne(ctx, message); err != nil {
+		return nil, errors.Wrap(err, "inserting message failed")
+	}
+
+	// Отправитель прочитал беседу до своего сообщения включительно
+	_, err = s.db.Collection(conversationsCollectionName).UpdateOne(ctx, bson.M{"_id": conversationID}, bson.M{"$set": bson.M{
+		"last_message":          message,
+		"updated_at":            message.CreatedAt,
+		"reads." + userID.Hex(): models.ReadReceipt{MessageID: message.ID, ReadAt: message.CreatedAt},
+	}})
+	if err != nil {
+		return nil, errors.Wrap(err, "updating conversation failed")
+	}
+
+	s.hub.Publish(conversation.MemberIDs, models.ChatEvent{
+		Type:           models.ChatEventMessage,
+		ConversationID: conversationID,
+		Message:        message,
+	})
+	return message, nil
+}
+
+// ListMessages возвращает сообщения беседы старше before (или последние, если before пустой), новые первыми
+func (s *ChatService) ListMessages(ctx context.Context, userID, conversationID primitive.ObjectID, before string, limit int) (*models.MessagePage, error) {
+	if _, err := s.memberConversation(ctx, userID, conversationID); err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"conversation_id": conversationID}
+	if before != "" {
+		beforeID, err := primitive.ObjectIDFromHex(before)
+		if err != nil {
+			return nil, ErrInvalidCursor
+		}
+		filter["_id"] = bson.M{"$lt": beforeID}
+	}
+
+	cursor, err := s.db.Collection(messagesCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "_id", Value: -1}}).
+		SetLimit(int64(limit+1)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding messages failed")
+	}
+	page := &models.MessagePage{Messages: []models.Message{}}
+	if err := cursor.All(ctx, &page.Messages); err != nil {
+		return nil, errors.Wrap(err, "decoding messages failed")
+	}
+	if len(page.Messages) > limit {
+		page.Messages = page.Messages[:limit]
+		page.NextBefore = page.Messages[limit-1].ID.Hex()
+	}
+	return page, nil
+}
+
+// MarkRead отмечает беседу прочитанной до указанного сообщения и уведомляет участников.
+// Отметка никогда не сдвигается назад.
+func (s *ChatService) MarkRead(ctx context.Context, userID, conversationID, messageID primitive.ObjectID) (*models.ReadReceipt, error) {
+	conversation, err := s.memberConversation(ctx, userID, conversationID)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := s.db.Collection(messagesCollectionName).CountDocuments(ctx, bson.M{"_id": messageID, "conversation_id": conversationID})
+	if err != nil {
+		return nil, errors.Wrap(err, "checking message failed")
+	}
+	if count == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	field := "reads." + userID.Hex()
+	receipt := models.ReadReceipt{MessageID: messageID, ReadAt: time.Now()}
+	result, err := s.db.Collection(conversationsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": conversationID, "$or": bson.A{
+			bson.M{field: bson.M{"$exists": false}},
+			bson.M{field + ".message_id": bson.M{"$lt": messageID}},
+		}},
+		bson.M{"$set": bson.M{field: receipt}},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating read receipt failed")
+	}
+	if result.ModifiedCount == 0 {
+		current := conversation.Reads[userID.Hex()]
+		return &current, nil
+	}
+
+	s.hub.Publish(conversation.MemberIDs, models.ChatEvent{
+		Type:           models.ChatEventRead,
+		ConversationID: conversationID,
+		UserID:         &userID,
+		MessageID:      &messageID,
+	})
+	return &receipt, nil
+}
+
+// Subscribe подписывает подключение пользователя на события его бесед
+func (s *ChatService) Subscribe(userID primitive.ObjectID) (<-chan models.ChatEvent, func()) {
+	return s.hub.Subscribe(userID)
+}
+
+// memberConversation возвращает беседу, если пользователь в ней участвует.
+// Чужая беседа выглядит как отсутствующая.
+func (s *ChatService) memberConversation(ctx context.Context, userID, conversationID primitive.ObjectID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := s.db.Collection(conversationsCollectionName).FindOne(ctx, bson.M{"_id": conversationID, "member_ids": userID}).Decode(&conversation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding conversation failed")
+	}
+	return &conversation, nil
+}
+
+// countUnread подсчитывает чужие сообщения после отметки о прочтении пользователя
+func (s *ChatService) countUnread(ctx context.Context, userID primitive.ObjectID, conversation *models.Conversation) error {
+	if conversation.LastMessage == nil {
+		return nil
+	}
+	filter := bson.M{"conversation_id": conversation.ID, "sender_id": bson.M{"$ne": userID}}
+	if receipt, ok := conversation.Reads[userID.Hex()]; ok {
+		if receipt.MessageID == conversation.LastMessage.ID {
+			return nil
+		}
+		filter["_id"] = bson.M{"$gt": receipt.MessageID}
+	}
+	count, err := s.db.Collection(messagesCollectionName).CountDocuments(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "counting unread messages failed")
+	}
+	conversation.UnreadCount = count
+	return nil
+}
+
+// friendMembers проверяет, что все добавляемые пользователи - друзья участника
+func (s *ChatService) friendMembers(ctx context.Context, userID primitive.ObjectID, memberIDs []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	members := make([]primitive.ObjectID, 0, len(memberIDs))
+	for _, memberID := range uniqueObjectIDs(memberIDs) {
+		if memberID == userID {
+			continue
+		}
+		friends, err := s.friendService.AreFriends(ctx, userID, memberID)
+		if err != nil {
+			return nil, err
+		}
+		if !friends {
+			return nil, ErrNotFriends
+		}
+		members = append(members, memberID)
+	}
+	return members, nil
+}
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxSimulatedCouriers ограничивает число курьеров, создаваемых за один запрос
+const MaxSimulatedCouriers = 50
+
+// simulationBatchSize число ожидающих доставок, обрабатываемых за один шаг симуляции
+const simulationBatchSize = 20
+
+// SpawnSimulatedCouriers создает свободных курьеров без учетных записей в случайных
+// точках круга заданного радиуса. Нужны для локального запуска доставки.
+func (s *DeliveryService) SpawnSimulatedCouriers(ctx context.Context, count int, center models.GeoPoint, radiusMeters float64) ([]models.Courier, error) {
+	if !validPoint(center) {
+		return nil, ErrInvalidCoordinates
+	}
+	if count < 1 {
+		count = 1
+	}
+	if count > MaxSimulatedCouriers {
+		count = MaxSimulatedCouriers
+	}
+
+	now := time.Now()
+	couriers := make([]models.Courier, 0, count)
+	documents := make([]interface{}, 0, count)
+	for i := 0; i < count; i++ {
+		id := primitive.NewObjectID()
+		location := offsetPoint(center, rand.Float64()*radiusMeters, rand.Float64()*2*math.Pi)
+		courier := models.Courier{
+			ID:                id,
+			Name:              fmt.Sprintf("Simulated courier %s", id.Hex()[18:]),
+			Status:            models.CourierStatusAvailable,
+			Location:          &location,
+			LocationUpdatedAt: &now,
+			Simulated:         true,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+		couriers = append(couriers, courier)
+		documents = append(documents, courier)
+	}
+	if _, err := s.db.Collection(couriersCollectionName).InsertMany(ctx, documents); err != nil {
+		return nil, errors.Wrap(err, "inserting simulated couriers failed")
+	}
+	return couriers, nil
+}
+
+// RunSimulation периодически назначает ожидающие доставки ближайшим курьерам и
+// двигает симулируемых курьеров к ресторану и клиенту, пока не отменен контекст
+func (s *DeliveryService) RunSimulation(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := s.assignWaiting(ctx); err != nil {
+			log.Printf("Courier simulation assignment failed: %v", err)
+		}
+		if err := s.moveSimulatedCouriers(ctx, interval); err != nil {
+			log.Printf("Courier simulation step failed: %v", err)
+		}
+	}
+}
+
+// assignWaiting назначает курьеров на самые старые ожидающие доставки
+func (s *DeliveryService) assignWaiting(ctx context.Context) error {
+	cursor, err := s.db.Collection(deliveriesCollectionName).Find(ctx,
+		bson.M{"status": models.DeliveryStatusAwaitingCourier},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetLimit(simulationBatchSize),
+	)
+	if err != nil {
+		return errors.Wrap(err, "finding waiting deliveries failed")
+	}
+	var deliveries []models.Delivery
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return errors.Wrap(err, "decoding waiting deliveries failed")
+	}
+
+	for _, delivery := range deliveries {
+		_, err := s.Assign(ctx, delivery.RestaurantID, delivery.OrderID, nil)
+		switch errors.Cause(err) {
+		case nil, ErrNoCourierAvailable, ErrDeliveryAlreadyAssigned, ErrOrderClosed:
+		default:
+			log.Printf("Failed to assign courier to order %s: %v", delivery.OrderID, err)
+		}
+	}
+	return nil
+}
+
+// moveSimulatedCouriers продвигает занятых симулируемых курьеров на расстояние,
+// которое они проезжают за интервал, и отмечает получение и вручение заказа по прибытии
+func (s *DeliveryService) moveSimulatedCouriers(ctx context.Context, interval time.Duration) error {
+	cursor, err := s.db.Collection(couriersCollectionName).Find(ctx,
+		bson.M{"simulated": true, "status": models.CourierStatusBusy})
+	if err != nil {
+		return errors.Wrap(err, "finding simulated couriers failed")
+	}
+	var couriers []models.Courier
+	if err := cursor.All(ctx, &couriers); err != nil {
+		return errors.Wrap(err, "decoding simulated couriers failed")
+	}
+
+	step := s.speedMetersPerMin * interval.Minutes()
+	for _, courier := range couriers {
+		if courier.ActiveDeliveryID == nil {
+			continue
+		}
+		delivery, err := s.findDelivery(ctx, bson.M{"_id": *courier.ActiveDeliveryID})
+		if err != nil {
+			log.Printf("Failed to load delivery of simulated courier %s: %v", courier.ID.Hex(), err)
+			continue
+		}
+
+		target := delivery.Pickup
+		if delivery.Status == models.DeliveryStatusPickedUp {
+			target = delivery.Location
+		}
+		position := target
+		if courier.Location != nil {
+			position = moveTowards(*courier.Location, target, step)
+		}
+		if _, err := s.UpdateCourierLocation(ctx, courier.ID, position); err != nil {
+			log.Printf("Failed to move simulated courier %s: %v", courier.ID.Hex(), err)
+			continue
+		}
+		if position != target {
+			continue
+		}
+
+		switch delivery.Status {
+		case models.DeliveryStatusAssigned:
+			_, err = s.PickUp(ctx, courier.ID, delivery.ID)
+		case models.DeliveryStatusPickedUp:
+			_, err = s.Deliver(ctx, courier.ID, delivery.ID)
+		}
+		if err != nil {
+			log.Printf("Simulated courier %s failed to advance delivery: %v", courier.ID.Hex(), err)
+		}
+	}
+	return nil
+}
+
+// moveTowards возвращает точку, сдвинутую от from к to не более чем на step метров.
+// На расстояниях доставки достаточно линейной интерполяции координат.
+func moveTowards(from, to models.GeoPoint, step float64) models.GeoPoint {
+	distance := DistanceMeters(from, to)
+	if distance <= step {
+		return to
+	}
+	fraction := step / distance
+	return models.NewGeoPoint(
+		from.Lat()+(to.Lat()-from.Lat())*fraction,
+		from.Lng()+(to.Lng()-from.Lng())*fraction,
+	)
+}
+
+// offsetPoint возвращает точку на расстоянии meters от center в направлении bearing (радианы)
+func offsetPoint(center models.GeoPoint, meters, bearing float64) models.GeoPoint {
+	dLat := meters * math.Cos(bearing) / earthRadiusMeters
+	dLng := meters * math.Sin(bearing) / (earthRadiusMeters * math.Cos(center.Lat()*math.Pi/180))
+	return models.NewGeoPoint(center.Lat()+dLat*180/math.Pi, center.Lng()+dLng*180/math.Pi)
+}
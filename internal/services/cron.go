@@ -0,0 +1,200 @@
+package services
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrInvalidCronSpec = errors.New("invalid cron spec")
+
+// allCronHours маска поля часов, в которой разрешен каждый час
+const allCronHours = 1<<24 - 1
+
+// cronMacros сокращения для распространенных расписаний
+var cronMacros = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// cronSchedule вычисляет время следующего запуска периодической задачи
+type cronSchedule interface {
+	Next(after time.Time) time.Time
+}
+
+// everySchedule запускает задачу с постоянным интервалом ("@every 15m")
+type everySchedule struct {
+	interval time.Duration
+}
+
+// Next возвращает момент через интервал после after
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// fieldSchedule классическое расписание из пяти полей: минута, час, день месяца, месяц, день недели.
+// Каждое поле хранится битовой маской допустимых значений.
+type fieldSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// Если ограничены и день месяца, и день недели, подходит любой из них, как в cron
+	domAny, dowAny bool
+	location       *time.Location
+}
+
+// parseCron разбирает расписание: пять полей cron (поддерживаются *, списки, диапазоны и шаг),
+// сокращения @hourly, @daily, @weekly, @monthly и интервал "@every <duration>".
+// Время полей считается в часовом поясе location.
+func parseCron(spec string, location *time.Location) (cronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || interval < time.Second {
+			return nil, errors.Wrapf(ErrInvalidCronSpec, "%q", spec)
+		}
+		return everySchedule{interval: interval}, nil
+	}
+	if macro, ok := cronMacros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Wrapf(ErrInvalidCronSpec, "%q: expected 5 fields", spec)
+	}
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	var masks [5]uint64
+	for i, field := range fields {
+		mask, err := parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidCronSpec, "%q: %v", spec, err)
+		}
+		masks[i] = mask
+	}
+	// Воскресенье можно записать и как 0, и как 7
+	if masks[4]&(1<<7) != 0 {
+		masks[4] |= 1
+	}
+
+	return &fieldSchedule{
+		minute:   masks[0],
+		hour:     masks[1],
+		dom:      masks[2],
+		month:    masks[3],
+		dow:      masks[4],
+		domAny:   fields[2] == "*",
+		dowAny:   fields[4] == "*",
+		location: location,
+	}, nil
+}
+
+// parseCronField разбирает одно поле расписания в битовую маску значений
+func parseCronField(field string, min, max int) (uint64, error) {
+	var mask uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			value, err := strconv.Atoi(part[i+1:])
+			if err != nil || value <= 0 {
+				return 0, errors.Errorf("invalid step in %q", part)
+			}
+			rangePart, step = part[:i], value
+		}
+
+		from, to := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err1, err2 error
+			from, err1 = strconv.Atoi(bounds[0])
+			to, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, errors.Errorf("invalid range %q", rangePart)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, errors.Errorf("invalid value %q", rangePart)
+			}
+			from, to = value, value
+			// "5/15" означает с 5 до конца диапазона с шагом 15
+			if step > 1 {
+				to = max
+			}
+		}
+		if from < min || to > max || from > to {
+			return 0, errors.Errorf("%q is out of range %d-%d", part, min, max)
+		}
+		for value := from; value <= to; value += step {
+			mask |= 1 << uint(value)
+		}
+	}
+	return mask, nil
+}
+
+// Next возвращает первый подходящий момент с точностью до минуты строго после after.
+// Поиск ограничен пятью годами, чтобы невозможное расписание (31 февраля) не зациклило планировщик.
+//
+// Расписание с заданными часами ищется по местному времени на часах: при переводе часов вперед
+// запуск из пропущенного часа сдвигается вместе с ними, а при переводе назад повторившийся час
+// не запускает задачу второй раз. Ежечасные расписания идут по реальному времени, как в cron.
+func (s *fieldSchedule) Next(after time.Time) time.Time {
+	zone := s.location
+	t := after.In(s.location)
+	if s.hour != allCronHours {
+		zone = time.UTC
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, zone)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, zone)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, zone)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, zone)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		if zone == s.location {
+			return t
+		}
+		// Повторившееся при переводе назад время приходится на его второе наступление
+		if next := s.inLocation(t); next.After(after) {
+			return next
+		}
+		t = t.Add(time.Minute)
+	}
+	if zone == s.location {
+		return limit
+	}
+	return s.inLocation(limit)
+}
+
+// inLocation переводит время на часах из UTC в часовой пояс расписания
+func (s *fieldSchedule) inLocation(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, s.location)
+}
+
+// dayMatches проверяет день месяца и день недели по правилам cron
+func (s *fieldSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		wantErr bool
+	}{
+		{name: "five fields", spec: "*/15 9-17 * * 1-5"},
+		{name: "macro", spec: "@daily"},
+		{name: "every", spec: "@every 15m"},
+		{name: "sunday as seven", spec: "0 10 * * 7"},
+		{name: "four fields", spec: "0 10 * *", wantErr: true},
+		{name: "minute out of range", spec: "60 * * * *", wantErr: true},
+		{name: "weekday out of range", spec: "0 0 * * 8", wantErr: true},
+		{name: "zero step", spec: "*/0 * * * *", wantErr: true},
+		{name: "reversed range", spec: "5-1 * * * *", wantErr: true},
+		{name: "not a number", spec: "a * * * *", wantErr: true},
+		{name: "every too short", spec: "@every 500ms", wantErr: true},
+		{name: "unknown macro", spec: "@yearly", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseCron(tt.spec, time.UTC)
+			if tt.wantErr {
+				if errors.Cause(err) != ErrInvalidCronSpec {
+					t.Fatalf("parseCron(%q) error = %v, want %v", tt.spec, err, ErrInvalidCronSpec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCron(%q) error = %v", tt.spec, err)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("no tzdata: %v", err)
+	}
+	utc := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+	cest := time.FixedZone("CEST", 2*60*60)
+	cet := time.FixedZone("CET", 60*60)
+
+	tests := []struct {
+		name     string
+		spec     string
+		location *time.Location
+		after    time.Time
+		want     time.Time
+	}{
+		{name: "step", spec: "*/15 * * * *", after: utc(19, 10, 7), want: utc(19, 10, 15)},
+		{name: "step from offset", spec: "5/20 * * * *", after: utc(19, 10, 30), want: utc(19, 10, 45)},
+		{name: "step wraps to next hour", spec: "5/20 * * * *", after: utc(19, 10, 45), want: utc(19, 11, 5)},
+		{name: "strictly after", spec: "*/15 * * * *", after: utc(19, 10, 15), want: utc(19, 10, 30)},
+		{name: "range", spec: "0 9-17 * * *", after: utc(19, 17, 30), want: utc(20, 9, 0)},
+		{name: "list with range", spec: "0 9,12-13 * * *", after: utc(19, 9, 0), want: utc(19, 12, 0)},
+		{name: "range with step", spec: "0 8-20/4 * * *", after: utc(19, 12, 0), want: utc(19, 16, 0)},
+		{name: "sunday as zero", spec: "0 10 * * 0", after: utc(19, 0, 0), want: utc(25, 10, 0)},
+		{name: "sunday as seven", spec: "0 10 * * 7", after: utc(19, 0, 0), want: utc(25, 10, 0)},
+		{name: "weekday range to seven", spec: "0 10 * * 6-7", after: utc(24, 11, 0), want: utc(25, 10, 0)},
+		{name: "day of month only", spec: "0 0 13 * *", after: utc(19, 0, 0), want: time.Date(2026, time.November, 13, 0, 0, 0, 0, time.UTC)},
+		{name: "day of week only", spec: "0 0 * * 5", after: utc(19, 0, 0), want: utc(23, 0, 0)},
+		{name: "day of month or week", spec: "0 0 13 * 5", after: utc(19, 0, 0), want: utc(23, 0, 0)},
+		{name: "day of month or week by date", spec: "0 0 20 * 5", after: utc(19, 0, 0), want: utc(20, 0, 0)},
+		{name: "month", spec: "0 0 1 1 *", after: utc(19, 0, 0), want: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "leap day", spec: "0 0 29 2 *", after: utc(19, 0, 0), want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{name: "impossible date stops at limit", spec: "0 0 31 2 *", after: utc(19, 0, 0), want: utc(19, 0, 1).AddDate(5, 0, 0)},
+		{
+			name: "location", spec: "0 9 * * *", location: berlin,
+			after: utc(19, 8, 0), want: time.Date(2026, time.October, 20, 9, 0, 0, 0, cest),
+		},
+		{
+			name: "spring forward runs skipped time after the change", spec: "30 2 * * *", location: berlin,
+			after: time.Date(2026, time.March, 29, 0, 0, 0, 0, cet), want: time.Date(2026, time.March, 29, 3, 30, 0, 0, cest),
+		},
+		{
+			name: "spring forward keeps next day", spec: "30 2 * * *", location: berlin,
+			after: time.Date(2026, time.March, 29, 3, 30, 0, 0, cest), want: time.Date(2026, time.March, 30, 2, 30, 0, 0, cest),
+		},
+		{
+			name: "spring forward hourly", spec: "30 * * * *", location: berlin,
+			after: time.Date(2026, time.March, 29, 1, 30, 0, 0, cet), want: time.Date(2026, time.March, 29, 3, 30, 0, 0, cest),
+		},
+		{
+			name: "fall back runs repeated time once", spec: "30 2 * * *", location: berlin,
+			after: time.Date(2026, time.October, 25, 2, 0, 0, 0, cest), want: time.Date(2026, time.October, 25, 2, 30, 0, 0, cet),
+		},
+		{
+			name: "fall back does not repeat", spec: "30 2 * * *", location: berlin,
+			after: time.Date(2026, time.October, 25, 2, 30, 0, 0, cet), want: time.Date(2026, time.October, 26, 2, 30, 0, 0, cet),
+		},
+		{
+			name: "fall back skips second occurrence", spec: "30 2 * * *", location: berlin,
+			after: time.Date(2026, time.October, 25, 2, 30, 0, 0, cest), want: time.Date(2026, time.October, 26, 2, 30, 0, 0, cet),
+		},
+		{
+			name: "fall back hourly runs in both hours", spec: "30 * * * *", location: berlin,
+			after: time.Date(2026, time.October, 25, 2, 30, 0, 0, cest), want: time.Date(2026, time.October, 25, 2, 30, 0, 0, cet),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			location := tt.location
+			if location == nil {
+				location = time.UTC
+			}
+			schedule, err := parseCron(tt.spec, location)
+			if err != nil {
+				t.Fatalf("parseCron(%q) error = %v", tt.spec, err)
+			}
+			if got := schedule.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.after, got.In(location), tt.want)
+			}
+		})
+	}
+}
+
+func TestEveryScheduleNext(t *testing.T) {
+	schedule, err := parseCron("@every 90s", time.UTC)
+	if err != nil {
+		t.Fatalf("parseCron() error = %v", err)
+	}
+	after := time.Date(2026, time.October, 19, 10, 0, 30, 0, time.UTC)
+	if got, want := schedule.Next(after), after.Add(90*time.Second); !got.Equal(want) {
+		t.Errorf("Next() = %v, want %v", got, want)
+	}
+}
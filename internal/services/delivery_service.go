@@ -0,0 +1,782 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	deliveriesCollectionName = "deliveries"
+	couriersCollectionName   = "couriers"
+)
+
+// deliveryPickupMinutes время на получение заказа курьером в ресторане
+const deliveryPickupMinutes = 3
+
+// maxCourierCandidates число ближайших курьеров, которым по очереди предлагается доставка
+const maxCourierCandidates = 5
+
+var (
+	ErrDeliveryNotFound          = errors.New("delivery not found")
+	ErrCourierNotFound           = errors.New("courier not found")
+	ErrCourierUnavailable        = errors.New("courier is not available")
+	ErrCourierBusy               = errors.New("courier has an active delivery")
+	ErrNoCourierAvailable        = errors.New("no courier available nearby")
+	ErrOutOfDeliveryRange        = errors.New("address is outside the delivery area")
+	ErrRestaurantLocationUnknown = errors.New("restaurant location is not set")
+	ErrDeliveryAlreadyAssigned   = errors.New("delivery already has a courier")
+	ErrInvalidDeliveryTransition = errors.New("delivery cannot move to this status")
+	ErrOrderClosed               = errors.New("order is already delivered or cancelled")
+	ErrInvalidDeliveryFees       = errors.New("delivery fee rules must have increasing distances")
+	ErrRoleConflict              = errors.New("user already has another role")
+	ErrInvalidCourierStatus      = errors.New("courier status must be available or offline")
+)
+
+// DefaultDeliveryFees тарифы доставки для ресторанов, не задавших собственные
+var DefaultDeliveryFees = models.DeliveryFeeSettings{Rules: []models.DeliveryFeeRule{
+	{UpToMeters: 3000, Fee: 99},
+	{UpToMeters: 7000, Fee: 199},
+	{UpToMeters: 15000, Fee: 349},
+}}
+
+// DeliveryService структура сервиса доставки заказов курьерами
+type DeliveryService struct {
+	db                 *mongo.Database
+	geoService         *GeoService
+	orderService       *OrderService
+	speedMetersPerMin  float64
+	assignRadiusMeters float64
+}
+
+// NewDeliveryService создает новый экземпляр DeliveryService.
+// Скорость курьера задается COURIER_SPEED_KMH, радиус автоматического
+// назначения курьера - COURIER_ASSIGN_RADIUS_M.
+func NewDeliveryService(client *mongo.Client, dbName string, geoService *GeoService, orderService *OrderService) *DeliveryService {
+	return &DeliveryService{
+		db:                 client.Database(dbName),
+		geoService:         geoService,
+		orderService:       orderService,
+		speedMetersPerMin:  float64(envInt("COURIER_SPEED_KMH", 20)) * 1000 / 60,
+		assignRadiusMeters: float64(envInt("COURIER_ASSIGN_RADIUS_M", 10000)),
+	}
+}
+
+// EnsureIndexes создает индексы доставок и курьеров
+func (s *DeliveryService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(deliveriesCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating delivery indexes failed")
+	}
+
+	_, err = s.db.Collection(couriersCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "location", Value: "2dsphere"}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "simulated", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating courier indexes failed")
+	}
+	return nil
+}
+
+// GetFees возвращает тарифы доставки ресторана или тарифы по умолчанию
+func (s *DeliveryService) GetFees(ctx context.Context, restaurantID primitive.ObjectID) (*models.DeliveryFeeSettings, error) {
+	_, fees, err := s.restaurantDelivery(ctx, restaurantID)
+	return fees, err
+}
+
+// SetFees сохраняет тарифы доставки ресторана, упорядочивая правила по расстоянию
+func (s *DeliveryService) SetFees(ctx context.Context, restaurantID primitive.ObjectID, fees *models.DeliveryFeeSettings) error {
+	if err := fees.Validate(); err != nil {
+		return err
+	}
+	sort.Slice(fees.Rules, func(i, j int) bool { return fees.Rules[i].UpToMeters < fees.Rules[j].UpToMeters })
+	for i := 1; i < len(fees.Rules); i++ {
+		if fees.Rules[i].UpToMeters == fees.Rules[i-1].UpToMeters {
+			return ErrInvalidDeliveryFees
+		}
+	}
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID,
+		bson.M{"$set": bson.M{"deliveryFees": fees}})
+	if err != nil {
+		return errors.Wrap(err, "saving delivery fees failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrRestaurantNotFound
+	}
+	return nil
+}
+
+// Quote рассчитывает стоимость и время доставки из ресторана в точку
+func (s *DeliveryService) Quote(ctx context.Context, restaurantID primitive.ObjectID, point models.GeoPoint) (*models.DeliveryQuote, error) {
+	quote, _, err := s.quote(ctx, restaurantID, point)
+	return quote, err
+}
+
+// Geocode возвращает координаты адреса доставки
+func (s *DeliveryService) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	return s.geoService.Geocode(ctx, address)
+}
+
+// RequestDelivery оформляет доставку заказа пользователя по адресу. Пока курьер
+// не назначен, повторный запрос меняет адрес. Если точка не передана, адрес геокодируется.
+func (s *DeliveryService) RequestDelivery(ctx context.Context, userID primitive.ObjectID, orderID, address string, point *models.GeoPoint) (*models.Delivery, error) {
+	order, err := s.orderService.GetOrder(ctx, EntityTypeUser, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if isClosedOrder(order.Status) {
+		return nil, ErrOrderClosed
+	}
+	restaurantID, err := primitive.ObjectIDFromHex(order.RestaurantID)
+	if err != nil {
+		return nil, ErrRestaurantNotFound
+	}
+
+	address = strings.TrimSpace(address)
+	if point == nil {
+		if address == "" {
+			return nil, ErrInvalidCoordinates
+		}
+		if point, err = s.geoService.Geocode(ctx, address); err != nil {
+			return nil, err
+		}
+	}
+	quote, pickup, err := s.quote(ctx, restaurantID, *point)
+	if err != nil {
+		return nil, err
+	}
+	if !quote.Serviceable {
+		return nil, ErrOutOfDeliveryRange
+	}
+
+	details := models.OrderDelivery{Address: address, Location: *point, DistanceM: quote.DistanceM, Fee: quote.Fee}
+	now := time.Now()
+	eta := now.Add(time.Duration(quote.EstimatedMinutes+deliveryPickupMinutes) * time.Minute)
+	var delivery models.Delivery
+	err = s.db.Collection(deliveriesCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"order_id": orderID, "status": models.DeliveryStatusAwaitingCourier},
+		bson.M{
+			"$set": bson.M{
+				"user_id":    userID,
+				"address":    details.Address,
+				"location":   details.Location,
+				"distance_m": details.DistanceM,
+				"fee":        details.Fee,
+				"eta":        eta,
+				"updated_at": now,
+			},
+			"$setOnInsert": bson.M{
+				"restaurant_id": restaurantID,
+				"status":        models.DeliveryStatusAwaitingCourier,
+				"pickup":        pickup,
+				"created_at":    now,
+			},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		// Доставка заказа уже перешла к курьеру, поэтому фильтр не совпал, а вставка нарушила уникальность
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrDeliveryAlreadyAssigned
+		}
+		return nil, errors.Wrap(err, "saving delivery failed")
+	}
+
+	if err := s.orderService.SetDelivery(ctx, restaurantID, orderID, &details); err != nil {
+		return nil, err
+	}
+	s.orderService.DeliveryChanged(ctx, &delivery)
+	return &delivery, nil
+}
+
+// Tracking возвращает состояние доставки заказа с положением курьера и свежей оценкой времени прибытия.
+// Доступно участникам заказа и ресторану.
+func (s *DeliveryService) Tracking(ctx context.Context, entityType string, entityID primitive.ObjectID, orderID string) (*models.DeliveryTracking, error) {
+	if _, err := s.orderService.GetOrder(ctx, entityType, entityID, orderID); err != nil {
+		return nil, err
+	}
+	delivery, err := s.findDelivery(ctx, bson.M{"order_id": orderID})
+	if err != nil {
+		return nil, err
+	}
+
+	tracking := &models.DeliveryTracking{Delivery: delivery}
+	var courierLocation *models.GeoPoint
+	if delivery.CourierID != nil {
+		courier, err := s.GetCourier(ctx, *delivery.CourierID)
+		if err != nil && err != ErrCourierNotFound {
+			return nil, err
+		}
+		if courier != nil {
+			courierLocation = courier.Location
+			tracking.Courier = &models.CourierPosition{
+				ID:        courier.ID,
+				Name:      courier.Name,
+				Location:  courier.Location,
+				UpdatedAt: courier.LocationUpdatedAt,
+			}
+		}
+	}
+	delivery.ETA, tracking.RemainingMeters = s.estimate(delivery, courierLocation, time.Now())
+	return tracking, nil
+}
+
+// ListRestaurantDeliveries возвращает доставки ресторана: активные или с указанным статусом
+func (s *DeliveryService) ListRestaurantDeliveries(ctx context.Context, restaurantID primitive.ObjectID, status string, page, limit int) ([]models.Delivery, error) {
+	filter := bson.M{"restaurant_id": restaurantID}
+	if status != "" {
+		filter["status"] = status
+	} else {
+		filter["status"] = bson.M{"$in": bson.A{
+			models.DeliveryStatusAwaitingCourier, models.DeliveryStatusAssigned, models.DeliveryStatusPickedUp,
+		}}
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := s.db.Collection(deliveriesCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding deliveries failed")
+	}
+	deliveries := []models.Delivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, errors.Wrap(err, "decoding deliveries failed")
+	}
+	return deliveries, nil
+}
+
+// NearbyCouriers возвращает свободных курьеров рядом с рестораном, ближайших первыми
+func (s *DeliveryService) NearbyCouriers(ctx context.Context, restaurantID primitive.ObjectID, limit int) ([]models.Courier, error) {
+	location, _, err := s.restaurantDelivery(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if location == nil {
+		return nil, ErrRestaurantLocationUnknown
+	}
+	return s.availableCouriersNear(ctx, *location, limit)
+}
+
+// Assign назначает курьера на доставку заказа ресторана. Если courierID не указан,
+// выбирается ближайший к ресторану свободный курьер.
+func (s *DeliveryService) Assign(ctx context.Context, restaurantID primitive.ObjectID, orderID string, courierID *primitive.ObjectID) (*models.Delivery, error) {
+	delivery, err := s.findDelivery(ctx, bson.M{"order_id": orderID, "restaurant_id": restaurantID})
+	if err != nil {
+		return nil, err
+	}
+	if delivery.Status != models.DeliveryStatusAwaitingCourier {
+		return nil, ErrDeliveryAlreadyAssigned
+	}
+
+	order, err := s.orderService.GetOrder(ctx, EntityTypeRestaurant, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if isClosedOrder(order.Status) {
+		s.cancelDelivery(ctx, delivery)
+		return nil, ErrOrderClosed
+	}
+
+	if courierID != nil {
+		return s.assignTo(ctx, delivery, *courierID)
+	}
+
+	candidates, err := s.availableCouriersNear(ctx, delivery.Pickup, maxCourierCandidates)
+	if err != nil {
+		return nil, err
+	}
+	for _, candidate := range candidates {
+		assigned, err := s.assignTo(ctx, delivery, candidate.ID)
+		if errors.Cause(err) == ErrCourierUnavailable {
+			// Курьера успели назначить на другую доставку
+			continue
+		}
+		return assigned, err
+	}
+	return nil, ErrNoCourierAvailable
+}
+
+// GrantCourier выдает пользователю роль курьера и создает его профиль.
+// Новая роль попадает в токен при следующем входе или обновлении токена.
+func (s *DeliveryService) GrantCourier(ctx context.Context, userID primitive.ObjectID) (*models.Courier, error) {
+	var user struct {
+		Name    string `bson:"name"`
+		Surname string `bson:"surname"`
+		Phone   string `bson:"phone"`
+		Roles   string `bson:"roles"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"name": 1, "surname": 1, "phone": 1, "roles": 1}),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "finding user failed")
+	}
+	if user.Roles != "" && user.Roles != models.RoleCourier {
+		return nil, ErrRoleConflict
+	}
+
+	// Роль проверяется и в фильтре: между чтением и записью пользователю могли выдать другую
+	result, err := s.db.Collection(EntityTypeUser).UpdateOne(ctx,
+		bson.M{"_id": userID, "roles": bson.M{"$in": bson.A{"", nil, models.RoleCourier}}},
+		bson.M{"$set": bson.M{"roles": models.RoleCourier}})
+	if err != nil {
+		return nil, errors.Wrap(err, "granting courier role failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrRoleConflict
+	}
+
+	now := time.Now()
+	var courier models.Courier
+	err = s.db.Collection(couriersCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{
+			"$set":         bson.M{"name": strings.TrimSpace(user.Name + " " + user.Surname), "phone": user.Phone, "updated_at": now},
+			"$setOnInsert": bson.M{"status": models.CourierStatusOffline, "simulated": false, "created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&courier)
+	if err != nil {
+		return nil, errors.Wrap(err, "saving courier failed")
+	}
+	return &courier, nil
+}
+
+// RevokeCourier снимает с пользователя роль курьера. Курьера с активной доставкой снять нельзя.
+func (s *DeliveryService) RevokeCourier(ctx context.Context, userID primitive.ObjectID) error {
+	result, err := s.db.Collection(couriersCollectionName).DeleteOne(ctx,
+		bson.M{"_id": userID, "status": bson.M{"$ne": models.CourierStatusBusy}})
+	if err != nil {
+		return errors.Wrap(err, "deleting courier failed")
+	}
+	if result.DeletedCount == 0 {
+		if _, err := s.GetCourier(ctx, userID); err != nil {
+			return err
+		}
+		return ErrCourierBusy
+	}
+
+	_, err = s.db.Collection(EntityTypeUser).UpdateOne(ctx,
+		bson.M{"_id": userID, "roles": models.RoleCourier},
+		bson.M{"$unset": bson.M{"roles": ""}})
+	if err != nil {
+		return errors.Wrap(err, "revoking courier role failed")
+	}
+	return nil
+}
+
+// ListCouriers возвращает курьеров, при необходимости с указанным статусом
+func (s *DeliveryService) ListCouriers(ctx context.Context, status string, page, limit int) ([]models.Courier, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := s.db.Collection(couriersCollectionName).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding couriers failed")
+	}
+	couriers := []models.Courier{}
+	if err := cursor.All(ctx, &couriers); err != nil {
+		return nil, errors.Wrap(err, "decoding couriers failed")
+	}
+	return couriers, nil
+}
+
+// GetCourier возвращает профиль курьера
+func (s *DeliveryService) GetCourier(ctx context.Context, courierID primitive.ObjectID) (*models.Courier, error) {
+	var courier models.Courier
+	err := s.db.Collection(couriersCollectionName).FindOne(ctx, bson.M{"_id": courierID}).Decode(&courier)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCourierNotFound
+		}
+		return nil, errors.Wrap(err, "finding courier failed")
+	}
+	return &courier, nil
+}
+
+// SetCourierStatus выходит на линию или уходит с нее. Занятый курьер статус не меняет.
+func (s *DeliveryService) SetCourierStatus(ctx context.Context, courierID primitive.ObjectID, status string) (*models.Courier, error) {
+	if status != models.CourierStatusAvailable && status != models.CourierStatusOffline {
+		return nil, ErrInvalidCourierStatus
+	}
+
+	var courier models.Courier
+	err := s.db.Collection(couriersCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": courierID, "status": bson.M{"$ne": models.CourierStatusBusy}},
+		bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&courier)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.GetCourier(ctx, courierID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCourierBusy
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating courier status failed")
+	}
+	return &courier, nil
+}
+
+// UpdateCourierLocation сохраняет положение курьера и пересчитывает время прибытия его доставки
+func (s *DeliveryService) UpdateCourierLocation(ctx context.Context, courierID primitive.ObjectID, point models.GeoPoint) (*models.Courier, error) {
+	if !validPoint(point) {
+		return nil, ErrInvalidCoordinates
+	}
+
+	now := time.Now()
+	var courier models.Courier
+	err := s.db.Collection(couriersCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": courierID},
+		bson.M{"$set": bson.M{"location": point, "location_updated_at": now, "updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&courier)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCourierNotFound
+		}
+		return nil, errors.Wrap(err, "updating courier location failed")
+	}
+
+	if courier.ActiveDeliveryID != nil {
+		delivery, err := s.findDelivery(ctx, bson.M{"_id": *courier.ActiveDeliveryID})
+		if err != nil {
+			return nil, err
+		}
+		eta, _ := s.estimate(delivery, courier.Location, now)
+		if eta != nil {
+			_, err := s.db.Collection(deliveriesCollectionName).UpdateByID(ctx, delivery.ID,
+				bson.M{"$set": bson.M{"eta": eta, "updated_at": now}})
+			if err != nil {
+				return nil, errors.Wrap(err, "updating delivery eta failed")
+			}
+		}
+	}
+	return &courier, nil
+}
+
+// CurrentDelivery возвращает активную доставку курьера
+func (s *DeliveryService) CurrentDelivery(ctx context.Context, courierID primitive.ObjectID) (*models.Delivery, error) {
+	courier, err := s.GetCourier(ctx, courierID)
+	if err != nil {
+		return nil, err
+	}
+	if courier.ActiveDeliveryID == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	return s.findDelivery(ctx, bson.M{"_id": *courier.ActiveDeliveryID})
+}
+
+// PickUp отмечает, что курьер забрал заказ в ресторане
+func (s *DeliveryService) PickUp(ctx context.Context, courierID, deliveryID primitive.ObjectID) (*models.Delivery, error) {
+	courier, err := s.GetCourier(ctx, courierID)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := s.findDelivery(ctx, bson.M{"_id": deliveryID, "courier_id": courierID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery.Status = models.DeliveryStatusPickedUp
+	eta, _ := s.estimate(delivery, courier.Location, now)
+	updated, err := s.transition(ctx, deliveryID, courierID, models.DeliveryStatusAssigned, bson.M{
+		"status":       models.DeliveryStatusPickedUp,
+		"picked_up_at": now,
+		"eta":          eta,
+		"updated_at":   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Заказ, который кухня не отметила, к моменту выдачи курьеру уже приготовлен
+	s.advanceOrder(ctx, updated, models.OrderStatusPreparing)
+	return updated, nil
+}
+
+// Deliver отмечает доставку завершенной, освобождает курьера и закрывает заказ
+func (s *DeliveryService) Deliver(ctx context.Context, courierID, deliveryID primitive.ObjectID) (*models.Delivery, error) {
+	now := time.Now()
+	updated, err := s.transition(ctx, deliveryID, courierID, models.DeliveryStatusPickedUp, bson.M{
+		"status":       models.DeliveryStatusDelivered,
+		"delivered_at": now,
+		"eta":          now,
+		"updated_at":   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.releaseCourier(ctx, courierID, deliveryID)
+	s.advanceOrder(ctx, updated, models.OrderStatusPreparing, models.OrderStatusDelivered)
+	return updated, nil
+}
+
+// assignTo закрепляет свободного курьера за доставкой
+func (s *DeliveryService) assignTo(ctx context.Context, delivery *models.Delivery, courierID primitive.ObjectID) (*models.Delivery, error) {
+	now := time.Now()
+	var courier models.Courier
+	err := s.db.Collection(couriersCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": courierID, "status": models.CourierStatusAvailable},
+		bson.M{"$set": bson.M{"status": models.CourierStatusBusy, "active_delivery_id": delivery.ID, "updated_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&courier)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.GetCourier(ctx, courierID); err != nil {
+			return nil, err
+		}
+		return nil, ErrCourierUnavailable
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reserving courier failed")
+	}
+
+	planned := *delivery
+	planned.Status = models.DeliveryStatusAssigned
+	eta, _ := s.estimate(&planned, courier.Location, now)
+
+	var assigned models.Delivery
+	err = s.db.Collection(deliveriesCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": delivery.ID, "status": models.DeliveryStatusAwaitingCourier},
+		bson.M{"$set": bson.M{
+			"status":      models.DeliveryStatusAssigned,
+			"courier_id":  courierID,
+			"assigned_at": now,
+			"eta":         eta,
+			"updated_at":  now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&assigned)
+	if err != nil {
+		// Курьер не должен остаться занятым доставкой, которая ему не досталась
+		s.releaseCourier(ctx, courierID, delivery.ID)
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDeliveryAlreadyAssigned
+		}
+		return nil, errors.Wrap(err, "assigning courier failed")
+	}
+
+	s.orderService.DeliveryChanged(ctx, &assigned)
+	return &assigned, nil
+}
+
+// transition переводит доставку курьера из статуса from, сохраняя поля set
+func (s *DeliveryService) transition(ctx context.Context, deliveryID, courierID primitive.ObjectID, from string, set bson.M) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.Collection(deliveriesCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": deliveryID, "courier_id": courierID, "status": from},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		if _, err := s.findDelivery(ctx, bson.M{"_id": deliveryID, "courier_id": courierID}); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidDeliveryTransition
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating delivery failed")
+	}
+
+	s.orderService.DeliveryChanged(ctx, &delivery)
+	return &delivery, nil
+}
+
+// releaseCourier возвращает курьера на линию после доставки
+func (s *DeliveryService) releaseCourier(ctx context.Context, courierID, deliveryID primitive.ObjectID) {
+	_, err := s.db.Collection(couriersCollectionName).UpdateOne(ctx,
+		bson.M{"_id": courierID, "active_delivery_id": deliveryID},
+		bson.M{
+			"$set":   bson.M{"status": models.CourierStatusAvailable, "updated_at": time.Now()},
+			"$unset": bson.M{"active_delivery_id": ""},
+		})
+	if err != nil {
+		log.Printf("Failed to release courier %s: %v", courierID.Hex(), err)
+	}
+}
+
+// cancelDelivery отменяет ожидающую курьера доставку закрытого заказа
+func (s *DeliveryService) cancelDelivery(ctx context.Context, delivery *models.Delivery) {
+	now := time.Now()
+	result, err := s.db.Collection(deliveriesCollectionName).UpdateOne(ctx,
+		bson.M{"_id": delivery.ID, "status": models.DeliveryStatusAwaitingCourier},
+		bson.M{"$set": bson.M{"status": models.DeliveryStatusCancelled, "updated_at": now}, "$unset": bson.M{"eta": ""}})
+	if err != nil {
+		log.Printf("Failed to cancel delivery %s: %v", delivery.ID.Hex(), err)
+		return
+	}
+	if result.ModifiedCount > 0 {
+		delivery.Status, delivery.UpdatedAt, delivery.ETA = models.DeliveryStatusCancelled, now, nil
+		s.orderService.DeliveryChanged(ctx, delivery)
+	}
+}
+
+// advanceOrder проводит заказ через указанные статусы, пропуская недопустимые переходы.
+// Статус доставки уже сохранен, поэтому ошибки только логируются.
+func (s *DeliveryService) advanceOrder(ctx context.Context, delivery *models.Delivery, statuses ...string) {
+	for _, status := range statuses {
+		_, err := s.orderService.UpdateStatus(ctx, delivery.RestaurantID, delivery.OrderID, status)
+		cause := errors.Cause(err)
+		if err != nil && cause != ErrInvalidOrderTransition && cause != ErrOrderConflict {
+			log.Printf("Failed to move order %s to %s: %v", delivery.OrderID, status, err)
+		}
+	}
+}
+
+// availableCouriersNear возвращает свободных курьеров в радиусе назначения, ближайших первыми
+func (s *DeliveryService) availableCouriersNear(ctx context.Context, point models.GeoPoint, limit int) ([]models.Courier, error) {
+	cursor, err := s.db.Collection(couriersCollectionName).Find(ctx, bson.M{
+		"status": models.CourierStatusAvailable,
+		"location": bson.M{"$nearSphere": bson.M{
+			"$geometry":    point,
+			"$maxDistance": s.assignRadiusMeters,
+		}},
+	}, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding nearby couriers failed")
+	}
+	couriers := []models.Courier{}
+	if err := cursor.All(ctx, &couriers); err != nil {
+		return nil, errors.Wrap(err, "decoding couriers failed")
+	}
+	return couriers, nil
+}
+
+// quote рассчитывает доставку и возвращает точку выдачи заказа
+func (s *DeliveryService) quote(ctx context.Context, restaurantID primitive.ObjectID, point models.GeoPoint) (*models.DeliveryQuote, models.GeoPoint, error) {
+	serviceability, err := s.geoService.CheckServiceable(ctx, restaurantID, point)
+	if err != nil {
+		return nil, models.GeoPoint{}, err
+	}
+	location, fees, err := s.restaurantDelivery(ctx, restaurantID)
+	if err != nil {
+		return nil, models.GeoPoint{}, err
+	}
+	if location == nil {
+		return nil, models.GeoPoint{}, ErrRestaurantLocationUnknown
+	}
+
+	distance := DistanceMeters(*location, point)
+	quote := &models.DeliveryQuote{DistanceM: math.Round(distance)}
+	fee, inRange := deliveryFee(fees, distance)
+	quote.Serviceable = inRange && (!serviceability.HasDeliveryZone || serviceability.Serviceable)
+	if quote.Serviceable {
+		quote.Fee = fee
+		quote.EstimatedMinutes = s.travelMinutes(distance)
+	}
+	return quote, *location, nil
+}
+
+// restaurantDelivery возвращает координаты и тарифы доставки ресторана
+func (s *DeliveryService) restaurantDelivery(ctx context.Context, restaurantID primitive.ObjectID) (*models.GeoPoint, *models.DeliveryFeeSettings, error) {
+	var restaurant struct {
+		Location *models.GeoPoint            `bson:"location"`
+		Fees     *models.DeliveryFeeSettings `bson:"deliveryFees"`
+	}
+	err := s.db.Collection(EntityTypeRestaurant).FindOne(ctx, bson.M{"_id": restaurantID},
+		options.FindOne().SetProjection(bson.M{"location": 1, "deliveryFees": 1}),
+	).Decode(&restaurant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, ErrRestaurantNotFound
+		}
+		return nil, nil, errors.Wrap(err, "finding restaurant failed")
+	}
+	if restaurant.Fees == nil || len(restaurant.Fees.Rules) == 0 {
+		fees := DefaultDeliveryFees
+		restaurant.Fees = &fees
+	}
+	return restaurant.Location, restaurant.Fees, nil
+}
+
+// findDelivery возвращает доставку по фильтру
+func (s *DeliveryService) findDelivery(ctx context.Context, filter bson.M) (*models.Delivery, error) {
+	var delivery models.Delivery
+	err := s.db.Collection(deliveriesCollectionName).FindOne(ctx, filter).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, errors.Wrap(err, "finding delivery failed")
+	}
+	return &delivery, nil
+}
+
+// estimate оценивает время прибытия и оставшийся путь курьера до клиента.
+// Без известного положения курьера считается, что он уже в ресторане.
+func (s *DeliveryService) estimate(delivery *models.Delivery, courierLocation *models.GeoPoint, now time.Time) (*time.Time, float64) {
+	var remaining float64
+	minutes := 0
+	switch delivery.Status {
+	case models.DeliveryStatusAwaitingCourier:
+		remaining = delivery.DistanceM
+		minutes = deliveryPickupMinutes
+	case models.DeliveryStatusAssigned:
+		remaining = delivery.DistanceM
+		if courierLocation != nil {
+			remaining += DistanceMeters(*courierLocation, delivery.Pickup)
+		}
+		minutes = deliveryPickupMinutes
+	case models.DeliveryStatusPickedUp:
+		remaining = delivery.DistanceM
+		if courierLocation != nil {
+			remaining = DistanceMeters(*courierLocation, delivery.Location)
+		}
+	default:
+		return delivery.ETA, 0
+	}
+
+	eta := now.Add(time.Duration(minutes+s.travelMinutes(remaining)) * time.Minute)
+	return &eta, math.Round(remaining)
+}
+
+// travelMinutes возвращает время в пути на расстояние с округлением вверх
+func (s *DeliveryService) travelMinutes(meters float64) int {
+	return int(math.Ceil(meters / s.speedMetersPerMin))
+}
+
+// deliveryFee возвращает стоимость доставки на расстояние и признак того, что ресторан туда доставляет
+func deliveryFee(fees *models.DeliveryFeeSettings, distance float64) (float64, bool) {
+	for _, rule := range fees.Rules {
+		if distance <= float64(rule.UpToMeters) {
+			return rule.Fee, true
+		}
+	}
+	return 0, false
+}
+
+// isClosedOrder сообщает, что заказ уже доставлен или отменен
+func isClosedOrder(status string) bool {
+	return status == models.OrderStatusDelivered || status == models.OrderStatusCancelled
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGrantCourier(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+	user := func(roles string) bson.D {
+		return bson.D{{Key: "_id", Value: userID}, {Key: "name", Value: "Ivan"}, {Key: "roles", Value: roles}}
+	}
+	matched := func(n int) bson.D {
+		return bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: n}, {Key: "nModified", Value: n}}
+	}
+	courier := bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+		{Key: "_id", Value: userID},
+		{Key: "status", Value: models.CourierStatusOffline},
+	}}}
+
+	tests := []struct {
+		name      string
+		responses []bson.D
+		wantErr   error
+	}{
+		{
+			name: "user without role",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user("")),
+				matched(1),
+				courier,
+			},
+		},
+		{
+			name: "already a courier",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user(models.RoleCourier)),
+				matched(1),
+				courier,
+			},
+		},
+		{
+			name:      "admin",
+			responses: []bson.D{mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user(models.RoleAdmin))},
+			wantErr:   ErrRoleConflict,
+		},
+		{
+			name: "role granted meanwhile",
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user("")),
+				matched(0),
+			},
+			wantErr: ErrRoleConflict,
+		},
+		{
+			name:      "no such user",
+			responses: []bson.D{mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch)},
+			wantErr:   ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &DeliveryService{db: mt.DB}
+			mt.AddMockResponses(tt.responses...)
+
+			got, err := s.GrantCourier(context.Background(), userID)
+			if errors.Cause(err) != tt.wantErr {
+				mt.Fatalf("GrantCourier() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got.ID != userID {
+				mt.Errorf("courier id = %s, want %s", got.ID.Hex(), userID.Hex())
+			}
+		})
+	}
+}
+
+func TestDeliveryFee(t *testing.T) {
+	tests := []struct {
+		distance   float64
+		wantFee    float64
+		wantServed bool
+	}{
+		{distance: 0, wantFee: 99, wantServed: true},
+		{distance: 3000, wantFee: 99, wantServed: true},
+		{distance: 3000.5, wantFee: 199, wantServed: true},
+		{distance: 15000, wantFee: 349, wantServed: true},
+		{distance: 15000.1, wantServed: false},
+	}
+
+	for _, tt := range tests {
+		fee, served := deliveryFee(&DefaultDeliveryFees, tt.distance)
+		if fee != tt.wantFee || served != tt.wantServed {
+			t.Errorf("deliveryFee(%v) = %v, %v; want %v, %v", tt.distance, fee, served, tt.wantFee, tt.wantServed)
+		}
+	}
+}
+
+func TestSetFeesRejectsDuplicateDistances(t *testing.T) {
+	s := &DeliveryService{}
+	fees := &models.DeliveryFeeSettings{Rules: []models.DeliveryFeeRule{
+		{UpToMeters: 5000, Fee: 199},
+		{UpToMeters: 2000, Fee: 99},
+		{UpToMeters: 5000, Fee: 149},
+	}}
+
+	if err := s.SetFees(context.Background(), primitive.NewObjectID(), fees); err != ErrInvalidDeliveryFees {
+		t.Fatalf("SetFees() error = %v, want %v", err, ErrInvalidDeliveryFees)
+	}
+	if fees.Rules[0].UpToMeters != 2000 {
+		t.Errorf("rules are not sorted by distance: %v", fees.Rules)
+	}
+}
+
+func TestDeliveryEstimate(t *testing.T) {
+	s := &DeliveryService{speedMetersPerMin: 100}
+	now := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	pickup := models.NewGeoPoint(55.75, 37.60)
+	destination := models.NewGeoPoint(55.76, 37.61)
+	elsewhere := models.NewGeoPoint(55.74, 37.59)
+	toPickup := DistanceMeters(elsewhere, pickup)
+	promised := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		status        string
+		courier       *models.GeoPoint
+		wantMinutes   int
+		wantRemaining float64
+	}{
+		{name: "awaiting courier", status: models.DeliveryStatusAwaitingCourier, wantMinutes: 18, wantRemaining: 1500},
+		{name: "assigned without location", status: models.DeliveryStatusAssigned, wantMinutes: 18, wantRemaining: 1500},
+		{
+			name: "assigned courier on the way to pickup", status: models.DeliveryStatusAssigned, courier: &elsewhere,
+			wantMinutes:   3 + int(math.Ceil((1500+toPickup)/100)),
+			wantRemaining: math.Round(1500 + toPickup),
+		},
+		{name: "picked up without location", status: models.DeliveryStatusPickedUp, wantMinutes: 15, wantRemaining: 1500},
+		{name: "picked up at the door", status: models.DeliveryStatusPickedUp, courier: &destination, wantMinutes: 0, wantRemaining: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := &models.Delivery{
+				Status:        tt.status,
+				Pickup:        pickup,
+				OrderDelivery: models.OrderDelivery{Location: destination, DistanceM: 1500},
+			}
+			eta, remaining := s.estimate(delivery, tt.courier, now)
+			if want := now.Add(time.Duration(tt.wantMinutes) * time.Minute); eta == nil || !eta.Equal(want) {
+				t.Errorf("estimate() eta = %v, want %v", eta, want)
+			}
+			if remaining != tt.wantRemaining {
+				t.Errorf("estimate() remaining = %v, want %v", remaining, tt.wantRemaining)
+			}
+		})
+	}
+
+	t.Run("delivered keeps stored eta", func(t *testing.T) {
+		delivery := &models.Delivery{Status: models.DeliveryStatusDelivered, ETA: &promised}
+		if eta, remaining := s.estimate(delivery, &elsewhere, now); eta != &promised || remaining != 0 {
+			t.Errorf("estimate() = %v, %v; want stored eta and no distance", eta, remaining)
+		}
+	})
+}
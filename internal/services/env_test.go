@@ -0,0 +1,7 @@
+package services
+
+import "os"
+
+// Пакет читает SECRET_KEY в init; переменные пакета инициализируются раньше init,
+// поэтому тестам не нужен файл .env
+var _ = os.Setenv("SECRET_KEY", "test-secret")
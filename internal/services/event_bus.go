@@ -0,0 +1,363 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	domainEventsCollectionName = "domain_events"
+
+	// domainEventRetention сколько хранятся опубликованные события
+	domainEventRetention = 7 * 24 * time.Hour
+	// domainEventLease на сколько событие закрепляется за реле
+	domainEventLease = time.Minute
+	// domainEventBatch сколько событий публикуется за один тик
+	domainEventBatch = 100
+	// domainEventHandlerTimeout ограничивает обработку события одним подписчиком
+	domainEventHandlerTimeout = 30 * time.Second
+)
+
+var (
+	ErrDomainEventNotFound     = errors.New("domain event not found")
+	ErrTransactionsUnavailable = errors.New("MongoDB does not support transactions: run a replica set or set MONGO_TRANSACTIONS=off")
+)
+
+// EventHandler обрабатывает доменное событие. Доставка выполняется не менее одного раза,
+// поэтому обработчик должен спокойно переносить повтор того же события.
+type EventHandler func(ctx context.Context, event *models.DomainEvent) error
+
+// eventSubscriber подписчик на один тип событий. Имя сохраняется в событии после
+// успешной обработки, поэтому при повторе события подписчик не вызывается снова.
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+// EventBus структура шины доменных событий с исходящей очередью (transactional outbox).
+// Сервисы записывают событие через Record в той же транзакции, что и изменение состояния,
+// а RunRelay публикует его в Redis Stream и вызывает подписчиков внутри процесса.
+type EventBus struct {
+	client       *mongo.Client
+	db           *mongo.Database
+	redis        *RedisService
+	stream       string
+	streamMaxLen int64
+	transactions bool
+	maxAttempts  int
+	retryBase    time.Duration
+	retryMax     time.Duration
+
+	mu          sync.RWMutex
+	subscribers map[string][]eventSubscriber
+}
+
+// NewEventBus создает новый экземпляр EventBus. События публикуются в поток
+// EVENT_STREAM (по умолчанию domain_events), длина потока ограничена EVENT_STREAM_MAXLEN.
+// Без redisService события получают только подписчики внутри процесса.
+func NewEventBus(client *mongo.Client, dbName string, redisService *RedisService) *EventBus {
+	stream := os.Getenv("EVENT_STREAM")
+	if stream == "" {
+		stream = "domain_events"
+	}
+	return &EventBus{
+		client:       client,
+		db:           client.Database(dbName),
+		redis:        redisService,
+		stream:       stream,
+		streamMaxLen: int64(envInt("EVENT_STREAM_MAXLEN", 100000)),
+		maxAttempts:  envInt("EVENT_MAX_ATTEMPTS", 10),
+		retryBase:    time.Duration(envInt("EVENT_RETRY_BASE_SECONDS", 5)) * time.Second,
+		retryMax:     30 * time.Minute,
+		subscribers:  make(map[string][]eventSubscriber),
+	}
+}
+
+// EnsureIndexes создает индексы исходящей очереди событий
+func (b *EventBus) EnsureIndexes(ctx context.Context) error {
+	_, err := b.db.Collection(domainEventsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "occurred_at", Value: -1}}},
+		{
+			// Неопубликованные события поля published_at не имеют и не удаляются
+			Keys:    bson.D{{Key: "published_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(domainEventRetention.Seconds())),
+		},
+	})
+	return errors.Wrap(err, "creating domain event indexes failed")
+}
+
+// DetectTransactions проверяет, что MongoDB поддерживает транзакции (набор реплик или mongos),
+// и возвращает ErrTransactionsUnavailable, если нет. Без транзакций изменения и события пишутся
+// отдельно, и событие может потеряться, если процесс упадет между записями, поэтому работа
+// без них разрешается только явно через MONGO_TRANSACTIONS=off (например, для локального одиночного сервера).
+func (b *EventBus) DetectTransactions(ctx context.Context) error {
+	if os.Getenv("MONGO_TRANSACTIONS") == "off" {
+		b.transactions = false
+		log.Println("MONGO_TRANSACTIONS=off: domain events are written without transactions")
+		return nil
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := b.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return errors.Wrap(err, "checking MongoDB topology failed")
+	}
+	b.transactions = hello.SetName != "" || hello.Msg == "isdbgrid"
+	if !b.transactions {
+		return ErrTransactionsUnavailable
+	}
+	return nil
+}
+
+// WithTransaction выполняет fn в транзакции MongoDB. Все операции внутри fn должны
+// использовать переданный контекст; при временных ошибках fn может быть вызвана повторно.
+func (b *EventBus) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !b.transactions {
+		return fn(ctx)
+	}
+	session, err := b.client.StartSession()
+	if err != nil {
+		return errors.Wrap(err, "starting session failed")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+// Record записывает событие в исходящую очередь. Внутри WithTransaction событие
+// сохраняется только вместе с остальными изменениями транзакции.
+func (b *EventBus) Record(ctx context.Context, eventType, aggregateID string, payload interface{}) error {
+	doc, err := toDocument(payload)
+	if err != nil {
+		return errors.Wrap(err, "encoding event payload failed")
+	}
+
+	now := time.Now()
+	event := models.DomainEvent{
+		ID:            primitive.NewObjectID(),
+		Type:          eventType,
+		AggregateID:   aggregateID,
+		Payload:       doc,
+		OccurredAt:    now,
+		Status:        models.DomainEventPending,
+		Handled:       []string{},
+		NextAttemptAt: now,
+	}
+	if _, err := b.db.Collection(domainEventsCollectionName).InsertOne(ctx, event); err != nil {
+		return errors.Wrap(err, "recording domain event failed")
+	}
+	return nil
+}
+
+// Subscribe регистрирует обработчик событий типа eventType. Имя подписчика должно быть
+// постоянным: по нему реле помнит, кто уже обработал событие.
+func (b *EventBus) Subscribe(eventType, name string, handler EventHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[eventType] = append(b.subscribers[eventType], eventSubscriber{name: name, handler: handler})
+}
+
+// ListEvents возвращает страницу событий очереди, новые первыми
+func (b *EventBus) ListEvents(ctx context.Context, status, eventType string, page, limit int) ([]models.DomainEvent, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if eventType != "" {
+		filter["type"] = eventType
+	}
+
+	cursor, err := b.db.Collection(domainEventsCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "occurred_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding domain events failed")
+	}
+	events := []models.DomainEvent{}
+	if err := cursor.All(ctx, &events); err != nil {
+		return nil, errors.Wrap(err, "decoding domain events failed")
+	}
+	return events, nil
+}
+
+// RetryEvent возвращает проваленное событие в очередь. Подписчики, уже обработавшие
+// событие, повторно его не получат.
+func (b *EventBus) RetryEvent(ctx context.Context, eventID primitive.ObjectID) error {
+	result, err := b.db.Collection(domainEventsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": eventID, "status": models.DomainEventFailed},
+		bson.M{"$set": bson.M{"status": models.DomainEventPending, "attempts": 0, "next_attempt_at": time.Now()}},
+	)
+	if err != nil {
+		return errors.Wrap(err, "retrying domain event failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrDomainEventNotFound
+	}
+	return nil
+}
+
+// RunRelay периодически публикует события из очереди, пока не отменен контекст
+func (b *EventBus) RunRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for i := 0; i < domainEventBatch; i++ {
+				if !b.relayNext(ctx) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// relayNext забирает и публикует одно событие, самое раннее из готовых; false, если публиковать нечего
+func (b *EventBus) relayNext(ctx context.Context) bool {
+	now := time.Now()
+	var event models.DomainEvent
+	err := b.db.Collection(domainEventsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"status": models.DomainEventPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"status": models.DomainEventPublishing, "locked_until": bson.M{"$lte": now}},
+		}},
+		bson.M{
+			"$set": bson.M{"status": models.DomainEventPublishing, "locked_until": now.Add(domainEventLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "occurred_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&event)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to claim domain event: %v", err)
+		}
+		return false
+	}
+
+	if err := b.complete(ctx, &event, b.deliver(ctx, &event)); err != nil {
+		log.Printf("Failed to update domain event %s: %v", event.ID.Hex(), err)
+	}
+	return true
+}
+
+// deliver публикует событие в Redis Stream и передает его подписчикам, которые его еще не обработали.
+// Каждый успешный шаг сразу сохраняется, чтобы повтор не задевал уже выполненное.
+func (b *EventBus) deliver(ctx context.Context, event *models.DomainEvent) error {
+	var failures []string
+	collection := b.db.Collection(domainEventsCollectionName)
+
+	if event.StreamID == "" && b.redis != nil {
+		streamID, err := b.appendToStream(ctx, event)
+		if err != nil {
+			failures = append(failures, err.Error())
+		} else if _, err := collection.UpdateByID(ctx, event.ID, bson.M{"$set": bson.M{"stream_id": streamID}}); err != nil {
+			log.Printf("Failed to save stream ID of domain event %s: %v", event.ID.Hex(), err)
+		}
+	}
+
+	handled := make(map[string]bool, len(event.Handled))
+	for _, name := range event.Handled {
+		handled[name] = true
+	}
+	b.mu.RLock()
+	subscribers := b.subscribers[event.Type]
+	b.mu.RUnlock()
+
+	for _, subscriber := range subscribers {
+		if handled[subscriber.name] {
+			continue
+		}
+		handleCtx, cancel := context.WithTimeout(ctx, domainEventHandlerTimeout)
+		err := subscriber.handler(handleCtx, event)
+		cancel()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", subscriber.name, err))
+			continue
+		}
+		if _, err := collection.UpdateByID(ctx, event.ID, bson.M{"$addToSet": bson.M{"handled": subscriber.name}}); err != nil {
+			log.Printf("Failed to mark domain event %s handled by %s: %v", event.ID.Hex(), subscriber.name, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// appendToStream добавляет событие в Redis Stream и возвращает ID записи
+func (b *EventBus) appendToStream(ctx context.Context, event *models.DomainEvent) (string, error) {
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding event payload failed")
+	}
+	streamID, err := b.redis.Client.XAdd(ctx, &redis.XAddArgs{
+		Stream: b.stream,
+		MaxLen: b.streamMaxLen,
+		Approx: true,
+		Values: map[string]interface{}{
+			"event_id":     event.ID.Hex(),
+			"type":         event.Type,
+			"aggregate_id": event.AggregateID,
+			"occurred_at":  event.OccurredAt.Format(time.RFC3339Nano),
+			"payload":      string(payload),
+		},
+	}).Result()
+	if err != nil {
+		return "", errors.Wrap(err, "adding event to stream failed")
+	}
+	return streamID, nil
+}
+
+// complete сохраняет результат публикации события
+func (b *EventBus) complete(ctx context.Context, event *models.DomainEvent, deliverErr error) error {
+	now := time.Now()
+	set := bson.M{}
+	unset := bson.M{"locked_until": ""}
+
+	switch {
+	case deliverErr == nil:
+		set["status"] = models.DomainEventPublished
+		set["published_at"] = now
+		unset["last_error"] = ""
+	case event.Attempts >= b.maxAttempts:
+		set["status"] = models.DomainEventFailed
+		set["last_error"] = deliverErr.Error()
+		log.Printf("Domain event %s (%s) failed after %d attempts: %v", event.ID.Hex(), event.Type, event.Attempts, deliverErr)
+	default:
+		set["status"] = models.DomainEventPending
+		set["next_attempt_at"] = now.Add(retryDelay(b.retryBase, b.retryMax, event.Attempts))
+		set["last_error"] = deliverErr.Error()
+	}
+
+	_, err := b.db.Collection(domainEventsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": event.ID, "status": models.DomainEventPublishing},
+		bson.M{"$set": set, "$unset": unset},
+	)
+	return errors.Wrap(err, "saving publish result failed")
+}
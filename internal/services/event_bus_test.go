@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestDetectTransactions(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name             string
+		setting          string
+		hello            bson.D
+		wantErr          error
+		wantTransactions bool
+	}{
+		{
+			name:             "replica set",
+			hello:            bson.D{{Key: "ok", Value: 1}, {Key: "setName", Value: "rs0"}},
+			wantTransactions: true,
+		},
+		{
+			name:             "sharded cluster",
+			hello:            bson.D{{Key: "ok", Value: 1}, {Key: "msg", Value: "isdbgrid"}},
+			wantTransactions: true,
+		},
+		{
+			name:    "standalone fails",
+			hello:   bson.D{{Key: "ok", Value: 1}, {Key: "isWritablePrimary", Value: true}},
+			wantErr: ErrTransactionsUnavailable,
+		},
+		{
+			name:    "standalone explicitly allowed",
+			setting: "off",
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			mt.Setenv("MONGO_TRANSACTIONS", tt.setting)
+			if tt.hello != nil {
+				mt.AddMockResponses(tt.hello)
+			}
+			bus := &EventBus{client: mt.Client, transactions: true}
+
+			if err := bus.DetectTransactions(context.Background()); err != tt.wantErr {
+				mt.Fatalf("DetectTransactions() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && bus.transactions != tt.wantTransactions {
+				mt.Errorf("transactions = %v, want %v", bus.transactions, tt.wantTransactions)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/base64"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const favoriteListsCollectionName = "favorite_lists"
+
+// Ограничения размеров списков избранного
+const (
+	maxFavoriteListsPerUser = 50
+	maxFavoriteListEntries  = 200
+)
+
+var (
+	ErrFavoriteListNotFound  = errors.New("favorite list not found")
+	ErrFavoriteListForbidden = errors.New("only the owner can change this list")
+	ErrFavoriteListLimit     = errors.New("too many favorite lists")
+	ErrFavoriteListFull      = errors.New("favorite list is full")
+	ErrEntryExists           = errors.New("restaurant is already in the list")
+	ErrEntryNotFound         = errors.New("restaurant is not in the list")
+	ErrInvalidOrder          = errors.New("order may only list restaurants of the list, each once")
+	ErrFavoriteListConflict  = errors.New("favorite list was changed concurrently, retry")
+)
+
+// FavoriteListUpdate описывает изменяемые поля списка; nil означает "не менять"
+type FavoriteListUpdate struct {
+	Name        *string
+	Description *string
+	Visibility  *string
+}
+
+// FavoriteListService структура сервиса именованных списков избранного
+type FavoriteListService struct {
+	db            *mongo.Database
+	friendService *FriendService
+}
+
+// NewFavoriteListService создает новый экземпляр FavoriteListService
+func NewFavoriteListService(client *mongo.Client, dbName string, friendService *FriendService) *FavoriteListService {
+	return &FavoriteListService{
+		db:            client.Database(dbName),
+		friendService: friendService,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции списков избранного
+func (s *FavoriteListService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(favoriteListsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "owner_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		{
+			Keys: bson.D{{Key: "share_token", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"share_token": bson.M{"$exists": true},
+			}),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating favorite list indexes failed")
+	}
+	return nil
+}
+
+// CreateList создает пустой список избранного пользователя
+func (s *FavoriteListService) CreateList(ctx context.Context, list *models.FavoriteList) error {
+	if list.Visibility == "" {
+		list.Visibility = models.ListVisibilityPrivate
+	}
+	list.Entries = []models.FavoriteListEntry{}
+	if err := list.Validate(); err != nil {
+		return err
+	}
+
+	count, err := s.db.Collection(favoriteListsCollectionName).CountDocuments(ctx, bson.M{"owner_id": list.OwnerID})
+	if err != nil {
+		return errors.Wrap(err, "counting favorite lists failed")
+	}
+	if count >= maxFavoriteListsPerUser {
+		return ErrFavoriteListLimit
+	}
+
+	now := time.Now()
+	list.ID = primitive.NewObjectID()
+	list.ShareToken = ""
+	list.CopiedFrom = nil
+	list.CreatedAt = now
+	list.UpdatedAt = now
+	if _, err := s.db.Collection(favoriteListsCollectionName).InsertOne(ctx, list); err != nil {
+		return errors.Wrap(err, "inserting favorite list failed")
+	}
+	return nil
+}
+
+// ListOwnLists возвращает все списки пользователя
+func (s *FavoriteListService) ListOwnLists(ctx context.Context, ownerID primitive.ObjectID) ([]models.FavoriteList, error) {
+	return s.findLists(ctx, bson.M{"owner_id": ownerID}, ownerID)
+}
+
+// ListUserLists возвращает списки другого пользователя, доступные просматривающему
+func (s *FavoriteListService) ListUserLists(ctx context.Context, viewerID, ownerID primitive.ObjectID) ([]models.FavoriteList, error) {
+	if viewerID == ownerID {
+		return s.ListOwnLists(ctx, ownerID)
+	}
+
+	visible := []string{models.ListVisibilityPublic}
+	friends, err := s.friendService.AreFriends(ctx, viewerID, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	if friends {
+		visible = append(visible, models.ListVisibilityFriends)
+	}
+	return s.findLists(ctx, bson.M{"owner_id": ownerID, "visibility": bson.M{"$in": visible}}, viewerID)
+}
+
+// GetList возвращает список, если просматривающему разрешен доступ по видимости
+func (s *FavoriteListService) GetList(ctx context.Context, viewerID, listID primitive.ObjectID) (*models.FavoriteList, error) {
+	list, err := s.findList(ctx, bson.M{"_id": listID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, viewerID, list, false); err != nil {
+		return nil, err
+	}
+	return s.present(ctx, viewerID, list)
+}
+
+// GetSharedList возвращает список по ссылке. Ссылка открывает список друзьям владельца
+// независимо от его видимости; публичный список по ссылке доступен всем.
+func (s *FavoriteListService) GetSharedList(ctx context.Context, viewerID primitive.ObjectID, token string) (*models.FavoriteList, error) {
+	list, err := s.findList(ctx, bson.M{"share_token": token})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, viewerID, list, true); err != nil {
+		return nil, err
+	}
+	return s.present(ctx, viewerID, list)
+}
+
+// UpdateList изменяет название, описание или видимость списка
+func (s *FavoriteListService) UpdateList(ctx context.Context, ownerID, listID primitive.ObjectID, update FavoriteListUpdate) (*models.FavoriteList, error) {
+	list, err := s.ownedList(ctx, ownerID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if update.Name != nil {
+		list.Name = *update.Name
+	}
+	if update.Description != nil {
+		list.Description = *update.Description
+	}
+	if update.Visibility != nil {
+		list.Visibility = *update.Visibility
+	}
+	if err := list.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.modify(ctx, ownerID, listID, bson.M{}, bson.M{"$set": bson.M{
+		"name":        list.Name,
+		"description": list.Description,
+		"visibility":  list.Visibility,
+	}}, nil)
+}
+
+// DeleteList удаляет список владельца
+func (s *FavoriteListService) DeleteList(ctx context.Context, ownerID, listID primitive.ObjectID) error {
+	result, err := s.db.Collection(favoriteListsCollectionName).DeleteOne(ctx, bson.M{"_id": listID, "owner_id": ownerID})
+	if err != nil {
+		return errors.Wrap(err, "deleting favorite list failed")
+	}
+	if result.DeletedCount == 0 {
+		_, err := s.ownedList(ctx, ownerID, listID)
+		if err == nil {
+			err = ErrFavoriteListNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// AddEntry добавляет ресторан в конец списка
+func (s *FavoriteListService) AddEntry(ctx context.Context, ownerID, listID, restaurantID primitive.ObjectID, note string) (*models.FavoriteList, error) {
+	entry := models.FavoriteListEntry{RestaurantID: restaurantID, Note: note, AddedAt: time.Now()}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ensureRestaurantExists(ctx, s.db, restaurantID); err != nil {
+		return nil, err
+	}
+
+	return s.modify(ctx, ownerID, listID, bson.M{
+		"entries.restaurant_id":                             bson.M{"$ne": restaurantID},
+		"entries." + strconv.Itoa(maxFavoriteListEntries-1): bson.M{"$exists": false},
+	}, bson.M{"$push": bson.M{"entries": entry}}, func(list *models.FavoriteList) error {
+		if list.HasEntry(restaurantID) {
+			return ErrEntryExists
+		}
+		return ErrFavoriteListFull
+	})
+}
+
+// UpdateEntryNote меняет заметку к ресторану в списке
+func (s *FavoriteListService) UpdateEntryNote(ctx context.Context, ownerID, listID, restaurantID primitive.ObjectID, note string) (*models.FavoriteList, error) {
+	entry := models.FavoriteListEntry{RestaurantID: restaurantID, Note: note}
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s.modify(ctx, ownerID, listID, bson.M{"entries.restaurant_id": restaurantID},
+		bson.M{"$set": bson.M{"entries.$.note": note}}, entryMissing)
+}
+
+// RemoveEntry удаляет ресторан из списка
+func (s *FavoriteListService) RemoveEntry(ctx context.Context, ownerID, listID, restaurantID primitive.ObjectID) (*models.FavoriteList, error) {
+	return s.modify(ctx, ownerID, listID, bson.M{"entries.restaurant_id": restaurantID},
+		bson.M{"$pull": bson.M{"entries": bson.M{"restaurant_id": restaurantID}}}, entryMissing)
+}
+
+// Reorder задает новый порядок ресторанов. Не упомянутые в order рестораны
+// сохраняют взаимный порядок и переносятся в конец списка.
+func (s *FavoriteListService) Reorder(ctx context.Context, ownerID, listID primitive.ObjectID, order []primitive.ObjectID) (*models.FavoriteList, error) {
+	list, err := s.ownedList(ctx, ownerID, listID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.FavoriteListEntry, len(list.Entries))
+	for _, entry := range list.Entries {
+		byID[entry.RestaurantID] = entry
+	}
+	if len(uniqueObjectIDs(order)) != len(order) {
+		return nil, ErrInvalidOrder
+	}
+	entries := make([]models.FavoriteListEntry, 0, len(list.Entries))
+	placed := make(map[primitive.ObjectID]bool, len(order))
+	for _, restaurantID := range order {
+		entry, ok := byID[restaurantID]
+		if !ok {
+			return nil, ErrInvalidOrder
+		}
+		entries = append(entries, entry)
+		placed[restaurantID] = true
+	}
+	for _, entry := range list.Entries {
+		if !placed[entry.RestaurantID] {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Оптимистичная блокировка по updated_at: порядок строится по прочитанному составу списка
+	return s.modify(ctx, ownerID, listID, bson.M{"updated_at": list.UpdatedAt},
+		bson.M{"$set": bson.M{"entries": entries}}, func(*models.FavoriteList) error {
+			return ErrFavoriteListConflict
+		})
+}
+
+// CreateShareLink создает новый токен ссылки на список; прежняя ссылка перестает работать
+func (s *FavoriteListService) CreateShareLink(ctx context.Context, ownerID, listID primitive.ObjectID) (*models.FavoriteList, error) {
+	token, err := GenerateRandomSecret(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating share token failed")
+	}
+	return s.modify(ctx, ownerID, listID, bson.M{},
+		bson.M{"$set": bson.M{"share_token": base64.RawURLEncoding.EncodeToString(token)}}, nil)
+}
+
+// RevokeShareLink отключает ссылку на список
+func (s *FavoriteListService) RevokeShareLink(ctx context.Context, ownerID, listID primitive.ObjectID) (*models.FavoriteList, error) {
+	return s.modify(ctx, ownerID, listID, bson.M{}, bson.M{"$unset": bson.M{"share_token": ""}}, nil)
+}
+
+// CopyList копирует доступный пользователю список в его собственный приватный список
+func (s *FavoriteListService) CopyList(ctx context.Context, userID, listID primitive.ObjectID) (*models.FavoriteList, error) {
+	source, err := s.findList(ctx, bson.M{"_id": listID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, userID, source, false); err != nil {
+		return nil, err
+	}
+	return s.copyList(ctx, userID, source)
+}
+
+// CopySharedList копирует список, открытый по ссылке
+func (s *FavoriteListService) CopySharedList(ctx context.Context, userID primitive.ObjectID, token string) (*models.FavoriteList, error) {
+	source, err := s.findList(ctx, bson.M{"share_token": token})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkAccess(ctx, userID, source, true); err != nil {
+		return nil, err
+	}
+	return s.copyList(ctx, userID, source)
+}
+
+// copyList создает у пользователя приватную копию списка с заметками
+func (s *FavoriteListService) copyList(ctx context.Context, userID primitive.ObjectID, source *models.FavoriteList) (*models.FavoriteList, error) {
+	copied := &models.FavoriteList{
+		OwnerID:     userID,
+		Name:        source.Name,
+		Description: source.Description,
+		Visibility:  models.ListVisibilityPrivate,
+	}
+	if err := s.CreateList(ctx, copied); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	entries := make([]models.FavoriteListEntry, 0, len(source.Entries))
+	for _, entry := range source.Entries {
+		entries = append(entries, models.FavoriteListEntry{RestaurantID: entry.RestaurantID, Note: entry.Note, AddedAt: now})
+	}
+	return s.modify(ctx, userID, copied.ID, bson.M{}, bson.M{"$set": bson.M{
+		"entries":     entries,
+		"copied_from": source.ID,
+	}}, nil)
+}
+
+// modify атомарно обновляет список владельца при выполнении дополнительного условия.
+// Если условие не выполнено, ошибку определяет onMismatch по текущему состоянию списка.
+func (s *FavoriteListService) modify(ctx context.Context, ownerID, listID primitive.ObjectID, condition, update bson.M, onMismatch func(*models.FavoriteList) error) (*models.FavoriteList, error) {
+	filter := bson.M{"_id": listID, "owner_id": ownerID}
+	for key, value := range condition {
+		filter[key] = value
+	}
+	if set, ok := update["$set"].(bson.M); ok {
+		set["updated_at"] = time.Now()
+	} else {
+		update["$set"] = bson.M{"updated_at": time.Now()}
+	}
+
+	var list models.FavoriteList
+	err := s.db.Collection(favoriteListsCollectionName).FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		current, err := s.ownedList(ctx, ownerID, listID)
+		if err != nil {
+			return nil, err
+		}
+		if onMismatch == nil {
+			return nil, ErrFavoriteListConflict
+		}
+		return nil, onMismatch(current)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating favorite list failed")
+	}
+	return s.present(ctx, ownerID, &list)
+}
+
+// ownedList возвращает список, проверяя, что пользователь его владелец
+func (s *FavoriteListService) ownedList(ctx context.Context, ownerID, listID primitive.ObjectID) (*models.FavoriteList, error) {
+	list, err := s.findList(ctx, bson.M{"_id": listID})
+	if err != nil {
+		return nil, err
+	}
+	if list.OwnerID != ownerID {
+		return nil, ErrFavoriteListForbidden
+	}
+	return list, nil
+}
+
+// checkAccess проверяет право просмотра списка. Чужой закрытый список выглядит как отсутствующий.
+func (s *FavoriteListService) checkAccess(ctx context.Context, viewerID primitive.ObjectID, list *models.FavoriteList, viaLink bool) error {
+	if list.OwnerID == viewerID || list.Visibility == models.ListVisibilityPublic {
+		return nil
+	}
+	if list.Visibility == models.ListVisibilityFriends || viaLink {
+		friends, err := s.friendService.AreFriends(ctx, viewerID, list.OwnerID)
+		if err != nil {
+			return err
+		}
+		if friends {
+			return nil
+		}
+	}
+	return ErrFavoriteListNotFound
+}
+
+// findList загружает один список по фильтру
+func (s *FavoriteListService) findList(ctx context.Context, filter bson.M) (*models.FavoriteList, error) {
+	var list models.FavoriteList
+	err := s.db.Collection(favoriteListsCollectionName).FindOne(ctx, filter).Decode(&list)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrFavoriteListNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding favorite list failed")
+	}
+	return &list, nil
+}
+
+// findLists загружает списки по фильтру в порядке создания
+func (s *FavoriteListService) findLists(ctx context.Context, filter bson.M, viewerID primitive.ObjectID) ([]models.FavoriteList, error) {
+	cursor, err := s.db.Collection(favoriteListsCollectionName).Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding favorite lists failed")
+	}
+	lists := []models.FavoriteList{}
+	if err := cursor.All(ctx, &lists); err != nil {
+		return nil, errors.Wrap(err, "decoding favorite lists failed")
+	}
+	for i := range lists {
+		if _, err := s.present(ctx, viewerID, &lists[i]); err != nil {
+			return nil, err
+		}
+	}
+	return lists, nil
+}
+
+// present подгружает данные ресторанов и скрывает токен ссылки от всех, кроме владельца.
+// Заблокированные рестораны в выдачу не попадают.
+func (s *FavoriteListService) present(ctx context.Context, viewerID primitive.ObjectID, list *models.FavoriteList) (*models.FavoriteList, error) {
+	if list.OwnerID != viewerID {
+		list.ShareToken = ""
+	}
+	if list.Entries == nil {
+		list.Entries = []models.FavoriteListEntry{}
+	}
+	if len(list.Entries) == 0 {
+		return list, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		ids = append(ids, entry.RestaurantID)
+	}
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "banned": bson.M{"$ne": true}},
+		options.Find().SetProjection(restaurantSummaryProjection),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurants failed")
+	}
+	var restaurants []models.RestaurantSummary
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurants failed")
+	}
+	byID := make(map[primitive.ObjectID]models.RestaurantSummary, len(restaurants))
+	for _, restaurant := range restaurants {
+		byID[restaurant.ID] = restaurant
+	}
+
+	entries := make([]models.FavoriteListEntry, 0, len(list.Entries))
+	for _, entry := range list.Entries {
+		restaurant, ok := byID[entry.RestaurantID]
+		if !ok {
+			continue
+		}
+		entry.Restaurant = &restaurant
+		entries = append(entries, entry)
+	}
+	list.Entries = entries
+	return list, nil
+}
+
+// entryMissing сообщает об отсутствии ресторана в списке
+func entryMissing(*models.FavoriteList) error {
+	return ErrEntryNotFound
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestFavoriteListAccess(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	owner, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+	low, high := orderPair(owner, viewer)
+	friends := mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch, bson.D{
+		{Key: "user_low", Value: low}, {Key: "user_high", Value: high}, {Key: "status", Value: models.FriendshipStatusAccepted},
+	})
+	strangers := mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch)
+
+	tests := []struct {
+		name       string
+		viewer     primitive.ObjectID
+		visibility string
+		viaLink    bool
+		friendship bson.D // nil - проверка дружбы не ожидается
+		wantErr    error
+	}{
+		{name: "owner sees private", viewer: owner, visibility: models.ListVisibilityPrivate},
+		{name: "anyone sees public", viewer: viewer, visibility: models.ListVisibilityPublic},
+		{name: "friend sees friends list", viewer: viewer, visibility: models.ListVisibilityFriends, friendship: friends},
+		{name: "stranger misses friends list", viewer: viewer, visibility: models.ListVisibilityFriends, friendship: strangers, wantErr: ErrFavoriteListNotFound},
+		{name: "private without link", viewer: viewer, visibility: models.ListVisibilityPrivate, wantErr: ErrFavoriteListNotFound},
+		{name: "friend opens private link", viewer: viewer, visibility: models.ListVisibilityPrivate, viaLink: true, friendship: friends},
+		{name: "stranger opens private link", viewer: viewer, visibility: models.ListVisibilityPrivate, viaLink: true, friendship: strangers, wantErr: ErrFavoriteListNotFound},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &FavoriteListService{db: mt.DB, friendService: &FriendService{db: mt.DB}}
+			if tt.friendship != nil {
+				mt.AddMockResponses(tt.friendship)
+			}
+
+			list := &models.FavoriteList{OwnerID: owner, Visibility: tt.visibility}
+			if err := s.checkAccess(context.Background(), tt.viewer, list, tt.viaLink); err != tt.wantErr {
+				mt.Fatalf("checkAccess() error = %v, want %v", err, tt.wantErr)
+			}
+			if events := len(mt.GetAllStartedEvents()); (tt.friendship != nil) != (events == 1) {
+				mt.Errorf("commands = %d, friendship check expected: %v", events, tt.friendship != nil)
+			}
+		})
+	}
+}
+
+func TestFavoriteListPresentHidesTokenAndBannedRestaurants(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	owner, viewer := primitive.NewObjectID(), primitive.NewObjectID()
+	open, banned := primitive.NewObjectID(), primitive.NewObjectID()
+
+	mt.Run("other viewer", func(mt *mtest.T) {
+		s := &FavoriteListService{db: mt.DB}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.restaurant", mtest.FirstBatch,
+			bson.D{{Key: "_id", Value: open}, {Key: "name", Value: "Пельменная"}}))
+
+		list := &models.FavoriteList{
+			OwnerID:    owner,
+			ShareToken: "secret",
+			Entries:    []models.FavoriteListEntry{{RestaurantID: banned}, {RestaurantID: open, Note: "по пятницам"}},
+		}
+		got, err := s.present(context.Background(), viewer, list)
+		if err != nil {
+			mt.Fatalf("present() error = %v", err)
+		}
+		if got.ShareToken != "" {
+			mt.Errorf("ShareToken = %q, want hidden from other viewers", got.ShareToken)
+		}
+		if len(got.Entries) != 1 || got.Entries[0].Restaurant == nil || got.Entries[0].Restaurant.Name != "Пельменная" {
+			mt.Errorf("Entries = %+v, want only the open restaurant", got.Entries)
+		}
+	})
+
+	mt.Run("owner keeps token", func(mt *mtest.T) {
+		s := &FavoriteListService{db: mt.DB}
+		got, err := s.present(context.Background(), owner, &models.FavoriteList{OwnerID: owner, ShareToken: "secret"})
+		if err != nil {
+			mt.Fatalf("present() error = %v", err)
+		}
+		if got.ShareToken != "secret" || got.Entries == nil {
+			mt.Errorf("present() = %+v, want token and empty entries", got)
+		}
+	})
+}
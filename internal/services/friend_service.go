@@ -0,0 +1,598 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	friendshipsCollectionName = "friendships"
+	userBlocksCollectionName  = "user_blocks"
+)
+
+// Статусы дружбы с точки зрения просматривающего профиль
+const (
+	FriendshipViewNone            = "none"
+	FriendshipViewPendingOutgoing = "pending_outgoing"
+	FriendshipViewPendingIncoming = "pending_incoming"
+	FriendshipViewFriends         = "friends"
+)
+
+var (
+	ErrUserNotFound          = errors.New("user not found")
+	ErrSelfFriendship        = errors.New("cannot befriend yourself")
+	ErrAlreadyFriends        = errors.New("users are already friends")
+	ErrFriendRequestExists   = errors.New("friend request already sent")
+	ErrFriendRequestNotFound = errors.New("friend request not found")
+	ErrFriendshipNotFound    = errors.New("friendship not found")
+	ErrUserBlocked           = errors.New("user is blocked")
+	ErrBlockNotFound         = errors.New("block not found")
+)
+
+// Проекции публичных данных пользователя; пароль и токены никогда не выбираются
+var (
+	userSummaryProjection = bson.M{"name": 1, "surname": 1, "avatar": 1, "avatarImage": 1}
+	userProfileProjection = bson.M{"name": 1, "surname": 1, "avatar": 1, "avatarImage": 1, "description": 1, "interests": 1}
+)
+
+// FriendService структура сервиса дружбы и блокировок пользователей
+type FriendService struct {
+	db                  *mongo.Database
+	notificationService *NotificationService
+}
+
+// NewFriendService создает новый экземпляр FriendService
+func NewFriendService(client *mongo.Client, dbName string, notificationService *NotificationService) *FriendService {
+	return &FriendService{
+		db:                  client.Database(dbName),
+		notificationService: notificationService,
+	}
+}
+
+// EnsureIndexes создает индексы коллекций дружбы и блокировок
+func (s *FriendService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(friendshipsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_low", Value: 1}, {Key: "user_high", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "user_low", Value: 1}, {Key: "status", Value: 1}, {Key: "accepted_at", Value: -1}}},
+		{Keys: bson.D{{Key: "user_high", Value: 1}, {Key: "status", Value: 1}, {Key: "accepted_at", Value: -1}}},
+		{Keys: bson.D{{Key: "addressee_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "requester_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating friendship indexes failed")
+	}
+
+	_, err = s.db.Collection(userBlocksCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "blocker_id", Value: 1}, {Key: "blocked_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "blocked_id", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating block indexes failed")
+	}
+	return nil
+}
+
+// SendRequest отправляет заявку в друзья. Если встречная заявка уже есть, дружба принимается сразу.
+func (s *FriendService) SendRequest(ctx context.Context, fromID, toID primitive.ObjectID) (*models.Friendship, error) {
+	if fromID == toID {
+		return nil, ErrSelfFriendship
+	}
+	if err := s.ensureUserExists(ctx, toID); err != nil {
+		return nil, err
+	}
+	blocked, err := s.IsBlocked(ctx, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserBlocked
+	}
+
+	existing, err := s.findPair(ctx, fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		switch {
+		case existing.Status == models.FriendshipStatusAccepted:
+			return nil, ErrAlreadyFriends
+		case existing.RequesterID == fromID:
+			return nil, ErrFriendRequestExists
+		default:
+			return s.AcceptRequest(ctx, fromID, toID)
+		}
+	}
+
+	low, high := orderPair(fromID, toID)
+	friendship := &models.Friendship{
+		ID:          primitive.NewObjectID(),
+		UserLow:     low,
+		UserHigh:    high,
+		RequesterID: fromID,
+		AddresseeID: toID,
+		Status:      models.FriendshipStatusPending,
+		CreatedAt:   time.Now(),
+	}
+	if _, err := s.db.Collection(friendshipsCollectionName).InsertOne(ctx, friendship); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrFriendRequestExists
+		}
+		return nil, errors.Wrap(err, "inserting friend request failed")
+	}
+	s.notifyRequest(ctx, fromID, toID)
+	return friendship, nil
+}
+
+// notifyRequest уведомляет адресата о заявке; ошибка уведомления не отменяет заявку
+func (s *FriendService) notifyRequest(ctx context.Context, fromID, toID primitive.ObjectID) {
+	senders, err := s.userSummaries(ctx, []primitive.ObjectID{fromID})
+	if err != nil || len(senders) == 0 {
+		log.Printf("Failed to load friend request sender %s: %v", fromID.Hex(), err)
+		return
+	}
+	sender := senders[0]
+	name := strings.TrimSpace(sender.Name + " " + sender.Surname)
+	err = s.notificationService.Notify(ctx, []primitive.ObjectID{toID}, NotificationFriendRequest, name+" хочет добавить вас в друзья",
+		map[string]string{"from_user_id": fromID.Hex(), "from_name": name})
+	if err != nil {
+		log.Printf("Failed to send %s notification: %v", NotificationFriendRequest, err)
+	}
+}
+
+// AcceptRequest принимает входящую заявку в друзья
+func (s *FriendService) AcceptRequest(ctx context.Context, userID, requesterID primitive.ObjectID) (*models.Friendship, error) {
+	now := time.Now()
+	var friendship models.Friendship
+	err := s.db.Collection(friendshipsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"requester_id": requesterID, "addressee_id": userID, "status": models.FriendshipStatusPending},
+		bson.M{"$set": bson.M{"status": models.FriendshipStatusAccepted, "accepted_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&friendship)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrFriendRequestNotFound
+		}
+		return nil, errors.Wrap(err, "accepting friend request failed")
+	}
+	return &friendship, nil
+}
+
+// DeclineRequest отклоняет входящую заявку в друзья
+func (s *FriendService) DeclineRequest(ctx context.Context, userID, requesterID primitive.ObjectID) error {
+	return s.deleteFriendship(ctx, bson.M{
+		"requester_id": requesterID,
+		"addressee_id": userID,
+		"status":       models.FriendshipStatusPending,
+	}, ErrFriendRequestNotFound)
+}
+
+// CancelRequest отменяет исходящую заявку в друзья
+func (s *FriendService) CancelRequest(ctx context.Context, userID, addresseeID primitive.ObjectID) error {
+	return s.deleteFriendship(ctx, bson.M{
+		"requester_id": userID,
+		"addressee_id": addresseeID,
+		"status":       models.FriendshipStatusPending,
+	}, ErrFriendRequestNotFound)
+}
+
+// Unfriend удаляет пользователя из друзей
+func (s *FriendService) Unfriend(ctx context.Context, userID, friendID primitive.ObjectID) error {
+	low, high := orderPair(userID, friendID)
+	return s.deleteFriendship(ctx, bson.M{
+		"user_low":  low,
+		"user_high": high,
+		"status":    models.FriendshipStatusAccepted,
+	}, ErrFriendshipNotFound)
+}
+
+// AreFriends проверяет, являются ли пользователи друзьями
+func (s *FriendService) AreFriends(ctx context.Context, a, b primitive.ObjectID) (bool, error) {
+	friendship, err := s.findPair(ctx, a, b)
+	if err != nil {
+		return false, err
+	}
+	return friendship != nil && friendship.Status == models.FriendshipStatusAccepted, nil
+}
+
+// FriendIDs возвращает ID всех друзей пользователя
+func (s *FriendService) FriendIDs(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := s.db.Collection(friendshipsCollectionName).Find(ctx, s.friendsFilter(userID),
+		options.Find().SetProjection(bson.M{"user_low": 1, "user_high": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding friends failed")
+	}
+	var friendships []models.Friendship
+	if err := cursor.All(ctx, &friendships); err != nil {
+		return nil, errors.Wrap(err, "decoding friends failed")
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(friendships))
+	for _, f := range friendships {
+		ids = append(ids, otherUser(f, userID))
+	}
+	return ids, nil
+}
+
+// ListFriends возвращает страницу друзей пользователя с количеством общих друзей
+func (s *FriendService) ListFriends(ctx context.Context, userID primitive.ObjectID, page, limit int) (*models.UserSummaryPage, error) {
+	collection := s.db.Collection(friendshipsCollectionName)
+	filter := s.friendsFilter(userID)
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting friends failed")
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "accepted_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding friends failed")
+	}
+	var friendships []models.Friendship
+	if err := cursor.All(ctx, &friendships); err != nil {
+		return nil, errors.Wrap(err, "decoding friends failed")
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(friendships))
+	since := make(map[primitive.ObjectID]*time.Time, len(friendships))
+	for _, f := range friendships {
+		id := otherUser(f, userID)
+		ids = append(ids, id)
+		since[id] = f.AcceptedAt
+	}
+
+	users, err := s.userSummaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mutual, err := s.mutualCounts(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Since = since[users[i].ID]
+		users[i].MutualCount = mutual[users[i].ID]
+	}
+
+	return &models.UserSummaryPage{Users: users, Total: total, Page: page, Limit: limit}, nil
+}
+
+// ListRequests возвращает страницу входящих или исходящих заявок в друзья
+func (s *FriendService) ListRequests(ctx context.Context, userID primitive.ObjectID, incoming bool, page, limit int) (*models.UserSummaryPage, error) {
+	collection := s.db.Collection(friendshipsCollectionName)
+	filter := bson.M{"requester_id": userID, "status": models.FriendshipStatusPending}
+	if incoming {
+		filter = bson.M{"addressee_id": userID, "status": models.FriendshipStatusPending}
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting friend requests failed")
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding friend requests failed")
+	}
+	var friendships []models.Friendship
+	if err := cursor.All(ctx, &friendships); err != nil {
+		return nil, errors.Wrap(err, "decoding friend requests failed")
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(friendships))
+	since := make(map[primitive.ObjectID]time.Time, len(friendships))
+	for _, f := range friendships {
+		id := otherUser(f, userID)
+		ids = append(ids, id)
+		since[id] = f.CreatedAt
+	}
+
+	users, err := s.userSummaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mutual, err := s.mutualCounts(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		createdAt := since[users[i].ID]
+		users[i].Since = &createdAt
+		users[i].MutualCount = mutual[users[i].ID]
+	}
+
+	return &models.UserSummaryPage{Users: users, Total: total, Page: page, Limit: limit}, nil
+}
+
+// MutualCount возвращает количество общих друзей двух пользователей
+func (s *FriendService) MutualCount(ctx context.Context, userID, otherID primitive.ObjectID) (int, error) {
+	counts, err := s.mutualCounts(ctx, userID, []primitive.ObjectID{otherID})
+	if err != nil {
+		return 0, err
+	}
+	return counts[otherID], nil
+}
+
+// GetProfile возвращает профиль пользователя для просматривающего.
+// Если между пользователями есть блокировка, профиль считается не найденным.
+func (s *FriendService) GetProfile(ctx context.Context, viewerID, userID primitive.ObjectID) (*models.UserProfile, error) {
+	blocked, err := s.IsBlocked(ctx, viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrUserNotFound
+	}
+
+	var user struct {
+		models.UserSummary `bson:",inline"`
+		Description        string `bson:"description"`
+		Interests          string `bson:"interests"`
+	}
+	err = s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(userProfileProjection),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "finding user failed")
+	}
+
+	profile := &models.UserProfile{
+		UserSummary:      user.UserSummary,
+		Description:      user.Description,
+		Interests:        user.Interests,
+		FriendshipStatus: FriendshipViewNone,
+	}
+	if viewerID == userID {
+		return profile, nil
+	}
+
+	friendship, err := s.findPair(ctx, viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case friendship == nil:
+	case friendship.Status == models.FriendshipStatusAccepted:
+		profile.FriendshipStatus = FriendshipViewFriends
+		profile.Since = friendship.AcceptedAt
+	case friendship.RequesterID == viewerID:
+		profile.FriendshipStatus = FriendshipViewPendingOutgoing
+	default:
+		profile.FriendshipStatus = FriendshipViewPendingIncoming
+	}
+
+	profile.MutualCount, err = s.MutualCount(ctx, viewerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Block блокирует пользователя и удаляет существующую дружбу или заявки между пользователями
+func (s *FriendService) Block(ctx context.Context, blockerID, blockedID primitive.ObjectID) error {
+	if blockerID == blockedID {
+		return ErrSelfFriendship
+	}
+	if err := s.ensureUserExists(ctx, blockedID); err != nil {
+		return err
+	}
+
+	_, err := s.db.Collection(userBlocksCollectionName).UpdateOne(ctx,
+		bson.M{"blocker_id": blockerID, "blocked_id": blockedID},
+		bson.M{"$setOnInsert": bson.M{"created_at": time.Now()}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return errors.Wrap(err, "blocking user failed")
+	}
+
+	low, high := orderPair(blockerID, blockedID)
+	_, err = s.db.Collection(friendshipsCollectionName).DeleteOne(ctx, bson.M{"user_low": low, "user_high": high})
+	if err != nil {
+		return errors.Wrap(err, "removing friendship failed")
+	}
+	return nil
+}
+
+// Unblock снимает блокировку пользователя
+func (s *FriendService) Unblock(ctx context.Context, blockerID, blockedID primitive.ObjectID) error {
+	result, err := s.db.Collection(userBlocksCollectionName).DeleteOne(ctx, bson.M{"blocker_id": blockerID, "blocked_id": blockedID})
+	if err != nil {
+		return errors.Wrap(err, "unblocking user failed")
+	}
+	if result.DeletedCount == 0 {
+		return ErrBlockNotFound
+	}
+	return nil
+}
+
+// ListBlocked возвращает пользователей, заблокированных пользователем
+func (s *FriendService) ListBlocked(ctx context.Context, blockerID primitive.ObjectID) ([]models.UserSummary, error) {
+	cursor, err := s.db.Collection(userBlocksCollectionName).Find(ctx, bson.M{"blocker_id": blockerID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding blocks failed")
+	}
+	var blocks []models.UserBlock
+	if err := cursor.All(ctx, &blocks); err != nil {
+		return nil, errors.Wrap(err, "decoding blocks failed")
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(blocks))
+	for _, block := range blocks {
+		ids = append(ids, block.BlockedID)
+	}
+	return s.userSummaries(ctx, ids)
+}
+
+// IsBlocked проверяет, заблокировал ли один из пользователей другого
+func (s *FriendService) IsBlocked(ctx context.Context, a, b primitive.ObjectID) (bool, error) {
+	count, err := s.db.Collection(userBlocksCollectionName).CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"blocker_id": a, "blocked_id": b},
+		bson.M{"blocker_id": b, "blocked_id": a},
+	}})
+	if err != nil {
+		return false, errors.Wrap(err, "checking block failed")
+	}
+	return count > 0, nil
+}
+
+// friendsFilter возвращает фильтр принятых дружб пользователя
+func (s *FriendService) friendsFilter(userID primitive.ObjectID) bson.M {
+	return bson.M{
+		"$or":    bson.A{bson.M{"user_low": userID}, bson.M{"user_high": userID}},
+		"status": models.FriendshipStatusAccepted,
+	}
+}
+
+// findPair возвращает связь между двумя пользователями или nil
+func (s *FriendService) findPair(ctx context.Context, a, b primitive.ObjectID) (*models.Friendship, error) {
+	low, high := orderPair(a, b)
+	var friendship models.Friendship
+	err := s.db.Collection(friendshipsCollectionName).FindOne(ctx, bson.M{"user_low": low, "user_high": high}).Decode(&friendship)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding friendship failed")
+	}
+	return &friendship, nil
+}
+
+// deleteFriendship удаляет связь по фильтру или возвращает notFound
+func (s *FriendService) deleteFriendship(ctx context.Context, filter bson.M, notFound error) error {
+	result, err := s.db.Collection(friendshipsCollectionName).DeleteOne(ctx, filter)
+	if err != nil {
+		return errors.Wrap(err, "deleting friendship failed")
+	}
+	if result.DeletedCount == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// mutualCounts считает общих друзей пользователя с каждым из переданных пользователей
+func (s *FriendService) mutualCounts(ctx context.Context, userID primitive.ObjectID, others []primitive.ObjectID) (map[primitive.ObjectID]int, error) {
+	counts := make(map[primitive.ObjectID]int, len(others))
+	if len(others) == 0 {
+		return counts, nil
+	}
+	mine, err := s.FriendIDs(ctx, userID)
+	if err != nil || len(mine) == 0 {
+		return counts, err
+	}
+
+	// Дружбы, связывающие кого-то из others с кем-то из друзей пользователя
+	cursor, err := s.db.Collection(friendshipsCollectionName).Find(ctx, bson.M{
+		"status": models.FriendshipStatusAccepted,
+		"$or": bson.A{
+			bson.M{"user_low": bson.M{"$in": others}, "user_high": bson.M{"$in": mine}},
+			bson.M{"user_high": bson.M{"$in": others}, "user_low": bson.M{"$in": mine}},
+		},
+	}, options.Find().SetProjection(bson.M{"user_low": 1, "user_high": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding mutual friends failed")
+	}
+	var friendships []models.Friendship
+	if err := cursor.All(ctx, &friendships); err != nil {
+		return nil, errors.Wrap(err, "decoding mutual friends failed")
+	}
+
+	wanted := make(map[primitive.ObjectID]bool, len(others))
+	for _, id := range others {
+		wanted[id] = true
+	}
+	for _, f := range friendships {
+		if wanted[f.UserLow] {
+			counts[f.UserLow]++
+		}
+		if wanted[f.UserHigh] {
+			counts[f.UserHigh]++
+		}
+	}
+	return counts, nil
+}
+
+// userSummaries загружает публичные данные пользователей, сохраняя порядок ids
+func (s *FriendService) userSummaries(ctx context.Context, ids []primitive.ObjectID) ([]models.UserSummary, error) {
+	users := []models.UserSummary{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+	cursor, err := s.db.Collection(EntityTypeUser).Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(userSummaryProjection),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding users failed")
+	}
+	var found []models.UserSummary
+	if err := cursor.All(ctx, &found); err != nil {
+		return nil, errors.Wrap(err, "decoding users failed")
+	}
+
+	byID := make(map[primitive.ObjectID]models.UserSummary, len(found))
+	for _, user := range found {
+		byID[user.ID] = user
+	}
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+// ensureUserExists проверяет существование пользователя
+func (s *FriendService) ensureUserExists(ctx context.Context, userID primitive.ObjectID) error {
+	count, err := s.db.Collection(EntityTypeUser).CountDocuments(ctx, bson.M{"_id": userID})
+	if err != nil {
+		return errors.Wrap(err, "checking user failed")
+	}
+	if count == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// orderPair упорядочивает пару ID пользователей
+func orderPair(a, b primitive.ObjectID) (primitive.ObjectID, primitive.ObjectID) {
+	if a.Hex() < b.Hex() {
+		return a, b
+	}
+	return b, a
+}
+
+// otherUser возвращает второго участника связи
+func otherUser(f models.Friendship, userID primitive.ObjectID) primitive.ObjectID {
+	if f.UserLow == userID {
+		return f.UserHigh
+	}
+	return f.UserLow
+}
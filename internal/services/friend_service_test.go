@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestGetProfileHidesBlockedUsers(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	viewer, owner := primitive.NewObjectID(), primitive.NewObjectID()
+	ownerDoc := bson.D{{Key: "_id", Value: owner}, {Key: "name", Value: "Анна"}, {Key: "surname", Value: "Иванова"}}
+
+	mt.Run("blocked", func(mt *mtest.T) {
+		s := &FriendService{db: mt.DB}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.user_blocks", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}))
+
+		if _, err := s.GetProfile(context.Background(), viewer, owner); err != ErrUserNotFound {
+			mt.Fatalf("GetProfile() error = %v, want %v", err, ErrUserNotFound)
+		}
+		if events := mt.GetAllStartedEvents(); len(events) != 1 {
+			mt.Errorf("commands = %d, want only the block check before the user is read", len(events))
+		}
+	})
+
+	mt.Run("stranger", func(mt *mtest.T) {
+		s := &FriendService{db: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.user_blocks", mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, ownerDoc),
+			mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch),
+			mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch),
+		)
+
+		profile, err := s.GetProfile(context.Background(), viewer, owner)
+		if err != nil {
+			mt.Fatalf("GetProfile() error = %v", err)
+		}
+		if profile.Name != "Анна" || profile.FriendshipStatus != FriendshipViewNone {
+			mt.Errorf("GetProfile() = %+v, want Анна without friendship", profile)
+		}
+	})
+}
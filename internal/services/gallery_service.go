@@ -0,0 +1,604 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const restaurantPhotosCollectionName = "restaurant_photos"
+
+var (
+	ErrPhotoNotFound     = errors.New("photo not found")
+	ErrPhotoForbidden    = errors.New("not allowed to modify this photo")
+	ErrPhotoLimitReached = errors.New("photo limit reached")
+	ErrInvalidPhotoOrder = errors.New("order may only list photos of the restaurant, each once")
+	ErrInvalidCoverPhoto = errors.New("only approved photos of this restaurant can be the cover")
+	ErrReviewNotVisible  = errors.New("photos can only be added to a published review")
+)
+
+// PhotoDetails подпись и теги фотографии; nil-поля при изменении не трогаются
+type PhotoDetails struct {
+	Caption *string
+	Tags    []string
+}
+
+// GalleryService структура сервиса галереи ресторана: упорядоченных фото ресторана
+// и фото из отзывов пользователей, которые публикуются после модерации
+type GalleryService struct {
+	db                  *mongo.Database
+	mediaService        *MediaService
+	reviewService       *ReviewService
+	maxRestaurantPhotos int
+	maxReviewPhotos     int
+}
+
+// NewGalleryService создает новый экземпляр GalleryService
+func NewGalleryService(client *mongo.Client, dbName string, mediaService *MediaService, reviewService *ReviewService) *GalleryService {
+	return &GalleryService{
+		db:                  client.Database(dbName),
+		mediaService:        mediaService,
+		reviewService:       reviewService,
+		maxRestaurantPhotos: envInt("GALLERY_MAX_PHOTOS", 100),
+		maxReviewPhotos:     envInt("GALLERY_MAX_REVIEW_PHOTOS", 5),
+	}
+}
+
+// EnsureIndexes создает индексы коллекции фотографий
+func (s *GalleryService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(restaurantPhotosCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "source", Value: 1}, {Key: "status", Value: 1}, {Key: "position", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "source", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "review_id", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating photo indexes failed")
+	}
+	return nil
+}
+
+// AddRestaurantPhoto загружает фото ресторана в конец галереи; такие фото публикуются сразу
+func (s *GalleryService) AddRestaurantPhoto(ctx context.Context, restaurantID primitive.ObjectID, data []byte, details PhotoDetails) (*models.RestaurantPhoto, error) {
+	photo := &models.RestaurantPhoto{
+		RestaurantID: restaurantID,
+		Source:       models.PhotoSourceRestaurant,
+		Status:       models.PhotoStatusApproved,
+	}
+	applyPhotoDetails(photo, details)
+	if err := photo.Validate(); err != nil {
+		return nil, err
+	}
+
+	collection := s.db.Collection(restaurantPhotosCollectionName)
+	filter := bson.M{"restaurant_id": restaurantID, "source": models.PhotoSourceRestaurant}
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting photos failed")
+	}
+	if count >= int64(s.maxRestaurantPhotos) {
+		return nil, ErrPhotoLimitReached
+	}
+
+	var last models.RestaurantPhoto
+	err = collection.FindOne(ctx, filter, options.FindOne().SetSort(bson.D{{Key: "position", Value: -1}})).Decode(&last)
+	switch {
+	case err == nil:
+		photo.Position = last.Position + 1
+	case err != mongo.ErrNoDocuments:
+		return nil, errors.Wrap(err, "finding last photo failed")
+	}
+
+	return s.insertPhoto(ctx, photo, "gallery/"+restaurantID.Hex(), data)
+}
+
+// AddReviewPhoto прикладывает фото автора к его опубликованному отзыву; фото ждет модерации
+func (s *GalleryService) AddReviewPhoto(ctx context.Context, userID, reviewID primitive.ObjectID, data []byte, details PhotoDetails) (*models.RestaurantPhoto, error) {
+	review, err := s.reviewService.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrReviewForbidden
+	}
+	if !isReviewVisible(review.Status) {
+		return nil, ErrReviewNotVisible
+	}
+
+	photo := &models.RestaurantPhoto{
+		RestaurantID: review.RestaurantID,
+		Source:       models.PhotoSourceReview,
+		ReviewID:     &review.ID,
+		UserID:       &userID,
+		Status:       models.PhotoStatusPending,
+	}
+	applyPhotoDetails(photo, details)
+	if err := photo.Validate(); err != nil {
+		return nil, err
+	}
+
+	count, err := s.db.Collection(restaurantPhotosCollectionName).CountDocuments(ctx, bson.M{"review_id": reviewID})
+	if err != nil {
+		return nil, errors.Wrap(err, "counting review photos failed")
+	}
+	if count >= int64(s.maxReviewPhotos) {
+		return nil, ErrPhotoLimitReached
+	}
+
+	return s.insertPhoto(ctx, photo, "gallery/"+review.RestaurantID.Hex()+"/reviews/"+reviewID.Hex(), data)
+}
+
+// insertPhoto обрабатывает изображение и сохраняет фотографию; при ошибке записи файлы удаляются
+func (s *GalleryService) insertPhoto(ctx context.Context, photo *models.RestaurantPhoto, prefix string, data []byte) (*models.RestaurantPhoto, error) {
+	if int64(len(data)) > s.mediaService.MaxUploadBytes() {
+		return nil, ErrImageTooLarge
+	}
+	image, err := s.mediaService.storeImage(ctx, prefix, data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	photo.ID = primitive.NewObjectID()
+	photo.Image = *image
+	photo.CreatedAt = now
+	photo.UpdatedAt = now
+	if _, err := s.db.Collection(restaurantPhotosCollectionName).InsertOne(ctx, photo); err != nil {
+		s.mediaService.deleteBlobs(image)
+		return nil, errors.Wrap(err, "saving photo failed")
+	}
+
+	s.mediaService.SignImage(&photo.Image)
+	return photo, nil
+}
+
+// UpdatePhoto изменяет подпись и теги фото ресторана
+func (s *GalleryService) UpdatePhoto(ctx context.Context, restaurantID, photoID primitive.ObjectID, details PhotoDetails) (*models.RestaurantPhoto, error) {
+	photo, err := s.restaurantPhoto(ctx, restaurantID, photoID)
+	if err != nil {
+		return nil, err
+	}
+	applyPhotoDetails(photo, details)
+	if err := photo.Validate(); err != nil {
+		return nil, err
+	}
+
+	photo.UpdatedAt = time.Now()
+	update := bson.M{"$set": bson.M{"caption": photo.Caption, "tags": photo.Tags, "updated_at": photo.UpdatedAt}}
+	if _, err := s.db.Collection(restaurantPhotosCollectionName).UpdateByID(ctx, photoID, update); err != nil {
+		return nil, errors.Wrap(err, "updating photo failed")
+	}
+
+	s.mediaService.SignImage(&photo.Image)
+	return photo, nil
+}
+
+// DeleteRestaurantPhoto удаляет фото ресторана и его файлы
+func (s *GalleryService) DeleteRestaurantPhoto(ctx context.Context, restaurantID, photoID primitive.ObjectID) error {
+	photo, err := s.restaurantPhoto(ctx, restaurantID, photoID)
+	if err != nil {
+		return err
+	}
+	return s.deletePhotos(ctx, []models.RestaurantPhoto{*photo})
+}
+
+// DeleteReviewPhoto удаляет фото, приложенное автором к своему отзыву
+func (s *GalleryService) DeleteReviewPhoto(ctx context.Context, userID, reviewID, photoID primitive.ObjectID) error {
+	var photo models.RestaurantPhoto
+	err := s.db.Collection(restaurantPhotosCollectionName).FindOne(ctx, bson.M{"_id": photoID, "review_id": reviewID}).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		return ErrPhotoNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "finding photo failed")
+	}
+	if photo.UserID == nil || *photo.UserID != userID {
+		return ErrPhotoForbidden
+	}
+	return s.deletePhotos(ctx, []models.RestaurantPhoto{photo})
+}
+
+// DeleteReviewPhotos удаляет все фото удаленного отзыва
+func (s *GalleryService) DeleteReviewPhotos(ctx context.Context, reviewID primitive.ObjectID) error {
+	cursor, err := s.db.Collection(restaurantPhotosCollectionName).Find(ctx, bson.M{"review_id": reviewID})
+	if err != nil {
+		return errors.Wrap(err, "finding review photos failed")
+	}
+	var photos []models.RestaurantPhoto
+	if err := cursor.All(ctx, &photos); err != nil {
+		return errors.Wrap(err, "decoding review photos failed")
+	}
+	return s.deletePhotos(ctx, photos)
+}
+
+// HandleReviewDeleted удаляет фото и их файлы по событию review.deleted.
+// Ошибка возвращается реле, и очистка повторяется, пока не пройдет.
+func (s *GalleryService) HandleReviewDeleted(ctx context.Context, event *models.DomainEvent) error {
+	var payload models.ReviewDeletedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return errors.Wrap(err, "decoding review event failed")
+	}
+	return s.DeleteReviewPhotos(ctx, payload.ReviewID)
+}
+
+// deletePhotos удаляет фотографии, их файлы и снимает их с обложки ресторана
+func (s *GalleryService) deletePhotos(ctx context.Context, photos []models.RestaurantPhoto) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(photos))
+	for _, photo := range photos {
+		ids = append(ids, photo.ID)
+	}
+
+	if _, err := s.db.Collection(restaurantPhotosCollectionName).DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}}); err != nil {
+		return errors.Wrap(err, "deleting photos failed")
+	}
+	_, err := s.db.Collection(EntityTypeRestaurant).UpdateOne(ctx,
+		bson.M{"_id": photos[0].RestaurantID, "coverPhotoId": bson.M{"$in": ids}},
+		bson.M{"$unset": bson.M{"coverPhotoId": ""}},
+	)
+	if err != nil {
+		return errors.Wrap(err, "clearing cover photo failed")
+	}
+
+	for i := range photos {
+		s.mediaService.deleteBlobs(&photos[i].Image)
+	}
+	return nil
+}
+
+// Reorder меняет порядок фото ресторана. Перечисленные фото идут первыми в указанном порядке,
+// остальные сохраняют прежний порядок после них.
+func (s *GalleryService) Reorder(ctx context.Context, restaurantID primitive.ObjectID, order []primitive.ObjectID) ([]models.RestaurantPhoto, error) {
+	photos, err := s.findPhotos(ctx, bson.M{"restaurant_id": restaurantID, "source": models.PhotoSourceRestaurant},
+		options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]models.RestaurantPhoto, len(photos))
+	for _, photo := range photos {
+		byID[photo.ID] = photo
+	}
+	if len(uniqueObjectIDs(order)) != len(order) {
+		return nil, ErrInvalidPhotoOrder
+	}
+	ordered := make([]models.RestaurantPhoto, 0, len(photos))
+	placed := make(map[primitive.ObjectID]bool, len(order))
+	for _, photoID := range order {
+		photo, ok := byID[photoID]
+		if !ok {
+			return nil, ErrInvalidPhotoOrder
+		}
+		ordered = append(ordered, photo)
+		placed[photoID] = true
+	}
+	for _, photo := range photos {
+		if !placed[photo.ID] {
+			ordered = append(ordered, photo)
+		}
+	}
+
+	now := time.Now()
+	writes := make([]mongo.WriteModel, 0, len(ordered))
+	for i := range ordered {
+		ordered[i].Position = i
+		ordered[i].UpdatedAt = now
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": ordered[i].ID}).
+			SetUpdate(bson.M{"$set": bson.M{"position": i, "updated_at": now}}))
+	}
+	if len(writes) > 0 {
+		if _, err := s.db.Collection(restaurantPhotosCollectionName).BulkWrite(ctx, writes); err != nil {
+			return nil, errors.Wrap(err, "saving photo order failed")
+		}
+	}
+
+	s.signPhotos(ordered)
+	return ordered, nil
+}
+
+// SetCover выбирает обложку ресторана из его одобренных фото, в том числе из отзывов
+func (s *GalleryService) SetCover(ctx context.Context, restaurantID, photoID primitive.ObjectID) (*models.RestaurantPhoto, error) {
+	var photo models.RestaurantPhoto
+	err := s.db.Collection(restaurantPhotosCollectionName).FindOne(ctx, publicPhotoFilter(bson.M{
+		"_id":           photoID,
+		"restaurant_id": restaurantID,
+	})).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrInvalidCoverPhoto
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding photo failed")
+	}
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID, bson.M{"$set": bson.M{"coverPhotoId": photoID}})
+	if err != nil {
+		return nil, errors.Wrap(err, "saving cover photo failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrRestaurantNotFound
+	}
+
+	photo.Cover = true
+	s.mediaService.SignImage(&photo.Image)
+	return &photo, nil
+}
+
+// ClearCover сбрасывает выбор обложки; обложкой снова становится первое фото ресторана
+func (s *GalleryService) ClearCover(ctx context.Context, restaurantID primitive.ObjectID) error {
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID, bson.M{"$unset": bson.M{"coverPhotoId": ""}})
+	if err != nil {
+		return errors.Wrap(err, "clearing cover photo failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrRestaurantNotFound
+	}
+	return nil
+}
+
+// Gallery возвращает публичную галерею ресторана: обложку, все фото ресторана по порядку
+// и страницу одобренных фото из отзывов, начиная с новых. tag фильтрует фото по тегу.
+func (s *GalleryService) Gallery(ctx context.Context, restaurantID primitive.ObjectID, tag string, page, limit int) (*models.RestaurantGallery, error) {
+	var restaurant struct {
+		CoverPhotoID *primitive.ObjectID `bson:"coverPhotoId"`
+	}
+	err := s.db.Collection(EntityTypeRestaurant).FindOne(ctx, bson.M{"_id": restaurantID},
+		options.FindOne().SetProjection(bson.M{"coverPhotoId": 1}),
+	).Decode(&restaurant)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrRestaurantNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurant failed")
+	}
+
+	visible := func(source string) bson.M {
+		filter := publicPhotoFilter(bson.M{"restaurant_id": restaurantID, "source": source})
+		if tag != "" {
+			filter["tags"] = tag
+		}
+		return filter
+	}
+
+	gallery := &models.RestaurantGallery{RestaurantID: restaurantID, Page: page, Limit: limit}
+	gallery.Photos, err = s.findPhotos(ctx, visible(models.PhotoSourceRestaurant),
+		options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+
+	userFilter := visible(models.PhotoSourceReview)
+	gallery.UserPhotoTotal, err = s.db.Collection(restaurantPhotosCollectionName).CountDocuments(ctx, userFilter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting user photos failed")
+	}
+	gallery.UserPhotos, err = s.findPhotos(ctx, userFilter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	gallery.Cover, err = s.cover(ctx, restaurantID, restaurant.CoverPhotoID)
+	if err != nil {
+		return nil, err
+	}
+	if gallery.Cover != nil {
+		for _, photos := range [][]models.RestaurantPhoto{gallery.Photos, gallery.UserPhotos} {
+			for i := range photos {
+				photos[i].Cover = photos[i].ID == gallery.Cover.ID
+			}
+		}
+		s.mediaService.SignImage(&gallery.Cover.Image)
+	}
+
+	s.signPhotos(gallery.Photos)
+	s.signPhotos(gallery.UserPhotos)
+	return gallery, nil
+}
+
+// cover возвращает выбранную обложку, если она еще показывается, иначе первое фото ресторана
+func (s *GalleryService) cover(ctx context.Context, restaurantID primitive.ObjectID, coverID *primitive.ObjectID) (*models.RestaurantPhoto, error) {
+	collection := s.db.Collection(restaurantPhotosCollectionName)
+	var photo models.RestaurantPhoto
+	if coverID != nil {
+		err := collection.FindOne(ctx, publicPhotoFilter(bson.M{"_id": *coverID})).Decode(&photo)
+		if err == nil {
+			photo.Cover = true
+			return &photo, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, errors.Wrap(err, "finding cover photo failed")
+		}
+	}
+
+	err := collection.FindOne(ctx,
+		publicPhotoFilter(bson.M{"restaurant_id": restaurantID, "source": models.PhotoSourceRestaurant}),
+		options.FindOne().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "created_at", Value: 1}}),
+	).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding cover photo failed")
+	}
+	photo.Cover = true
+	return &photo, nil
+}
+
+// AttachReviewPhotos добавляет к отзывам их одобренные фото
+func (s *GalleryService) AttachReviewPhotos(ctx context.Context, reviews []models.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	ids := make([]primitive.ObjectID, 0, len(reviews))
+	for _, review := range reviews {
+		ids = append(ids, review.ID)
+	}
+
+	photos, err := s.findPhotos(ctx,
+		publicPhotoFilter(bson.M{"review_id": bson.M{"$in": ids}}),
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return err
+	}
+	s.signPhotos(photos)
+
+	byReview := make(map[primitive.ObjectID][]models.RestaurantPhoto, len(reviews))
+	for _, photo := range photos {
+		byReview[*photo.ReviewID] = append(byReview[*photo.ReviewID], photo)
+	}
+	for i := range reviews {
+		reviews[i].Photos = byReview[reviews[i].ID]
+	}
+	return nil
+}
+
+// ListModerationQueue возвращает фото из отзывов с указанным статусом, начиная с самых старых
+func (s *GalleryService) ListModerationQueue(ctx context.Context, status string, page, limit int) (*models.PhotoPage, error) {
+	if status == "" {
+		status = models.PhotoStatusPending
+	}
+	filter := bson.M{"source": models.PhotoSourceReview, "status": status}
+
+	total, err := s.db.Collection(restaurantPhotosCollectionName).CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting photo queue failed")
+	}
+	photos, err := s.findPhotos(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+
+	s.signPhotos(photos)
+	return &models.PhotoPage{Photos: photos, Total: total, Page: page, Limit: limit}, nil
+}
+
+// BulkModerate применяет действие модератора к списку фото из отзывов
+func (s *GalleryService) BulkModerate(ctx context.Context, actorID primitive.ObjectID, photoIDs []primitive.ObjectID, action, reason string) ([]models.PhotoModerationResult, error) {
+	var status string
+	switch action {
+	case models.ModerationActionApprove:
+		status = models.PhotoStatusApproved
+	case models.ModerationActionReject:
+		status = models.PhotoStatusRejected
+	default:
+		return nil, ErrInvalidModerationAction
+	}
+
+	results := make([]models.PhotoModerationResult, 0, len(photoIDs))
+	for _, photoID := range photoIDs {
+		result := models.PhotoModerationResult{PhotoID: photoID}
+		if err := s.setPhotoStatus(ctx, photoID, status, actorID, action, reason); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Status = status
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// setPhotoStatus меняет статус фото из отзыва и записывает действие в журнал модерации
+func (s *GalleryService) setPhotoStatus(ctx context.Context, photoID primitive.ObjectID, status string, actorID primitive.ObjectID, action, reason string) error {
+	update := bson.M{"$set": bson.M{"status": status, "updated_at": time.Now()}}
+	if status == models.PhotoStatusRejected && reason != "" {
+		update["$set"].(bson.M)["moderation_reason"] = reason
+	} else {
+		update["$unset"] = bson.M{"moderation_reason": ""}
+	}
+
+	var before models.RestaurantPhoto
+	err := s.db.Collection(restaurantPhotosCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": photoID, "source": models.PhotoSourceReview},
+		update,
+	).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return ErrPhotoNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "updating photo status failed")
+	}
+
+	entry := models.ModerationAuditEntry{
+		PhotoID:    &photoID,
+		ActorID:    actorID,
+		Action:     action,
+		FromStatus: before.Status,
+		ToStatus:   status,
+		Reason:     reason,
+	}
+	if before.ReviewID != nil {
+		entry.ReviewID = *before.ReviewID
+	}
+	return writeModerationAudit(ctx, s.db, entry)
+}
+
+// restaurantPhoto возвращает фото, загруженное указанным рестораном
+func (s *GalleryService) restaurantPhoto(ctx context.Context, restaurantID, photoID primitive.ObjectID) (*models.RestaurantPhoto, error) {
+	var photo models.RestaurantPhoto
+	err := s.db.Collection(restaurantPhotosCollectionName).FindOne(ctx, bson.M{"_id": photoID}).Decode(&photo)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding photo failed")
+	}
+	if photo.RestaurantID != restaurantID || photo.Source != models.PhotoSourceRestaurant {
+		return nil, ErrPhotoForbidden
+	}
+	return &photo, nil
+}
+
+// findPhotos выполняет запрос к коллекции фотографий
+func (s *GalleryService) findPhotos(ctx context.Context, filter bson.M, findOptions *options.FindOptions) ([]models.RestaurantPhoto, error) {
+	cursor, err := s.db.Collection(restaurantPhotosCollectionName).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding photos failed")
+	}
+	photos := []models.RestaurantPhoto{}
+	if err := cursor.All(ctx, &photos); err != nil {
+		return nil, errors.Wrap(err, "decoding photos failed")
+	}
+	return photos, nil
+}
+
+// signPhotos заполняет подписанные ссылки на изображения фотографий
+func (s *GalleryService) signPhotos(photos []models.RestaurantPhoto) {
+	for i := range photos {
+		s.mediaService.SignImage(&photos[i].Image)
+	}
+}
+
+// publicPhotoFilter дополняет фильтр условиями публичного показа:
+// фото одобрено, а отзыв, к которому оно приложено, не снят с публикации
+func publicPhotoFilter(filter bson.M) bson.M {
+	filter["status"] = models.PhotoStatusApproved
+	filter["review_hidden"] = bson.M{"$ne": true}
+	return filter
+}
+
+// applyPhotoDetails переносит переданные подпись и теги в фотографию
+func applyPhotoDetails(photo *models.RestaurantPhoto, details PhotoDetails) {
+	if details.Caption != nil {
+		photo.Caption = *details.Caption
+	}
+	if details.Tags != nil {
+		photo.Tags = uniqueStrings(details.Tags)
+	}
+	if photo.Tags == nil {
+		photo.Tags = []string{}
+	}
+}
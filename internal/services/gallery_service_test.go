@@ -0,0 +1,193 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestAddReviewPhotoRequiresPublishedReview(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	authorID := primitive.NewObjectID()
+
+	tests := []struct {
+		name    string
+		userID  primitive.ObjectID
+		status  string
+		wantErr error
+	}{
+		{name: "approved review", userID: authorID, status: models.ReviewStatusApproved, wantErr: ErrImageTooLarge},
+		{name: "review without status", userID: authorID, status: "", wantErr: ErrImageTooLarge},
+		{name: "pending review", userID: authorID, status: models.ReviewStatusPending, wantErr: ErrReviewNotVisible},
+		{name: "rejected review", userID: authorID, status: models.ReviewStatusRejected, wantErr: ErrReviewNotVisible},
+		{name: "someone else's review", userID: primitive.NewObjectID(), status: models.ReviewStatusApproved, wantErr: ErrReviewForbidden},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			// Пустой лимит загрузки останавливает прошедшую проверки загрузку до обработки изображения
+			s := &GalleryService{
+				db:              mt.DB,
+				mediaService:    &MediaService{},
+				reviewService:   &ReviewService{db: mt.DB},
+				maxReviewPhotos: 5,
+			}
+			review := bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "user_id", Value: authorID},
+				{Key: "restaurant_id", Value: primitive.NewObjectID()},
+				{Key: "status", Value: tt.status},
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, review),
+				mtest.CreateCursorResponse(0, "db.restaurant_photos", mtest.FirstBatch, bson.D{{Key: "n", Value: 0}}),
+			)
+
+			_, err := s.AddReviewPhoto(context.Background(), tt.userID, review[0].Value.(primitive.ObjectID), []byte("image"), PhotoDetails{})
+			if errors.Cause(err) != tt.wantErr {
+				t.Fatalf("AddReviewPhoto() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetReviewStatusHidesReviewPhotos(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name       string
+		from, to   string
+		wantHidden bool
+	}{
+		{name: "rejected", from: models.ReviewStatusApproved, to: models.ReviewStatusRejected, wantHidden: true},
+		{name: "sent back to moderation", from: models.ReviewStatusApproved, to: models.ReviewStatusPending, wantHidden: true},
+		{name: "approved again", from: models.ReviewStatusRejected, to: models.ReviewStatusApproved, wantHidden: false},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &ReviewService{db: mt.DB, eventBus: &EventBus{}}
+			reviewID := primitive.NewObjectID()
+			before := bson.D{
+				{Key: "_id", Value: reviewID},
+				{Key: "restaurant_id", Value: primitive.NewObjectID()},
+				{Key: "rating", Value: 4},
+				{Key: "status", Value: tt.from},
+			}
+			updated := bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}
+			mt.AddMockResponses(
+				bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: before}},
+				mtest.CreateSuccessResponse(),
+				updated,
+				updated,
+			)
+
+			if _, err := s.SetReviewStatus(context.Background(), reviewID, tt.to, primitive.NewObjectID(), "test", ""); err != nil {
+				t.Fatalf("SetReviewStatus() error = %v", err)
+			}
+
+			var photosUpdate bson.Raw
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "update" && event.Command.Lookup("update").StringValue() == restaurantPhotosCollectionName {
+					photosUpdate = event.Command.Lookup("updates").Array().Index(0).Value().Document()
+				}
+			}
+			if photosUpdate == nil {
+				t.Fatal("review photos were not updated")
+			}
+			if got := photosUpdate.Lookup("q", "review_id").ObjectID(); got != reviewID {
+				t.Errorf("filter review_id = %s, want %s", got.Hex(), reviewID.Hex())
+			}
+			if !photosUpdate.Lookup("multi").Boolean() {
+				t.Error("update is not applied to all review photos")
+			}
+			if got := photosUpdate.Lookup("u", "$set", "review_hidden").Boolean(); got != tt.wantHidden {
+				t.Errorf("review_hidden = %v, want %v", got, tt.wantHidden)
+			}
+		})
+	}
+}
+
+func TestAttachReviewPhotosSkipsHiddenReviews(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("filter", func(mt *mtest.T) {
+		s := &GalleryService{db: mt.DB, mediaService: &MediaService{}}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.restaurant_photos", mtest.FirstBatch))
+
+		reviews := []models.Review{{ID: primitive.NewObjectID()}}
+		if err := s.AttachReviewPhotos(context.Background(), reviews); err != nil {
+			t.Fatalf("AttachReviewPhotos() error = %v", err)
+		}
+
+		filter := mt.GetStartedEvent().Command.Lookup("filter").Document()
+		if got := filter.Lookup("status").StringValue(); got != models.PhotoStatusApproved {
+			t.Errorf("filter status = %q, want %q", got, models.PhotoStatusApproved)
+		}
+		if got := filter.Lookup("review_hidden", "$ne"); !got.Boolean() {
+			t.Errorf("filter review_hidden = %v, want {$ne: true}", got)
+		}
+	})
+}
+
+func TestHandleReviewDeletedRemovesPhotos(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("cleanup", func(mt *mtest.T) {
+		store, err := NewLocalBlobStore(t.TempDir(), "http://localhost/media", []byte("key"))
+		if err != nil {
+			t.Fatalf("NewLocalBlobStore() error = %v", err)
+		}
+		key := "gallery/r/reviews/1/large.jpg"
+		if err := store.Put(context.Background(), key, "image/jpeg", []byte("image")); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		s := &GalleryService{db: mt.DB, mediaService: &MediaService{store: store}}
+
+		reviewID, photoID := primitive.NewObjectID(), primitive.NewObjectID()
+		photo := bson.D{
+			{Key: "_id", Value: photoID},
+			{Key: "restaurant_id", Value: primitive.NewObjectID()},
+			{Key: "review_id", Value: reviewID},
+			{Key: "image", Value: bson.D{{Key: "variants", Value: bson.A{bson.D{{Key: "name", Value: "large"}, {Key: "key", Value: key}}}}}},
+		}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.restaurant_photos", mtest.FirstBatch, photo),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+		)
+
+		event := &models.DomainEvent{Type: models.DomainEventReviewDeleted, Payload: bson.M{"review_id": reviewID}}
+		if err := s.HandleReviewDeleted(context.Background(), event); err != nil {
+			t.Fatalf("HandleReviewDeleted() error = %v", err)
+		}
+
+		started := mt.GetAllStartedEvents()
+		if got := started[0].Command.Lookup("filter", "review_id").ObjectID(); got != reviewID {
+			t.Errorf("find review_id = %s, want %s", got.Hex(), reviewID.Hex())
+		}
+		if started[1].CommandName != "delete" {
+			t.Errorf("second command = %s, want delete", started[1].CommandName)
+		}
+		if _, err := os.Stat(filepath.Join(store.dir, filepath.FromSlash(key))); !os.IsNotExist(err) {
+			t.Errorf("blob %s still exists: %v", key, err)
+		}
+	})
+
+	mt.Run("failure is returned for retry", func(mt *mtest.T) {
+		s := &GalleryService{db: mt.DB, mediaService: &MediaService{}}
+		mt.AddMockResponses(mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 6, Message: "host unreachable"}))
+
+		event := &models.DomainEvent{Type: models.DomainEventReviewDeleted, Payload: bson.M{"review_id": primitive.NewObjectID()}}
+		if err := s.HandleReviewDeleted(context.Background(), event); err == nil {
+			t.Fatal("HandleReviewDeleted() error = nil, want error")
+		}
+	})
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"math"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const earthRadiusMeters = 6371000
+
+// MaxNearbyRadiusMeters - максимальный радиус поиска ближайших ресторанов
+const MaxNearbyRadiusMeters = 50000
+
+var (
+	ErrRestaurantNotFound  = errors.New("restaurant not found")
+	ErrInvalidCoordinates  = errors.New("invalid coordinates")
+	ErrInvalidDeliveryZone = errors.New("delivery zone polygon must have at least 3 distinct points")
+)
+
+// Serviceability результат проверки, доставляет ли ресторан по адресу
+type Serviceability struct {
+	Serviceable     bool    `json:"serviceable"`
+	HasDeliveryZone bool    `json:"has_delivery_zone"`
+	DistanceM       float64 `json:"distance_m,omitempty"`
+}
+
+// GeoService структура сервиса геопоиска ресторанов
+type GeoService struct {
+	db       *mongo.Database
+	geocoder Geocoder
+}
+
+// NewGeoService создает новый экземпляр GeoService
+func NewGeoService(client *mongo.Client, dbName string, geocoder Geocoder) *GeoService {
+	return &GeoService{
+		db:       client.Database(dbName),
+		geocoder: geocoder,
+	}
+}
+
+// EnsureIndexes создает геопространственные индексы ресторанов
+func (s *GeoService) EnsureIndexes(ctx context.Context) error {
+	// Зона доставки проверяется только у одного ресторана, загруженного по ID,
+	// поэтому индекс по deliveryZone не использовался ни одним запросом и удаляется
+	if _, err := s.db.Collection(EntityTypeRestaurant).Indexes().DropOne(ctx, "deliveryZone_2dsphere"); err != nil && !isIndexNotFound(err) {
+		return errors.Wrap(err, "dropping delivery zone index failed")
+	}
+
+	_, err := s.db.Collection(EntityTypeRestaurant).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating geo indexes failed")
+	}
+	return nil
+}
+
+// Geocode возвращает координаты адреса через настроенный геокодер
+func (s *GeoService) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	return s.geocoder.Geocode(ctx, address)
+}
+
+// SetRestaurantLocation сохраняет координаты ресторана.
+// Если точка не передана, она определяется геокодированием адреса.
+func (s *GeoService) SetRestaurantLocation(ctx context.Context, restaurantID primitive.ObjectID, address string, point *models.GeoPoint) (*models.GeoPoint, error) {
+	set := bson.M{}
+	if address != "" {
+		set["address"] = address
+	}
+	if point == nil {
+		if address == "" {
+			return nil, ErrInvalidCoordinates
+		}
+		geocoded, err := s.geocoder.Geocode(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		point = geocoded
+	}
+	if !validPoint(*point) {
+		return nil, ErrInvalidCoordinates
+	}
+	set["location"] = point
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID, bson.M{"$set": set})
+	if err != nil {
+		return nil, errors.Wrap(err, "updating restaurant location failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrRestaurantNotFound
+	}
+	return point, nil
+}
+
+// SetDeliveryZone сохраняет зоны доставки ресторана; пустой список удаляет зону.
+// Каждая зона задается внешним контуром из точек [долгота, широта].
+func (s *GeoService) SetDeliveryZone(ctx context.Context, restaurantID primitive.ObjectID, zones [][][2]float64) (*models.GeoMultiPolygon, error) {
+	var update bson.M
+	var zone *models.GeoMultiPolygon
+	if len(zones) == 0 {
+		update = bson.M{"$unset": bson.M{"deliveryZone": ""}}
+	} else {
+		zone = &models.GeoMultiPolygon{Type: "MultiPolygon"}
+		for _, ring := range zones {
+			closed, err := closeRing(ring)
+			if err != nil {
+				return nil, err
+			}
+			zone.Coordinates = append(zone.Coordinates, [][][2]float64{closed})
+		}
+		update = bson.M{"$set": bson.M{"deliveryZone": zone}}
+	}
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID, update)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating delivery zone failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrRestaurantNotFound
+	}
+	return zone, nil
+}
+
+// Nearby возвращает рестораны в радиусе от точки, отсортированные по расстоянию
+func (s *GeoService) Nearby(ctx context.Context, point models.GeoPoint, radiusMeters float64, limit int) ([]models.RestaurantSummary, error) {
+	if !validPoint(point) {
+		return nil, ErrInvalidCoordinates
+	}
+	if radiusMeters <= 0 || radiusMeters > MaxNearbyRadiusMeters {
+		radiusMeters = MaxNearbyRadiusMeters
+	}
+
+	projection := bson.M{"distance": 1}
+	for field, include := range restaurantSummaryProjection {
+		projection[field] = include
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$geoNear", Value: bson.M{
+			"near":          point,
+			"key":           "location",
+			"distanceField": "distance",
+			"maxDistance":   radiusMeters,
+			"spherical":     true,
+			"query":         bson.M{"banned": bson.M{"$ne": true}},
+		}}},
+		{{Key: "$limit", Value: limit}},
+		{{Key: "$project", Value: projection}},
+	}
+
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding nearby restaurants failed")
+	}
+	restaurants := []models.RestaurantSummary{}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding nearby restaurants failed")
+	}
+	return restaurants, nil
+}
+
+// WithinBox возвращает рестораны внутри прямоугольника карты.
+// Если передана точка origin, в результатах заполняется расстояние до нее.
+func (s *GeoService) WithinBox(ctx context.Context, southWest, northEast models.GeoPoint, origin *models.GeoPoint, limit int) ([]models.RestaurantSummary, error) {
+	if !validPoint(southWest) || !validPoint(northEast) || southWest.Lat() >= northEast.Lat() {
+		return nil, ErrInvalidCoordinates
+	}
+
+	sw, ne := southWest.Coordinates, northEast.Coordinates
+	box := bson.M{
+		"type": "Polygon",
+		"coordinates": bson.A{bson.A{
+			bson.A{sw[0], sw[1]},
+			bson.A{ne[0], sw[1]},
+			bson.A{ne[0], ne[1]},
+			bson.A{sw[0], ne[1]},
+			bson.A{sw[0], sw[1]},
+		}},
+	}
+	filter := bson.M{
+		"banned":   bson.M{"$ne": true},
+		"location": bson.M{"$geoWithin": bson.M{"$geometry": box}},
+	}
+
+	findOptions := options.Find().SetProjection(restaurantSummaryProjection).SetLimit(int64(limit))
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurants in box failed")
+	}
+	restaurants := []models.RestaurantSummary{}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurants in box failed")
+	}
+
+	if origin != nil {
+		for i := range restaurants {
+			if restaurants[i].Location != nil {
+				distance := DistanceMeters(*origin, *restaurants[i].Location)
+				restaurants[i].Distance = &distance
+			}
+		}
+	}
+	return restaurants, nil
+}
+
+// CheckServiceable проверяет, попадает ли точка в зону доставки ресторана
+func (s *GeoService) CheckServiceable(ctx context.Context, restaurantID primitive.ObjectID, point models.GeoPoint) (*Serviceability, error) {
+	if !validPoint(point) {
+		return nil, ErrInvalidCoordinates
+	}
+
+	var restaurant struct {
+		Location     *models.GeoPoint        `bson:"location"`
+		DeliveryZone *models.GeoMultiPolygon `bson:"deliveryZone"`
+	}
+	collection := s.db.Collection(EntityTypeRestaurant)
+	err := collection.FindOne(ctx, bson.M{"_id": restaurantID},
+		options.FindOne().SetProjection(bson.M{"location": 1, "deliveryZone": 1}),
+	).Decode(&restaurant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRestaurantNotFound
+		}
+		return nil, errors.Wrap(err, "finding restaurant failed")
+	}
+
+	result := &Serviceability{HasDeliveryZone: restaurant.DeliveryZone != nil}
+	if restaurant.Location != nil {
+		result.DistanceM = DistanceMeters(point, *restaurant.Location)
+	}
+	if result.HasDeliveryZone {
+		result.Serviceable = zoneContains(restaurant.DeliveryZone, point)
+	}
+	return result, nil
+}
+
+// DistanceMeters вычисляет расстояние между точками по формуле гаверсинусов
+func DistanceMeters(a, b models.GeoPoint) float64 {
+	lat1, lat2 := a.Lat()*math.Pi/180, b.Lat()*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng() - a.Lng()) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// validPoint проверяет допустимость координат
+func validPoint(p models.GeoPoint) bool {
+	return p.Lat() >= -90 && p.Lat() <= 90 && p.Lng() >= -180 && p.Lng() <= 180
+}
+
+// closeRing проверяет контур полигона, убирает повторы соседних вершин и замыкает его.
+// Контур, все точки которого совпадают или лежат на одной линии, отклоняется.
+func closeRing(ring [][2]float64) ([][2]float64, error) {
+	closed := make([][2]float64, 0, len(ring)+1)
+	for _, p := range ring {
+		if !validPoint(models.GeoPoint{Coordinates: p}) {
+			return nil, ErrInvalidCoordinates
+		}
+		if len(closed) == 0 || closed[len(closed)-1] != p {
+			closed = append(closed, p)
+		}
+	}
+	if len(closed) > 0 && closed[0] != closed[len(closed)-1] {
+		closed = append(closed, closed[0])
+	}
+	if len(closed) < 4 || ringArea(unwrapRing(closed)) == 0 {
+		return nil, ErrInvalidDeliveryZone
+	}
+	return closed, nil
+}
+
+// unwrapRing сдвигает долготы вершин на 360°, чтобы соседние вершины отличались не больше
+// чем на 180°. Так контур, пересекающий антимеридиан, становится непрерывным на плоскости.
+func unwrapRing(ring [][2]float64) [][2]float64 {
+	unwrapped := make([][2]float64, len(ring))
+	for i, p := range ring {
+		if i > 0 {
+			prev := unwrapped[i-1][0]
+			for p[0]-prev > 180 {
+				p[0] -= 360
+			}
+			for prev-p[0] > 180 {
+				p[0] += 360
+			}
+		}
+		unwrapped[i] = p
+	}
+	return unwrapped
+}
+
+// ringArea возвращает удвоенную ориентированную площадь замкнутого контура на плоскости долгота/широта
+func ringArea(ring [][2]float64) float64 {
+	area := 0.0
+	for i := 0; i+1 < len(ring); i++ {
+		area += ring[i][0]*ring[i+1][1] - ring[i+1][0]*ring[i][1]
+	}
+	return area
+}
+
+// ringContains проверяет методом трассировки луча, лежит ли точка внутри замкнутого контура.
+// Зоны доставки занимают несколько километров, поэтому ребра считаются прямыми на плоскости
+// долгота/широта; контуры через антимеридиан разворачиваются, а точка сдвигается в их диапазон.
+func ringContains(ring [][2]float64, point models.GeoPoint) bool {
+	unwrapped := unwrapRing(ring)
+	for _, shift := range []float64{0, 360, -360} {
+		x, y := point.Lng()+shift, point.Lat()
+		inside := false
+		for i, j := 0, len(unwrapped)-1; i < len(unwrapped); j, i = i, i+1 {
+			a, b := unwrapped[i], unwrapped[j]
+			if (a[1] > y) != (b[1] > y) && x < (b[0]-a[0])*(y-a[1])/(b[1]-a[1])+a[0] {
+				inside = !inside
+			}
+		}
+		if inside {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneContains проверяет, попадает ли точка в один из полигонов зоны доставки с учетом дыр
+func zoneContains(zone *models.GeoMultiPolygon, point models.GeoPoint) bool {
+	for _, polygon := range zone.Coordinates {
+		if len(polygon) == 0 || !ringContains(polygon[0], point) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContains(hole, point) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureRestaurantExists проверяет, что ресторан существует и не заблокирован
+func ensureRestaurantExists(ctx context.Context, db *mongo.Database, restaurantID primitive.ObjectID) error {
+	count, err := db.Collection(EntityTypeRestaurant).CountDocuments(ctx, bson.M{"_id": restaurantID, "banned": bson.M{"$ne": true}})
+	if err != nil {
+		return errors.Wrap(err, "checking restaurant failed")
+	}
+	if count == 0 {
+		return ErrRestaurantNotFound
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestCloseRing(t *testing.T) {
+	tests := []struct {
+		name    string
+		ring    [][2]float64
+		want    [][2]float64
+		wantErr error
+	}{
+		{
+			name: "closes open ring",
+			ring: [][2]float64{{0, 0}, {1, 0}, {1, 1}},
+			want: [][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		},
+		{
+			name: "keeps closed ring",
+			ring: [][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+			want: [][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		},
+		{
+			name: "drops repeated neighbours",
+			ring: [][2]float64{{0, 0}, {0, 0}, {1, 0}, {1, 1}, {1, 1}},
+			want: [][2]float64{{0, 0}, {1, 0}, {1, 1}, {0, 0}},
+		},
+		{
+			name: "crosses antimeridian",
+			ring: [][2]float64{{179.5, 10}, {-179.5, 10}, {-179.5, 11}, {179.5, 11}},
+			want: [][2]float64{{179.5, 10}, {-179.5, 10}, {-179.5, 11}, {179.5, 11}, {179.5, 10}},
+		},
+		{name: "empty", ring: nil, wantErr: ErrInvalidDeliveryZone},
+		{name: "two points", ring: [][2]float64{{0, 0}, {1, 1}}, wantErr: ErrInvalidDeliveryZone},
+		{name: "same point", ring: [][2]float64{{5, 5}, {5, 5}, {5, 5}, {5, 5}}, wantErr: ErrInvalidDeliveryZone},
+		{name: "two distinct points closed", ring: [][2]float64{{0, 0}, {1, 1}, {0, 0}}, wantErr: ErrInvalidDeliveryZone},
+		{name: "collinear", ring: [][2]float64{{0, 0}, {1, 1}, {2, 2}}, wantErr: ErrInvalidDeliveryZone},
+		{name: "collinear across antimeridian", ring: [][2]float64{{179, 0}, {-179, 0}, {-178, 0}}, wantErr: ErrInvalidDeliveryZone},
+		{name: "latitude out of range", ring: [][2]float64{{0, 0}, {1, 91}, {1, 1}}, wantErr: ErrInvalidCoordinates},
+		{name: "longitude out of range", ring: [][2]float64{{0, 0}, {181, 0}, {1, 1}}, wantErr: ErrInvalidCoordinates},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := closeRing(tt.ring)
+			if tt.wantErr != nil {
+				if errors.Cause(err) != tt.wantErr {
+					t.Fatalf("closeRing() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("closeRing() unexpected error: %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("closeRing() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("closeRing() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestZoneContains(t *testing.T) {
+	square := [][2]float64{{37.5, 55.7}, {37.7, 55.7}, {37.7, 55.8}, {37.5, 55.8}, {37.5, 55.7}}
+	hole := [][2]float64{{37.58, 55.74}, {37.62, 55.74}, {37.62, 55.76}, {37.58, 55.76}, {37.58, 55.74}}
+	antimeridian := [][2]float64{{179.5, -17}, {-179.5, -17}, {-179.5, -16}, {179.5, -16}, {179.5, -17}}
+	other := [][2]float64{{30.2, 59.9}, {30.4, 59.9}, {30.4, 60}, {30.2, 60}, {30.2, 59.9}}
+
+	tests := []struct {
+		name  string
+		zone  [][][][2]float64
+		point models.GeoPoint
+		want  bool
+	}{
+		{name: "inside", zone: [][][][2]float64{{square}}, point: models.NewGeoPoint(55.75, 37.6), want: true},
+		{name: "outside", zone: [][][][2]float64{{square}}, point: models.NewGeoPoint(55.75, 37.8), want: false},
+		{name: "in hole", zone: [][][][2]float64{{square, hole}}, point: models.NewGeoPoint(55.75, 37.6), want: false},
+		{name: "around hole", zone: [][][][2]float64{{square, hole}}, point: models.NewGeoPoint(55.72, 37.6), want: true},
+		{name: "second polygon", zone: [][][][2]float64{{square}, {other}}, point: models.NewGeoPoint(59.95, 30.3), want: true},
+		{name: "antimeridian east side", zone: [][][][2]float64{{antimeridian}}, point: models.NewGeoPoint(-16.5, 179.8), want: true},
+		{name: "antimeridian west side", zone: [][][][2]float64{{antimeridian}}, point: models.NewGeoPoint(-16.5, -179.8), want: true},
+		{name: "antimeridian on the line", zone: [][][][2]float64{{antimeridian}}, point: models.NewGeoPoint(-16.5, 180), want: true},
+		{name: "antimeridian far side", zone: [][][][2]float64{{antimeridian}}, point: models.NewGeoPoint(-16.5, 0), want: false},
+		{name: "antimeridian outside", zone: [][][][2]float64{{antimeridian}}, point: models.NewGeoPoint(-16.5, 179), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := &models.GeoMultiPolygon{Type: "MultiPolygon", Coordinates: tt.zone}
+			if got := zoneContains(zone, tt.point); got != tt.want {
+				t.Errorf("zoneContains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGeoIndexes(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("drops delivery zone index", func(mt *mtest.T) {
+		s := &GeoService{db: mt.DB}
+		mt.AddMockResponses(mtest.CreateSuccessResponse(), mtest.CreateSuccessResponse())
+
+		if err := s.EnsureIndexes(context.Background()); err != nil {
+			mt.Fatalf("EnsureIndexes() error = %v", err)
+		}
+
+		if got := mt.GetStartedEvent().Command.Lookup("index").StringValue(); got != "deliveryZone_2dsphere" {
+			mt.Errorf("dropped index = %q, want deliveryZone_2dsphere", got)
+		}
+		indexes, _ := mt.GetStartedEvent().Command.Lookup("indexes").Array().Values()
+		if len(indexes) != 1 || indexes[0].Document().Lookup("name").StringValue() != "location_2dsphere" {
+			mt.Errorf("created indexes = %v, want only location_2dsphere", indexes)
+		}
+	})
+
+	mt.Run("index already dropped", func(mt *mtest.T) {
+		s := &GeoService{db: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 27, Name: "IndexNotFound", Message: "index not found with name [deliveryZone_2dsphere]"}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		if err := s.EnsureIndexes(context.Background()); err != nil {
+			mt.Fatalf("EnsureIndexes() error = %v", err)
+		}
+	})
+}
+
+func TestFixtureGeocoder(t *testing.T) {
+	geocoder := NewFixtureGeocoder(map[string]models.GeoPoint{
+		"Тверская  ул., 1": models.NewGeoPoint(55.757, 37.615),
+	})
+
+	point, err := geocoder.Geocode(context.Background(), "  тверская ул., 1 ")
+	if err != nil {
+		t.Fatalf("Geocode() unexpected error: %v", err)
+	}
+	if point.Lat() != 55.757 || point.Lng() != 37.615 {
+		t.Errorf("Geocode() = %v, want 55.757, 37.615", point.Coordinates)
+	}
+
+	if _, err := geocoder.Geocode(context.Background(), "Невский пр., 1"); err != ErrAddressNotFound {
+		t.Errorf("Geocode() error = %v, want %v", err, ErrAddressNotFound)
+	}
+}
+
+func TestNewGeocoderFromEnv(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset falls back to fixtures", want: "*services.FixtureGeocoder"},
+		{name: "url selects nominatim", env: map[string]string{"GEOCODER_URL": "http://geocoder.local"}, want: "*services.CachingGeocoder"},
+		{name: "explicit fixture ignores url", env: map[string]string{"GEOCODER": "fixture", "GEOCODER_URL": "http://geocoder.local"}, want: "*services.FixtureGeocoder"},
+		{name: "nominatim requires url", env: map[string]string{"GEOCODER": "nominatim"}, wantErr: true},
+		{name: "unknown driver", env: map[string]string{"GEOCODER": "google"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"GEOCODER", "GEOCODER_URL", "GEOCODER_FIXTURES"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			geocoder, err := NewGeocoderFromEnv()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("NewGeocoderFromEnv() = %T, want error", geocoder)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewGeocoderFromEnv() unexpected error: %v", err)
+			}
+			if got := fmt.Sprintf("%T", geocoder); got != tt.want {
+				t.Errorf("NewGeocoderFromEnv() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// countingGeocoder считает обращения к геокодеру
+type countingGeocoder struct {
+	next  Geocoder
+	calls int
+	err   error
+}
+
+func (g *countingGeocoder) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	g.calls++
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.next.Geocode(ctx, address)
+}
+
+func TestCachingGeocoder(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	remote := &countingGeocoder{next: NewFixtureGeocoder(map[string]models.GeoPoint{
+		"Тверская ул., 1": models.NewGeoPoint(55.757, 37.615),
+		"Невский пр., 1":  models.NewGeoPoint(59.936, 30.315),
+		"Арбат, 1":        models.NewGeoPoint(55.752, 37.598),
+	})}
+	geocoder := NewCachingGeocoder(remote, 2, time.Second)
+	geocoder.now = func() time.Time { return now }
+
+	if _, err := geocoder.Geocode(ctx, "Тверская ул., 1"); err != nil {
+		t.Fatalf("Geocode() unexpected error: %v", err)
+	}
+	point, err := geocoder.Geocode(ctx, "  тверская ул., 1")
+	if err != nil {
+		t.Fatalf("cached Geocode() unexpected error: %v", err)
+	}
+	if point.Lat() != 55.757 || remote.calls != 1 {
+		t.Fatalf("cached Geocode() = %v after %d calls, want 55.757 after 1 call", point.Coordinates, remote.calls)
+	}
+
+	if _, err := geocoder.Geocode(ctx, "Невский пр., 1"); err != ErrGeocoderRateLimited {
+		t.Fatalf("Geocode() within interval error = %v, want %v", err, ErrGeocoderRateLimited)
+	}
+
+	now = now.Add(time.Second)
+	if _, err := geocoder.Geocode(ctx, "Луна, 1"); err != ErrAddressNotFound {
+		t.Fatalf("Geocode() error = %v, want %v", err, ErrAddressNotFound)
+	}
+	if _, err := geocoder.Geocode(ctx, "Луна, 1"); err != ErrAddressNotFound || remote.calls != 2 {
+		t.Fatalf("cached Geocode() error = %v after %d calls, want %v after 2 calls", err, remote.calls, ErrAddressNotFound)
+	}
+
+	// третий адрес вытесняет давно использованную Тверскую
+	now = now.Add(time.Second)
+	if _, err := geocoder.Geocode(ctx, "Арбат, 1"); err != nil {
+		t.Fatalf("Geocode() unexpected error: %v", err)
+	}
+	now = now.Add(time.Second)
+	if _, err := geocoder.Geocode(ctx, "Тверская ул., 1"); err != nil || remote.calls != 4 {
+		t.Fatalf("evicted Geocode() error = %v after %d calls, want refetch as call 4", err, remote.calls)
+	}
+
+	remote.err = errors.New("connection refused")
+	now = now.Add(time.Second)
+	if _, err := geocoder.Geocode(ctx, "Невский пр., 1"); err != remote.err {
+		t.Fatalf("Geocode() error = %v, want %v", err, remote.err)
+	}
+	remote.err = nil
+	now = now.Add(time.Second)
+	if _, err := geocoder.Geocode(ctx, "Невский пр., 1"); err != nil || remote.calls != 6 {
+		t.Fatalf("Geocode() after failure error = %v after %d calls, want retry as call 6", err, remote.calls)
+	}
+}
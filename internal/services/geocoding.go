@@ -0,0 +1,248 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrAddressNotFound     = errors.New("address not found")
+	ErrGeocoderRateLimited = errors.New("too many geocoding requests, try again later")
+)
+
+// Geocoder преобразует почтовый адрес в координаты
+type Geocoder interface {
+	Geocode(ctx context.Context, address string) (*models.GeoPoint, error)
+}
+
+// NewGeocoderFromEnv создает геокодер по переменной окружения GEOCODER.
+// "fixture" использует офлайн-справочник из файла GEOCODER_FIXTURES, "nominatim" — сервер из GEOCODER_URL
+// с кэшем и ограничением частоты запросов. Без GEOCODER выбирается Nominatim, только если задан GEOCODER_URL,
+// чтобы публичные маршруты не обращались к внешнему сервису без явной настройки.
+func NewGeocoderFromEnv() (Geocoder, error) {
+	driver, baseURL := os.Getenv("GEOCODER"), os.Getenv("GEOCODER_URL")
+	if driver == "" {
+		driver = "fixture"
+		if baseURL != "" {
+			driver = "nominatim"
+		}
+	}
+
+	switch driver {
+	case "fixture":
+		path := os.Getenv("GEOCODER_FIXTURES")
+		if path == "" {
+			return NewFixtureGeocoder(nil), nil
+		}
+		return LoadFixtureGeocoder(path)
+	case "nominatim":
+		if baseURL == "" {
+			return nil, errors.New("GEOCODER_URL is required for the nominatim geocoder")
+		}
+		return NewCachingGeocoder(
+			NewNominatimGeocoder(baseURL),
+			envInt("GEOCODER_CACHE_SIZE", 1000),
+			time.Duration(envInt("GEOCODER_MIN_INTERVAL_MS", 1000))*time.Millisecond,
+		), nil
+	default:
+		return nil, errors.Errorf("unknown geocoder: %s", driver)
+	}
+}
+
+// FixtureGeocoder офлайн-геокодер по заранее известным адресам, используется в тестах и локально
+type FixtureGeocoder struct {
+	points map[string]models.GeoPoint
+}
+
+// NewFixtureGeocoder создает геокодер из справочника адрес -> точка
+func NewFixtureGeocoder(points map[string]models.GeoPoint) *FixtureGeocoder {
+	normalized := make(map[string]models.GeoPoint, len(points))
+	for address, point := range points {
+		normalized[normalizeAddress(address)] = point
+	}
+	return &FixtureGeocoder{points: normalized}
+}
+
+// LoadFixtureGeocoder загружает справочник из JSON-файла вида {"адрес": {"lat": 0, "lng": 0}}
+func LoadFixtureGeocoder(path string) (*FixtureGeocoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening geocoder fixtures failed")
+	}
+	defer file.Close()
+
+	var raw map[string]struct {
+		Lat float64 `json:"lat"`
+		Lng float64 `json:"lng"`
+	}
+	if err := json.NewDecoder(file).Decode(&raw); err != nil {
+		return nil, errors.Wrap(err, "decoding geocoder fixtures failed")
+	}
+
+	points := make(map[string]models.GeoPoint, len(raw))
+	for address, p := range raw {
+		points[address] = models.NewGeoPoint(p.Lat, p.Lng)
+	}
+	return NewFixtureGeocoder(points), nil
+}
+
+// Geocode возвращает координаты адреса из справочника
+func (g *FixtureGeocoder) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	point, ok := g.points[normalizeAddress(address)]
+	if !ok {
+		return nil, ErrAddressNotFound
+	}
+	return &point, nil
+}
+
+// NominatimGeocoder геокодер на основе API OpenStreetMap Nominatim
+type NominatimGeocoder struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewNominatimGeocoder создает геокодер Nominatim для сервера baseURL
+func NewNominatimGeocoder(baseURL string) *NominatimGeocoder {
+	return &NominatimGeocoder{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Geocode запрашивает координаты адреса у Nominatim
+func (g *NominatimGeocoder) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	query := url.Values{"q": {address}, "format": {"json"}, "limit": {"1"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/search?"+query.Encode(), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "building geocoding request failed")
+	}
+	req.Header.Set("User-Agent", "food&friends")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "geocoding request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("geocoding request failed with status %d", resp.StatusCode)
+	}
+
+	var results []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, errors.Wrap(err, "decoding geocoding response failed")
+	}
+	if len(results) == 0 {
+		return nil, ErrAddressNotFound
+	}
+
+	lat, err := strconv.ParseFloat(results[0].Lat, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid latitude in geocoding response")
+	}
+	lng, err := strconv.ParseFloat(results[0].Lon, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid longitude in geocoding response")
+	}
+	point := models.NewGeoPoint(lat, lng)
+	return &point, nil
+}
+
+// CachingGeocoder кэширует ответы другого геокодера в LRU-кэше и ограничивает частоту обращений к нему.
+// Отсутствие адреса тоже кэшируется, чтобы повторные запросы несуществующих адресов не доходили до сервера.
+type CachingGeocoder struct {
+	next        Geocoder
+	size        int
+	minInterval time.Duration
+	now         func() time.Time
+
+	mu          sync.Mutex
+	entries     map[string]*list.Element
+	order       *list.List // от недавно использованных к давно использованным
+	nextAllowed time.Time
+}
+
+// geocodeCacheEntry запись кэша геокодера; point == nil означает, что адрес не найден
+type geocodeCacheEntry struct {
+	address string
+	point   *models.GeoPoint
+}
+
+// NewCachingGeocoder создает кэширующий геокодер на size адресов, пропускающий
+// к next не больше одного запроса за minInterval
+func NewCachingGeocoder(next Geocoder, size int, minInterval time.Duration) *CachingGeocoder {
+	return &CachingGeocoder{
+		next:        next,
+		size:        max(size, 1),
+		minInterval: minInterval,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+	}
+}
+
+// Geocode возвращает координаты из кэша либо запрашивает их у next, если лимит запросов не исчерпан
+func (g *CachingGeocoder) Geocode(ctx context.Context, address string) (*models.GeoPoint, error) {
+	key := normalizeAddress(address)
+
+	g.mu.Lock()
+	if elem, ok := g.entries[key]; ok {
+		g.order.MoveToFront(elem)
+		entry := elem.Value.(*geocodeCacheEntry)
+		g.mu.Unlock()
+		if entry.point == nil {
+			return nil, ErrAddressNotFound
+		}
+		point := *entry.point
+		return &point, nil
+	}
+	now := g.now()
+	if now.Before(g.nextAllowed) {
+		g.mu.Unlock()
+		return nil, ErrGeocoderRateLimited
+	}
+	g.nextAllowed = now.Add(g.minInterval)
+	g.mu.Unlock()
+
+	point, err := g.next.Geocode(ctx, address)
+	if err != nil && errors.Cause(err) != ErrAddressNotFound {
+		return nil, err // временные ошибки не кэшируются
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if elem, ok := g.entries[key]; ok {
+		g.order.MoveToFront(elem)
+		elem.Value.(*geocodeCacheEntry).point = point
+	} else {
+		g.entries[key] = g.order.PushFront(&geocodeCacheEntry{address: key, point: point})
+		if g.order.Len() > g.size {
+			oldest := g.order.Back()
+			g.order.Remove(oldest)
+			delete(g.entries, oldest.Value.(*geocodeCacheEntry).address)
+		}
+	}
+	if point == nil {
+		return nil, err
+	}
+	cached := *point
+	return &cached, nil
+}
+
+// normalizeAddress приводит адрес к виду для сравнения
+func normalizeAddress(address string) string {
+	return strings.Join(strings.Fields(strings.ToLower(address)), " ")
+}
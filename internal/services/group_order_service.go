@@ -0,0 +1,532 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/base64"
+	"log"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const groupOrdersCollectionName = "group_orders"
+
+// Ограничения группового заказа
+const (
+	MaxGroupOrderParticipants = 20
+	MaxGroupOrderItemQuantity = 50
+)
+
+// NotificationGroupOrderSubmitted тип уведомления об отправке группового заказа
+const NotificationGroupOrderSubmitted = "group_order_submitted"
+
+var (
+	ErrGroupOrderNotFound     = errors.New("group order not found")
+	ErrGroupOrderForbidden    = errors.New("only the host can do this")
+	ErrGroupOrderNotOpen      = errors.New("group order is not open for changes")
+	ErrGroupOrderNotLocked    = errors.New("group order must be locked first")
+	ErrGroupOrderNotSubmitted = errors.New("group order is not submitted yet")
+	ErrGroupOrderFull         = errors.New("group order has too many participants")
+	ErrGroupOrderEmpty        = errors.New("group order has no items")
+	ErrAlreadyParticipant     = errors.New("user already joined this group order")
+	ErrHostCannotLeave        = errors.New("host cannot leave, cancel the order instead")
+	ErrInvalidOrderItem       = errors.New("unknown menu item or invalid quantity")
+	ErrInvalidSplitMode       = errors.New("split mode must be by_item, even or custom")
+	ErrSplitMismatch          = errors.New("custom shares must add up to the order total")
+	ErrShareAlreadyPaid       = errors.New("share is already paid")
+)
+
+// GroupOrderService структура сервиса групповых заказов
+type GroupOrderService struct {
+	db                  *mongo.Database
+	notificationService *NotificationService
+	eventBus            *EventBus
+}
+
+// NewGroupOrderService создает новый экземпляр GroupOrderService
+func NewGroupOrderService(client *mongo.Client, dbName string, notificationService *NotificationService, eventBus *EventBus) *GroupOrderService {
+	return &GroupOrderService{
+		db:                  client.Database(dbName),
+		notificationService: notificationService,
+		eventBus:            eventBus,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции групповых заказов
+func (s *GroupOrderService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(groupOrdersCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "join_code", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "participants.user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating group order indexes failed")
+	}
+	return nil
+}
+
+// CreateGroupOrder создает групповой заказ, хозяин становится первым участником
+func (s *GroupOrderService) CreateGroupOrder(ctx context.Context, hostID, restaurantID primitive.ObjectID, splitMode string) (*models.GroupOrder, error) {
+	if splitMode == "" {
+		splitMode = models.SplitByItem
+	}
+	if !validSplitMode(splitMode) || splitMode == models.SplitCustom {
+		return nil, ErrInvalidSplitMode
+	}
+	if err := ensureRestaurantExists(ctx, s.db, restaurantID); err != nil {
+		return nil, err
+	}
+
+	code, err := GenerateRandomSecret(12)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating join code failed")
+	}
+
+	now := time.Now()
+	order := &models.GroupOrder{
+		ID:           primitive.NewObjectID(),
+		HostID:       hostID,
+		RestaurantID: restaurantID,
+		JoinCode:     base64.RawURLEncoding.EncodeToString(code),
+		Status:       models.GroupOrderStatusOpen,
+		SplitMode:    splitMode,
+		Participants: []models.GroupOrderParticipant{{UserID: hostID, Items: []models.GroupOrderItem{}, JoinedAt: now}},
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	if _, err := s.db.Collection(groupOrdersCollectionName).InsertOne(ctx, order); err != nil {
+		return nil, errors.Wrap(err, "inserting group order failed")
+	}
+	return s.present(order, hostID), nil
+}
+
+// GetGroupOrder возвращает групповой заказ участнику
+func (s *GroupOrderService) GetGroupOrder(ctx context.Context, userID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	order, err := s.findOrder(ctx, bson.M{"_id": orderID})
+	if err != nil {
+		return nil, err
+	}
+	if order.Participant(userID) == nil {
+		return nil, ErrGroupOrderNotFound
+	}
+	return s.present(order, userID), nil
+}
+
+// Join добавляет пользователя в открытый групповой заказ по коду приглашения
+func (s *GroupOrderService) Join(ctx context.Context, userID primitive.ObjectID, joinCode string) (*models.GroupOrder, error) {
+	order, err := s.findOrder(ctx, bson.M{"join_code": joinCode})
+	if err != nil {
+		return nil, err
+	}
+	if order.Participant(userID) != nil {
+		return nil, ErrAlreadyParticipant
+	}
+	if order.Status != models.GroupOrderStatusOpen {
+		return nil, ErrGroupOrderNotOpen
+	}
+
+	participant := models.GroupOrderParticipant{UserID: userID, Items: []models.GroupOrderItem{}, JoinedAt: time.Now()}
+	return s.update(ctx, userID, bson.M{
+		"_id":                  order.ID,
+		"status":               models.GroupOrderStatusOpen,
+		"participants.user_id": bson.M{"$ne": userID},
+		"$expr":                bson.M{"$lt": bson.A{bson.M{"$size": "$participants"}, MaxGroupOrderParticipants}},
+	}, bson.M{
+		"$push": bson.M{"participants": participant},
+		"$set":  bson.M{"updated_at": participant.JoinedAt},
+	}, ErrGroupOrderFull)
+}
+
+// Leave удаляет участника из открытого заказа вместе с его блюдами
+func (s *GroupOrderService) Leave(ctx context.Context, userID, orderID primitive.ObjectID) error {
+	order, err := s.participantOrder(ctx, userID, orderID)
+	if err != nil {
+		return err
+	}
+	if order.HostID == userID {
+		return ErrHostCannotLeave
+	}
+
+	_, err = s.update(ctx, userID, bson.M{"_id": orderID, "status": models.GroupOrderStatusOpen}, bson.M{
+		"$pull":  bson.M{"participants": bson.M{"user_id": userID}},
+		"$unset": bson.M{"custom_shares." + userID.Hex(): ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}, ErrGroupOrderNotOpen)
+	return err
+}
+
+// SetItems заменяет блюда участника. Цены фиксируются по текущему меню ресторана.
+func (s *GroupOrderService) SetItems(ctx context.Context, userID, orderID primitive.ObjectID, items []models.OrderItem) (*models.GroupOrder, error) {
+	order, err := s.participantOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.GroupOrderStatusOpen {
+		return nil, ErrGroupOrderNotOpen
+	}
+
+	var restaurant struct {
+		Menu []models.MenuItem `bson:"menu"`
+	}
+	err = s.db.Collection(EntityTypeRestaurant).FindOne(ctx, bson.M{"_id": order.RestaurantID},
+		options.FindOne().SetProjection(bson.M{"menu": 1}),
+	).Decode(&restaurant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRestaurantNotFound
+		}
+		return nil, errors.Wrap(err, "finding restaurant menu failed")
+	}
+	menu := make(map[string]models.MenuItem, len(restaurant.Menu))
+	for _, item := range restaurant.Menu {
+		menu[item.ID] = item
+	}
+
+	priced := make([]models.GroupOrderItem, 0, len(items))
+	for _, item := range items {
+		menuItem, ok := menu[item.MenuItemID]
+		if !ok || item.Quantity < 1 || item.Quantity > MaxGroupOrderItemQuantity {
+			return nil, ErrInvalidOrderItem
+		}
+		priced = append(priced, models.GroupOrderItem{OrderItem: item, Name: menuItem.Name, Price: menuItem.Price})
+	}
+
+	return s.update(ctx, userID, bson.M{"_id": orderID, "status": models.GroupOrderStatusOpen}, bson.M{"$set": bson.M{
+		"participants.$[participant].items": priced,
+		"updated_at":                        time.Now(),
+	}}, ErrGroupOrderNotOpen, options.FindOneAndUpdate().SetArrayFilters(options.ArrayFilters{
+		Filters: []interface{}{bson.M{"participant.user_id": userID}},
+	}))
+}
+
+// Lock фиксирует состав заказа: участники больше не могут менять блюда
+func (s *GroupOrderService) Lock(ctx context.Context, hostID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	return s.transition(ctx, hostID, orderID, models.GroupOrderStatusOpen, models.GroupOrderStatusLocked, ErrGroupOrderNotOpen)
+}
+
+// Unlock снова открывает заказ для изменений
+func (s *GroupOrderService) Unlock(ctx context.Context, hostID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	return s.transition(ctx, hostID, orderID, models.GroupOrderStatusLocked, models.GroupOrderStatusOpen, ErrGroupOrderNotLocked)
+}
+
+// Cancel отменяет заказ, который еще не отправлен в ресторан
+func (s *GroupOrderService) Cancel(ctx context.Context, hostID, orderID primitive.ObjectID) error {
+	order, err := s.hostOrder(ctx, hostID, orderID)
+	if err != nil {
+		return err
+	}
+	_, err = s.update(ctx, hostID, bson.M{
+		"_id":    order.ID,
+		"status": bson.M{"$in": bson.A{models.GroupOrderStatusOpen, models.GroupOrderStatusLocked}},
+	}, bson.M{"$set": bson.M{"status": models.GroupOrderStatusCancelled, "updated_at": time.Now()}}, ErrGroupOrderNotOpen)
+	return err
+}
+
+// SetSplit задает способ разделения счета. Для custom передаются суммы по hex ID участников;
+// их сумма сверяется с итогом заказа при отправке.
+func (s *GroupOrderService) SetSplit(ctx context.Context, hostID, orderID primitive.ObjectID, mode string, customShares map[string]float64) (*models.GroupOrder, error) {
+	if !validSplitMode(mode) {
+		return nil, ErrInvalidSplitMode
+	}
+	order, err := s.hostOrder(ctx, hostID, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"split_mode": mode, "updated_at": time.Now()}}
+	if mode == models.SplitCustom {
+		for userHex, amount := range customShares {
+			userID, err := primitive.ObjectIDFromHex(userHex)
+			if err != nil || order.Participant(userID) == nil || amount < 0 {
+				return nil, ErrSplitMismatch
+			}
+		}
+		update["$set"].(bson.M)["custom_shares"] = customShares
+	} else {
+		update["$unset"] = bson.M{"custom_shares": ""}
+	}
+
+	return s.update(ctx, hostID, bson.M{
+		"_id":    orderID,
+		"status": bson.M{"$in": bson.A{models.GroupOrderStatusOpen, models.GroupOrderStatusLocked}},
+	}, update, ErrGroupOrderNotOpen)
+}
+
+// Submit отправляет зафиксированный заказ в ресторан и закрепляет доли участников
+func (s *GroupOrderService) Submit(ctx context.Context, hostID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	order, err := s.hostOrder(ctx, hostID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.GroupOrderStatusLocked {
+		return nil, ErrGroupOrderNotLocked
+	}
+
+	total, shares, err := computeSplit(order)
+	if err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, ErrGroupOrderEmpty
+	}
+
+	// Смена статуса, заказы у ресторана и участников и событие order.placed сохраняются вместе
+	now := time.Now()
+	var submitted *models.GroupOrder
+	err = s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		submitted, err = s.update(ctx, hostID, bson.M{"_id": orderID, "status": models.GroupOrderStatusLocked}, bson.M{"$set": bson.M{
+			"status":       models.GroupOrderStatusSubmitted,
+			"total":        total,
+			"shares":       shares,
+			"order_id":     orderID.Hex(),
+			"submitted_at": now,
+			"updated_at":   now,
+		}}, ErrGroupOrderNotLocked)
+		if err != nil {
+			return err
+		}
+		return s.placeOrders(ctx, submitted, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var participantIDs []primitive.ObjectID
+	for _, participant := range submitted.Participants {
+		if participant.UserID != hostID {
+			participantIDs = append(participantIDs, participant.UserID)
+		}
+	}
+	err = s.notificationService.Notify(ctx, participantIDs, NotificationGroupOrderSubmitted, "Групповой заказ отправлен в ресторан",
+		map[string]string{"group_order_id": orderID.Hex()})
+	if err != nil {
+		log.Printf("Failed to send %s notifications: %v", NotificationGroupOrderSubmitted, err)
+	}
+	return submitted, nil
+}
+
+// MarkPaid отмечает долю участника оплаченной. Участник отмечает свою долю,
+// хозяин может отметить долю любого участника (например, при оплате наличными).
+func (s *GroupOrderService) MarkPaid(ctx context.Context, actorID, orderID, userID primitive.ObjectID) (*models.GroupOrder, error) {
+	order, err := s.participantOrder(ctx, actorID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if actorID != userID && order.HostID != actorID {
+		return nil, ErrGroupOrderForbidden
+	}
+	if order.Status != models.GroupOrderStatusSubmitted {
+		return nil, ErrGroupOrderNotSubmitted
+	}
+
+	now := time.Now()
+	return s.update(ctx, actorID, bson.M{
+		"_id":    orderID,
+		"shares": bson.M{"$elemMatch": bson.M{"user_id": userID, "payment_status": models.PaymentStatusUnpaid}},
+	}, bson.M{"$set": bson.M{
+		"shares.$.payment_status": models.PaymentStatusPaid,
+		"shares.$.paid_at":        now,
+		"updated_at":              now,
+	}}, ErrShareAlreadyPaid)
+}
+
+// placeOrders сохраняет заказ у ресторана и копии с собственными блюдами у каждого участника,
+// чтобы участники могли оставить отзыв после доставки, и записывает событие order.placed
+func (s *GroupOrderService) placeOrders(ctx context.Context, order *models.GroupOrder, now time.Time) error {
+	restaurantOrder := models.Order{
+		ID:           order.OrderID,
+		UserID:       order.HostID.Hex(),
+		RestaurantID: order.RestaurantID.Hex(),
+		Items:        []models.OrderItem{},
+		Status:       models.OrderStatusPending,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	for _, participant := range order.Participants {
+		for _, item := range participant.Items {
+			restaurantOrder.Items = append(restaurantOrder.Items, item.OrderItem)
+		}
+	}
+	_, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, order.RestaurantID,
+		bson.M{"$push": bson.M{"orders": restaurantOrder}})
+	if err != nil {
+		return errors.Wrap(err, "placing restaurant order failed")
+	}
+
+	for _, participant := range order.Participants {
+		userOrder := restaurantOrder
+		userOrder.UserID = participant.UserID.Hex()
+		userOrder.Items = make([]models.OrderItem, 0, len(participant.Items))
+		for _, item := range participant.Items {
+			userOrder.Items = append(userOrder.Items, item.OrderItem)
+		}
+		_, err := s.db.Collection(EntityTypeUser).UpdateByID(ctx, participant.UserID,
+			bson.M{"$push": bson.M{"orders": userOrder}})
+		if err != nil {
+			return errors.Wrap(err, "placing participant order failed")
+		}
+	}
+
+	participants := make([]primitive.ObjectID, 0, len(order.Participants))
+	for _, participant := range order.Participants {
+		participants = append(participants, participant.UserID)
+	}
+	return s.eventBus.Record(ctx, models.DomainEventOrderPlaced, restaurantOrder.ID, models.OrderPlacedPayload{
+		OrderID:      restaurantOrder.ID,
+		RestaurantID: order.RestaurantID,
+		UserIDs:      participants,
+		GroupOrderID: &order.ID,
+	})
+}
+
+// transition переводит заказ хозяина из одного статуса в другой
+func (s *GroupOrderService) transition(ctx context.Context, hostID, orderID primitive.ObjectID, from, to string, notAllowed error) (*models.GroupOrder, error) {
+	if _, err := s.hostOrder(ctx, hostID, orderID); err != nil {
+		return nil, err
+	}
+	return s.update(ctx, hostID, bson.M{"_id": orderID, "status": from},
+		bson.M{"$set": bson.M{"status": to, "updated_at": time.Now()}}, notAllowed)
+}
+
+// update атомарно изменяет заказ, подходящий под фильтр, и возвращает новую версию.
+// Если заказ не подошел под фильтр, возвращается notMatched.
+func (s *GroupOrderService) update(ctx context.Context, viewerID primitive.ObjectID, filter, update bson.M, notMatched error, opts ...*options.FindOneAndUpdateOptions) (*models.GroupOrder, error) {
+	opts = append(opts, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	var order models.GroupOrder
+	err := s.db.Collection(groupOrdersCollectionName).FindOneAndUpdate(ctx, filter, update, opts...).Decode(&order)
+	if err == mongo.ErrNoDocuments {
+		return nil, notMatched
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating group order failed")
+	}
+	return s.present(&order, viewerID), nil
+}
+
+// findOrder возвращает групповой заказ по фильтру
+func (s *GroupOrderService) findOrder(ctx context.Context, filter bson.M) (*models.GroupOrder, error) {
+	var order models.GroupOrder
+	err := s.db.Collection(groupOrdersCollectionName).FindOne(ctx, filter).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrGroupOrderNotFound
+		}
+		return nil, errors.Wrap(err, "finding group order failed")
+	}
+	return &order, nil
+}
+
+// participantOrder возвращает заказ, если пользователь является его участником
+func (s *GroupOrderService) participantOrder(ctx context.Context, userID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	order, err := s.findOrder(ctx, bson.M{"_id": orderID})
+	if err != nil {
+		return nil, err
+	}
+	if order.Participant(userID) == nil {
+		return nil, ErrGroupOrderNotFound
+	}
+	return order, nil
+}
+
+// hostOrder возвращает заказ, если пользователь является его хозяином
+func (s *GroupOrderService) hostOrder(ctx context.Context, hostID, orderID primitive.ObjectID) (*models.GroupOrder, error) {
+	order, err := s.participantOrder(ctx, hostID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.HostID != hostID {
+		return nil, ErrGroupOrderForbidden
+	}
+	return order, nil
+}
+
+// present рассчитывает предварительные доли для неотправленного заказа
+// и скрывает код приглашения от всех, кроме хозяина
+func (s *GroupOrderService) present(order *models.GroupOrder, viewerID primitive.ObjectID) *models.GroupOrder {
+	if order.HostID != viewerID {
+		order.JoinCode = ""
+	}
+	if order.Status == models.GroupOrderStatusOpen || order.Status == models.GroupOrderStatusLocked {
+		// Для custom-разделения с несовпадающей суммой возвращаются доли по блюдам
+		total, shares, err := computeSplit(order)
+		if err != nil {
+			preview := *order
+			preview.SplitMode = models.SplitByItem
+			total, shares, _ = computeSplit(&preview)
+		}
+		order.Total, order.Shares = total, shares
+	}
+	return order
+}
+
+// computeSplit рассчитывает итог заказа и доли участников.
+// Расчет ведется в копейках; остаток от деления поровну достается первым участникам.
+func computeSplit(order *models.GroupOrder) (float64, []models.ParticipantShare, error) {
+	subtotals := make([]int64, len(order.Participants))
+	var total int64
+	for i, participant := range order.Participants {
+		for _, item := range participant.Items {
+			subtotals[i] += toCents(item.Price) * int64(item.Quantity)
+		}
+		total += subtotals[i]
+	}
+
+	amounts := make([]int64, len(order.Participants))
+	switch order.SplitMode {
+	case models.SplitEvenly:
+		count := int64(len(order.Participants))
+		for i := range amounts {
+			amounts[i] = total / count
+			if int64(i) < total%count {
+				amounts[i]++
+			}
+		}
+	case models.SplitCustom:
+		var sum int64
+		for i, participant := range order.Participants {
+			amounts[i] = toCents(order.CustomShares[participant.UserID.Hex()])
+			sum += amounts[i]
+		}
+		if sum != total {
+			return 0, nil, ErrSplitMismatch
+		}
+	default:
+		copy(amounts, subtotals)
+	}
+
+	shares := make([]models.ParticipantShare, 0, len(order.Participants))
+	for i, participant := range order.Participants {
+		shares = append(shares, models.ParticipantShare{
+			UserID:        participant.UserID,
+			Subtotal:      fromCents(subtotals[i]),
+			Amount:        fromCents(amounts[i]),
+			PaymentStatus: models.PaymentStatusUnpaid,
+		})
+	}
+	return fromCents(total), shares, nil
+}
+
+// validSplitMode проверяет способ разделения счета
+func validSplitMode(mode string) bool {
+	switch mode {
+	case models.SplitByItem, models.SplitEvenly, models.SplitCustom:
+		return true
+	}
+	return false
+}
+
+// toCents переводит сумму в копейки
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// fromCents переводит копейки в сумму
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
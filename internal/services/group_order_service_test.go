@@ -0,0 +1,145 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestComputeSplit(t *testing.T) {
+	u1, u2, u3 := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	item := func(price float64, quantity int) models.GroupOrderItem {
+		return models.GroupOrderItem{OrderItem: models.OrderItem{Quantity: quantity}, Price: price}
+	}
+	participants := []models.GroupOrderParticipant{
+		{UserID: u1, Items: []models.GroupOrderItem{item(0.1, 3), item(33.33, 1)}},
+		{UserID: u2, Items: []models.GroupOrderItem{item(0.2, 1), item(19.99, 2)}},
+		{UserID: u3, Items: []models.GroupOrderItem{item(26.08, 1)}},
+	}
+	// Итого: 33.63 + 40.18 + 26.08 = 99.89
+
+	tests := []struct {
+		name          string
+		mode          string
+		participants  []models.GroupOrderParticipant
+		customShares  map[string]float64
+		wantTotal     float64
+		wantSubtotals []float64
+		wantAmounts   []float64
+		wantErr       error
+	}{
+		{
+			name:          "by item",
+			mode:          models.SplitByItem,
+			participants:  participants,
+			wantTotal:     99.89,
+			wantSubtotals: []float64{33.63, 40.18, 26.08},
+			wantAmounts:   []float64{33.63, 40.18, 26.08},
+		},
+		{
+			name:          "evenly gives remainder cents to first participants",
+			mode:          models.SplitEvenly,
+			participants:  participants,
+			wantTotal:     99.89,
+			wantSubtotals: []float64{33.63, 40.18, 26.08},
+			wantAmounts:   []float64{33.30, 33.30, 33.29},
+		},
+		{
+			name: "evenly without float drift",
+			mode: models.SplitEvenly,
+			participants: []models.GroupOrderParticipant{
+				{UserID: u1, Items: []models.GroupOrderItem{item(0.1, 1), item(0.2, 1)}},
+				{UserID: u2},
+				{UserID: u3},
+			},
+			wantTotal:     0.3,
+			wantSubtotals: []float64{0.3, 0, 0},
+			wantAmounts:   []float64{0.1, 0.1, 0.1},
+		},
+		{
+			name:         "custom matching total",
+			mode:         models.SplitCustom,
+			participants: participants,
+			customShares: map[string]float64{
+				u1.Hex(): 50.5, u2.Hex(): 49.19, u3.Hex(): 0.2,
+			},
+			wantTotal:     99.89,
+			wantSubtotals: []float64{33.63, 40.18, 26.08},
+			wantAmounts:   []float64{50.5, 49.19, 0.2},
+		},
+		{
+			name:         "custom off by one cent",
+			mode:         models.SplitCustom,
+			participants: participants,
+			customShares: map[string]float64{
+				u1.Hex(): 50.5, u2.Hex(): 49.19, u3.Hex(): 0.19,
+			},
+			wantErr: ErrSplitMismatch,
+		},
+		{
+			name:         "custom missing participant pays nothing",
+			mode:         models.SplitCustom,
+			participants: participants,
+			customShares: map[string]float64{
+				u1.Hex(): 50, u2.Hex(): 49.89,
+			},
+			wantTotal:     99.89,
+			wantSubtotals: []float64{33.63, 40.18, 26.08},
+			wantAmounts:   []float64{50, 49.89, 0},
+		},
+		{
+			name:         "custom share for outsider is ignored",
+			mode:         models.SplitCustom,
+			participants: participants,
+			customShares: map[string]float64{
+				u1.Hex(): 99.89, primitive.NewObjectID().Hex(): 10,
+			},
+			wantTotal:     99.89,
+			wantSubtotals: []float64{33.63, 40.18, 26.08},
+			wantAmounts:   []float64{99.89, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := &models.GroupOrder{SplitMode: tt.mode, Participants: tt.participants, CustomShares: tt.customShares}
+			total, shares, err := computeSplit(order)
+			if tt.wantErr != nil {
+				if err != tt.wantErr {
+					t.Fatalf("computeSplit() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("computeSplit() unexpected error: %v", err)
+			}
+			if total != tt.wantTotal {
+				t.Errorf("total = %v, want %v", total, tt.wantTotal)
+			}
+			if len(shares) != len(tt.participants) {
+				t.Fatalf("shares = %d, want %d", len(shares), len(tt.participants))
+			}
+
+			var paid int64
+			for i, share := range shares {
+				if share.UserID != tt.participants[i].UserID {
+					t.Errorf("shares[%d].UserID = %v, want %v", i, share.UserID, tt.participants[i].UserID)
+				}
+				if share.Subtotal != tt.wantSubtotals[i] {
+					t.Errorf("shares[%d].Subtotal = %v, want %v", i, share.Subtotal, tt.wantSubtotals[i])
+				}
+				if share.Amount != tt.wantAmounts[i] {
+					t.Errorf("shares[%d].Amount = %v, want %v", i, share.Amount, tt.wantAmounts[i])
+				}
+				if share.PaymentStatus != models.PaymentStatusUnpaid {
+					t.Errorf("shares[%d].PaymentStatus = %q, want %q", i, share.PaymentStatus, models.PaymentStatusUnpaid)
+				}
+				paid += toCents(share.Amount)
+			}
+			if paid != toCents(total) {
+				t.Errorf("shares sum to %d cents, want %d", paid, toCents(total))
+			}
+		})
+	}
+}
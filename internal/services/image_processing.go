@@ -0,0 +1,315 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	_ "image/gif" // регистрация декодера GIF
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image format, expected jpeg, png or gif")
+	ErrInvalidImage     = errors.New("image is corrupted or cannot be decoded")
+	ErrImageTooLarge    = errors.New("image is too large")
+)
+
+// maxImagePixels ограничивает размер декодируемого изображения, чтобы маленький файл
+// с огромными заявленными размерами не исчерпал память. 24 Мп (6000x4000) хватает для
+// снимков с телефона, при этом одна обработка держит в памяти около 200 МБ: декодированное
+// изображение, его копию в RGBA и повернутую копию.
+const maxImagePixels = 24_000_000
+
+// imageVariantSpec описывает один размер, в который пережимается загруженное изображение
+type imageVariantSpec struct {
+	Name    string
+	MaxSize int  // наибольшая сторона
+	Square  bool // обрезать по центру до квадрата
+}
+
+// processedImage результат обработки одного варианта
+type processedImage struct {
+	Spec        imageVariantSpec
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// sniffImageType определяет тип изображения по содержимому, а не по заявленному клиентом заголовку
+func sniffImageType(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, nil
+	default:
+		return "", ErrUnsupportedImage
+	}
+}
+
+// processImage декодирует изображение, поворачивает его по EXIF и пережимает в указанные размеры.
+// Повторное кодирование отбрасывает EXIF и прочие метаданные исходного файла, включая геолокацию.
+func processImage(data []byte, specs []imageVariantSpec) ([]processedImage, error) {
+	contentType, err := sniffImageType(data)
+	if err != nil {
+		return nil, err
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	src := toRGBA(decoded)
+	if contentType == "image/jpeg" {
+		src = applyOrientation(src, jpegOrientation(data))
+	}
+	// Прозрачность сохраняется только в PNG, остальное кодируется в JPEG
+	opaque := src.Opaque()
+
+	result := make([]processedImage, 0, len(specs))
+	for _, spec := range specs {
+		variant := src
+		if spec.Square {
+			variant = cropSquare(variant)
+		}
+		width, height := fitSize(variant.Bounds().Dx(), variant.Bounds().Dy(), spec.MaxSize)
+		variant = resizeBox(variant, width, height)
+
+		var buf bytes.Buffer
+		processed := processedImage{Spec: spec, Width: width, Height: height}
+		if opaque {
+			processed.ContentType = "image/jpeg"
+			err = jpeg.Encode(&buf, variant, &jpeg.Options{Quality: 85})
+		} else {
+			processed.ContentType = "image/png"
+			err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, variant)
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "encoding image failed")
+		}
+		processed.Data = buf.Bytes()
+		result = append(result, processed)
+	}
+	return result, nil
+}
+
+// toRGBA приводит изображение к RGBA с началом координат в нуле
+func toRGBA(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Src)
+	return dst
+}
+
+// fitSize вписывает размеры в квадрат maxSize, не увеличивая изображение
+func fitSize(width, height, maxSize int) (int, int) {
+	if width <= maxSize && height <= maxSize {
+		return width, height
+	}
+	if width >= height {
+		return maxSize, max(1, height*maxSize/width)
+	}
+	return max(1, width*maxSize/height), maxSize
+}
+
+// cropSquare вырезает центральный квадрат
+func cropSquare(src *image.RGBA) *image.RGBA {
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	if width == height {
+		return src
+	}
+	size := min(width, height)
+	x0, y0 := (width-size)/2, (height-size)/2
+	return toRGBA(src.SubImage(image.Rect(x0, y0, x0+size, y0+size)))
+}
+
+// resizeBox уменьшает изображение усреднением по площади: каждый пиксель результата
+// равен среднему покрываемых им пикселей источника с учетом частичного покрытия.
+// Каналы RGBA хранятся с предумноженной альфой, поэтому усреднение корректно и для прозрачности.
+func resizeBox(src *image.RGBA, width, height int) *image.RGBA {
+	srcW, srcH := src.Bounds().Dx(), src.Bounds().Dy()
+	if srcW == width && srcH == height {
+		return src
+	}
+	xWeights := boxWeights(srcW, width)
+	yWeights := boxWeights(srcH, height)
+
+	// Проход по горизонтали: srcH строк по width пикселей
+	tmp := make([]float64, srcH*width*4)
+	for y := 0; y < srcH; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, weights := range xWeights {
+			var acc [4]float64
+			for _, w := range weights {
+				p := row[w.index*4:]
+				acc[0] += float64(p[0]) * w.weight
+				acc[1] += float64(p[1]) * w.weight
+				acc[2] += float64(p[2]) * w.weight
+				acc[3] += float64(p[3]) * w.weight
+			}
+			copy(tmp[(y*width+x)*4:], acc[:])
+		}
+	}
+
+	// Проход по вертикали
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, weights := range yWeights {
+		for x := 0; x < width; x++ {
+			var acc [4]float64
+			for _, w := range weights {
+				p := tmp[(w.index*width+x)*4:]
+				acc[0] += p[0] * w.weight
+				acc[1] += p[1] * w.weight
+				acc[2] += p[2] * w.weight
+				acc[3] += p[3] * w.weight
+			}
+			out := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 4; c++ {
+				out[c] = clampUint8(acc[c])
+			}
+		}
+	}
+	return dst
+}
+
+type boxWeight struct {
+	index  int
+	weight float64
+}
+
+// boxWeights вычисляет для каждого пикселя результата покрываемые пиксели источника и их доли
+func boxWeights(srcSize, dstSize int) [][]boxWeight {
+	scale := float64(srcSize) / float64(dstSize)
+	weights := make([][]boxWeight, dstSize)
+	for i := range weights {
+		start, end := float64(i)*scale, float64(i+1)*scale
+		for j := int(start); j < srcSize && float64(j) < end; j++ {
+			overlap := min(end, float64(j+1)) - max(start, float64(j))
+			if overlap > 0 {
+				weights[i] = append(weights[i], boxWeight{index: j, weight: overlap / scale})
+			}
+		}
+	}
+	return weights
+}
+
+func clampUint8(value float64) uint8 {
+	switch {
+	case value <= 0:
+		return 0
+	case value >= 255:
+		return 255
+	default:
+		return uint8(value + 0.5)
+	}
+}
+
+// applyOrientation поворачивает и отражает изображение согласно тегу EXIF Orientation (1-8),
+// так как после удаления метаданных клиенты уже не смогут сделать это сами
+func applyOrientation(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstW, dstH := width, height
+	if orientation >= 5 {
+		dstW, dstH = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // отражение по горизонтали
+				sx, sy = width-1-x, y
+			case 3: // поворот на 180°
+				sx, sy = width-1-x, height-1-y
+			case 4: // отражение по вертикали
+				sx, sy = x, height-1-y
+			case 5: // транспонирование
+				sx, sy = y, x
+			case 6: // поворот на 90° по часовой стрелке
+				sx, sy = y, height-1-x
+			case 7: // транспонирование относительно побочной диагонали
+				sx, sy = width-1-y, height-1-x
+			case 8: // поворот на 90° против часовой стрелки
+				sx, sy = width-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// jpegOrientation читает тег Orientation из сегмента APP1 Exif файла JPEG; 1 - если тега нет
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // начало данных изображения или конец файла
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && len(segment) >= 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation ищет тег 0x0112 в первом IFD заголовка TIFF
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	if order.Uint16(tiff[2:]) != 42 {
+		return 1
+	}
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		// Тип 3 - SHORT, значение лежит в первых двух байтах поля значения
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			return int(order.Uint16(tiff[entry+8:]))
+		}
+	}
+	return 1
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// exifSegment собирает сегмент APP1 с заголовком TIFF и первым IFD из переданных записей
+func exifSegment(order binary.AppendByteOrder, entries ...[3]uint16) []byte {
+	tiff := []byte("MM")
+	if order == binary.LittleEndian {
+		tiff = []byte("II")
+	}
+	tiff = order.AppendUint16(tiff, 42)
+	tiff = order.AppendUint32(tiff, 8)
+	tiff = order.AppendUint16(tiff, uint16(len(entries)))
+	for _, entry := range entries {
+		// тег, тип, количество, значение
+		tiff = order.AppendUint16(tiff, entry[0])
+		tiff = order.AppendUint16(tiff, entry[1])
+		tiff = order.AppendUint32(tiff, 1)
+		tiff = order.AppendUint16(tiff, entry[2])
+		tiff = order.AppendUint16(tiff, 0)
+	}
+	tiff = order.AppendUint32(tiff, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withSegments вставляет сегменты сразу после маркера SOI файла JPEG
+func withSegments(jpegData []byte, segments ...[]byte) []byte {
+	result := append([]byte{}, jpegData[:2]...)
+	for _, segment := range segments {
+		result = append(result, segment...)
+	}
+	return append(result, jpegData[2:]...)
+}
+
+func encodeTestJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeTestJPEG(t, 4, 2)
+	jfif := []byte{0xFF, 0xE0, 0x00, 0x07, 'J', 'F', 'I', 'F', 0x00}
+	orientation := func(value uint16) [3]uint16 { return [3]uint16{0x0112, 3, value} }
+	exif := exifSegment(binary.BigEndian, orientation(6))
+	truncated := withSegments(plain, exif)[:2+len(exif)-1] // файл обрывается внутри сегмента
+	badMagic := exifSegment(binary.BigEndian, orientation(6))
+	badMagic[4+6+3] = 43
+	badOffset := exifSegment(binary.LittleEndian, orientation(6))
+	binary.LittleEndian.PutUint32(badOffset[4+6+4:], 1000)
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{name: "big endian", data: withSegments(plain, exif), want: 6},
+		{name: "little endian", data: withSegments(plain, exifSegment(binary.LittleEndian, orientation(8))), want: 8},
+		{name: "after other tags", data: withSegments(plain, exifSegment(binary.BigEndian, [3]uint16{0x010F, 2, 0}, orientation(3))), want: 3},
+		{name: "after JFIF segment", data: withSegments(plain, jfif, exifSegment(binary.LittleEndian, orientation(5))), want: 5},
+		{name: "no exif", data: plain, want: 1},
+		{name: "no orientation tag", data: withSegments(plain, exifSegment(binary.BigEndian, [3]uint16{0x010F, 2, 0})), want: 1},
+		{name: "orientation of wrong type", data: withSegments(plain, exifSegment(binary.BigEndian, [3]uint16{0x0112, 4, 6})), want: 1},
+		{name: "truncated segment", data: truncated, want: 1},
+		{name: "bad tiff magic", data: withSegments(plain, badMagic), want: 1},
+		{name: "ifd offset out of range", data: withSegments(plain, badOffset), want: 1},
+		{name: "not a jpeg", data: []byte("\x89PNG\r\n\x1a\n"), want: 1},
+		{name: "empty", data: nil, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.data); got != tt.want {
+				t.Errorf("jpegOrientation() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// Исходное изображение 3x2, пиксели помечены буквами в красном канале:
+	//   a b c
+	//   d e f
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i, label := range "abcdef" {
+		src.Set(i%3, i/3, color.RGBA{R: uint8(label), A: 255})
+	}
+
+	tests := []struct {
+		orientation int
+		want        []string // строки результата
+	}{
+		{orientation: 0, want: []string{"abc", "def"}},
+		{orientation: 1, want: []string{"abc", "def"}},
+		{orientation: 2, want: []string{"cba", "fed"}},
+		{orientation: 3, want: []string{"fed", "cba"}},
+		{orientation: 4, want: []string{"def", "abc"}},
+		{orientation: 5, want: []string{"ad", "be", "cf"}},
+		{orientation: 6, want: []string{"da", "eb", "fc"}},
+		{orientation: 7, want: []string{"fc", "eb", "da"}},
+		{orientation: 8, want: []string{"cf", "be", "ad"}},
+		{orientation: 9, want: []string{"abc", "def"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(rune('0'+tt.orientation)), func(t *testing.T) {
+			dst := applyOrientation(src, tt.orientation)
+			var got []string
+			for y := 0; y < dst.Bounds().Dy(); y++ {
+				var row []byte
+				for x := 0; x < dst.Bounds().Dx(); x++ {
+					row = append(row, dst.RGBAAt(x, y).R)
+				}
+				got = append(got, string(row))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("applyOrientation(%d) = %v, want %v", tt.orientation, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("applyOrientation(%d) = %v, want %v", tt.orientation, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFitSize(t *testing.T) {
+	tests := []struct {
+		name                   string
+		width, height, maxSize int
+		wantWidth, wantHeight  int
+	}{
+		{name: "smaller is kept", width: 100, height: 50, maxSize: 160, wantWidth: 100, wantHeight: 50},
+		{name: "exact fit is kept", width: 160, height: 160, maxSize: 160, wantWidth: 160, wantHeight: 160},
+		{name: "landscape", width: 4000, height: 3000, maxSize: 1600, wantWidth: 1600, wantHeight: 1200},
+		{name: "portrait", width: 3000, height: 4000, maxSize: 1600, wantWidth: 1200, wantHeight: 1600},
+		{name: "square", width: 1000, height: 1000, maxSize: 640, wantWidth: 640, wantHeight: 640},
+		{name: "rounds down", width: 1000, height: 333, maxSize: 640, wantWidth: 640, wantHeight: 213},
+		{name: "thin strip keeps one pixel", width: 10000, height: 2, maxSize: 160, wantWidth: 160, wantHeight: 1},
+		{name: "tall strip keeps one pixel", width: 3, height: 9000, maxSize: 160, wantWidth: 1, wantHeight: 160},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height := fitSize(tt.width, tt.height, tt.maxSize)
+			if width != tt.wantWidth || height != tt.wantHeight {
+				t.Errorf("fitSize(%d, %d, %d) = %dx%d, want %dx%d",
+					tt.width, tt.height, tt.maxSize, width, height, tt.wantWidth, tt.wantHeight)
+			}
+		})
+	}
+}
+
+// pngWithSize возвращает PNG 1x1, в заголовке которого заявлены другие размеры
+func pngWithSize(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+	data := buf.Bytes()
+	// Сигнатура (8 байт), длина и тип чанка IHDR (8 байт), затем ширина и высота
+	binary.BigEndian.PutUint32(data[16:], width)
+	binary.BigEndian.PutUint32(data[20:], height)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestProcessImage(t *testing.T) {
+	specs := []imageVariantSpec{
+		{Name: "thumbnail", MaxSize: 4, Square: true},
+		{Name: "large", MaxSize: 16},
+	}
+	landscape := encodeTestJPEG(t, 40, 20)
+	rotated := withSegments(landscape, exifSegment(binary.BigEndian, [3]uint16{0x0112, 3, 6}))
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+		want    [][2]int // размеры вариантов
+	}{
+		{name: "resized", data: landscape, want: [][2]int{{4, 4}, {16, 8}}},
+		{name: "rotated by exif", data: rotated, want: [][2]int{{4, 4}, {8, 16}}},
+		{name: "too many pixels", data: pngWithSize(t, 6001, 4000), wantErr: ErrImageTooLarge},
+		{name: "not an image", data: []byte("plain text"), wantErr: ErrUnsupportedImage},
+		{name: "corrupted", data: landscape[:len(landscape)/2], wantErr: ErrInvalidImage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variants, err := processImage(tt.data, specs)
+			if errors.Cause(err) != tt.wantErr {
+				t.Fatalf("processImage() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(variants) != len(tt.want) {
+				t.Fatalf("len(variants) = %d, want %d", len(variants), len(tt.want))
+			}
+			for i, variant := range variants {
+				if variant.Width != tt.want[i][0] || variant.Height != tt.want[i][1] {
+					t.Errorf("%s = %dx%d, want %dx%d", variant.Spec.Name, variant.Width, variant.Height, tt.want[i][0], tt.want[i][1])
+				}
+				if variant.ContentType != "image/jpeg" {
+					t.Errorf("%s content type = %q, want image/jpeg", variant.Spec.Name, variant.ContentType)
+				}
+				if jpegOrientation(variant.Data) != 1 {
+					t.Errorf("%s keeps exif orientation", variant.Spec.Name)
+				}
+			}
+		})
+	}
+}
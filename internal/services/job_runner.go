@@ -0,0 +1,725 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	jobsCollectionName         = "jobs"
+	jobSchedulesCollectionName = "job_schedules"
+
+	// jobRetention сколько хранятся завершенные задачи
+	jobRetention = 14 * 24 * time.Hour
+	// jobLease на сколько задача и блокировка ее типа закрепляются за исполнителем;
+	// пока обработчик работает, аренда продлевается каждые jobHeartbeatInterval
+	jobLease             = time.Minute
+	jobHeartbeatInterval = 20 * time.Second
+	// jobLockRetryDelay через сколько повторяется задача, не получившая блокировку
+	jobLockRetryDelay = 15 * time.Second
+	// defaultJobTimeout и defaultJobMaxAttempts применяются, если при регистрации они не заданы
+	defaultJobTimeout     = 5 * time.Minute
+	defaultJobMaxAttempts = 5
+)
+
+var (
+	ErrJobNotFound         = errors.New("job not found")
+	ErrJobDuplicate        = errors.New("job with this unique key is already queued")
+	ErrJobNotRetryable     = errors.New("only failed or cancelled jobs can be retried")
+	ErrJobNotCancellable   = errors.New("only pending jobs can be cancelled")
+	ErrJobTypeUnknown      = errors.New("unknown job type")
+	ErrJobScheduleNotFound = errors.New("job schedule not found")
+)
+
+// releaseLockScript снимает блокировку, только если она все еще принадлежит владельцу
+var releaseLockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("del", KEYS[1])
+end
+return 0`)
+
+// extendLockScript продлевает блокировку, только если она все еще принадлежит владельцу
+var extendLockScript = redis.NewScript(`
+if redis.call("get", KEYS[1]) == ARGV[1] then
+	return redis.call("pexpire", KEYS[1], ARGV[2])
+end
+return 0`)
+
+// JobHandler выполняет задачу. Ошибка приводит к повтору с экспоненциальной задержкой,
+// поэтому обработчик должен спокойно переносить повторное выполнение.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// JobOptions параметры выполнения задач одного типа
+type JobOptions struct {
+	MaxAttempts int
+	Timeout     time.Duration
+	// Exclusive запрещает одновременное выполнение задач этого типа на разных репликах;
+	// на время выполнения берется блокировка в Redis
+	Exclusive bool
+}
+
+// EnqueueOptions параметры постановки задачи в очередь
+type EnqueueOptions struct {
+	RunAt       time.Time // нулевое значение - как можно скорее
+	UniqueKey   string
+	MaxAttempts int // 0 - значение из регистрации типа
+}
+
+// registeredJob обработчик типа задач с параметрами
+type registeredJob struct {
+	handler JobHandler
+	options JobOptions
+}
+
+// recurringJob периодическая задача, зарегистрированная в процессе
+type recurringJob struct {
+	spec     string
+	jobType  string
+	payload  interface{}
+	schedule cronSchedule
+}
+
+// JobRunner структура сервиса фоновых задач. Задачи хранятся в коллекции jobs:
+// отложенные задачи ждут run_at, упавшие повторяются с задержкой, исчерпавшие
+// попытки остаются в статусе failed до ручного повтора. Периодические задачи
+// ставит в очередь только та реплика, что первой отметит запуск в job_schedules.
+type JobRunner struct {
+	db          *mongo.Database
+	redis       *RedisService
+	workerID    string
+	concurrency int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	heartbeat   time.Duration
+	location    *time.Location
+
+	mu        sync.RWMutex
+	handlers  map[string]registeredJob
+	schedules map[string]recurringJob
+}
+
+// NewJobRunner создает новый экземпляр JobRunner. JOBS_CONCURRENCY задает число
+// одновременно выполняемых задач на реплике (по умолчанию 4), JOBS_RETRY_BASE_SECONDS -
+// первую задержку повтора. Расписания считаются в часовом поясе RESTAURANT_TIMEZONE.
+func NewJobRunner(client *mongo.Client, dbName string, redisService *RedisService) *JobRunner {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return &JobRunner{
+		db:          client.Database(dbName),
+		redis:       redisService,
+		workerID:    fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix)),
+		concurrency: envInt("JOBS_CONCURRENCY", 4),
+		retryBase:   time.Duration(envInt("JOBS_RETRY_BASE_SECONDS", 10)) * time.Second,
+		retryMax:    time.Hour,
+		heartbeat:   jobHeartbeatInterval,
+		location:    restaurantLocation(),
+		handlers:    make(map[string]registeredJob),
+		schedules:   make(map[string]recurringJob),
+	}
+}
+
+// EnsureIndexes создает индексы очереди задач
+func (j *JobRunner) EnsureIndexes(ctx context.Context) error {
+	_, err := j.db.Collection(jobsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "type", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			// Незавершенная задача с уникальным ключом может быть только одна
+			Keys: bson.D{{Key: "active_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"active_key": bson.M{"$exists": true},
+			}),
+		},
+		{
+			// Незавершенные задачи поля finished_at не имеют и не удаляются
+			Keys:    bson.D{{Key: "finished_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(jobRetention.Seconds())),
+		},
+	})
+	return errors.Wrap(err, "creating job indexes failed")
+}
+
+// Register регистрирует обработчик задач типа jobType
+func (j *JobRunner) Register(jobType string, handler JobHandler, opts JobOptions) {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultJobMaxAttempts
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultJobTimeout
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.handlers[jobType] = registeredJob{handler: handler, options: opts}
+}
+
+// Schedule регистрирует периодическую задачу с расписанием в формате cron.
+// Новое расписание впервые срабатывает сразу после запуска Run.
+func (j *JobRunner) Schedule(name, spec, jobType string, payload interface{}) error {
+	schedule, err := parseCron(spec, j.location)
+	if err != nil {
+		return err
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.schedules[name] = recurringJob{spec: spec, jobType: jobType, payload: payload, schedule: schedule}
+	return nil
+}
+
+// Enqueue ставит задачу в очередь. Если задача с тем же уникальным ключом еще не завершена,
+// возвращается она вместе с ErrJobDuplicate.
+func (j *JobRunner) Enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions) (*models.Job, error) {
+	return j.enqueue(ctx, jobType, payload, opts, "")
+}
+
+// enqueue ставит задачу в очередь; schedule - имя расписания, создавшего задачу
+func (j *JobRunner) enqueue(ctx context.Context, jobType string, payload interface{}, opts EnqueueOptions, schedule string) (*models.Job, error) {
+	doc, err := toDocument(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding job payload failed")
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		ID:          primitive.NewObjectID(),
+		Type:        jobType,
+		Payload:     doc,
+		Status:      models.JobStatusPending,
+		UniqueKey:   opts.UniqueKey,
+		ActiveKey:   opts.UniqueKey,
+		Schedule:    schedule,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+		CreatedAt:   now,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = now
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = defaultJobMaxAttempts
+		j.mu.RLock()
+		if registered, ok := j.handlers[jobType]; ok {
+			job.MaxAttempts = registered.options.MaxAttempts
+		}
+		j.mu.RUnlock()
+	}
+
+	if _, err := j.db.Collection(jobsCollectionName).InsertOne(ctx, job); err != nil {
+		if opts.UniqueKey != "" && mongo.IsDuplicateKeyError(err) {
+			existing, findErr := j.findJob(ctx, bson.M{"active_key": opts.UniqueKey})
+			if findErr != nil {
+				return nil, findErr
+			}
+			return existing, ErrJobDuplicate
+		}
+		return nil, errors.Wrap(err, "enqueueing job failed")
+	}
+	return job, nil
+}
+
+// GetJob возвращает задачу по ID
+func (j *JobRunner) GetJob(ctx context.Context, jobID primitive.ObjectID) (*models.Job, error) {
+	return j.findJob(ctx, bson.M{"_id": jobID})
+}
+
+// ListJobs возвращает страницу задач, новые первыми. Параметры status и jobType фильтруют задачи.
+func (j *JobRunner) ListJobs(ctx context.Context, status, jobType string, page, limit int) ([]models.Job, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if jobType != "" {
+		filter["type"] = jobType
+	}
+
+	cursor, err := j.db.Collection(jobsCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding jobs failed")
+	}
+	jobs := []models.Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, errors.Wrap(err, "decoding jobs failed")
+	}
+	return jobs, nil
+}
+
+// RetryJob возвращает проваленную или отмененную задачу в очередь со сброшенным счетчиком попыток
+func (j *JobRunner) RetryJob(ctx context.Context, jobID primitive.ObjectID) (*models.Job, error) {
+	job, err := j.GetJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+		return nil, ErrJobNotRetryable
+	}
+
+	set := bson.M{"status": models.JobStatusPending, "attempts": 0, "run_at": time.Now()}
+	if job.UniqueKey != "" {
+		set["active_key"] = job.UniqueKey
+	}
+	updated, err := j.updateJob(ctx,
+		bson.M{"_id": jobID, "status": job.Status},
+		bson.M{"$set": set, "$unset": bson.M{"finished_at": ""}},
+	)
+	if err != nil && mongo.IsDuplicateKeyError(errors.Cause(err)) {
+		return nil, ErrJobDuplicate
+	}
+	if err == ErrJobNotFound {
+		return nil, ErrJobNotRetryable
+	}
+	return updated, err
+}
+
+// CancelJob отменяет задачу, которая еще не начала выполняться
+func (j *JobRunner) CancelJob(ctx context.Context, jobID primitive.ObjectID) (*models.Job, error) {
+	job, err := j.updateJob(ctx,
+		bson.M{"_id": jobID, "status": models.JobStatusPending},
+		bson.M{
+			"$set":   bson.M{"status": models.JobStatusCancelled, "finished_at": time.Now()},
+			"$unset": bson.M{"active_key": ""},
+		},
+	)
+	if err == ErrJobNotFound {
+		if _, findErr := j.GetJob(ctx, jobID); findErr != nil {
+			return nil, findErr
+		}
+		return nil, ErrJobNotCancellable
+	}
+	return job, err
+}
+
+// ListSchedules возвращает периодические задачи со временем следующего запуска
+func (j *JobRunner) ListSchedules(ctx context.Context) ([]models.JobSchedule, error) {
+	cursor, err := j.db.Collection(jobSchedulesCollectionName).Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding job schedules failed")
+	}
+	schedules := []models.JobSchedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, errors.Wrap(err, "decoding job schedules failed")
+	}
+	return schedules, nil
+}
+
+// TriggerSchedule сразу ставит в очередь задачу расписания, не меняя время следующего запуска
+func (j *JobRunner) TriggerSchedule(ctx context.Context, name string) (*models.Job, error) {
+	j.mu.RLock()
+	recurring, ok := j.schedules[name]
+	j.mu.RUnlock()
+	if !ok {
+		return nil, ErrJobScheduleNotFound
+	}
+	return j.enqueueScheduled(ctx, name, recurring)
+}
+
+// Run запускает обработчики очереди и планировщик периодических задач, пока не отменен контекст.
+// interval задает, как часто проверяются очередь и расписания.
+func (j *JobRunner) Run(ctx context.Context, interval time.Duration) {
+	if err := j.syncSchedules(ctx); err != nil {
+		log.Printf("Failed to sync job schedules: %v", err)
+	}
+
+	for i := 0; i < j.concurrency; i++ {
+		go j.work(ctx, interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		j.runDueSchedules(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// work выполняет задачи из очереди, пока они есть, затем ждет следующего тика
+func (j *JobRunner) work(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for j.runNext(ctx) {
+			}
+		}
+	}
+}
+
+// runNext забирает и выполняет одну готовую задачу известного реплике типа; false, если выполнять нечего.
+// Задача, чей обработчик не уложился в аренду (например, реплика упала), забирается повторно.
+func (j *JobRunner) runNext(ctx context.Context) bool {
+	j.mu.RLock()
+	types := make([]string, 0, len(j.handlers))
+	for jobType := range j.handlers {
+		types = append(types, jobType)
+	}
+	j.mu.RUnlock()
+	if len(types) == 0 {
+		return false
+	}
+
+	now := time.Now()
+	var job models.Job
+	err := j.db.Collection(jobsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{
+			"type": bson.M{"$in": types},
+			"$or": bson.A{
+				bson.M{"status": models.JobStatusPending, "run_at": bson.M{"$lte": now}},
+				bson.M{"status": models.JobStatusRunning, "locked_until": bson.M{"$lte": now}},
+			},
+		},
+		bson.M{
+			"$set": bson.M{
+				"status":       models.JobStatusRunning,
+				"locked_until": now.Add(jobLease),
+				"worker":       j.workerID,
+				"started_at":   now,
+			},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "run_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to claim job: %v", err)
+		}
+		return false
+	}
+
+	j.mu.RLock()
+	registered, ok := j.handlers[job.Type]
+	j.mu.RUnlock()
+	if !ok {
+		j.finish(ctx, &job, ErrJobTypeUnknown)
+		return true
+	}
+
+	var lock *jobLock
+	if registered.options.Exclusive {
+		var acquired bool
+		lock, acquired = j.acquireLock(ctx, "jobs:lock:"+job.Type, jobLease)
+		if !acquired {
+			j.postpone(ctx, &job)
+			return true
+		}
+		defer lock.release()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, registered.options.Timeout)
+	stop := j.keepLease(runCtx, cancel, &job, lock)
+	err = registered.handler(runCtx, &job)
+	stop()
+	cancel()
+	j.finish(ctx, &job, err)
+	return true
+}
+
+// keepLease продлевает аренду задачи и блокировку ее типа, пока выполняется обработчик.
+// Если аренда потеряна (задачу уже забрала другая реплика), контекст обработчика отменяется.
+// Возвращаемая функция останавливает продление и дожидается его завершения.
+func (j *JobRunner) keepLease(ctx context.Context, cancel context.CancelFunc, job *models.Job, lock *jobLock) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(j.heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if !j.extendLease(ctx, job, lock) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// extendLease продлевает аренду задачи и блокировку на jobLease; false, если аренда потеряна.
+// Временные ошибки только логируются: аренда еще действует, и продление повторится.
+func (j *JobRunner) extendLease(ctx context.Context, job *models.Job, lock *jobLock) bool {
+	result, err := j.db.Collection(jobsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": models.JobStatusRunning, "worker": j.workerID},
+		bson.M{"$set": bson.M{"locked_until": time.Now().Add(jobLease)}},
+	)
+	if err != nil {
+		log.Printf("Failed to extend lease of job %s: %v", job.ID.Hex(), err)
+		return true
+	}
+	if result.MatchedCount == 0 {
+		log.Printf("Lease of job %s (%s) was lost, cancelling", job.ID.Hex(), job.Type)
+		return false
+	}
+
+	extended, err := lock.extend(ctx, jobLease)
+	if err != nil {
+		log.Printf("Failed to extend lock %s: %v", lock.key, err)
+		return true
+	}
+	if !extended {
+		log.Printf("Lock %s was lost, cancelling job %s", lock.key, job.ID.Hex())
+	}
+	return extended
+}
+
+// finish сохраняет результат выполнения задачи
+func (j *JobRunner) finish(ctx context.Context, job *models.Job, runErr error) {
+	now := time.Now()
+	set := bson.M{}
+	unset := bson.M{"locked_until": ""}
+
+	switch {
+	case runErr == nil:
+		set["status"] = models.JobStatusSucceeded
+		set["finished_at"] = now
+		unset["active_key"] = ""
+		unset["last_error"] = ""
+	case runErr == ErrJobTypeUnknown || job.Attempts >= job.MaxAttempts:
+		set["status"] = models.JobStatusFailed
+		set["finished_at"] = now
+		set["last_error"] = runErr.Error()
+		unset["active_key"] = ""
+		log.Printf("Job %s (%s) failed after %d attempts: %v", job.ID.Hex(), job.Type, job.Attempts, runErr)
+	default:
+		set["status"] = models.JobStatusPending
+		set["run_at"] = now.Add(retryDelay(j.retryBase, j.retryMax, job.Attempts))
+		set["last_error"] = runErr.Error()
+	}
+
+	_, err := j.db.Collection(jobsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": models.JobStatusRunning, "worker": j.workerID},
+		bson.M{"$set": set, "$unset": unset},
+	)
+	if err != nil {
+		log.Printf("Failed to save result of job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// postpone возвращает задачу в очередь, не засчитывая попытку: блокировку держит другая реплика
+func (j *JobRunner) postpone(ctx context.Context, job *models.Job) {
+	_, err := j.db.Collection(jobsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": job.ID, "status": models.JobStatusRunning, "worker": j.workerID},
+		bson.M{
+			"$set":   bson.M{"status": models.JobStatusPending, "run_at": time.Now().Add(jobLockRetryDelay)},
+			"$inc":   bson.M{"attempts": -1},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	if err != nil {
+		log.Printf("Failed to postpone job %s: %v", job.ID.Hex(), err)
+	}
+}
+
+// jobLock распределенная блокировка в Redis. Снимается и продлевается, только пока
+// принадлежит этой реплике; нулевой client означает работу без Redis.
+type jobLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// acquireLock берет распределенную блокировку в Redis на время ttl.
+// Без Redis блокировка всегда считается полученной.
+func (j *JobRunner) acquireLock(ctx context.Context, key string, ttl time.Duration) (*jobLock, bool) {
+	if j.redis == nil {
+		return &jobLock{key: key}, true
+	}
+	lock := &jobLock{client: j.redis.Client, key: key, token: primitive.NewObjectID().Hex()}
+	acquired, err := lock.client.SetNX(ctx, key, lock.token, ttl).Result()
+	if err != nil {
+		log.Printf("Failed to acquire lock %s: %v", key, err)
+		return nil, false
+	}
+	if !acquired {
+		return nil, false
+	}
+	return lock, true
+}
+
+// extend продлевает блокировку на ttl; false, если блокировка уже не принадлежит реплике
+func (l *jobLock) extend(ctx context.Context, ttl time.Duration) (bool, error) {
+	if l == nil || l.client == nil {
+		return true, nil
+	}
+	extended, err := extendLockScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return extended == 1, nil
+}
+
+// release снимает блокировку, если она еще принадлежит реплике
+func (l *jobLock) release() {
+	if l.client == nil {
+		return
+	}
+	if err := releaseLockScript.Run(context.Background(), l.client, []string{l.key}, l.token).Err(); err != nil {
+		log.Printf("Failed to release lock %s: %v", l.key, err)
+	}
+}
+
+// syncSchedules сохраняет зарегистрированные расписания в базе. Для нового расписания
+// или измененного spec время следующего запуска вычисляется заново.
+func (j *JobRunner) syncSchedules(ctx context.Context) error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+
+	now := time.Now()
+	collection := j.db.Collection(jobSchedulesCollectionName)
+	for name, recurring := range j.schedules {
+		var existing models.JobSchedule
+		err := collection.FindOne(ctx, bson.M{"_id": name}).Decode(&existing)
+		switch {
+		case err == mongo.ErrNoDocuments:
+			_, err = collection.InsertOne(ctx, models.JobSchedule{
+				Name:      name,
+				Spec:      recurring.spec,
+				JobType:   recurring.jobType,
+				NextRunAt: now,
+				UpdatedAt: now,
+			})
+			if mongo.IsDuplicateKeyError(err) {
+				err = nil
+			}
+		case err != nil:
+		case existing.Spec != recurring.spec || existing.JobType != recurring.jobType:
+			_, err = collection.UpdateOne(ctx, bson.M{"_id": name}, bson.M{"$set": bson.M{
+				"spec":        recurring.spec,
+				"job_type":    recurring.jobType,
+				"next_run_at": recurring.schedule.Next(now),
+				"updated_at":  now,
+			}})
+		}
+		if err != nil {
+			return errors.Wrapf(err, "syncing schedule %s failed", name)
+		}
+	}
+	return nil
+}
+
+// runDueSchedules ставит в очередь задачи наступивших расписаний. Запуск отмечается условным
+// обновлением в базе, поэтому из нескольких реплик задачу ставит только одна.
+// Пропущенные запуски (например, пока сервер был остановлен) не наверстываются.
+func (j *JobRunner) runDueSchedules(ctx context.Context) {
+	j.mu.RLock()
+	schedules := make(map[string]recurringJob, len(j.schedules))
+	for name, recurring := range j.schedules {
+		schedules[name] = recurring
+	}
+	j.mu.RUnlock()
+
+	now := time.Now()
+	for name, recurring := range schedules {
+		result, err := j.db.Collection(jobSchedulesCollectionName).UpdateOne(ctx,
+			bson.M{"_id": name, "spec": recurring.spec, "next_run_at": bson.M{"$lte": now}},
+			bson.M{"$set": bson.M{"next_run_at": recurring.schedule.Next(now), "last_run_at": now}},
+		)
+		if err != nil {
+			log.Printf("Failed to claim schedule %s: %v", name, err)
+			continue
+		}
+		if result.ModifiedCount == 0 {
+			continue
+		}
+
+		job, err := j.enqueueScheduled(ctx, name, recurring)
+		if err == ErrJobDuplicate {
+			log.Printf("Skipping schedule %s: previous job %s is still %s", name, job.ID.Hex(), job.Status)
+			continue
+		}
+		if err != nil {
+			log.Printf("Failed to enqueue scheduled job %s: %v", name, err)
+			continue
+		}
+		if _, err := j.db.Collection(jobSchedulesCollectionName).UpdateOne(ctx,
+			bson.M{"_id": name}, bson.M{"$set": bson.M{"last_job_id": job.ID}},
+		); err != nil {
+			log.Printf("Failed to save last job of schedule %s: %v", name, err)
+		}
+	}
+}
+
+// enqueueScheduled ставит задачу расписания. Уникальный ключ не дает запуску наложиться
+// на предыдущий, если тот еще не завершен.
+func (j *JobRunner) enqueueScheduled(ctx context.Context, name string, recurring recurringJob) (*models.Job, error) {
+	return j.enqueue(ctx, recurring.jobType, recurring.payload, EnqueueOptions{UniqueKey: "schedule:" + name}, name)
+}
+
+// findJob возвращает задачу по фильтру
+func (j *JobRunner) findJob(ctx context.Context, filter bson.M) (*models.Job, error) {
+	var job models.Job
+	err := j.db.Collection(jobsCollectionName).FindOne(ctx, filter).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding job failed")
+	}
+	return &job, nil
+}
+
+// updateJob атомарно изменяет задачу, подходящую под фильтр, и возвращает новую версию
+func (j *JobRunner) updateJob(ctx context.Context, filter, update bson.M) (*models.Job, error) {
+	var job models.Job
+	err := j.db.Collection(jobsCollectionName).FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating job failed")
+	}
+	return &job, nil
+}
+
+// toDocument преобразует данные задачи или события в BSON-документ
+func toDocument(value interface{}) (bson.M, error) {
+	if value == nil {
+		return nil, nil
+	}
+	raw, err := bson.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
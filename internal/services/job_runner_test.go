@@ -0,0 +1,91 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestExtendLease(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name     string
+		response bson.D
+		want     bool
+	}{
+		{name: "lease extended", response: bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}, want: true},
+		{name: "lease lost", response: bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}}, want: false},
+		{name: "transient error keeps running", response: mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 6, Message: "host unreachable"}), want: true},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			j := &JobRunner{db: mt.DB, workerID: "worker-1"}
+			job := &models.Job{ID: primitive.NewObjectID(), Type: "test"}
+			mt.AddMockResponses(tt.response)
+
+			before := time.Now()
+			if got := j.extendLease(context.Background(), job, &jobLock{key: "jobs:lock:test"}); got != tt.want {
+				t.Fatalf("extendLease() = %v, want %v", got, tt.want)
+			}
+
+			update := mt.GetStartedEvent().Command.Lookup("updates").Array().Index(0).Value().Document()
+			filter := update.Lookup("q").Document()
+			if filter.Lookup("_id").ObjectID() != job.ID || filter.Lookup("worker").StringValue() != "worker-1" ||
+				filter.Lookup("status").StringValue() != string(models.JobStatusRunning) {
+				t.Errorf("filter = %v, want job owned by worker-1", filter)
+			}
+			lockedUntil := update.Lookup("u", "$set", "locked_until").Time()
+			if lockedUntil.Before(before.Add(jobLease).Truncate(time.Millisecond)) {
+				t.Errorf("locked_until = %v, want at least %v", lockedUntil, before.Add(jobLease))
+			}
+		})
+	}
+}
+
+func TestKeepLeaseCancelsLostJob(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("cancel on lost lease", func(mt *mtest.T) {
+		j := &JobRunner{db: mt.DB, workerID: "worker-1", heartbeat: 10 * time.Millisecond}
+		job := &models.Job{ID: primitive.NewObjectID(), Type: "test"}
+		mt.AddMockResponses(
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 0}, {Key: "nModified", Value: 0}},
+		)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stop := j.keepLease(ctx, cancel, job, nil)
+		<-ctx.Done()
+		stop()
+
+		if ctx.Err() != context.Canceled {
+			t.Fatalf("ctx.Err() = %v, want %v", ctx.Err(), context.Canceled)
+		}
+		if got := len(mt.GetAllStartedEvents()); got != 2 {
+			t.Errorf("lease updates = %d, want 2", got)
+		}
+	})
+
+	mt.Run("stop without lease updates", func(mt *mtest.T) {
+		j := &JobRunner{db: mt.DB, workerID: "worker-1", heartbeat: time.Hour}
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		j.keepLease(ctx, cancel, &models.Job{ID: primitive.NewObjectID()}, nil)()
+
+		if ctx.Err() != nil {
+			t.Errorf("ctx.Err() = %v, want nil", ctx.Err())
+		}
+		if got := len(mt.GetAllStartedEvents()); got != 0 {
+			t.Errorf("lease updates = %d, want 0", got)
+		}
+	})
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Отметки кухни хранятся, пока заказ может оставаться на экране
+const kitchenProgressTTL = 48 * time.Hour
+
+var (
+	ErrOrderNotInKitchen = errors.New("order is not being prepared")
+	ErrOrderItemNotFound = errors.New("order item not found")
+)
+
+// kitchenStatuses статусы заказов, которые показываются на кухонном экране, в порядке колонок
+var kitchenStatuses = []string{models.OrderStatusPending, models.OrderStatusConfirmed, models.OrderStatusPreparing}
+
+// KitchenService структура сервиса кухонного экрана ресторана
+type KitchenService struct {
+	db                 *mongo.Database
+	orderService       *OrderService
+	defaultPrepMinutes int
+}
+
+// NewKitchenService создает новый экземпляр KitchenService.
+// Норматив по умолчанию задается переменной KITCHEN_DEFAULT_PREP_MINUTES.
+func NewKitchenService(client *mongo.Client, dbName string, orderService *OrderService) *KitchenService {
+	return &KitchenService{
+		db:                 client.Database(dbName),
+		orderService:       orderService,
+		defaultPrepMinutes: envInt("KITCHEN_DEFAULT_PREP_MINUTES", 15),
+	}
+}
+
+// EnsureIndexes создает индексы для отметок кухни
+func (s *KitchenService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection("kitchen_progress").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "restaurant_id", Value: 1}, {Key: "order_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "updated_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(kitchenProgressTTL.Seconds())),
+		},
+	})
+	return errors.Wrap(err, "creating kitchen indexes failed")
+}
+
+// GetSettings возвращает нормативы кухни ресторана или значения по умолчанию
+func (s *KitchenService) GetSettings(ctx context.Context, restaurantID primitive.ObjectID) (*models.KitchenSettings, error) {
+	var restaurant struct {
+		Kitchen *models.KitchenSettings `bson:"kitchenSettings"`
+	}
+	err := s.db.Collection(EntityTypeRestaurant).FindOne(ctx, bson.M{"_id": restaurantID},
+		options.FindOne().SetProjection(bson.M{"kitchenSettings": 1}),
+	).Decode(&restaurant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRestaurantNotFound
+		}
+		return nil, errors.Wrap(err, "finding kitchen settings failed")
+	}
+	return s.settingsOrDefault(restaurant.Kitchen), nil
+}
+
+// SetSettings сохраняет нормативы кухни ресторана
+func (s *KitchenService) SetSettings(ctx context.Context, restaurantID primitive.ObjectID, settings *models.KitchenSettings) error {
+	if settings.PrepTargets == nil {
+		settings.PrepTargets = map[string]int{}
+	}
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID,
+		bson.M{"$set": bson.M{"kitchenSettings": settings}})
+	if err != nil {
+		return errors.Wrap(err, "saving kitchen settings failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrRestaurantNotFound
+	}
+	return nil
+}
+
+// Tickets возвращает активные заказы ресторана по возрастанию обещанного времени.
+// Если задана станция, в билетах остаются только ее позиции; status ограничивает статус заказа.
+func (s *KitchenService) Tickets(ctx context.Context, restaurantID primitive.ObjectID, station, status string) ([]models.KitchenTicket, error) {
+	statuses := kitchenStatuses
+	if status != "" {
+		statuses = []string{status}
+	}
+	tickets, err := s.load(ctx, restaurantID, bson.M{"$in": bson.A{"$$order.status", statuses}})
+	if err != nil {
+		return nil, err
+	}
+	if station == "" {
+		return tickets, nil
+	}
+
+	filtered := make([]models.KitchenTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		items := make([]models.KitchenTicketItem, 0, len(ticket.Items))
+		for _, item := range ticket.Items {
+			if item.Station == station {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			ticket.Items = items
+			filtered = append(filtered, ticket)
+		}
+	}
+	return filtered, nil
+}
+
+// Board возвращает кухонный экран: заказы по статусам и очереди неготовых позиций по станциям
+func (s *KitchenService) Board(ctx context.Context, restaurantID primitive.ObjectID, station string) (*models.KitchenBoard, error) {
+	tickets, err := s.Tickets(ctx, restaurantID, station, "")
+	if err != nil {
+		return nil, err
+	}
+
+	board := &models.KitchenBoard{
+		GeneratedAt: time.Now(),
+		Statuses:    make([]models.KitchenStatusGroup, 0, len(kitchenStatuses)),
+		Stations:    []models.KitchenStation{},
+	}
+	byStatus := make(map[string][]models.KitchenTicket, len(kitchenStatuses))
+	byStation := make(map[string][]models.KitchenStationItem)
+	for _, ticket := range tickets {
+		if ticket.Late {
+			board.LateCount++
+		}
+		byStatus[ticket.Status] = append(byStatus[ticket.Status], ticket)
+		for _, item := range ticket.Items {
+			if item.State == models.KitchenItemDone {
+				continue
+			}
+			byStation[item.Station] = append(byStation[item.Station], models.KitchenStationItem{
+				OrderID:           ticket.OrderID,
+				PromisedAt:        ticket.PromisedAt,
+				KitchenTicketItem: item,
+			})
+		}
+	}
+
+	for _, status := range kitchenStatuses {
+		group := models.KitchenStatusGroup{Status: status, Tickets: byStatus[status]}
+		if group.Tickets == nil {
+			group.Tickets = []models.KitchenTicket{}
+		}
+		board.Statuses = append(board.Statuses, group)
+	}
+	for name, items := range byStation {
+		board.Stations = append(board.Stations, models.KitchenStation{Station: name, Items: items})
+	}
+	sort.Slice(board.Stations, func(i, j int) bool {
+		return board.Stations[i].Station < board.Stations[j].Station
+	})
+	return board, nil
+}
+
+// StartItem отмечает позицию начатой. Первая начатая позиция переводит подтвержденный заказ в приготовление.
+func (s *KitchenService) StartItem(ctx context.Context, restaurantID primitive.ObjectID, orderID string, index int) (*models.KitchenTicket, error) {
+	now := time.Now()
+	return s.bump(ctx, restaurantID, orderID, index, bson.M{
+		"$min": bson.M{s.itemField(index, "started_at"): now},
+		"$set": bson.M{"updated_at": now},
+	}, true)
+}
+
+// FinishItem отмечает позицию готовой
+func (s *KitchenService) FinishItem(ctx context.Context, restaurantID primitive.ObjectID, orderID string, index int) (*models.KitchenTicket, error) {
+	now := time.Now()
+	return s.bump(ctx, restaurantID, orderID, index, bson.M{
+		"$min": bson.M{s.itemField(index, "started_at"): now, s.itemField(index, "done_at"): now},
+		"$set": bson.M{"updated_at": now},
+	}, true)
+}
+
+// ResetItem снимает отметки с позиции, если ее отметили по ошибке
+func (s *KitchenService) ResetItem(ctx context.Context, restaurantID primitive.ObjectID, orderID string, index int) (*models.KitchenTicket, error) {
+	return s.bump(ctx, restaurantID, orderID, index, bson.M{
+		"$unset": bson.M{"items." + strconv.Itoa(index): ""},
+		"$set":   bson.M{"updated_at": time.Now()},
+	}, false)
+}
+
+// bump применяет отметку к позиции заказа, который готовится на кухне, и возвращает обновленный билет
+func (s *KitchenService) bump(ctx context.Context, restaurantID primitive.ObjectID, orderID string, index int, update bson.M, startsPreparing bool) (*models.KitchenTicket, error) {
+	order, err := s.orderService.GetOrder(ctx, EntityTypeRestaurant, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusConfirmed && order.Status != models.OrderStatusPreparing {
+		return nil, ErrOrderNotInKitchen
+	}
+	if index < 0 || index >= len(order.Items) {
+		return nil, ErrOrderItemNotFound
+	}
+
+	_, err = s.db.Collection("kitchen_progress").UpdateOne(ctx,
+		bson.M{"restaurant_id": restaurantID, "order_id": orderID},
+		update,
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "saving kitchen progress failed")
+	}
+
+	if startsPreparing && order.Status == models.OrderStatusConfirmed {
+		_, err := s.orderService.UpdateStatus(ctx, restaurantID, orderID, models.OrderStatusPreparing)
+		// Заказ мог уже сменить статус по запросу другого сотрудника
+		if err != nil && errors.Cause(err) != ErrOrderConflict && errors.Cause(err) != ErrInvalidOrderTransition {
+			return nil, err
+		}
+	}
+
+	tickets, err := s.load(ctx, restaurantID, bson.M{"$eq": bson.A{"$$order._id", orderID}})
+	if err != nil {
+		return nil, err
+	}
+	if len(tickets) == 0 {
+		return nil, ErrOrderNotFound
+	}
+	return &tickets[0], nil
+}
+
+// itemField возвращает путь к отметке позиции в документе прогресса
+func (s *KitchenService) itemField(index int, field string) string {
+	return "items." + strconv.Itoa(index) + "." + field
+}
+
+// load собирает билеты для заказов ресторана, удовлетворяющих условию над $$order
+func (s *KitchenService) load(ctx context.Context, restaurantID primitive.ObjectID, condition bson.M) ([]models.KitchenTicket, error) {
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": restaurantID}}},
+		{{Key: "$project", Value: bson.M{
+			"menu":            1,
+			"kitchenSettings": 1,
+			"orders": bson.M{"$filter": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$orders", bson.A{}}},
+				"as":    "order",
+				"cond":  condition,
+			}},
+		}}},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "loading kitchen orders failed")
+	}
+	var restaurants []struct {
+		Menu    []models.MenuItem       `bson:"menu"`
+		Kitchen *models.KitchenSettings `bson:"kitchenSettings"`
+		Orders  []models.Order          `bson:"orders"`
+	}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding kitchen orders failed")
+	}
+	if len(restaurants) == 0 {
+		return nil, ErrRestaurantNotFound
+	}
+	restaurant := restaurants[0]
+
+	orderIDs := make([]string, 0, len(restaurant.Orders))
+	for _, order := range restaurant.Orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	progress, err := s.progress(ctx, restaurantID, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	menu := make(map[string]models.MenuItem, len(restaurant.Menu))
+	for _, item := range restaurant.Menu {
+		menu[item.ID] = item
+	}
+	settings := s.settingsOrDefault(restaurant.Kitchen)
+	now := time.Now()
+
+	tickets := make([]models.KitchenTicket, 0, len(restaurant.Orders))
+	for _, order := range restaurant.Orders {
+		tickets = append(tickets, buildKitchenTicket(order, menu, settings, progress[order.ID], now))
+	}
+	sort.SliceStable(tickets, func(i, j int) bool {
+		if !tickets[i].PromisedAt.Equal(tickets[j].PromisedAt) {
+			return tickets[i].PromisedAt.Before(tickets[j].PromisedAt)
+		}
+		return tickets[i].CreatedAt.Before(tickets[j].CreatedAt)
+	})
+	return tickets, nil
+}
+
+// progress возвращает отметки кухни по заказам: ID заказа -> номер позиции -> отметки
+func (s *KitchenService) progress(ctx context.Context, restaurantID primitive.ObjectID, orderIDs []string) (map[string]map[string]models.KitchenItemProgress, error) {
+	result := make(map[string]map[string]models.KitchenItemProgress, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return result, nil
+	}
+
+	cursor, err := s.db.Collection("kitchen_progress").Find(ctx, bson.M{
+		"restaurant_id": restaurantID,
+		"order_id":      bson.M{"$in": orderIDs},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding kitchen progress failed")
+	}
+	var docs []struct {
+		OrderID string                                `bson:"order_id"`
+		Items   map[string]models.KitchenItemProgress `bson:"items"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, errors.Wrap(err, "decoding kitchen progress failed")
+	}
+	for _, doc := range docs {
+		result[doc.OrderID] = doc.Items
+	}
+	return result, nil
+}
+
+// settingsOrDefault дополняет нормативы ресторана значениями по умолчанию
+func (s *KitchenService) settingsOrDefault(settings *models.KitchenSettings) *models.KitchenSettings {
+	if settings == nil {
+		settings = &models.KitchenSettings{}
+	}
+	if settings.PrepTargets == nil {
+		settings.PrepTargets = map[string]int{}
+	}
+	if settings.DefaultPrepMinutes <= 0 {
+		settings.DefaultPrepMinutes = s.defaultPrepMinutes
+	}
+	return settings
+}
+
+// buildKitchenTicket строит билет заказа. Позиции готовятся параллельно, поэтому
+// обещанное время определяется самой долгой из них.
+func buildKitchenTicket(order models.Order, menu map[string]models.MenuItem, settings *models.KitchenSettings, progress map[string]models.KitchenItemProgress, now time.Time) models.KitchenTicket {
+	ticket := models.KitchenTicket{
+		OrderID:   order.ID,
+		Status:    order.Status,
+		Items:     make([]models.KitchenTicketItem, 0, len(order.Items)),
+		CreatedAt: order.CreatedAt,
+		Ready:     len(order.Items) > 0,
+	}
+
+	longest := 0
+	for index, orderItem := range order.Items {
+		item := models.KitchenTicketItem{
+			Index:      index,
+			MenuItemID: orderItem.MenuItemID,
+			Station:    models.KitchenStationOther,
+			Quantity:   orderItem.Quantity,
+			State:      models.KitchenItemQueued,
+		}
+		if menuItem, ok := menu[orderItem.MenuItemID]; ok {
+			item.Name = menuItem.Name
+			if menuItem.Category != "" {
+				item.Station = menuItem.Category
+			}
+		}
+		item.TargetMinutes = settings.DefaultPrepMinutes
+		if target, ok := settings.PrepTargets[item.Station]; ok {
+			item.TargetMinutes = target
+		}
+		if item.TargetMinutes > longest {
+			longest = item.TargetMinutes
+		}
+
+		marks := progress[strconv.Itoa(index)]
+		item.StartedAt, item.DoneAt = marks.StartedAt, marks.DoneAt
+		switch {
+		case item.DoneAt != nil:
+			item.State = models.KitchenItemDone
+		case item.StartedAt != nil:
+			item.State = models.KitchenItemStarted
+		}
+		if item.State != models.KitchenItemDone {
+			ticket.Ready = false
+			item.Late = now.After(order.CreatedAt.Add(time.Duration(item.TargetMinutes) * time.Minute))
+		}
+		ticket.Items = append(ticket.Items, item)
+	}
+
+	ticket.PromisedAt = order.CreatedAt.Add(time.Duration(longest) * time.Minute)
+	if !ticket.Ready && now.After(ticket.PromisedAt) {
+		ticket.Late = true
+		ticket.LateMinutes = int(now.Sub(ticket.PromisedAt).Minutes())
+	}
+	return ticket
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildKitchenTicket(t *testing.T) {
+	created := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		moment := created.Add(time.Duration(minutes) * time.Minute)
+		return &moment
+	}
+	order := models.Order{
+		ID:        "order-1",
+		Status:    "preparing",
+		CreatedAt: created,
+		Items: []models.OrderItem{
+			{MenuItemID: "burger", Quantity: 2},
+			{MenuItemID: "lemonade", Quantity: 1},
+			{MenuItemID: "removed", Quantity: 1}, // блюдо удалено из меню
+		},
+	}
+	menu := map[string]models.MenuItem{
+		"burger":   {Name: "Бургер", Category: "grill"},
+		"lemonade": {Name: "Лимонад", Category: "drinks"},
+	}
+	settings := &models.KitchenSettings{DefaultPrepMinutes: 10, PrepTargets: map[string]int{"grill": 20, "drinks": 5}}
+
+	tests := []struct {
+		name            string
+		progress        map[string]models.KitchenItemProgress
+		now             time.Time
+		wantStates      []string
+		wantLateItems   []bool
+		wantLate        bool
+		wantLateMinutes int
+		wantReady       bool
+	}{
+		{
+			name:          "fresh order",
+			now:           *at(4),
+			wantStates:    []string{models.KitchenItemQueued, models.KitchenItemQueued, models.KitchenItemQueued},
+			wantLateItems: []bool{false, false, false},
+		},
+		{
+			name:          "item over its own target while order is on time",
+			progress:      map[string]models.KitchenItemProgress{"1": {StartedAt: at(1), DoneAt: at(3)}},
+			now:           *at(12),
+			wantStates:    []string{models.KitchenItemQueued, models.KitchenItemDone, models.KitchenItemQueued},
+			wantLateItems: []bool{false, false, true},
+		},
+		{
+			name: "order past the longest target",
+			progress: map[string]models.KitchenItemProgress{
+				"0": {StartedAt: at(2)},
+				"1": {DoneAt: at(3)},
+				"2": {DoneAt: at(9)},
+			},
+			now:             *at(25),
+			wantStates:      []string{models.KitchenItemStarted, models.KitchenItemDone, models.KitchenItemDone},
+			wantLateItems:   []bool{true, false, false},
+			wantLate:        true,
+			wantLateMinutes: 5,
+		},
+		{
+			name: "ready order is never late",
+			progress: map[string]models.KitchenItemProgress{
+				"0": {DoneAt: at(28)},
+				"1": {DoneAt: at(3)},
+				"2": {DoneAt: at(9)},
+			},
+			now:           *at(40),
+			wantStates:    []string{models.KitchenItemDone, models.KitchenItemDone, models.KitchenItemDone},
+			wantLateItems: []bool{false, false, false},
+			wantReady:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := buildKitchenTicket(order, menu, settings, tt.progress, tt.now)
+
+			var states []string
+			var lateItems []bool
+			for _, item := range ticket.Items {
+				states = append(states, item.State)
+				lateItems = append(lateItems, item.Late)
+			}
+			if !reflect.DeepEqual(states, tt.wantStates) || !reflect.DeepEqual(lateItems, tt.wantLateItems) {
+				t.Errorf("items = %v late %v, want %v late %v", states, lateItems, tt.wantStates, tt.wantLateItems)
+			}
+			if ticket.Late != tt.wantLate || ticket.LateMinutes != tt.wantLateMinutes || ticket.Ready != tt.wantReady {
+				t.Errorf("ticket late = %v (%d min), ready = %v; want %v (%d min), %v",
+					ticket.Late, ticket.LateMinutes, ticket.Ready, tt.wantLate, tt.wantLateMinutes, tt.wantReady)
+			}
+			if want := created.Add(20 * time.Minute); !ticket.PromisedAt.Equal(want) {
+				t.Errorf("PromisedAt = %v, want %v", ticket.PromisedAt, want)
+			}
+		})
+	}
+
+	t.Run("stations and targets", func(t *testing.T) {
+		ticket := buildKitchenTicket(order, menu, settings, nil, created)
+		var got [][2]interface{}
+		for _, item := range ticket.Items {
+			got = append(got, [2]interface{}{item.Station, item.TargetMinutes})
+		}
+		want := [][2]interface{}{{"grill", 20}, {"drinks", 5}, {models.KitchenStationOther, 10}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("stations = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("empty order is not ready", func(t *testing.T) {
+		if ticket := buildKitchenTicket(models.Order{CreatedAt: created}, menu, settings, nil, created); ticket.Ready {
+			t.Errorf("Ready = true for an order without items")
+		}
+	})
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrImageOwnerNotFound = errors.New("image owner not found")
+	ErrMenuItemNotFound   = errors.New("menu item not found")
+)
+
+// imageVariantSpecs размеры, в которые пережимается каждое загруженное изображение
+var imageVariantSpecs = []imageVariantSpec{
+	{Name: models.ImageVariantThumbnail, MaxSize: 160, Square: true},
+	{Name: models.ImageVariantMedium, MaxSize: 640},
+	{Name: models.ImageVariantLarge, MaxSize: 1600},
+}
+
+// MediaService структура сервиса загрузки изображений: аватаров пользователей и ресторанов и фото блюд.
+// Файлы хранятся в BlobStore, в документах сущностей - только их ключи.
+type MediaService struct {
+	db           *mongo.Database
+	store        BlobStore
+	redisService *RedisService
+	maxBytes     int64
+	urlTTL       time.Duration
+	// processing ограничивает число одновременно обрабатываемых изображений,
+	// чтобы параллельные загрузки не умножали пиковое потребление памяти
+	processing chan struct{}
+}
+
+// NewMediaService создает новый экземпляр MediaService
+func NewMediaService(client *mongo.Client, dbName string, store BlobStore, redisService *RedisService) *MediaService {
+	urlTTL, err := time.ParseDuration(os.Getenv("MEDIA_URL_TTL"))
+	if err != nil || urlTTL <= 0 {
+		urlTTL = time.Hour
+	}
+	return &MediaService{
+		db:           client.Database(dbName),
+		store:        store,
+		redisService: redisService,
+		maxBytes:     int64(envInt("MEDIA_MAX_UPLOAD_MB", 10)) << 20,
+		urlTTL:       urlTTL,
+		processing:   make(chan struct{}, max(envInt("MEDIA_PROCESSING_CONCURRENCY", 2), 1)),
+	}
+}
+
+// Store возвращает хранилище файлов
+func (s *MediaService) Store() BlobStore {
+	return s.store
+}
+
+// MaxUploadBytes возвращает наибольший допустимый размер загружаемого файла
+func (s *MediaService) MaxUploadBytes() int64 {
+	return s.maxBytes
+}
+
+// SetUserAvatar загружает аватар пользователя, заменяя предыдущий
+func (s *MediaService) SetUserAvatar(ctx context.Context, userID primitive.ObjectID, data []byte) (*models.Image, error) {
+	return s.replaceImage(ctx, avatarSlot(EntityTypeUser, userID), data)
+}
+
+// RemoveUserAvatar удаляет загруженный аватар пользователя
+func (s *MediaService) RemoveUserAvatar(ctx context.Context, userID primitive.ObjectID) error {
+	return s.removeImage(ctx, avatarSlot(EntityTypeUser, userID))
+}
+
+// SetRestaurantAvatar загружает аватар ресторана, заменяя предыдущий
+func (s *MediaService) SetRestaurantAvatar(ctx context.Context, restaurantID primitive.ObjectID, data []byte) (*models.Image, error) {
+	return s.replaceImage(ctx, avatarSlot(EntityTypeRestaurant, restaurantID), data)
+}
+
+// RemoveRestaurantAvatar удаляет загруженный аватар ресторана
+func (s *MediaService) RemoveRestaurantAvatar(ctx context.Context, restaurantID primitive.ObjectID) error {
+	return s.removeImage(ctx, avatarSlot(EntityTypeRestaurant, restaurantID))
+}
+
+// SetMenuItemImage загружает фото блюда из меню ресторана, заменяя предыдущее
+func (s *MediaService) SetMenuItemImage(ctx context.Context, restaurantID primitive.ObjectID, itemID string, data []byte) (*models.Image, error) {
+	return s.replaceImage(ctx, menuItemSlot(restaurantID, itemID), data)
+}
+
+// RemoveMenuItemImage удаляет загруженное фото блюда
+func (s *MediaService) RemoveMenuItemImage(ctx context.Context, restaurantID primitive.ObjectID, itemID string) error {
+	return s.removeImage(ctx, menuItemSlot(restaurantID, itemID))
+}
+
+// imageOwner часть документа пользователя или ресторана, в которой хранятся изображения
+type imageOwner struct {
+	AvatarImage *models.Image     `bson:"avatarImage"`
+	Menu        []models.MenuItem `bson:"menu"`
+}
+
+// imageSlot место хранения изображения в документе владельца
+type imageSlot struct {
+	collection string
+	ownerID    primitive.ObjectID
+	filter     bson.M
+	field      string
+	prefix     string // префикс ключей файлов в хранилище
+	notFound   error
+	current    func(*imageOwner) *models.Image
+}
+
+// avatarSlot аватар пользователя или ресторана
+func avatarSlot(entityType string, ownerID primitive.ObjectID) imageSlot {
+	return imageSlot{
+		collection: entityType,
+		ownerID:    ownerID,
+		filter:     bson.M{"_id": ownerID},
+		field:      "avatarImage",
+		prefix:     "avatars/" + entityType + "/" + ownerID.Hex(),
+		notFound:   ErrImageOwnerNotFound,
+		current:    func(owner *imageOwner) *models.Image { return owner.AvatarImage },
+	}
+}
+
+// menuItemSlot фото блюда; позиция в массиве меню определяется оператором $
+func menuItemSlot(restaurantID primitive.ObjectID, itemID string) imageSlot {
+	return imageSlot{
+		collection: EntityTypeRestaurant,
+		ownerID:    restaurantID,
+		filter:     bson.M{"_id": restaurantID, "menu._id": itemID},
+		field:      "menu.$.image",
+		prefix:     "menu/" + restaurantID.Hex() + "/" + itemID,
+		notFound:   ErrMenuItemNotFound,
+		current: func(owner *imageOwner) *models.Image {
+			for _, item := range owner.Menu {
+				if item.ID == itemID {
+					return item.Image
+				}
+			}
+			return nil
+		},
+	}
+}
+
+// replaceImage обрабатывает и сохраняет изображение, записывает его в документ владельца
+// и удаляет файлы предыдущего изображения
+func (s *MediaService) replaceImage(ctx context.Context, slot imageSlot, data []byte) (*models.Image, error) {
+	if int64(len(data)) > s.maxBytes {
+		return nil, ErrImageTooLarge
+	}
+	// Владельца проверяем до обработки, чтобы не сохранять файлы впустую
+	count, err := s.db.Collection(slot.collection).CountDocuments(ctx, slot.filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding image owner failed")
+	}
+	if count == 0 {
+		return nil, slot.notFound
+	}
+
+	image, err := s.storeImage(ctx, slot.prefix, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner imageOwner
+	err = s.db.Collection(slot.collection).FindOneAndUpdate(ctx, slot.filter,
+		bson.M{"$set": bson.M{slot.field: image}},
+		options.FindOneAndUpdate().
+			SetProjection(bson.M{"avatarImage": 1, "menu": 1}).
+			SetReturnDocument(options.Before),
+	).Decode(&owner)
+	if err != nil {
+		s.deleteBlobs(image)
+		if err == mongo.ErrNoDocuments {
+			return nil, slot.notFound
+		}
+		return nil, errors.Wrap(err, "saving image failed")
+	}
+
+	if old := slot.current(&owner); old != nil {
+		s.deleteBlobs(old)
+	}
+	s.invalidate(slot.ownerID)
+	s.SignImage(image)
+	return image, nil
+}
+
+// removeImage удаляет изображение из документа владельца и его файлы; отсутствие изображения не ошибка
+func (s *MediaService) removeImage(ctx context.Context, slot imageSlot) error {
+	var owner imageOwner
+	err := s.db.Collection(slot.collection).FindOneAndUpdate(ctx, slot.filter,
+		bson.M{"$unset": bson.M{slot.field: ""}},
+		options.FindOneAndUpdate().
+			SetProjection(bson.M{"avatarImage": 1, "menu": 1}).
+			SetReturnDocument(options.Before),
+	).Decode(&owner)
+	if err == mongo.ErrNoDocuments {
+		return slot.notFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "removing image failed")
+	}
+
+	if old := slot.current(&owner); old != nil {
+		s.deleteBlobs(old)
+	}
+	s.invalidate(slot.ownerID)
+	return nil
+}
+
+// storeImage пережимает изображение во все размеры и сохраняет файлы в хранилище
+func (s *MediaService) storeImage(ctx context.Context, prefix string, data []byte) (*models.Image, error) {
+	variants, err := s.processImage(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	image := &models.Image{
+		ID:         primitive.NewObjectID(),
+		UploadedAt: time.Now(),
+	}
+	for _, variant := range variants {
+		extension := ".jpg"
+		if variant.ContentType == "image/png" {
+			extension = ".png"
+		}
+		key := prefix + "/" + image.ID.Hex() + "/" + variant.Spec.Name + extension
+		if err := s.store.Put(ctx, key, variant.ContentType, variant.Data); err != nil {
+			s.deleteBlobs(image)
+			return nil, errors.Wrap(err, "storing image failed")
+		}
+		image.Variants = append(image.Variants, models.ImageVariant{
+			Name:        variant.Spec.Name,
+			Key:         key,
+			ContentType: variant.ContentType,
+			Width:       variant.Width,
+			Height:      variant.Height,
+			Size:        len(variant.Data),
+		})
+	}
+	return image, nil
+}
+
+// processImage пережимает изображение, дожидаясь свободного слота обработки
+func (s *MediaService) processImage(ctx context.Context, data []byte) ([]processedImage, error) {
+	select {
+	case s.processing <- struct{}{}:
+		defer func() { <-s.processing }()
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "waiting for image processing failed")
+	}
+	return processImage(data, imageVariantSpecs)
+}
+
+// deleteBlobs удаляет файлы изображения; ошибки только логируются,
+// так как осиротевший файл не виден клиентам без подписанной ссылки
+func (s *MediaService) deleteBlobs(image *models.Image) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, variant := range image.Variants {
+		if err := s.store.Delete(ctx, variant.Key); err != nil {
+			log.Printf("Failed to delete image blob %s: %v", variant.Key, err)
+		}
+	}
+}
+
+// invalidate сбрасывает кэш сущности, чтобы GET /users/{id} и /restaurants/{id} увидели новое изображение
+func (s *MediaService) invalidate(ownerID primitive.ObjectID) {
+	if err := s.redisService.InvalidateEntity(ownerID.Hex()); err != nil {
+		log.Printf("Failed to invalidate cached entity %s: %v", ownerID.Hex(), err)
+	}
+}
+
+// SignImage заполняет подписанные ссылки на все размеры изображения
+func (s *MediaService) SignImage(image *models.Image) {
+	if image == nil {
+		return
+	}
+	for i := range image.Variants {
+		signed, err := s.store.SignedURL(image.Variants[i].Key, s.urlTTL)
+		if err != nil {
+			log.Printf("Failed to sign image url %s: %v", image.Variants[i].Key, err)
+			continue
+		}
+		image.Variants[i].URL = signed
+	}
+}
+
+// SignEntity заполняет подписанные ссылки на изображения в ответе: пользователях, ресторанах
+// с блюдами меню, их публичных карточках и содержащих их списках. Прочие значения не меняются.
+func (s *MediaService) SignEntity(entity interface{}) {
+	switch value := entity.(type) {
+	case *models.User:
+		s.SignImage(value.AvatarImage)
+	case *models.Restaurant:
+		s.SignImage(value.AvatarImage)
+		for i := range value.Menu {
+			s.SignImage(value.Menu[i].Image)
+		}
+	case []models.User:
+		for i := range value {
+			s.SignEntity(&value[i])
+		}
+	case []models.Restaurant:
+		for i := range value {
+			s.SignEntity(&value[i])
+		}
+	case *models.RestaurantSummary:
+		s.SignImage(value.AvatarImage)
+	case []models.RestaurantSummary:
+		for i := range value {
+			s.SignImage(value[i].AvatarImage)
+		}
+	case *models.RestaurantSearchPage:
+		s.SignEntity(value.Restaurants)
+	case []models.Recommendation:
+		for i := range value {
+			s.SignImage(value[i].Restaurant.AvatarImage)
+		}
+	case *models.UserSummary:
+		s.SignImage(value.AvatarImage)
+	case []models.UserSummary:
+		for i := range value {
+			s.SignImage(value[i].AvatarImage)
+		}
+	case *models.UserSummaryPage:
+		s.SignEntity(value.Users)
+	case *models.UserProfile:
+		s.SignImage(value.AvatarImage)
+	case *models.FavoriteList:
+		for i := range value.Entries {
+			s.SignEntity(value.Entries[i].Restaurant)
+		}
+	case []models.FavoriteList:
+		for i := range value {
+			s.SignEntity(&value[i])
+		}
+	case *models.ActivityFeedPage:
+		for i := range value.Activities {
+			s.SignEntity(value.Activities[i].Actor)
+		}
+	}
+}
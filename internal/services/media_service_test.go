@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestMediaServiceProcessImageWaitsForSlot(t *testing.T) {
+	s := &MediaService{processing: make(chan struct{}, 1)}
+	s.processing <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.processImage(ctx, []byte("not an image")); errors.Cause(err) != context.DeadlineExceeded {
+		t.Fatalf("processImage() with busy slots error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	<-s.processing
+	if _, err := s.processImage(context.Background(), []byte("not an image")); errors.Cause(err) != ErrUnsupportedImage {
+		t.Fatalf("processImage() error = %v, want %v", err, ErrUnsupportedImage)
+	}
+	if len(s.processing) != 0 {
+		t.Errorf("processing slots in use = %d, want 0", len(s.processing))
+	}
+}
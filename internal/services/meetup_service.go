@@ -0,0 +1,454 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const meetupsCollectionName = "meetups"
+
+// Типы уведомлений о встречах
+const (
+	NotificationMeetupInvite    = "meetup_invite"
+	NotificationMeetupRSVP      = "meetup_rsvp"
+	NotificationMeetupUpdated   = "meetup_updated"
+	NotificationMeetupCancelled = "meetup_cancelled"
+)
+
+var (
+	ErrMeetupNotFound   = errors.New("meetup not found")
+	ErrMeetupForbidden  = errors.New("not allowed to manage this meetup")
+	ErrMeetupNotActive  = errors.New("meetup is cancelled or already started")
+	ErrMeetupFull       = errors.New("no seats left")
+	ErrMeetupInPast     = errors.New("meetup must start in the future")
+	ErrNotInvited       = errors.New("user is not invited to this meetup")
+	ErrInviteeNotFriend = errors.New("only friends can be invited")
+	ErrInvalidRSVP      = errors.New("rsvp must be yes, no or maybe")
+	ErrSeatsBelowGuests = errors.New("seats cannot be less than confirmed guests")
+	ErrAlreadyInvited   = errors.New("user is already invited")
+	ErrCannotInviteSelf = errors.New("organizer cannot invite themselves")
+	ErrMeetupConflict   = errors.New("meetup was changed concurrently, retry")
+)
+
+// MeetupUpdate изменяемые организатором поля встречи; nil означает "не менять"
+type MeetupUpdate struct {
+	RestaurantID *primitive.ObjectID
+	Title        *string
+	Description  *string
+	StartsAt     *time.Time
+	Seats        *int
+}
+
+// MeetupService структура сервиса совместных походов в ресторан
+type MeetupService struct {
+	db                  *mongo.Database
+	friendService       *FriendService
+	notificationService *NotificationService
+}
+
+// NewMeetupService создает новый экземпляр MeetupService
+func NewMeetupService(client *mongo.Client, dbName string, friendService *FriendService, notificationService *NotificationService) *MeetupService {
+	return &MeetupService{
+		db:                  client.Database(dbName),
+		friendService:       friendService,
+		notificationService: notificationService,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции встреч
+func (s *MeetupService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(meetupsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "organizer_id", Value: 1}, {Key: "starts_at", Value: -1}}},
+		{Keys: bson.D{{Key: "invitations.user_id", Value: 1}, {Key: "starts_at", Value: -1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "starts_at", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating meetup indexes failed")
+	}
+	return nil
+}
+
+// CreateMeetup создает встречу и приглашает указанных друзей организатора
+func (s *MeetupService) CreateMeetup(ctx context.Context, meetup *models.Meetup, inviteeIDs []primitive.ObjectID) error {
+	if err := meetup.Validate(); err != nil {
+		return err
+	}
+	if !meetup.StartsAt.After(time.Now()) {
+		return ErrMeetupInPast
+	}
+	if err := ensureRestaurantExists(ctx, s.db, meetup.RestaurantID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	meetup.ID = primitive.NewObjectID()
+	meetup.Status = models.MeetupStatusScheduled
+	meetup.Invitations = []models.MeetupInvitation{}
+	meetup.CreatedAt = now
+	meetup.UpdatedAt = now
+
+	invitations, err := s.buildInvitations(ctx, meetup, inviteeIDs, now)
+	if err != nil {
+		return err
+	}
+	meetup.Invitations = invitations
+
+	if _, err := s.db.Collection(meetupsCollectionName).InsertOne(ctx, meetup); err != nil {
+		return errors.Wrap(err, "inserting meetup failed")
+	}
+
+	s.notify(ctx, invitedIDs(invitations), NotificationMeetupInvite, "Вас пригласили: "+meetup.Title, meetup)
+	return nil
+}
+
+// GetMeetup возвращает встречу, если пользователь является ее участником
+func (s *MeetupService) GetMeetup(ctx context.Context, userID, meetupID primitive.ObjectID) (*models.Meetup, error) {
+	meetup, err := s.findMeetup(ctx, meetupID)
+	if err != nil {
+		return nil, err
+	}
+	if !meetup.IsParticipant(userID) {
+		return nil, ErrMeetupNotFound
+	}
+	return meetup, nil
+}
+
+// ListUserMeetups возвращает встречи, которые пользователь организует или в которые приглашен
+func (s *MeetupService) ListUserMeetups(ctx context.Context, userID primitive.ObjectID, upcoming bool, page, limit int) ([]models.Meetup, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"organizer_id": userID},
+		bson.M{"invitations.user_id": userID},
+	}}
+	sort := bson.D{{Key: "starts_at", Value: -1}}
+	if upcoming {
+		filter["starts_at"] = bson.M{"$gte": time.Now()}
+		filter["status"] = models.MeetupStatusScheduled
+		sort = bson.D{{Key: "starts_at", Value: 1}}
+	}
+
+	cursor, err := s.db.Collection(meetupsCollectionName).Find(ctx, filter, options.Find().
+		SetSort(sort).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding meetups failed")
+	}
+	meetups := []models.Meetup{}
+	if err := cursor.All(ctx, &meetups); err != nil {
+		return nil, errors.Wrap(err, "decoding meetups failed")
+	}
+	return meetups, nil
+}
+
+// InviteFriends приглашает во встречу дополнительных друзей организатора
+func (s *MeetupService) InviteFriends(ctx context.Context, organizerID, meetupID primitive.ObjectID, inviteeIDs []primitive.ObjectID) (*models.Meetup, error) {
+	meetup, err := s.activeOrganizedMeetup(ctx, organizerID, meetupID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	invitations, err := s.buildInvitations(ctx, meetup, inviteeIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(invitations) == 0 {
+		return meetup, nil
+	}
+
+	newIDs := invitedIDs(invitations)
+	result, err := s.db.Collection(meetupsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": meetupID, "invitations.user_id": bson.M{"$nin": newIDs}},
+		bson.M{
+			"$push": bson.M{"invitations": bson.M{"$each": invitations}},
+			"$set":  bson.M{"updated_at": now},
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "inviting friends failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrAlreadyInvited
+	}
+
+	meetup.Invitations = append(meetup.Invitations, invitations...)
+	meetup.UpdatedAt = now
+	s.notify(ctx, newIDs, NotificationMeetupInvite, "Вас пригласили: "+meetup.Title, meetup)
+	return meetup, nil
+}
+
+// RSVP сохраняет ответ приглашенного. Ответ "yes" принимается, только если остались места.
+func (s *MeetupService) RSVP(ctx context.Context, userID, meetupID primitive.ObjectID, status string) (*models.Meetup, error) {
+	switch status {
+	case models.RSVPYes, models.RSVPNo, models.RSVPMaybe:
+	default:
+		return nil, ErrInvalidRSVP
+	}
+
+	meetup, err := s.findMeetup(ctx, meetupID)
+	if err != nil {
+		return nil, err
+	}
+	if !meetup.IsParticipant(userID) || meetup.OrganizerID == userID {
+		return nil, ErrNotInvited
+	}
+	if meetup.Status != models.MeetupStatusScheduled || !meetup.StartsAt.After(time.Now()) {
+		return nil, ErrMeetupNotActive
+	}
+
+	filter := bson.M{"_id": meetupID, "status": models.MeetupStatusScheduled, "invitations.user_id": userID}
+	if status == models.RSVPYes {
+		// Проверка мест выполняется атомарно вместе с обновлением: подтвержденные гости без
+		// текущего пользователя плюс организатор должны оставлять свободное место
+		filter["$expr"] = bson.M{"$lt": bson.A{
+			bson.M{"$add": bson.A{1, bson.M{"$size": bson.M{"$filter": bson.M{
+				"input": "$invitations",
+				"cond": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$$this.status", models.RSVPYes}},
+					bson.M{"$ne": bson.A{"$$this.user_id", userID}},
+				}},
+			}}}}},
+			"$seats",
+		}}
+	}
+
+	now := time.Now()
+	var updated models.Meetup
+	err = s.db.Collection(meetupsCollectionName).FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{
+			"invitations.$[invitee].status":       status,
+			"invitations.$[invitee].responded_at": now,
+			"updated_at":                          now,
+		}},
+		options.FindOneAndUpdate().
+			SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"invitee.user_id": userID}}}).
+			SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		if status == models.RSVPYes {
+			return nil, ErrMeetupFull
+		}
+		return nil, ErrMeetupNotActive
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "saving rsvp failed")
+	}
+
+	s.notify(ctx, []primitive.ObjectID{updated.OrganizerID}, NotificationMeetupRSVP, "Ответ на приглашение: "+updated.Title, &updated)
+	return &updated, nil
+}
+
+// UpdateMeetup изменяет встречу и уведомляет участников
+func (s *MeetupService) UpdateMeetup(ctx context.Context, organizerID, meetupID primitive.ObjectID, update MeetupUpdate) (*models.Meetup, error) {
+	meetup, err := s.activeOrganizedMeetup(ctx, organizerID, meetupID)
+	if err != nil {
+		return nil, err
+	}
+	previousUpdatedAt := meetup.UpdatedAt
+
+	if update.RestaurantID != nil && *update.RestaurantID != meetup.RestaurantID {
+		if err := ensureRestaurantExists(ctx, s.db, *update.RestaurantID); err != nil {
+			return nil, err
+		}
+		meetup.RestaurantID = *update.RestaurantID
+	}
+	if update.Title != nil {
+		meetup.Title = *update.Title
+	}
+	if update.Description != nil {
+		meetup.Description = *update.Description
+	}
+	if update.StartsAt != nil {
+		if !update.StartsAt.After(time.Now()) {
+			return nil, ErrMeetupInPast
+		}
+		meetup.StartsAt = *update.StartsAt
+	}
+	if update.Seats != nil {
+		if *update.Seats < meetup.ConfirmedCount() {
+			return nil, ErrSeatsBelowGuests
+		}
+		meetup.Seats = *update.Seats
+	}
+	if err := meetup.Validate(); err != nil {
+		return nil, err
+	}
+	meetup.UpdatedAt = time.Now()
+
+	// Оптимистичная блокировка по updated_at защищает проверку мест от одновременных ответов
+	result, err := s.db.Collection(meetupsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": meetupID, "updated_at": previousUpdatedAt},
+		bson.M{"$set": bson.M{
+			"restaurant_id": meetup.RestaurantID,
+			"title":         meetup.Title,
+			"description":   meetup.Description,
+			"starts_at":     meetup.StartsAt,
+			"seats":         meetup.Seats,
+			"updated_at":    meetup.UpdatedAt,
+		}},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating meetup failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrMeetupConflict
+	}
+
+	s.notify(ctx, respondingIDs(meetup), NotificationMeetupUpdated, "Встреча изменена: "+meetup.Title, meetup)
+	return meetup, nil
+}
+
+// CancelMeetup отменяет встречу и уведомляет участников
+func (s *MeetupService) CancelMeetup(ctx context.Context, organizerID, meetupID primitive.ObjectID) error {
+	meetup, err := s.activeOrganizedMeetup(ctx, organizerID, meetupID)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.Collection(meetupsCollectionName).UpdateByID(ctx, meetupID, bson.M{"$set": bson.M{
+		"status":     models.MeetupStatusCancelled,
+		"updated_at": time.Now(),
+	}})
+	if err != nil {
+		return errors.Wrap(err, "cancelling meetup failed")
+	}
+
+	s.notify(ctx, respondingIDs(meetup), NotificationMeetupCancelled, "Встреча отменена: "+meetup.Title, meetup)
+	return nil
+}
+
+// ListRestaurantMeetups возвращает предстоящие групповые визиты в ресторан в заданном интервале
+func (s *MeetupService) ListRestaurantMeetups(ctx context.Context, restaurantID primitive.ObjectID, from, to time.Time) ([]models.RestaurantMeetupView, error) {
+	cursor, err := s.db.Collection(meetupsCollectionName).Find(ctx, bson.M{
+		"restaurant_id": restaurantID,
+		"status":        models.MeetupStatusScheduled,
+		"starts_at":     bson.M{"$gte": from, "$lt": to},
+	}, options.Find().SetSort(bson.D{{Key: "starts_at", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurant meetups failed")
+	}
+	var meetups []models.Meetup
+	if err := cursor.All(ctx, &meetups); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurant meetups failed")
+	}
+
+	views := make([]models.RestaurantMeetupView, 0, len(meetups))
+	for _, meetup := range meetups {
+		view := models.RestaurantMeetupView{
+			ID:              meetup.ID,
+			Title:           meetup.Title,
+			StartsAt:        meetup.StartsAt,
+			Seats:           meetup.Seats,
+			ConfirmedGuests: meetup.ConfirmedCount(),
+		}
+		for _, invitation := range meetup.Invitations {
+			if invitation.Status == models.RSVPMaybe {
+				view.MaybeGuests++
+			}
+		}
+		views = append(views, view)
+	}
+	return views, nil
+}
+
+// findMeetup возвращает встречу по ID
+func (s *MeetupService) findMeetup(ctx context.Context, meetupID primitive.ObjectID) (*models.Meetup, error) {
+	var meetup models.Meetup
+	err := s.db.Collection(meetupsCollectionName).FindOne(ctx, bson.M{"_id": meetupID}).Decode(&meetup)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrMeetupNotFound
+		}
+		return nil, errors.Wrap(err, "finding meetup failed")
+	}
+	return &meetup, nil
+}
+
+// activeOrganizedMeetup возвращает предстоящую встречу, если пользователь ее организатор
+func (s *MeetupService) activeOrganizedMeetup(ctx context.Context, organizerID, meetupID primitive.ObjectID) (*models.Meetup, error) {
+	meetup, err := s.findMeetup(ctx, meetupID)
+	if err != nil {
+		return nil, err
+	}
+	if meetup.OrganizerID != organizerID {
+		return nil, ErrMeetupForbidden
+	}
+	if meetup.Status != models.MeetupStatusScheduled || !meetup.StartsAt.After(time.Now()) {
+		return nil, ErrMeetupNotActive
+	}
+	return meetup, nil
+}
+
+// buildInvitations проверяет приглашаемых и создает для них приглашения.
+// Приглашать можно только друзей организатора, уже приглашенные пропускаются.
+func (s *MeetupService) buildInvitations(ctx context.Context, meetup *models.Meetup, inviteeIDs []primitive.ObjectID, now time.Time) ([]models.MeetupInvitation, error) {
+	invitations := []models.MeetupInvitation{}
+	seen := make(map[primitive.ObjectID]bool, len(inviteeIDs))
+	for _, inviteeID := range inviteeIDs {
+		if inviteeID == meetup.OrganizerID {
+			return nil, ErrCannotInviteSelf
+		}
+		if seen[inviteeID] || meetup.IsParticipant(inviteeID) {
+			continue
+		}
+		seen[inviteeID] = true
+
+		// Блокировка удаляет дружбу, поэтому проверка дружбы исключает и заблокированных
+		friends, err := s.friendService.AreFriends(ctx, meetup.OrganizerID, inviteeID)
+		if err != nil {
+			return nil, err
+		}
+		if !friends {
+			return nil, ErrInviteeNotFriend
+		}
+
+		invitations = append(invitations, models.MeetupInvitation{
+			UserID:    inviteeID,
+			Status:    models.RSVPInvited,
+			InvitedAt: now,
+		})
+	}
+	return invitations, nil
+}
+
+// notify отправляет уведомление о встрече; ошибка уведомления не отменяет основное действие
+func (s *MeetupService) notify(ctx context.Context, userIDs []primitive.ObjectID, notificationType, title string, meetup *models.Meetup) {
+	err := s.notificationService.Notify(ctx, userIDs, notificationType, title, map[string]string{
+		"meetup_id":     meetup.ID.Hex(),
+		"meetup_title":  meetup.Title,
+		"restaurant_id": meetup.RestaurantID.Hex(),
+		"starts_at":     meetup.StartsAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notifications: %v", notificationType, err)
+	}
+}
+
+// invitedIDs возвращает ID пользователей из приглашений
+func invitedIDs(invitations []models.MeetupInvitation) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(invitations))
+	for _, invitation := range invitations {
+		ids = append(ids, invitation.UserID)
+	}
+	return ids
+}
+
+// respondingIDs возвращает приглашенных, не отказавшихся от встречи
+func respondingIDs(meetup *models.Meetup) []primitive.ObjectID {
+	var ids []primitive.ObjectID
+	for _, invitation := range meetup.Invitations {
+		if invitation.Status != models.RSVPNo {
+			ids = append(ids, invitation.UserID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestBuildInvitations(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	organizer, invited, friend, stranger := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	meetup := func() *models.Meetup {
+		return &models.Meetup{
+			OrganizerID: organizer,
+			Invitations: []models.MeetupInvitation{{UserID: invited, Status: models.RSVPYes}},
+		}
+	}
+	friendship := func(other primitive.ObjectID) bson.D {
+		low, high := orderPair(organizer, other)
+		return bson.D{{Key: "user_low", Value: low}, {Key: "user_high", Value: high}, {Key: "status", Value: models.FriendshipStatusAccepted}}
+	}
+
+	mt.Run("skips duplicates and participants", func(mt *mtest.T) {
+		s := &MeetupService{friendService: &FriendService{db: mt.DB}}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch, friendship(friend)))
+
+		got, err := s.buildInvitations(context.Background(), meetup(), []primitive.ObjectID{friend, friend, invited}, now)
+		if err != nil {
+			mt.Fatalf("buildInvitations() error = %v", err)
+		}
+		want := []models.MeetupInvitation{{UserID: friend, Status: models.RSVPInvited, InvitedAt: now}}
+		if !reflect.DeepEqual(got, want) {
+			mt.Errorf("buildInvitations() = %+v, want %+v", got, want)
+		}
+	})
+
+	mt.Run("rejects strangers", func(mt *mtest.T) {
+		s := &MeetupService{friendService: &FriendService{db: mt.DB}}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.friendships", mtest.FirstBatch))
+
+		if _, err := s.buildInvitations(context.Background(), meetup(), []primitive.ObjectID{stranger}, now); err != ErrInviteeNotFriend {
+			mt.Fatalf("buildInvitations() error = %v, want %v", err, ErrInviteeNotFriend)
+		}
+	})
+
+	mt.Run("rejects organizer", func(mt *mtest.T) {
+		s := &MeetupService{friendService: &FriendService{db: mt.DB}}
+
+		if _, err := s.buildInvitations(context.Background(), meetup(), []primitive.ObjectID{organizer}, now); err != ErrCannotInviteSelf {
+			mt.Fatalf("buildInvitations() error = %v, want %v", err, ErrCannotInviteSelf)
+		}
+	})
+}
+
+func TestRespondingIDs(t *testing.T) {
+	yes, maybe, no, pending := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	meetup := &models.Meetup{Invitations: []models.MeetupInvitation{
+		{UserID: yes, Status: models.RSVPYes},
+		{UserID: maybe, Status: models.RSVPMaybe},
+		{UserID: no, Status: models.RSVPNo},
+		{UserID: pending, Status: models.RSVPInvited},
+	}}
+
+	want := []primitive.ObjectID{yes, maybe, pending}
+	if got := respondingIDs(meetup); !reflect.DeepEqual(got, want) {
+		t.Errorf("respondingIDs() = %v, want %v", got, want)
+	}
+}
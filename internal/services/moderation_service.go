@@ -0,0 +1,259 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	reviewReportsCollectionName   = "review_reports"
+	moderationAuditCollectionName = "moderation_audit"
+)
+
+// Флаги автоматической модерации
+const (
+	ModerationFlagBannedWords = "banned_words"
+	ModerationFlagLinkSpam    = "link_spam"
+	ModerationFlagRateLimit   = "rate_limit"
+)
+
+var (
+	ErrAlreadyReported         = errors.New("review has already been reported by this account")
+	ErrInvalidModerationAction = errors.New("unknown moderation action")
+)
+
+var linkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+`)
+
+// ModerationRules настройки автоматической проверки отзывов
+type ModerationRules struct {
+	BannedWords      []string // запрещенные слова в нижнем регистре
+	MaxLinks         int      // максимальное количество ссылок в тексте
+	MaxReviewsPerDay int      // максимальное количество отзывов пользователя за сутки
+	ReportThreshold  int      // количество жалоб, после которого отзыв возвращается в очередь
+}
+
+// ModerationRulesFromEnv загружает настройки модерации из переменных окружения
+func ModerationRulesFromEnv() ModerationRules {
+	rules := ModerationRules{
+		MaxLinks:         envInt("MODERATION_MAX_LINKS", 0),
+		MaxReviewsPerDay: envInt("MODERATION_MAX_REVIEWS_PER_DAY", 5),
+		ReportThreshold:  envInt("MODERATION_REPORT_THRESHOLD", 3),
+	}
+	for _, word := range strings.Split(os.Getenv("MODERATION_BANNED_WORDS"), ",") {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			rules.BannedWords = append(rules.BannedWords, word)
+		}
+	}
+	return rules
+}
+
+// CheckText возвращает флаги модерации для текста отзыва
+func (m ModerationRules) CheckText(text string) []string {
+	var flags []string
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	banned := make(map[string]struct{}, len(m.BannedWords))
+	for _, word := range m.BannedWords {
+		banned[word] = struct{}{}
+	}
+	for _, word := range words {
+		if _, ok := banned[word]; ok {
+			flags = append(flags, ModerationFlagBannedWords)
+			break
+		}
+	}
+
+	if len(linkPattern.FindAllString(text, -1)) > m.MaxLinks {
+		flags = append(flags, ModerationFlagLinkSpam)
+	}
+	return flags
+}
+
+// ModerationService структура сервиса модерации отзывов
+type ModerationService struct {
+	db            *mongo.Database
+	reviewService *ReviewService
+}
+
+// NewModerationService создает новый экземпляр ModerationService
+func NewModerationService(client *mongo.Client, dbName string, reviewService *ReviewService) *ModerationService {
+	return &ModerationService{
+		db:            client.Database(dbName),
+		reviewService: reviewService,
+	}
+}
+
+// EnsureIndexes создает индексы коллекций жалоб и журнала модерации
+func (s *ModerationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(reviewReportsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "review_id", Value: 1}, {Key: "reporter_id", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating report indexes failed")
+	}
+
+	_, err = s.db.Collection(moderationAuditCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "review_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating audit indexes failed")
+	}
+
+	_, err = s.db.Collection(reviewsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "status", Value: 1}, {Key: "report_count", Value: -1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating moderation queue index failed")
+	}
+	return nil
+}
+
+// ReportReview сохраняет жалобу на отзыв и возвращает его в очередь при достижении порога
+func (s *ModerationService) ReportReview(ctx context.Context, report *models.ReviewReport) error {
+	if err := validator.New().Struct(report); err != nil {
+		return err
+	}
+	if _, err := s.reviewService.GetReview(ctx, report.ReviewID); err != nil {
+		return err
+	}
+
+	report.ID = primitive.NewObjectID()
+	report.CreatedAt = time.Now()
+	if _, err := s.db.Collection(reviewReportsCollectionName).InsertOne(ctx, report); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyReported
+		}
+		return errors.Wrap(err, "inserting report failed")
+	}
+
+	var review models.Review
+	err := s.db.Collection(reviewsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": report.ReviewID},
+		bson.M{"$inc": bson.M{"report_count": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err != nil {
+		return errors.Wrap(err, "updating report count failed")
+	}
+
+	threshold := s.reviewService.rules.ReportThreshold
+	if threshold > 0 && review.ReportCount >= threshold && isReviewVisible(review.Status) {
+		_, err := s.reviewService.SetReviewStatus(ctx, review.ID, models.ReviewStatusPending, primitive.NilObjectID,
+			models.ModerationActionReported, report.Reason)
+		return err
+	}
+	return nil
+}
+
+// ListQueue возвращает отзывы с указанным статусом, начиная с наиболее обжалованных
+func (s *ModerationService) ListQueue(ctx context.Context, status string, page, limit int) (*models.ReviewPage, error) {
+	if status == "" {
+		status = models.ReviewStatusPending
+	}
+	collection := s.db.Collection(reviewsCollectionName)
+	filter := bson.M{"status": status}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting queue failed")
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "report_count", Value: -1}, {Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding queue failed")
+	}
+	reviews := []models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, errors.Wrap(err, "decoding queue failed")
+	}
+
+	return &models.ReviewPage{Reviews: reviews, Total: total, Page: page, Limit: limit}, nil
+}
+
+// BulkModerate применяет действие модератора к списку отзывов
+func (s *ModerationService) BulkModerate(ctx context.Context, actorID primitive.ObjectID, reviewIDs []primitive.ObjectID, action, reason string) ([]models.BulkModerationResult, error) {
+	var status string
+	switch action {
+	case models.ModerationActionApprove:
+		status = models.ReviewStatusApproved
+	case models.ModerationActionReject:
+		status = models.ReviewStatusRejected
+	default:
+		return nil, ErrInvalidModerationAction
+	}
+
+	results := make([]models.BulkModerationResult, 0, len(reviewIDs))
+	for _, reviewID := range reviewIDs {
+		result := models.BulkModerationResult{ReviewID: reviewID}
+		review, err := s.reviewService.SetReviewStatus(ctx, reviewID, status, actorID, action, reason)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Status = review.Status
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// ListAudit возвращает записи журнала модерации, при необходимости по одному отзыву
+func (s *ModerationService) ListAudit(ctx context.Context, reviewID primitive.ObjectID, page, limit int) ([]models.ModerationAuditEntry, error) {
+	filter := bson.M{}
+	if !reviewID.IsZero() {
+		filter["review_id"] = reviewID
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := s.db.Collection(moderationAuditCollectionName).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding audit entries failed")
+	}
+	entries := []models.ModerationAuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, errors.Wrap(err, "decoding audit entries failed")
+	}
+	return entries, nil
+}
+
+// writeModerationAudit добавляет запись в журнал модерации
+func writeModerationAudit(ctx context.Context, db *mongo.Database, entry models.ModerationAuditEntry) error {
+	entry.ID = primitive.NewObjectID()
+	entry.CreatedAt = time.Now()
+	if _, err := db.Collection(moderationAuditCollectionName).InsertOne(ctx, entry); err != nil {
+		return errors.Wrap(err, "writing moderation audit failed")
+	}
+	return nil
+}
+
+// envInt возвращает целочисленное значение переменной окружения или значение по умолчанию
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheckText(t *testing.T) {
+	rules := ModerationRules{BannedWords: []string{"спам", "scam"}, MaxLinks: 1}
+
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "clean", text: "Вкусные пельмени, быстрая доставка"},
+		{name: "banned word in any case", text: "Это SCAM!", want: []string{ModerationFlagBannedWords}},
+		{name: "banned word next to punctuation", text: "спам,спам,спам", want: []string{ModerationFlagBannedWords}},
+		{name: "banned word inside another word", text: "Спамеры тут ни при чем"},
+		{name: "links within limit", text: "Меню на https://example.com"},
+		{name: "too many links", text: "http://a.example и www.b.example", want: []string{ModerationFlagLinkSpam}},
+		{
+			name: "both rules",
+			text: "спам https://a.example https://b.example",
+			want: []string{ModerationFlagBannedWords, ModerationFlagLinkSpam},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules.CheckText(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckText(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModerationRulesFromEnv(t *testing.T) {
+	t.Setenv("MODERATION_BANNED_WORDS", " Спам, ,SCAM ")
+	t.Setenv("MODERATION_MAX_LINKS", "2")
+	t.Setenv("MODERATION_MAX_REVIEWS_PER_DAY", "")
+	t.Setenv("MODERATION_REPORT_THRESHOLD", "")
+
+	got := ModerationRulesFromEnv()
+	want := ModerationRules{BannedWords: []string{"спам", "scam"}, MaxLinks: 2, MaxReviewsPerDay: 5, ReportThreshold: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ModerationRulesFromEnv() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"sort"
+	"strconv"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const searchCollectionName = "search_documents"
+
+// searchCandidateLimit - количество кандидатов, выбираемых каждым способом поиска перед ранжированием
+const searchCandidateLimit = 200
+
+// searchText текст на одном языке; язык задает стемминг текстового индекса MongoDB
+type searchText struct {
+	Language string `bson:"language"`
+	Text     string `bson:"text"`
+}
+
+// searchDocument документ поискового индекса.
+// Текст хранится на русском и английском, чтобы стемминг работал для обоих языков.
+type searchDocument struct {
+	ID           string             `bson:"_id"`
+	Kind         string             `bson:"kind"`
+	RestaurantID primitive.ObjectID `bson:"restaurant_id"`
+	MenuItemID   string             `bson:"menu_item_id,omitempty"`
+	Name         string             `bson:"name"`
+	Description  string             `bson:"description"`
+	Title        []searchText       `bson:"title"`
+	Body         []searchText       `bson:"body"`
+	Terms        []string           `bson:"terms"`
+	Prefixes     []string           `bson:"prefixes"`
+	TypoKeys     []string           `bson:"typo_keys"`
+	IndexedAt    time.Time          `bson:"indexed_at"`
+	TextScore    float64            `bson:"score,omitempty"`
+}
+
+// MongoSearchIndex реализация SearchIndex на текстовых индексах MongoDB.
+// Опечатки и поиск по началу слова обрабатываются по префиксам слов, ключам опечаток и расстоянию редактирования.
+type MongoSearchIndex struct {
+	db *mongo.Database
+}
+
+// NewMongoSearchIndex создает новый экземпляр MongoSearchIndex
+func NewMongoSearchIndex(client *mongo.Client, dbName string) *MongoSearchIndex {
+	return &MongoSearchIndex{
+		db: client.Database(dbName),
+	}
+}
+
+// EnsureIndexes создает текстовый индекс и индексы префиксов
+func (s *MongoSearchIndex) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(searchCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "title.text", Value: "text"}, {Key: "body.text", Value: "text"}},
+			Options: options.Index().
+				SetWeights(bson.M{"title.text": 10, "body.text": 1}).
+				SetDefaultLanguage("russian").
+				SetLanguageOverride("language"),
+		},
+		{Keys: bson.D{{Key: "prefixes", Value: 1}}},
+		{Keys: bson.D{{Key: "typo_keys", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "indexed_at", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating search indexes failed")
+	}
+	return nil
+}
+
+// IndexRestaurant заменяет документы ресторана и его блюд в индексе. Документы обновляются
+// на месте, а затем удаляются только устаревшие, поэтому ресторан не пропадает из поиска
+// во время переиндексации и остается в нем, если запись не удалась.
+func (s *MongoSearchIndex) IndexRestaurant(ctx context.Context, restaurant *models.Restaurant) error {
+	if restaurant.Banned {
+		return s.RemoveRestaurant(ctx, restaurant.ID)
+	}
+
+	now := time.Now()
+	docs := restaurantSearchDocuments(restaurant, now)
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	for _, doc := range docs {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": doc.ID}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	collection := s.db.Collection(searchCollectionName)
+	if _, err := collection.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return errors.Wrap(err, "indexing restaurant failed")
+	}
+
+	// Блюда, удаленные из меню, не были обновлены в этом проходе
+	_, err := collection.DeleteMany(ctx, bson.M{"restaurant_id": restaurant.ID, "indexed_at": bson.M{"$lt": now}})
+	if err != nil {
+		return errors.Wrap(err, "removing stale documents failed")
+	}
+	return nil
+}
+
+// restaurantSearchDocuments создает документы индекса для ресторана и его блюд.
+// Ключ документа блюда - ID блюда; блюда с повторяющимся или пустым ID получают ключ по позиции в меню.
+func restaurantSearchDocuments(restaurant *models.Restaurant, now time.Time) []searchDocument {
+	docs := []searchDocument{newSearchDocument(models.SearchKindRestaurant, restaurant.ID, "", "",
+		restaurant.Name, restaurant.Description, restaurant.Category, now)}
+	seen := make(map[string]bool, len(restaurant.Menu))
+	for i, item := range restaurant.Menu {
+		key := item.ID
+		if key == "" || seen[key] {
+			key = "#" + strconv.Itoa(i)
+		}
+		seen[key] = true
+		docs = append(docs, newSearchDocument(models.SearchKindDish, restaurant.ID, item.ID, key,
+			item.Name, item.Description, item.Category+" "+restaurant.Name, now))
+	}
+	return docs
+}
+
+// RemoveRestaurant удаляет ресторан и его блюда из индекса
+func (s *MongoSearchIndex) RemoveRestaurant(ctx context.Context, restaurantID primitive.ObjectID) error {
+	_, err := s.db.Collection(searchCollectionName).DeleteMany(ctx, bson.M{"restaurant_id": restaurantID})
+	if err != nil {
+		return errors.Wrap(err, "removing restaurant from index failed")
+	}
+	return nil
+}
+
+// Prune удаляет документы ресторанов, не обновленных при последней полной переиндексации
+func (s *MongoSearchIndex) Prune(ctx context.Context, before time.Time) error {
+	_, err := s.db.Collection(searchCollectionName).DeleteMany(ctx, bson.M{"indexed_at": bson.M{"$lt": before}})
+	if err != nil {
+		return errors.Wrap(err, "pruning search index failed")
+	}
+	return nil
+}
+
+// Search ищет по текстовому индексу со стеммингом и по префиксам слов, объединяя результаты
+func (s *MongoSearchIndex) Search(ctx context.Context, text string, kinds []string, limit int) ([]models.SearchHit, error) {
+	queryTerms := tokenize(text)
+	if len(queryTerms) == 0 {
+		return []models.SearchHit{}, nil
+	}
+
+	base := bson.M{}
+	if len(kinds) > 0 {
+		base["kind"] = bson.M{"$in": kinds}
+	}
+	candidates := map[string]*searchDocument{}
+
+	// Полнотекстовый поиск со стеммингом языка запроса
+	textFilter := bson.M{"$text": bson.M{"$search": text, "$language": queryLanguage(text)}}
+	for k, v := range base {
+		textFilter[k] = v
+	}
+	textOptions := options.Find().
+		SetProjection(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetSort(bson.M{"score": bson.M{"$meta": "textScore"}}).
+		SetLimit(searchCandidateLimit)
+	if err := s.collectCandidates(ctx, textFilter, textOptions, candidates); err != nil {
+		return nil, err
+	}
+
+	// Поиск по началу слов для неполных слов и опечаток в конце слова
+	var prefixes []string
+	for _, term := range queryTerms {
+		prefixes = append(prefixes, termPrefix(term))
+	}
+	prefixFilter := bson.M{"prefixes": bson.M{"$in": prefixes}}
+	for k, v := range base {
+		prefixFilter[k] = v
+	}
+	if err := s.collectCandidates(ctx, prefixFilter, options.Find().SetLimit(searchCandidateLimit), candidates); err != nil {
+		return nil, err
+	}
+
+	// Поиск слов с опечаткой в первых трех буквах; короче четырех букв опечатки не исправляются
+	var typos []string
+	for _, term := range queryTerms {
+		if len([]rune(term)) >= 4 {
+			typos = append(typos, typoKeys(term)...)
+		}
+	}
+	if len(typos) > 0 {
+		typoFilter := bson.M{"typo_keys": bson.M{"$in": uniqueStrings(typos)}}
+		for k, v := range base {
+			typoFilter[k] = v
+		}
+		if err := s.collectCandidates(ctx, typoFilter, options.Find().SetLimit(searchCandidateLimit), candidates); err != nil {
+			return nil, err
+		}
+	}
+
+	hits := make([]models.SearchHit, 0, len(candidates))
+	for _, doc := range candidates {
+		score := doc.TextScore + fuzzyScore(queryTerms, doc.Terms)
+		if score == 0 {
+			continue
+		}
+
+		hit := models.SearchHit{
+			Kind:         doc.Kind,
+			RestaurantID: doc.RestaurantID,
+			MenuItemID:   doc.MenuItemID,
+			Name:         doc.Name,
+			Description:  doc.Description,
+			Score:        score,
+			Highlights:   map[string]string{},
+		}
+		if fragment := highlight(doc.Name, queryTerms, 120); fragment != "" {
+			hit.Highlights["name"] = fragment
+		}
+		if fragment := highlight(doc.Description, queryTerms, 160); fragment != "" {
+			hit.Highlights["description"] = fragment
+		}
+		hits = append(hits, hit)
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// collectCandidates добавляет найденные документы в набор кандидатов, сохраняя лучший текстовый балл
+func (s *MongoSearchIndex) collectCandidates(ctx context.Context, filter bson.M, findOptions *options.FindOptions, candidates map[string]*searchDocument) error {
+	cursor, err := s.db.Collection(searchCollectionName).Find(ctx, filter, findOptions)
+	if err != nil {
+		return errors.Wrap(err, "searching index failed")
+	}
+	var docs []searchDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return errors.Wrap(err, "decoding search results failed")
+	}
+	for i := range docs {
+		if existing, ok := candidates[docs[i].ID]; ok {
+			if docs[i].TextScore > existing.TextScore {
+				existing.TextScore = docs[i].TextScore
+			}
+			continue
+		}
+		candidates[docs[i].ID] = &docs[i]
+	}
+	return nil
+}
+
+// newSearchDocument создает документ индекса для ресторана или блюда; key отличает блюда одного ресторана
+func newSearchDocument(kind string, restaurantID primitive.ObjectID, menuItemID, key, name, description, extra string, now time.Time) searchDocument {
+	id := kind + ":" + restaurantID.Hex()
+	if key != "" {
+		id += ":" + key
+	}
+	body := description + " " + extra
+
+	terms := uniqueStrings(tokenize(name + " " + body))
+	prefixSet := make([]string, 0, len(terms)*2)
+	var typoSet []string
+	for _, term := range terms {
+		runes := []rune(term)
+		for n := 2; n <= 3 && n <= len(runes); n++ {
+			prefixSet = append(prefixSet, string(runes[:n]))
+		}
+		typoSet = append(typoSet, typoKeys(term)...)
+	}
+
+	return searchDocument{
+		ID:           id,
+		Kind:         kind,
+		RestaurantID: restaurantID,
+		MenuItemID:   menuItemID,
+		Name:         name,
+		Description:  description,
+		Title:        []searchText{{Language: "russian", Text: name}, {Language: "english", Text: name}},
+		Body:         []searchText{{Language: "russian", Text: body}, {Language: "english", Text: body}},
+		Terms:        terms,
+		Prefixes:     uniqueStrings(prefixSet),
+		TypoKeys:     uniqueStrings(typoSet),
+		IndexedAt:    now,
+	}
+}
+
+// fuzzyScore суммирует лучшие совпадения слов запроса со словами документа
+func fuzzyScore(queryTerms, docTerms []string) float64 {
+	total := 0.0
+	for _, query := range queryTerms {
+		best := 0.0
+		for _, term := range docTerms {
+			if score := termMatchScore(query, term); score > best {
+				best = score
+			}
+		}
+		total += best
+	}
+	return total
+}
+
+// termPrefix возвращает префикс слова, по которому ищутся кандидаты.
+// Опечатки в первых трех буквах префикс не находит, для них служат typoKeys.
+func termPrefix(term string) string {
+	runes := []rune(term)
+	if len(runes) > 3 {
+		return string(runes[:3])
+	}
+	return term
+}
+
+// typoKeys возвращает первые три буквы слова с удаленной одной из них. Замена, пропуск,
+// вставка или перестановка буквы в начале слова оставляют хотя бы один общий ключ,
+// поэтому по ним находятся кандидаты с опечаткой в префиксе.
+func typoKeys(term string) []string {
+	runes := []rune(term)
+	if len(runes) < 3 {
+		return nil
+	}
+	head := runes[:3]
+	keys := make([]string, 0, 3)
+	for skip := range head {
+		key := make([]rune, 0, 2)
+		for i, r := range head {
+			if i != skip {
+				key = append(key, r)
+			}
+		}
+		keys = append(keys, string(key))
+	}
+	return uniqueStrings(keys)
+}
+
+// queryLanguage определяет язык стемминга запроса по алфавиту
+func queryLanguage(text string) string {
+	for _, r := range text {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return "russian"
+		}
+	}
+	return "english"
+}
+
+// uniqueStrings возвращает строки без повторов, сохраняя порядок
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]struct{}, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
+}
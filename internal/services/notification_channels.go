@@ -0,0 +1,249 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ErrPermanentDelivery оборачивает ошибки отправки, повтор которых не поможет
+var ErrPermanentDelivery = errors.New("permanent delivery failure")
+
+// ErrPushSubscriptionGone означает, что браузер отозвал подписку
+var ErrPushSubscriptionGone = errors.Wrap(ErrPermanentDelivery, "push subscription is gone")
+
+// NotificationChannel отправляет сообщение из очереди по одному внешнему каналу
+type NotificationChannel interface {
+	Send(ctx context.Context, msg *models.OutboxMessage) error
+}
+
+// permanent помечает ошибку как неповторяемую
+func permanent(err error) error {
+	return errors.Wrap(ErrPermanentDelivery, err.Error())
+}
+
+// NewNotificationChannelsFromEnv создает адаптеры каналов по NOTIFY_EMAIL_DRIVER,
+// NOTIFY_SMS_DRIVER и NOTIFY_PUSH_DRIVER; по умолчанию используются локальные заглушки
+func NewNotificationChannelsFromEnv() (map[string]NotificationChannel, error) {
+	channels := map[string]NotificationChannel{}
+
+	switch os.Getenv("NOTIFY_EMAIL_DRIVER") {
+	case "smtp":
+		channel, err := NewSMTPChannel(os.Getenv("SMTP_HOST"), os.Getenv("SMTP_PORT"),
+			os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), os.Getenv("SMTP_FROM"))
+		if err != nil {
+			return nil, err
+		}
+		channels[models.NotificationChannelEmail] = channel
+	default:
+		channels[models.NotificationChannelEmail] = NewFakeChannel(models.NotificationChannelEmail)
+	}
+
+	switch os.Getenv("NOTIFY_SMS_DRIVER") {
+	case "http":
+		channel, err := NewSMSHTTPChannel(os.Getenv("SMS_API_URL"), os.Getenv("SMS_API_TOKEN"), os.Getenv("SMS_SENDER"))
+		if err != nil {
+			return nil, err
+		}
+		channels[models.NotificationChannelSMS] = channel
+	default:
+		channels[models.NotificationChannelSMS] = NewFakeChannel(models.NotificationChannelSMS)
+	}
+
+	switch os.Getenv("NOTIFY_PUSH_DRIVER") {
+	case "webpush":
+		channel, err := NewWebPushChannel(os.Getenv("VAPID_PUBLIC_KEY"), os.Getenv("VAPID_PRIVATE_KEY"), os.Getenv("VAPID_SUBJECT"))
+		if err != nil {
+			return nil, err
+		}
+		channels[models.NotificationChannelPush] = channel
+	default:
+		channels[models.NotificationChannelPush] = NewFakeChannel(models.NotificationChannelPush)
+	}
+	return channels, nil
+}
+
+// FakeChannel локальный канал для тестов и разработки: пишет сообщение в лог и хранит последние отправленные
+type FakeChannel struct {
+	name string
+	mu   sync.Mutex
+	sent []models.OutboxMessage
+	// Fail, если задана, возвращается вместо отправки, чтобы проверить повторы
+	Fail error
+}
+
+// fakeChannelCapacity ограничивает число сообщений, которые хранит FakeChannel
+const fakeChannelCapacity = 100
+
+// NewFakeChannel создает канал-заглушку
+func NewFakeChannel(name string) *FakeChannel {
+	return &FakeChannel{name: name}
+}
+
+// Send сохраняет сообщение вместо отправки
+func (c *FakeChannel) Send(ctx context.Context, msg *models.OutboxMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.Fail != nil {
+		return c.Fail
+	}
+
+	log.Printf("[%s] to %s: %s", c.name, msg.To, msg.Subject)
+	c.sent = append(c.sent, *msg)
+	if len(c.sent) > fakeChannelCapacity {
+		c.sent = c.sent[len(c.sent)-fakeChannelCapacity:]
+	}
+	return nil
+}
+
+// Sent возвращает сохраненные сообщения, новые последними
+func (c *FakeChannel) Sent() []models.OutboxMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]models.OutboxMessage{}, c.sent...)
+}
+
+// SMTPChannel отправляет письма через SMTP-сервер; STARTTLS используется, если сервер его поддерживает
+type SMTPChannel struct {
+	addr string
+	host string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPChannel создает канал электронной почты
+func NewSMTPChannel(host, port, username, password, from string) (*SMTPChannel, error) {
+	if host == "" || from == "" {
+		return nil, errors.New("SMTP_HOST and SMTP_FROM are required for smtp email driver")
+	}
+	if port == "" {
+		port = "587"
+	}
+	channel := &SMTPChannel{
+		addr: net.JoinHostPort(host, port),
+		host: host,
+		from: from,
+	}
+	if username != "" {
+		channel.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return channel, nil
+}
+
+// Send отправляет письмо
+func (c *SMTPChannel) Send(ctx context.Context, msg *models.OutboxMessage) error {
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return permanent(errors.New("invalid recipient address"))
+	}
+	if err := smtp.SendMail(c.addr, c.auth, c.from, []string{msg.To}, c.build(msg)); err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+			return permanent(err)
+		}
+		return errors.Wrap(err, "sending email failed")
+	}
+	return nil
+}
+
+// build собирает письмо в формате RFC 5322 с телом в quoted-printable
+func (c *SMTPChannel) build(msg *models.OutboxMessage) []byte {
+	var buf bytes.Buffer
+	messageID := make([]byte, 16)
+	_, _ = rand.Read(messageID)
+	subject := strings.NewReplacer("\r", " ", "\n", " ").Replace(msg.Subject)
+
+	fmt.Fprintf(&buf, "From: %s\r\n", c.from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: <%s@%s>\r\n", hex.EncodeToString(messageID), c.host)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	writer := quotedprintable.NewWriter(&buf)
+	_, _ = writer.Write([]byte(msg.Body))
+	_ = writer.Close()
+	return buf.Bytes()
+}
+
+// SMSHTTPChannel отправляет SMS через HTTP API провайдера.
+// Запрос: POST JSON {"from", "to", "text"} с токеном в заголовке Authorization.
+type SMSHTTPChannel struct {
+	url    string
+	token  string
+	sender string
+	client *http.Client
+}
+
+// NewSMSHTTPChannel создает SMS-канал
+func NewSMSHTTPChannel(url, token, sender string) (*SMSHTTPChannel, error) {
+	if url == "" {
+		return nil, errors.New("SMS_API_URL is required for http sms driver")
+	}
+	return &SMSHTTPChannel{
+		url:    url,
+		token:  token,
+		sender: sender,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Send отправляет SMS
+func (c *SMSHTTPChannel) Send(ctx context.Context, msg *models.OutboxMessage) error {
+	payload, err := json.Marshal(map[string]string{
+		"from": c.sender,
+		"to":   msg.To,
+		"text": msg.Body,
+	})
+	if err != nil {
+		return errors.Wrap(err, "encoding sms request failed")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "building sms request failed")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "sms request failed")
+	}
+	defer resp.Body.Close()
+	return deliveryStatusError("sms provider", resp)
+}
+
+// deliveryStatusError превращает ответ провайдера в ошибку; 4xx, кроме 408 и 429, не повторяются
+func deliveryStatusError(provider string, resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err := errors.Errorf("%s responded %d: %s", provider, resp.StatusCode, strings.TrimSpace(string(body)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const notificationsCollectionName = "notifications"
+
+// NotificationService структура сервиса уведомлений во входящих пользователя.
+// Копии уведомлений по почте, SMS и push отправляет notifier.
+type NotificationService struct {
+	db       *mongo.Database
+	notifier *Notifier
+}
+
+// NewNotificationService создает новый экземпляр NotificationService
+func NewNotificationService(client *mongo.Client, dbName string, notifier *Notifier) *NotificationService {
+	return &NotificationService{
+		db:       client.Database(dbName),
+		notifier: notifier,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции уведомлений
+func (s *NotificationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(notificationsCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "read", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating notification indexes failed")
+	}
+	return nil
+}
+
+// Notify сохраняет уведомление для каждого из пользователей и ставит его в очередь
+// внешних каналов по их настройкам
+func (s *NotificationService) Notify(ctx context.Context, userIDs []primitive.ObjectID, notificationType, title string, data map[string]string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	docs := make([]interface{}, 0, len(userIDs))
+	for _, userID := range userIDs {
+		docs = append(docs, models.Notification{
+			ID:        primitive.NewObjectID(),
+			UserID:    userID,
+			Type:      notificationType,
+			Title:     title,
+			Data:      data,
+			CreatedAt: now,
+		})
+	}
+	if _, err := s.db.Collection(notificationsCollectionName).InsertMany(ctx, docs); err != nil {
+		return errors.Wrap(err, "inserting notifications failed")
+	}
+	if s.notifier != nil {
+		return s.notifier.Dispatch(ctx, userIDs, notificationType, title, data)
+	}
+	return nil
+}
+
+// List возвращает уведомления пользователя, новые первыми
+func (s *NotificationService) List(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, page, limit int) ([]models.Notification, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	cursor, err := s.db.Collection(notificationsCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding notifications failed")
+	}
+	notifications := []models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, errors.Wrap(err, "decoding notifications failed")
+	}
+	return notifications, nil
+}
+
+// MarkRead отмечает уведомления пользователя прочитанными; пустой список отмечает все
+func (s *NotificationService) MarkRead(ctx context.Context, userID primitive.ObjectID, ids []primitive.ObjectID) error {
+	filter := bson.M{"user_id": userID, "read": false}
+	if len(ids) > 0 {
+		filter["_id"] = bson.M{"$in": ids}
+	}
+	if _, err := s.db.Collection(notificationsCollectionName).UpdateMany(ctx, filter, bson.M{"$set": bson.M{"read": true}}); err != nil {
+		return errors.Wrap(err, "marking notifications read failed")
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Типы уведомлений, которые отправляются не только во входящие
+const (
+	NotificationOrderStatus   = "order_status"
+	NotificationFriendRequest = "friend_request"
+	NotificationPasswordReset = "password_reset"
+)
+
+// notificationTemplate текст уведомления на одном языке; Short используется для SMS и push
+type notificationTemplate struct {
+	Subject string
+	Body    string
+	Short   string
+}
+
+// notificationTemplates шаблоны по типу и языку. Данные шаблона — поля data уведомления
+// и имена ресторанов, которые Notifier подставляет по *_id.
+var notificationTemplates = map[string]map[string]notificationTemplate{
+	NotificationOrderStatus: {
+		models.LocaleRU: {
+			Subject: "Заказ {{.restaurant_name}}: {{orderStatus .status}}",
+			Body:    "Статус вашего заказа в ресторане {{.restaurant_name}} изменился: {{orderStatus .status}}.",
+			Short:   "Заказ {{.restaurant_name}}: {{orderStatus .status}}",
+		},
+		models.LocaleEN: {
+			Subject: "Order from {{.restaurant_name}}: {{orderStatus .status}}",
+			Body:    "Your order from {{.restaurant_name}} is now {{orderStatus .status}}.",
+			Short:   "Order from {{.restaurant_name}}: {{orderStatus .status}}",
+		},
+	},
+	NotificationFriendRequest: {
+		models.LocaleRU: {
+			Subject: "{{.from_name}} хочет добавить вас в друзья",
+			Body:    "{{.from_name}} отправил(а) вам заявку в друзья. Ответить можно в разделе «Друзья».",
+			Short:   "{{.from_name}} хочет добавить вас в друзья",
+		},
+		models.LocaleEN: {
+			Subject: "{{.from_name}} wants to be your friend",
+			Body:    "{{.from_name}} sent you a friend request. You can respond in the Friends section.",
+			Short:   "{{.from_name}} sent you a friend request",
+		},
+	},
+	NotificationPasswordReset: {
+		models.LocaleRU: {
+			Subject: "Восстановление пароля",
+			Body: "Чтобы задать новый пароль, перейдите по ссылке:\n{{.link}}\n\n" +
+				"Ссылка действует {{.expires_minutes}} минут. Если вы не запрашивали восстановление, просто проигнорируйте это письмо.",
+			Short: "Восстановление пароля",
+		},
+		models.LocaleEN: {
+			Subject: "Password reset",
+			Body: "To set a new password, follow the link:\n{{.link}}\n\n" +
+				"The link is valid for {{.expires_minutes}} minutes. If you did not request a reset, just ignore this email.",
+			Short: "Password reset",
+		},
+	},
+	NotificationMeetupInvite: {
+		models.LocaleRU: {
+			Subject: "Приглашение: {{.meetup_title}}",
+			Body:    "Вас пригласили на встречу «{{.meetup_title}}» в {{.restaurant_name}}, {{datetime .starts_at}}.",
+			Short:   "Приглашение на «{{.meetup_title}}», {{datetime .starts_at}}",
+		},
+		models.LocaleEN: {
+			Subject: "Invitation: {{.meetup_title}}",
+			Body:    "You are invited to \"{{.meetup_title}}\" at {{.restaurant_name}} on {{datetime .starts_at}}.",
+			Short:   "Invitation to \"{{.meetup_title}}\", {{datetime .starts_at}}",
+		},
+	},
+	NotificationMeetupUpdated: {
+		models.LocaleRU: {
+			Subject: "Встреча изменена: {{.meetup_title}}",
+			Body:    "Встреча «{{.meetup_title}}» изменена: {{.restaurant_name}}, {{datetime .starts_at}}.",
+			Short:   "«{{.meetup_title}}» изменена: {{datetime .starts_at}}",
+		},
+		models.LocaleEN: {
+			Subject: "Meetup updated: {{.meetup_title}}",
+			Body:    "\"{{.meetup_title}}\" has been updated: {{.restaurant_name}}, {{datetime .starts_at}}.",
+			Short:   "\"{{.meetup_title}}\" updated: {{datetime .starts_at}}",
+		},
+	},
+	NotificationMeetupCancelled: {
+		models.LocaleRU: {
+			Subject: "Встреча отменена: {{.meetup_title}}",
+			Body:    "Встреча «{{.meetup_title}}» {{datetime .starts_at}} отменена.",
+			Short:   "«{{.meetup_title}}» отменена",
+		},
+		models.LocaleEN: {
+			Subject: "Meetup cancelled: {{.meetup_title}}",
+			Body:    "\"{{.meetup_title}}\" on {{datetime .starts_at}} has been cancelled.",
+			Short:   "\"{{.meetup_title}}\" cancelled",
+		},
+	},
+	NotificationReservationConfirmed: {
+		models.LocaleRU: {
+			Subject: "Бронирование подтверждено",
+			Body:    "Ресторан {{.restaurant_name}} подтвердил бронирование на {{datetime .starts_at}}.",
+			Short:   "{{.restaurant_name}}: бронь на {{datetime .starts_at}} подтверждена",
+		},
+		models.LocaleEN: {
+			Subject: "Reservation confirmed",
+			Body:    "{{.restaurant_name}} confirmed your reservation for {{datetime .starts_at}}.",
+			Short:   "{{.restaurant_name}}: reservation for {{datetime .starts_at}} confirmed",
+		},
+	},
+	NotificationReservationDeclined: {
+		models.LocaleRU: {
+			Subject: "Бронирование отклонено",
+			Body:    "К сожалению, ресторан {{.restaurant_name}} не может принять вас {{datetime .starts_at}}.",
+			Short:   "{{.restaurant_name}}: бронь на {{datetime .starts_at}} отклонена",
+		},
+		models.LocaleEN: {
+			Subject: "Reservation declined",
+			Body:    "Unfortunately, {{.restaurant_name}} cannot host you on {{datetime .starts_at}}.",
+			Short:   "{{.restaurant_name}}: reservation for {{datetime .starts_at}} declined",
+		},
+	},
+	NotificationWaitlistReady: {
+		models.LocaleRU: {
+			Subject: "Ваш стол готов",
+			Body:    "Ваш стол в ресторане {{.restaurant_name}} готов. Подойдите, пожалуйста, к хостес.",
+			Short:   "{{.restaurant_name}}: ваш стол готов",
+		},
+		models.LocaleEN: {
+			Subject: "Your table is ready",
+			Body:    "Your table at {{.restaurant_name}} is ready. Please come to the host stand.",
+			Short:   "{{.restaurant_name}}: your table is ready",
+		},
+	},
+	NotificationPollClosed: {
+		models.LocaleRU: {
+			Subject: "Итоги голосования: {{.poll_title}}",
+			Body:    "Голосование «{{.poll_title}}» завершено.{{if .winner_name}} Победил {{.winner_name}}.{{end}}",
+			Short:   "«{{.poll_title}}»: {{if .winner_name}}победил {{.winner_name}}{{else}}итоги готовы{{end}}",
+		},
+		models.LocaleEN: {
+			Subject: "Poll results: {{.poll_title}}",
+			Body:    "The poll \"{{.poll_title}}\" is closed.{{if .winner_name}} The winner is {{.winner_name}}.{{end}}",
+			Short:   "\"{{.poll_title}}\": {{if .winner_name}}{{.winner_name}} won{{else}}results are in{{end}}",
+		},
+	},
+}
+
+// orderStatusLabels названия статусов заказа в текстах уведомлений
+var orderStatusLabels = map[string]map[string]string{
+	models.LocaleRU: {
+		models.OrderStatusConfirmed: "подтвержден",
+		models.OrderStatusPreparing: "готовится",
+		models.OrderStatusDelivered: "доставлен",
+		models.OrderStatusCancelled: "отменен",
+	},
+	models.LocaleEN: {
+		models.OrderStatusConfirmed: "confirmed",
+		models.OrderStatusPreparing: "being prepared",
+		models.OrderStatusDelivered: "delivered",
+		models.OrderStatusCancelled: "cancelled",
+	},
+}
+
+// renderedNotification текст уведомления для отправки
+type renderedNotification struct {
+	Subject string
+	Body    string
+	Short   string
+}
+
+// renderNotification подставляет данные в шаблон типа на языке пользователя.
+// Для типов без шаблона используется заголовок уведомления из входящих.
+func renderNotification(notificationType, locale, title string, data map[string]string, loc *time.Location) (*renderedNotification, error) {
+	byLocale, ok := notificationTemplates[notificationType]
+	if !ok {
+		return &renderedNotification{Subject: title, Body: title, Short: title}, nil
+	}
+	tmpl, ok := byLocale[locale]
+	if !ok {
+		tmpl = byLocale[models.LocaleRU]
+	}
+
+	funcs := template.FuncMap{
+		"orderStatus": func(status string) string {
+			if label, ok := orderStatusLabels[locale][status]; ok {
+				return label
+			}
+			return status
+		},
+		"datetime": func(value string) string {
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return value
+			}
+			return parsed.In(loc).Format("02.01.2006 15:04")
+		},
+	}
+	vars := make(map[string]string, len(data)+1)
+	for key, value := range data {
+		vars[key] = value
+	}
+	vars["title"] = title
+
+	var result renderedNotification
+	for _, part := range []struct {
+		source string
+		target *string
+	}{
+		{tmpl.Subject, &result.Subject},
+		{tmpl.Body, &result.Body},
+		{tmpl.Short, &result.Short},
+	} {
+		parsed, err := template.New(notificationType).Funcs(funcs).Option("missingkey=zero").Parse(part.source)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s template failed", notificationType)
+		}
+		var out strings.Builder
+		if err := parsed.Execute(&out, vars); err != nil {
+			return nil, errors.Wrapf(err, "rendering %s template failed", notificationType)
+		}
+		*part.target = out.String()
+	}
+	return &result, nil
+}
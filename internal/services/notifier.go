@@ -0,0 +1,594 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	notificationOutboxCollectionName = "notification_outbox"
+	pushSubscriptionsCollectionName  = "push_subscriptions"
+
+	// notificationOutboxRetention сколько хранятся отправленные и проваленные сообщения
+	notificationOutboxRetention = 30 * 24 * time.Hour
+	// notificationOutboxLease на сколько сообщение закрепляется за отправителем
+	notificationOutboxLease = time.Minute
+	// notificationOutboxBatch сколько сообщений отправляется за один тик
+	notificationOutboxBatch = 100
+	// notificationSendTimeout ограничивает одну попытку отправки
+	notificationSendTimeout = 30 * time.Second
+)
+
+var (
+	ErrInvalidQuietHours        = errors.New("invalid quiet hours")
+	ErrInvalidPushSubscription  = errors.New("invalid push subscription")
+	ErrPushSubscriptionNotFound = errors.New("push subscription not found")
+	ErrOutboxMessageNotFound    = errors.New("outbox message not found")
+	ErrPushNotConfigured        = errors.New("web push is not configured")
+	ErrPushEndpointTaken        = errors.New("push endpoint is registered by another user")
+)
+
+// transactionalNotificationTypes уходят только на почту, без учета настроек и тихих часов;
+// их текст удаляется из очереди после отправки
+var transactionalNotificationTypes = map[string]bool{
+	NotificationPasswordReset: true,
+}
+
+// notificationNameKeys поля data с ID ресторанов, для которых в шаблон подставляется название
+var notificationNameKeys = []string{"restaurant_id", "winner_id"}
+
+// Notifier структура сервиса доставки уведомлений по почте, SMS и push.
+// Сообщения сначала попадают в очередь notification_outbox, а RunOutbox отправляет их
+// с повторами и экспоненциальной задержкой.
+type Notifier struct {
+	db          *mongo.Database
+	channels    map[string]NotificationChannel
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	now         func() time.Time
+}
+
+// NewNotifier создает новый экземпляр Notifier с адаптерами каналов по их именам
+func NewNotifier(client *mongo.Client, dbName string, channels map[string]NotificationChannel) *Notifier {
+	return &Notifier{
+		db:          client.Database(dbName),
+		channels:    channels,
+		maxAttempts: envInt("NOTIFY_MAX_ATTEMPTS", 8),
+		retryBase:   time.Duration(envInt("NOTIFY_RETRY_BASE_SECONDS", 30)) * time.Second,
+		retryMax:    time.Hour,
+		now:         time.Now,
+	}
+}
+
+// EnsureIndexes создает индексы очереди и push-подписок
+func (n *Notifier) EnsureIndexes(ctx context.Context) error {
+	_, err := n.db.Collection(notificationOutboxCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			// Сообщения в очереди поля completed_at не имеют и не удаляются
+			Keys:    bson.D{{Key: "completed_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(notificationOutboxRetention.Seconds())),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating notification outbox indexes failed")
+	}
+	_, err = n.db.Collection(pushSubscriptionsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "endpoint", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating push subscription indexes failed")
+	}
+	return nil
+}
+
+// notificationRecipient контакты и настройки получателя
+type notificationRecipient struct {
+	ID          primitive.ObjectID              `bson:"_id"`
+	Email       string                          `bson:"email"`
+	Phone       string                          `bson:"phone"`
+	Preferences *models.NotificationPreferences `bson:"notificationPreferences"`
+}
+
+// Dispatch ставит уведомление в очередь по каналам, которые выбрал каждый из пользователей.
+// В тихие часы отправка откладывается до их окончания.
+func (n *Notifier) Dispatch(ctx context.Context, userIDs []primitive.ObjectID, notificationType, title string, data map[string]string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	cursor, err := n.db.Collection(EntityTypeUser).Find(ctx, bson.M{"_id": bson.M{"$in": userIDs}},
+		options.Find().SetProjection(bson.M{"email": 1, "phone": 1, "notificationPreferences": 1}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "finding notification recipients failed")
+	}
+	var recipients []notificationRecipient
+	if err := cursor.All(ctx, &recipients); err != nil {
+		return errors.Wrap(err, "decoding notification recipients failed")
+	}
+
+	subscriptions, err := n.pushSubscriptions(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	data, err = n.withNames(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	now := n.now()
+	transactional := transactionalNotificationTypes[notificationType]
+	var docs []interface{}
+	for _, recipient := range recipients {
+		prefs := models.DefaultNotificationPreferences()
+		if recipient.Preferences != nil {
+			prefs = *recipient.Preferences
+		}
+		channels := prefs.ChannelsFor(notificationType)
+		if transactional {
+			channels = []string{models.NotificationChannelEmail}
+		}
+		if len(channels) == 0 {
+			continue
+		}
+
+		loc := time.Local
+		nextAttempt := now
+		if prefs.QuietHours != nil {
+			quietEnd, quiet, err := quietHoursEnd(prefs.QuietHours, now)
+			if err != nil {
+				log.Printf("Ignoring quiet hours of user %s: %v", recipient.ID.Hex(), err)
+			} else {
+				loc = quietEnd.Location()
+				if quiet && !transactional {
+					nextAttempt = quietEnd
+				}
+			}
+		}
+
+		rendered, err := renderNotification(notificationType, prefs.Locale, title, data, loc)
+		if err != nil {
+			return err
+		}
+		message := func(channel, to string) *models.OutboxMessage {
+			return &models.OutboxMessage{
+				ID:            primitive.NewObjectID(),
+				UserID:        recipient.ID,
+				Type:          notificationType,
+				Channel:       channel,
+				To:            to,
+				Subject:       rendered.Subject,
+				Body:          rendered.Short,
+				Data:          data,
+				Sensitive:     transactional,
+				Status:        models.OutboxStatusPending,
+				NextAttemptAt: nextAttempt,
+				CreatedAt:     now,
+			}
+		}
+
+		for _, channel := range channels {
+			if n.channels[channel] == nil {
+				continue
+			}
+			switch channel {
+			case models.NotificationChannelEmail:
+				if recipient.Email != "" {
+					msg := message(channel, recipient.Email)
+					msg.Body = rendered.Body
+					docs = append(docs, msg)
+				}
+			case models.NotificationChannelSMS:
+				if recipient.Phone != "" {
+					docs = append(docs, message(channel, recipient.Phone))
+				}
+			case models.NotificationChannelPush:
+				for _, subscription := range subscriptions[recipient.ID] {
+					msg := message(channel, subscription.Endpoint)
+					keys := subscription.Keys
+					msg.PushKeys = &keys
+					docs = append(docs, msg)
+				}
+			}
+		}
+	}
+
+	if len(docs) == 0 {
+		return nil
+	}
+	if _, err := n.db.Collection(notificationOutboxCollectionName).InsertMany(ctx, docs); err != nil {
+		return errors.Wrap(err, "enqueueing notifications failed")
+	}
+	return nil
+}
+
+// GetPreferences возвращает настройки уведомлений пользователя или настройки по умолчанию
+func (n *Notifier) GetPreferences(ctx context.Context, userID primitive.ObjectID) (*models.NotificationPreferences, error) {
+	var recipient notificationRecipient
+	err := n.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"notificationPreferences": 1}),
+	).Decode(&recipient)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "finding notification preferences failed")
+	}
+	if recipient.Preferences == nil {
+		prefs := models.DefaultNotificationPreferences()
+		return &prefs, nil
+	}
+	return recipient.Preferences, nil
+}
+
+// SetPreferences сохраняет настройки уведомлений пользователя
+func (n *Notifier) SetPreferences(ctx context.Context, userID primitive.ObjectID, prefs *models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	if prefs.Locale == "" {
+		prefs.Locale = models.LocaleRU
+	}
+	if prefs.Channels == nil {
+		prefs.Channels = []string{}
+	}
+	if err := prefs.Validate(); err != nil {
+		return nil, err
+	}
+	if prefs.QuietHours != nil {
+		if _, _, err := quietHoursEnd(prefs.QuietHours, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := n.db.Collection(EntityTypeUser).UpdateOne(ctx, bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"notificationPreferences": prefs}})
+	if err != nil {
+		return nil, errors.Wrap(err, "saving notification preferences failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrUserNotFound
+	}
+	return prefs, nil
+}
+
+// PushPublicKey возвращает ключ VAPID для подписки браузера
+func (n *Notifier) PushPublicKey() (string, error) {
+	channel, ok := n.channels[models.NotificationChannelPush].(*WebPushChannel)
+	if !ok {
+		return "", ErrPushNotConfigured
+	}
+	return channel.PublicKey(), nil
+}
+
+// SubscribePush сохраняет push-подписку браузера; повторная подписка обновляет ключи.
+// Endpoint, зарегистрированный другим пользователем, не перехватывается: при смене владельца
+// браузер должен сначала отписаться и получить новый endpoint.
+func (n *Notifier) SubscribePush(ctx context.Context, userID primitive.ObjectID, subscription *models.PushSubscription) (*models.PushSubscription, error) {
+	if err := subscription.Validate(); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(subscription.Endpoint, "https://") {
+		return nil, ErrInvalidPushSubscription
+	}
+	if p256dh, err := decodeBase64URL(subscription.Keys.P256dh); err != nil || len(p256dh) != 65 {
+		return nil, ErrInvalidPushSubscription
+	}
+	if auth, err := decodeBase64URL(subscription.Keys.Auth); err != nil || len(auth) != 16 {
+		return nil, ErrInvalidPushSubscription
+	}
+
+	var saved models.PushSubscription
+	// Уникальный индекс по endpoint не даст вставить подписку, если endpoint принадлежит другому пользователю
+	err := n.db.Collection(pushSubscriptionsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"endpoint": subscription.Endpoint, "user_id": userID},
+		bson.M{
+			"$set": bson.M{
+				"keys":       subscription.Keys,
+				"user_agent": subscription.UserAgent,
+			},
+			"$setOnInsert": bson.M{"created_at": time.Now()},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&saved)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrPushEndpointTaken
+		}
+		return nil, errors.Wrap(err, "saving push subscription failed")
+	}
+	return &saved, nil
+}
+
+// UnsubscribePush удаляет push-подписку пользователя
+func (n *Notifier) UnsubscribePush(ctx context.Context, userID primitive.ObjectID, endpoint string) error {
+	result, err := n.db.Collection(pushSubscriptionsCollectionName).DeleteOne(ctx, bson.M{"user_id": userID, "endpoint": endpoint})
+	if err != nil {
+		return errors.Wrap(err, "deleting push subscription failed")
+	}
+	if result.DeletedCount == 0 {
+		return ErrPushSubscriptionNotFound
+	}
+	return nil
+}
+
+// ListOutbox возвращает сообщения очереди, новые первыми; пустые status и channel не фильтруют
+func (n *Notifier) ListOutbox(ctx context.Context, status, channel string, page, limit int) ([]models.OutboxMessage, error) {
+	filter := bson.M{}
+	if status != "" {
+		filter["status"] = status
+	}
+	if channel != "" {
+		filter["channel"] = channel
+	}
+
+	cursor, err := n.db.Collection(notificationOutboxCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding outbox messages failed")
+	}
+	messages := []models.OutboxMessage{}
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, errors.Wrap(err, "decoding outbox messages failed")
+	}
+	for i := range messages {
+		if messages[i].Sensitive {
+			messages[i].Body = ""
+			messages[i].Data = nil
+		}
+	}
+	return messages, nil
+}
+
+// RetryMessage возвращает проваленное сообщение в очередь. Текст конфиденциальных сообщений
+// после провала удален, поэтому их повторить нельзя.
+func (n *Notifier) RetryMessage(ctx context.Context, messageID primitive.ObjectID) error {
+	result, err := n.db.Collection(notificationOutboxCollectionName).UpdateOne(ctx,
+		bson.M{"_id": messageID, "status": models.OutboxStatusFailed, "sensitive": false},
+		bson.M{
+			"$set":   bson.M{"status": models.OutboxStatusPending, "attempts": 0, "next_attempt_at": time.Now()},
+			"$unset": bson.M{"completed_at": ""},
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "retrying outbox message failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrOutboxMessageNotFound
+	}
+	return nil
+}
+
+// FakeSent возвращает сообщения, принятые каналом-заглушкой; false, если канал настоящий
+func (n *Notifier) FakeSent(channel string) ([]models.OutboxMessage, bool) {
+	fake, ok := n.channels[channel].(*FakeChannel)
+	if !ok {
+		return nil, false
+	}
+	return fake.Sent(), true
+}
+
+// RunOutbox периодически отправляет сообщения из очереди, пока не будет отменен ctx
+func (n *Notifier) RunOutbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for i := 0; i < notificationOutboxBatch; i++ {
+				if !n.sendNext(ctx) {
+					break
+				}
+			}
+		}
+	}
+}
+
+// sendNext забирает и отправляет одно готовое сообщение; false, если отправлять нечего.
+// Сообщение, чей отправитель не уложился в аренду, забирается повторно.
+func (n *Notifier) sendNext(ctx context.Context) bool {
+	now := time.Now()
+	var msg models.OutboxMessage
+	err := n.db.Collection(notificationOutboxCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"status": models.OutboxStatusPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"status": models.OutboxStatusSending, "locked_until": bson.M{"$lte": now}},
+		}},
+		bson.M{
+			"$set": bson.M{"status": models.OutboxStatusSending, "locked_until": now.Add(notificationOutboxLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&msg)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to claim outbox message: %v", err)
+		}
+		return false
+	}
+
+	channel := n.channels[msg.Channel]
+	if channel == nil {
+		err = permanent(errors.Errorf("channel %s is not configured", msg.Channel))
+	} else {
+		sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+		err = channel.Send(sendCtx, &msg)
+		cancel()
+	}
+	if err := n.complete(ctx, &msg, err); err != nil {
+		log.Printf("Failed to update outbox message %s: %v", msg.ID.Hex(), err)
+	}
+	return true
+}
+
+// complete сохраняет результат попытки отправки
+func (n *Notifier) complete(ctx context.Context, msg *models.OutboxMessage, sendErr error) error {
+	now := time.Now()
+	set := bson.M{}
+	unset := bson.M{"locked_until": ""}
+
+	switch {
+	case sendErr == nil:
+		set["status"] = models.OutboxStatusSent
+		set["sent_at"] = now
+		set["completed_at"] = now
+		unset["last_error"] = ""
+	case errors.Cause(sendErr) == ErrPermanentDelivery || msg.Attempts >= n.maxAttempts:
+		set["status"] = models.OutboxStatusFailed
+		set["completed_at"] = now
+		set["last_error"] = sendErr.Error()
+		if sendErr == ErrPushSubscriptionGone {
+			if _, err := n.db.Collection(pushSubscriptionsCollectionName).DeleteOne(ctx, bson.M{"endpoint": msg.To}); err != nil {
+				log.Printf("Failed to delete gone push subscription: %v", err)
+			}
+		}
+	default:
+		set["status"] = models.OutboxStatusPending
+		set["next_attempt_at"] = now.Add(retryDelay(n.retryBase, n.retryMax, msg.Attempts))
+		set["last_error"] = sendErr.Error()
+	}
+	if msg.Sensitive && set["status"] != models.OutboxStatusPending {
+		unset["body"] = ""
+		unset["data"] = ""
+	}
+
+	_, err := n.db.Collection(notificationOutboxCollectionName).UpdateOne(ctx,
+		bson.M{"_id": msg.ID, "status": models.OutboxStatusSending},
+		bson.M{"$set": set, "$unset": unset},
+	)
+	return errors.Wrap(err, "saving send result failed")
+}
+
+// retryDelay возвращает задержку перед следующей попыткой: удваивается с каждой попыткой,
+// не больше maxDelay, с разбросом до 10%, чтобы повторы не совпадали
+func retryDelay(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/10+1))
+}
+
+// pushSubscriptions загружает push-подписки пользователей
+func (n *Notifier) pushSubscriptions(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID][]models.PushSubscription, error) {
+	if n.channels[models.NotificationChannelPush] == nil {
+		return nil, nil
+	}
+	cursor, err := n.db.Collection(pushSubscriptionsCollectionName).Find(ctx, bson.M{"user_id": bson.M{"$in": userIDs}})
+	if err != nil {
+		return nil, errors.Wrap(err, "finding push subscriptions failed")
+	}
+	var subscriptions []models.PushSubscription
+	if err := cursor.All(ctx, &subscriptions); err != nil {
+		return nil, errors.Wrap(err, "decoding push subscriptions failed")
+	}
+
+	byUser := make(map[primitive.ObjectID][]models.PushSubscription)
+	for _, subscription := range subscriptions {
+		byUser[subscription.UserID] = append(byUser[subscription.UserID], subscription)
+	}
+	return byUser, nil
+}
+
+// withNames возвращает копию data с названиями ресторанов для полей из notificationNameKeys,
+// например restaurant_name для restaurant_id
+func (n *Notifier) withNames(ctx context.Context, data map[string]string) (map[string]string, error) {
+	result := make(map[string]string, len(data)+len(notificationNameKeys))
+	for key, value := range data {
+		result[key] = value
+	}
+
+	ids := []primitive.ObjectID{}
+	for _, key := range notificationNameKeys {
+		if id, err := primitive.ObjectIDFromHex(data[key]); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	cursor, err := n.db.Collection(EntityTypeRestaurant).Find(ctx, bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurant names failed")
+	}
+	var restaurants []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurant names failed")
+	}
+	names := make(map[string]string, len(restaurants))
+	for _, restaurant := range restaurants {
+		names[restaurant.ID.Hex()] = restaurant.Name
+	}
+	for _, key := range notificationNameKeys {
+		if name, ok := names[data[key]]; ok {
+			result[strings.TrimSuffix(key, "_id")+"_name"] = name
+		}
+	}
+	return result, nil
+}
+
+// quietHoursEnd возвращает окончание тихих часов, ближайшее к now, и признак того,
+// что now попадает в тихие часы. Время возвращается в часовом поясе пользователя.
+func quietHoursEnd(quiet *models.QuietHours, now time.Time) (time.Time, bool, error) {
+	start, err := time.Parse("15:04", quiet.Start)
+	if err != nil {
+		return time.Time{}, false, ErrInvalidQuietHours
+	}
+	end, err := time.Parse("15:04", quiet.End)
+	if err != nil {
+		return time.Time{}, false, ErrInvalidQuietHours
+	}
+	loc := time.Local
+	if quiet.Timezone != "" {
+		if loc, err = time.LoadLocation(quiet.Timezone); err != nil {
+			return time.Time{}, false, ErrInvalidQuietHours
+		}
+	}
+
+	local := now.In(loc)
+	minutes := local.Hour()*60 + local.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	endAt := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+	if !endAt.After(local) {
+		endAt = endAt.AddDate(0, 0, 1)
+	}
+
+	var quietNow bool
+	switch {
+	case startMinutes == endMinutes:
+		quietNow = false
+	case startMinutes < endMinutes:
+		quietNow = minutes >= startMinutes && minutes < endMinutes
+	default:
+		quietNow = minutes >= startMinutes || minutes < endMinutes
+	}
+	return endAt, quietNow, nil
+}
@@ -0,0 +1,422 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+// fakeNotifier создает Notifier с каналами-заглушками поверх mock-клиента mtest
+func fakeNotifier(mt *mtest.T, now time.Time) *Notifier {
+	return &Notifier{
+		db: mt.DB,
+		channels: map[string]NotificationChannel{
+			models.NotificationChannelEmail: NewFakeChannel(models.NotificationChannelEmail),
+			models.NotificationChannelSMS:   NewFakeChannel(models.NotificationChannelSMS),
+			models.NotificationChannelPush:  NewFakeChannel(models.NotificationChannelPush),
+		},
+		maxAttempts: 3,
+		retryBase:   time.Minute,
+		retryMax:    time.Hour,
+		now:         func() time.Time { return now },
+	}
+}
+
+// enqueuedMessages возвращает сообщения, которые Dispatch вставил в очередь
+func enqueuedMessages(mt *mtest.T) []models.OutboxMessage {
+	var messages []models.OutboxMessage
+	for _, event := range mt.GetAllStartedEvents() {
+		if event.CommandName != "insert" {
+			continue
+		}
+		values, err := event.Command.Lookup("documents").Array().Values()
+		if err != nil {
+			mt.Fatalf("reading inserted documents: %v", err)
+		}
+		for _, value := range values {
+			var msg models.OutboxMessage
+			if err := bson.Unmarshal(value.Document(), &msg); err != nil {
+				mt.Fatalf("decoding inserted document: %v", err)
+			}
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// deliver отправляет сообщение через sendNext, подставляя его как захваченное из очереди,
+// и возвращает $set обновления с результатом попытки
+func deliver(mt *mtest.T, n *Notifier, msg models.OutboxMessage) bson.Raw {
+	msg.Status = models.OutboxStatusSending
+	msg.Attempts++
+	doc, err := bson.Marshal(msg)
+	if err != nil {
+		mt.Fatalf("encoding outbox message: %v", err)
+	}
+	mt.ClearEvents()
+	mt.AddMockResponses(
+		bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.Raw(doc)}},
+		bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+	)
+	if !n.sendNext(context.Background()) {
+		mt.Fatalf("sendNext() found nothing to send")
+	}
+	for _, event := range mt.GetAllStartedEvents() {
+		if event.CommandName == "update" {
+			values, _ := event.Command.Lookup("updates").Array().Values()
+			return values[0].Document().Lookup("u", "$set").Document()
+		}
+	}
+	mt.Fatalf("sendNext() did not save the result")
+	return nil
+}
+
+func TestNotifierDispatch(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	userID := primitive.NewObjectID()
+	evening := time.Date(2024, 3, 10, 23, 30, 0, 0, moscow)
+	night := time.Date(2024, 3, 11, 3, 0, 0, 0, moscow)
+	day := time.Date(2024, 3, 11, 12, 0, 0, 0, moscow)
+	morning := time.Date(2024, 3, 11, 8, 0, 0, 0, moscow)
+	quiet := &models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Europe/Moscow"}
+	subscription := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "user_id", Value: userID},
+		{Key: "endpoint", Value: "https://push.example.com/1"},
+		{Key: "keys", Value: bson.D{{Key: "p256dh", Value: "key"}, {Key: "auth", Value: "secret"}}},
+	}
+
+	tests := []struct {
+		name             string
+		notificationType string
+		prefs            *models.NotificationPreferences
+		phone            string
+		subscriptions    []bson.D
+		now              time.Time
+		wantChannels     []string
+		wantNextAttempt  time.Time // нулевое - отправка сразу
+	}{
+		{
+			name:             "default preferences use email and push",
+			notificationType: NotificationFriendRequest,
+			phone:            "+79990000000",
+			subscriptions:    []bson.D{subscription},
+			now:              day,
+			wantChannels:     []string{models.NotificationChannelEmail, models.NotificationChannelPush},
+		},
+		{
+			name:             "per-type channels override defaults",
+			notificationType: NotificationFriendRequest,
+			prefs: &models.NotificationPreferences{
+				Locale:   models.LocaleEN,
+				Channels: []string{models.NotificationChannelEmail},
+				Types:    map[string][]string{NotificationFriendRequest: {models.NotificationChannelSMS}},
+			},
+			phone:        "+79990000000",
+			now:          day,
+			wantChannels: []string{models.NotificationChannelSMS},
+		},
+		{
+			name:             "sms skipped without phone",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelSMS}},
+			now:              day,
+		},
+		{
+			name:             "push without subscriptions",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelPush}},
+			now:              day,
+		},
+		{
+			name:             "all channels disabled",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{}},
+			now:              day,
+		},
+		{
+			name:             "quiet hours before midnight",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelEmail}, QuietHours: quiet},
+			now:              evening,
+			wantChannels:     []string{models.NotificationChannelEmail},
+			wantNextAttempt:  morning,
+		},
+		{
+			name:             "quiet hours after midnight",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelEmail}, QuietHours: quiet},
+			now:              night,
+			wantChannels:     []string{models.NotificationChannelEmail},
+			wantNextAttempt:  morning,
+		},
+		{
+			name:             "outside quiet hours",
+			notificationType: NotificationFriendRequest,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelEmail}, QuietHours: quiet},
+			now:              day,
+			wantChannels:     []string{models.NotificationChannelEmail},
+		},
+		{
+			name:             "transactional ignores quiet hours and preferences",
+			notificationType: NotificationPasswordReset,
+			prefs:            &models.NotificationPreferences{Locale: models.LocaleRU, Channels: []string{models.NotificationChannelSMS}, QuietHours: quiet},
+			phone:            "+79990000000",
+			subscriptions:    []bson.D{subscription},
+			now:              evening,
+			wantChannels:     []string{models.NotificationChannelEmail},
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			n := fakeNotifier(mt, tt.now)
+			user := bson.D{{Key: "_id", Value: userID}, {Key: "email", Value: "user@example.com"}, {Key: "phone", Value: tt.phone}}
+			if tt.prefs != nil {
+				user = append(user, bson.E{Key: "notificationPreferences", Value: tt.prefs})
+			}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, user),
+				mtest.CreateCursorResponse(0, "db.push_subscriptions", mtest.FirstBatch, tt.subscriptions...),
+				mtest.CreateSuccessResponse(),
+			)
+
+			data := map[string]string{"from_name": "Анна", "link": "https://example.com/reset", "expires_minutes": "30"}
+			if err := n.Dispatch(context.Background(), []primitive.ObjectID{userID}, tt.notificationType, "Title", data); err != nil {
+				mt.Fatalf("Dispatch() error: %v", err)
+			}
+
+			messages := enqueuedMessages(mt)
+			if len(messages) != len(tt.wantChannels) {
+				mt.Fatalf("enqueued %d messages, want %d", len(messages), len(tt.wantChannels))
+			}
+			for i, msg := range messages {
+				if msg.Channel != tt.wantChannels[i] {
+					mt.Errorf("messages[%d].Channel = %q, want %q", i, msg.Channel, tt.wantChannels[i])
+				}
+				if msg.Sensitive != transactionalNotificationTypes[tt.notificationType] {
+					mt.Errorf("messages[%d].Sensitive = %v", i, msg.Sensitive)
+				}
+				wantNext := tt.now
+				if !tt.wantNextAttempt.IsZero() {
+					wantNext = tt.wantNextAttempt
+				}
+				if !msg.NextAttemptAt.Equal(wantNext) {
+					mt.Errorf("messages[%d].NextAttemptAt = %v, want %v", i, msg.NextAttemptAt.In(moscow), wantNext)
+				}
+				if msg.Channel == models.NotificationChannelPush && (msg.PushKeys == nil || msg.To != "https://push.example.com/1") {
+					mt.Errorf("push message without subscription: to %q, keys %v", msg.To, msg.PushKeys)
+				}
+			}
+
+			// Сообщения, которые не отложены тихими часами, доходят до каналов
+			for _, msg := range messages {
+				if msg.NextAttemptAt.After(tt.now) {
+					continue
+				}
+				set := deliver(mt, n, msg)
+				if status := set.Lookup("status").StringValue(); status != models.OutboxStatusSent {
+					mt.Errorf("%s message status = %q, want sent", msg.Channel, status)
+				}
+				sent, _ := n.FakeSent(msg.Channel)
+				if len(sent) == 0 || sent[len(sent)-1].ID != msg.ID {
+					mt.Errorf("%s channel did not receive message %s", msg.Channel, msg.ID.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestNotifierRetryBackoff(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	tests := []struct {
+		name        string
+		attempts    int
+		sendErr     error
+		wantStatus  string
+		wantMinWait time.Duration
+	}{
+		{name: "first failure", attempts: 0, sendErr: errors.New("timeout"), wantStatus: models.OutboxStatusPending, wantMinWait: time.Minute},
+		{name: "second failure doubles", attempts: 1, sendErr: errors.New("timeout"), wantStatus: models.OutboxStatusPending, wantMinWait: 2 * time.Minute},
+		{name: "attempts exhausted", attempts: 2, sendErr: errors.New("timeout"), wantStatus: models.OutboxStatusFailed},
+		{name: "permanent failure", attempts: 0, sendErr: permanent(errors.New("bad address")), wantStatus: models.OutboxStatusFailed},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			n := fakeNotifier(mt, time.Now())
+			n.channels[models.NotificationChannelEmail].(*FakeChannel).Fail = tt.sendErr
+			msg := models.OutboxMessage{
+				ID:       primitive.NewObjectID(),
+				Channel:  models.NotificationChannelEmail,
+				To:       "user@example.com",
+				Attempts: tt.attempts,
+			}
+
+			before := time.Now()
+			set := deliver(mt, n, msg)
+			if status := set.Lookup("status").StringValue(); status != tt.wantStatus {
+				mt.Fatalf("status = %q, want %q", status, tt.wantStatus)
+			}
+			if set.Lookup("last_error").StringValue() == "" {
+				mt.Errorf("last_error is empty")
+			}
+			if tt.wantStatus != models.OutboxStatusPending {
+				return
+			}
+			wait := set.Lookup("next_attempt_at").Time().Sub(before)
+			if wait < tt.wantMinWait || wait > tt.wantMinWait+tt.wantMinWait/10+time.Second {
+				mt.Errorf("next attempt in %v, want %v plus up to 10%%", wait, tt.wantMinWait)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 0, want: 30 * time.Second},
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 3, want: 2 * time.Minute},
+		{attempts: 8, want: 64 * time.Minute},
+		{attempts: 9, want: 90 * time.Minute},
+		{attempts: 1000, want: 90 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 20; i++ {
+			got := retryDelay(30*time.Second, 90*time.Minute, tt.attempts)
+			if got < tt.want || got > tt.want+tt.want/10 {
+				t.Fatalf("retryDelay(%d) = %v, want %v plus up to 10%%", tt.attempts, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	utc := func(hour, minute int) time.Time { return time.Date(2024, 3, 10, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name      string
+		quiet     models.QuietHours
+		now       time.Time
+		wantEnd   time.Time
+		wantQuiet bool
+		wantErr   bool
+	}{
+		{name: "crossing midnight before it", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"}, now: utc(23, 0), wantEnd: utc(8, 0).AddDate(0, 0, 1), wantQuiet: true},
+		{name: "crossing midnight after it", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"}, now: utc(2, 0), wantEnd: utc(8, 0), wantQuiet: true},
+		{name: "crossing midnight at end", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"}, now: utc(8, 0), wantEnd: utc(8, 0).AddDate(0, 0, 1)},
+		{name: "crossing midnight at start", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"}, now: utc(22, 0), wantEnd: utc(8, 0).AddDate(0, 0, 1), wantQuiet: true},
+		{name: "crossing midnight daytime", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "UTC"}, now: utc(12, 0), wantEnd: utc(8, 0).AddDate(0, 0, 1)},
+		{name: "same day window", quiet: models.QuietHours{Start: "13:00", End: "15:00", Timezone: "UTC"}, now: utc(14, 0), wantEnd: utc(15, 0), wantQuiet: true},
+		{name: "empty window", quiet: models.QuietHours{Start: "10:00", End: "10:00", Timezone: "UTC"}, now: utc(10, 0), wantEnd: utc(10, 0).AddDate(0, 0, 1)},
+		{name: "user timezone", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Asia/Tokyo"}, now: utc(14, 0), wantEnd: utc(23, 0), wantQuiet: true},
+		{name: "invalid time", quiet: models.QuietHours{Start: "25:00", End: "08:00"}, now: utc(12, 0), wantErr: true},
+		{name: "invalid timezone", quiet: models.QuietHours{Start: "22:00", End: "08:00", Timezone: "Mars/Olympus"}, now: utc(12, 0), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			end, quiet, err := quietHoursEnd(&tt.quiet, tt.now)
+			if tt.wantErr {
+				if err != ErrInvalidQuietHours {
+					t.Fatalf("quietHoursEnd() error = %v, want %v", err, ErrInvalidQuietHours)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("quietHoursEnd() unexpected error: %v", err)
+			}
+			if quiet != tt.wantQuiet {
+				t.Errorf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if !end.Equal(tt.wantEnd) {
+				t.Errorf("end = %v, want %v", end.UTC(), tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestSubscribePush(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	browser, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := models.PushKeys{
+		P256dh: base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(make([]byte, 16)),
+	}
+	userID := primitive.NewObjectID()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		keys     models.PushKeys
+		response bson.D
+		wantErr  error
+	}{
+		{
+			name:     "new or own endpoint",
+			endpoint: "https://push.example.com/1",
+			keys:     keys,
+			response: bson.D{{Key: "ok", Value: 1}, {Key: "value", Value: bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "user_id", Value: userID},
+				{Key: "endpoint", Value: "https://push.example.com/1"},
+			}}},
+		},
+		{
+			name:     "endpoint of another user",
+			endpoint: "https://push.example.com/1",
+			keys:     keys,
+			response: mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 11000, Message: "E11000 duplicate key error"}),
+			wantErr:  ErrPushEndpointTaken,
+		},
+		{name: "plain http endpoint", endpoint: "http://push.example.com/1", keys: keys, wantErr: ErrInvalidPushSubscription},
+		{name: "short auth secret", endpoint: "https://push.example.com/1", keys: models.PushKeys{P256dh: keys.P256dh, Auth: "AAAA"}, wantErr: ErrInvalidPushSubscription},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			n := fakeNotifier(mt, time.Now())
+			if tt.response != nil {
+				mt.AddMockResponses(tt.response)
+			}
+
+			saved, err := n.SubscribePush(context.Background(), userID, &models.PushSubscription{Endpoint: tt.endpoint, Keys: tt.keys})
+			if err != tt.wantErr {
+				mt.Fatalf("SubscribePush() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if saved.UserID != userID {
+				mt.Errorf("saved.UserID = %v, want %v", saved.UserID, userID)
+			}
+			// Подписка ищется вместе с владельцем, поэтому чужая не обновляется
+			filter := mt.GetStartedEvent().Command.Lookup("query").Document()
+			if filter.Lookup("user_id").ObjectID() != userID || filter.Lookup("endpoint").StringValue() != tt.endpoint {
+				mt.Errorf("subscription filter = %v, want endpoint and user_id", filter)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Параметры хранения истории событий заказов для возобновления потока по Last-Event-ID
+const (
+	orderEventsSequenceKey = "order_events:seq"
+	orderEventsHistorySize = 200
+	orderEventsHistoryTTL  = 24 * time.Hour
+)
+
+// OrderEvents публикует события заказов через Redis pub/sub, чтобы подписчики
+// на любой реплике сервера получали их сразу. Последние события каждого потока
+// хранятся в Redis, чтобы переподключившийся клиент мог догнать пропущенное.
+type OrderEvents struct {
+	redis *RedisService
+}
+
+// NewOrderEvents создает новый экземпляр OrderEvents
+func NewOrderEvents(redisService *RedisService) *OrderEvents {
+	return &OrderEvents{
+		redis: redisService,
+	}
+}
+
+// OrderStream возвращает имя потока событий одного заказа
+func OrderStream(orderID string) string {
+	return "order_events:order:" + orderID
+}
+
+// RestaurantOrdersStream возвращает имя потока событий всех заказов ресторана
+func RestaurantOrdersStream(restaurantID primitive.ObjectID) string {
+	return "order_events:restaurant:" + restaurantID.Hex()
+}
+
+// Publish присваивает событию номер, сохраняет его в истории потоков заказа и ресторана
+// и рассылает подписчикам
+func (e *OrderEvents) Publish(ctx context.Context, event *models.OrderEvent) error {
+	id, err := e.redis.Client.Incr(ctx, orderEventsSequenceKey).Result()
+	if err != nil {
+		return errors.Wrap(err, "allocating order event id failed")
+	}
+	event.ID = id
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "encoding order event failed")
+	}
+
+	pipe := e.redis.Client.TxPipeline()
+	for _, stream := range []string{OrderStream(event.OrderID), RestaurantOrdersStream(event.RestaurantID)} {
+		history := stream + ":history"
+		pipe.ZAdd(ctx, history, redis.Z{Score: float64(id), Member: payload})
+		pipe.ZRemRangeByRank(ctx, history, 0, -orderEventsHistorySize-1)
+		pipe.Expire(ctx, history, orderEventsHistoryTTL)
+		pipe.Publish(ctx, stream, payload)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "publishing order event failed")
+	}
+	return nil
+}
+
+// History возвращает сохраненные события потока с номером больше afterID в порядке возрастания
+func (e *OrderEvents) History(ctx context.Context, stream string, afterID int64) ([]models.OrderEvent, error) {
+	raw, err := e.redis.Client.ZRangeByScore(ctx, stream+":history", &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(afterID, 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading order event history failed")
+	}
+
+	events := make([]models.OrderEvent, 0, len(raw))
+	for _, payload := range raw {
+		var event models.OrderEvent
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// Subscribe подписывается на поток событий. Канал закрывается при отмене ctx.
+// Подписка устанавливается до возврата, поэтому события, опубликованные после вызова, не теряются.
+func (e *OrderEvents) Subscribe(ctx context.Context, stream string) (<-chan models.OrderEvent, error) {
+	pubsub := e.redis.Client.Subscribe(ctx, stream)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, errors.Wrap(err, "subscribing to order events failed")
+	}
+
+	events := make(chan models.OrderEvent)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case message, ok := <-messages:
+				if !ok {
+					return
+				}
+				var event models.OrderEvent
+				if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+					log.Printf("Failed to decode order event: %v", err)
+					continue
+				}
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// fakeRedis минимальный сервер RESP2 с командами, которые использует OrderEvents
+type fakeRedis struct {
+	mu       sync.Mutex
+	counters map[string]int64
+	sets     map[string]map[string]float64
+	expires  map[string]bool
+	channels map[string]int
+}
+
+// newFakeRedis запускает сервер и возвращает клиента к нему
+func newFakeRedis(t *testing.T) (*fakeRedis, *RedisService) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	server := &fakeRedis{
+		counters: map[string]int64{},
+		sets:     map[string]map[string]float64{},
+		expires:  map[string]bool{},
+		channels: map[string]int{},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	client := redis.NewClient(&redis.Options{Addr: listener.Addr().String(), Protocol: 2, DisableIndentity: true})
+	t.Cleanup(func() {
+		client.Close()
+		listener.Close()
+	})
+	return server, &RedisService{Client: client}
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	var queued [][]string
+	inMulti := false
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			inMulti = true
+			io.WriteString(conn, "+OK\r\n")
+		case name == "EXEC":
+			replies := make([]string, 0, len(queued))
+			for _, command := range queued {
+				replies = append(replies, f.execute(command))
+			}
+			queued, inMulti = nil, false
+			io.WriteString(conn, fmt.Sprintf("*%d\r\n%s", len(replies), strings.Join(replies, "")))
+		case inMulti:
+			queued = append(queued, args)
+			io.WriteString(conn, "+QUEUED\r\n")
+		default:
+			io.WriteString(conn, f.execute(args))
+		}
+	}
+}
+
+func (f *fakeRedis) execute(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "INCR":
+		f.counters[args[1]]++
+		return fmt.Sprintf(":%d\r\n", f.counters[args[1]])
+	case "ZADD":
+		if f.sets[args[1]] == nil {
+			f.sets[args[1]] = map[string]float64{}
+		}
+		score, _ := strconv.ParseFloat(args[2], 64)
+		f.sets[args[1]][args[3]] = score
+		return ":1\r\n"
+	case "ZREMRANGEBYRANK":
+		members := f.sorted(args[1])
+		start, _ := strconv.Atoi(args[2])
+		stop, _ := strconv.Atoi(args[3])
+		if start < 0 {
+			start += len(members)
+		}
+		if stop < 0 {
+			stop += len(members)
+		}
+		removed := 0
+		for i := max(start, 0); i <= stop && i < len(members); i++ {
+			delete(f.sets[args[1]], members[i])
+			removed++
+		}
+		return fmt.Sprintf(":%d\r\n", removed)
+	case "EXPIRE":
+		f.expires[args[1]] = true
+		return ":1\r\n"
+	case "PUBLISH":
+		f.channels[args[1]]++
+		return ":0\r\n"
+	case "ZRANGEBYSCORE":
+		exclusive := strings.HasPrefix(args[2], "(")
+		min, _ := strconv.ParseFloat(strings.TrimPrefix(args[2], "("), 64)
+		var reply []string
+		for _, member := range f.sorted(args[1]) {
+			score := f.sets[args[1]][member]
+			if score > min || (!exclusive && score == min) {
+				reply = append(reply, fmt.Sprintf("$%d\r\n%s\r\n", len(member), member))
+			}
+		}
+		return fmt.Sprintf("*%d\r\n%s", len(reply), strings.Join(reply, ""))
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// sorted возвращает элементы множества по возрастанию оценки
+func (f *fakeRedis) sorted(key string) []string {
+	members := make([]string, 0, len(f.sets[key]))
+	for member := range f.sets[key] {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return f.sets[key][members[i]] < f.sets[key][members[j]] })
+	return members
+}
+
+// readRESPCommand читает команду клиента в виде массива bulk-строк
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func TestOrderEventsPublishAndHistory(t *testing.T) {
+	server, redisService := newFakeRedis(t)
+	events := NewOrderEvents(redisService)
+	ctx := context.Background()
+	restaurantID := primitive.NewObjectID()
+
+	for _, status := range []string{"confirmed", "preparing", "delivered"} {
+		event := &models.OrderEvent{Type: models.OrderEventStatusChanged, OrderID: "order-1", RestaurantID: restaurantID, Status: status}
+		if err := events.Publish(ctx, event); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+		if event.At.IsZero() {
+			t.Errorf("Publish() left At empty")
+		}
+	}
+	other := &models.OrderEvent{Type: models.OrderEventStatusChanged, OrderID: "order-2", RestaurantID: restaurantID, Status: "confirmed"}
+	if err := events.Publish(ctx, other); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if other.ID != 4 {
+		t.Errorf("event ID = %d, want 4 from the shared sequence", other.ID)
+	}
+
+	tests := []struct {
+		name    string
+		stream  string
+		afterID int64
+		want    []int64
+	}{
+		{name: "whole order", stream: OrderStream("order-1"), want: []int64{1, 2, 3}},
+		{name: "resume after last event id", stream: OrderStream("order-1"), afterID: 2, want: []int64{3}},
+		{name: "restaurant sees every order", stream: RestaurantOrdersStream(restaurantID), afterID: 1, want: []int64{2, 3, 4}},
+		{name: "caught up", stream: OrderStream("order-2"), afterID: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history, err := events.History(ctx, tt.stream, tt.afterID)
+			if err != nil {
+				t.Fatalf("History() error = %v", err)
+			}
+			var got []int64
+			for _, event := range history {
+				got = append(got, event.ID)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("History() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if server.channels[OrderStream("order-1")] != 3 || server.channels[RestaurantOrdersStream(restaurantID)] != 4 {
+		t.Errorf("published = %v, want 3 to the order and 4 to the restaurant", server.channels)
+	}
+	if !server.expires[OrderStream("order-1")+":history"] {
+		t.Errorf("history of order-1 has no expiry")
+	}
+}
+
+func TestOrderEventsHistoryIsTrimmed(t *testing.T) {
+	server, redisService := newFakeRedis(t)
+	events := NewOrderEvents(redisService)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for i := 0; i < orderEventsHistorySize+5; i++ {
+		if err := events.Publish(ctx, &models.OrderEvent{OrderID: "order-1", Status: "preparing"}); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	history, err := events.History(ctx, OrderStream("order-1"), 0)
+	if err != nil {
+		t.Fatalf("History() error = %v", err)
+	}
+	if len(history) != orderEventsHistorySize || history[0].ID != 6 {
+		t.Errorf("History() = %d events from %d, want %d from 6", len(history), history[0].ID, orderEventsHistorySize)
+	}
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	if got := len(server.sets[OrderStream("order-1")+":history"]); got != orderEventsHistorySize {
+		t.Errorf("stored events = %d, want %d", got, orderEventsHistorySize)
+	}
+}
@@ -0,0 +1,279 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrOrderNotFound          = errors.New("order not found")
+	ErrInvalidOrderTransition = errors.New("order cannot move to this status")
+	ErrOrderConflict          = errors.New("order was changed concurrently, retry")
+)
+
+// OrderService структура сервиса заказов. Заказы хранятся в документах ресторана
+// и пользователей; сервис меняет их статус согласованно и публикует события.
+type OrderService struct {
+	db                  *mongo.Database
+	events              *OrderEvents
+	notificationService *NotificationService
+	webhookService      *WebhookService
+}
+
+// NewOrderService создает новый экземпляр OrderService
+func NewOrderService(client *mongo.Client, dbName string, events *OrderEvents, notificationService *NotificationService, webhookService *WebhookService) *OrderService {
+	return &OrderService{
+		db:                  client.Database(dbName),
+		events:              events,
+		notificationService: notificationService,
+		webhookService:      webhookService,
+	}
+}
+
+// EnsureIndexes создает индексы для поиска встроенных заказов по ID
+func (s *OrderService) EnsureIndexes(ctx context.Context) error {
+	for _, collection := range []string{EntityTypeUser, EntityTypeRestaurant} {
+		_, err := s.db.Collection(collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys: bson.D{{Key: "orders._id", Value: 1}},
+		})
+		if err != nil {
+			return errors.Wrap(err, "creating order indexes failed")
+		}
+	}
+	return nil
+}
+
+// GetOrder возвращает заказ из документа сущности (пользователя или ресторана).
+// Чужой заказ выглядит как отсутствующий.
+func (s *OrderService) GetOrder(ctx context.Context, entityType string, entityID primitive.ObjectID, orderID string) (*models.Order, error) {
+	if entityType != EntityTypeUser && entityType != EntityTypeRestaurant {
+		return nil, ErrOrderNotFound
+	}
+
+	var doc struct {
+		Orders []models.Order `bson:"orders"`
+	}
+	err := s.db.Collection(entityType).FindOne(ctx,
+		bson.M{"_id": entityID, "orders._id": orderID},
+		options.FindOne().SetProjection(bson.M{"orders.$": 1}),
+	).Decode(&doc)
+	if err == mongo.ErrNoDocuments || (err == nil && len(doc.Orders) == 0) {
+		return nil, ErrOrderNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding order failed")
+	}
+	return &doc.Orders[0], nil
+}
+
+// PublishOrderPlaced рассылает подписчикам заказов событие о новом заказе из order.placed
+func (s *OrderService) PublishOrderPlaced(ctx context.Context, event *models.DomainEvent) error {
+	orderEvent, _, err := s.orderPlacedEvent(ctx, event)
+	if err != nil || orderEvent == nil || s.events == nil {
+		return err
+	}
+	return s.events.Publish(ctx, orderEvent)
+}
+
+// EnqueueOrderPlacedWebhooks ставит в очередь вебхуки ресторана о новом заказе из order.placed
+func (s *OrderService) EnqueueOrderPlacedWebhooks(ctx context.Context, event *models.DomainEvent) error {
+	orderEvent, order, err := s.orderPlacedEvent(ctx, event)
+	if err != nil || orderEvent == nil || s.webhookService == nil {
+		return err
+	}
+	return s.webhookService.Enqueue(ctx, orderEvent, order)
+}
+
+// orderPlacedEvent восстанавливает событие заказа и сам заказ по событию order.placed.
+// Если заказ уже удален вместе с рестораном, возвращается nil без ошибки.
+func (s *OrderService) orderPlacedEvent(ctx context.Context, event *models.DomainEvent) (*models.OrderEvent, *models.Order, error) {
+	var payload models.OrderPlacedPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return nil, nil, errors.Wrap(err, "decoding order event failed")
+	}
+	order, err := s.GetOrder(ctx, EntityTypeRestaurant, payload.RestaurantID, payload.OrderID)
+	if err == ErrOrderNotFound {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	participants := make([]string, 0, len(payload.UserIDs))
+	for _, userID := range payload.UserIDs {
+		participants = append(participants, userID.Hex())
+	}
+	return &models.OrderEvent{
+		Type:         models.OrderEventCreated,
+		OrderID:      order.ID,
+		RestaurantID: payload.RestaurantID,
+		UserIDs:      participants,
+		Status:       models.OrderStatusPending,
+		At:           order.CreatedAt,
+	}, order, nil
+}
+
+// UpdateStatus переводит заказ ресторана в новый статус, обновляет копии заказа
+// у пользователей и публикует событие подписчикам
+func (s *OrderService) UpdateStatus(ctx context.Context, restaurantID primitive.ObjectID, orderID, status string) (*models.Order, error) {
+	order, err := s.GetOrder(ctx, EntityTypeRestaurant, restaurantID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransition(order.Status, status) {
+		return nil, ErrInvalidOrderTransition
+	}
+
+	// Условие на прежний статус не дает двум сотрудникам одновременно провести заказ по разным веткам
+	now := time.Now()
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateOne(ctx,
+		bson.M{"_id": restaurantID, "orders": bson.M{"$elemMatch": bson.M{"_id": orderID, "status": order.Status}}},
+		bson.M{"$set": bson.M{"orders.$.status": status, "orders.$.updated_at": now}},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating order status failed")
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrOrderConflict
+	}
+
+	userIDs, err := s.updateUserCopies(ctx, orderID, bson.M{"status": status, "updated_at": now})
+	if err != nil {
+		return nil, err
+	}
+
+	previous := order.Status
+	order.Status = status
+	order.UpdatedAt = now
+	s.publish(ctx, &models.OrderEvent{
+		Type:           models.OrderEventStatusChanged,
+		OrderID:        orderID,
+		RestaurantID:   restaurantID,
+		UserIDs:        userIDs,
+		Status:         status,
+		PreviousStatus: previous,
+		At:             now,
+	}, order)
+	s.notifyStatus(ctx, restaurantID, order, userIDs)
+	return order, nil
+}
+
+// notifyStatus уведомляет участников заказа о новом статусе; ошибка уведомления не отменяет смену статуса
+func (s *OrderService) notifyStatus(ctx context.Context, restaurantID primitive.ObjectID, order *models.Order, userIDs []string) {
+	recipients := make([]primitive.ObjectID, 0, len(userIDs))
+	for _, userID := range userIDs {
+		if id, err := primitive.ObjectIDFromHex(userID); err == nil {
+			recipients = append(recipients, id)
+		}
+	}
+	err := s.notificationService.Notify(ctx, recipients, NotificationOrderStatus, "Статус заказа изменился", map[string]string{
+		"order_id":      order.ID,
+		"restaurant_id": restaurantID.Hex(),
+		"status":        order.Status,
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notifications: %v", NotificationOrderStatus, err)
+	}
+}
+
+// SetDelivery сохраняет адрес и стоимость доставки в заказе ресторана и копиях пользователей
+func (s *OrderService) SetDelivery(ctx context.Context, restaurantID primitive.ObjectID, orderID string, delivery *models.OrderDelivery) error {
+	now := time.Now()
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateOne(ctx,
+		bson.M{"_id": restaurantID, "orders._id": orderID},
+		bson.M{"$set": bson.M{"orders.$.delivery": delivery, "orders.$.updated_at": now}},
+	)
+	if err != nil {
+		return errors.Wrap(err, "saving order delivery failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrOrderNotFound
+	}
+	_, err = s.updateUserCopies(ctx, orderID, bson.M{"delivery": delivery, "updated_at": now})
+	return err
+}
+
+// DeliveryChanged публикует событие об изменении статуса доставки заказа
+func (s *OrderService) DeliveryChanged(ctx context.Context, delivery *models.Delivery) {
+	userIDs, err := s.orderUserIDs(ctx, delivery.OrderID)
+	if err != nil {
+		log.Printf("Failed to find order users: %v", err)
+	}
+	s.publish(ctx, &models.OrderEvent{
+		Type:         models.OrderEventDelivery,
+		OrderID:      delivery.OrderID,
+		RestaurantID: delivery.RestaurantID,
+		UserIDs:      userIDs,
+		Status:       delivery.Status,
+		At:           delivery.UpdatedAt,
+	}, nil)
+}
+
+// updateUserCopies обновляет поля копий заказа у пользователей и возвращает их hex ID
+func (s *OrderService) updateUserCopies(ctx context.Context, orderID string, fields bson.M) ([]string, error) {
+	userIDs, err := s.orderUserIDs(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := bson.M{}
+	for field, value := range fields {
+		set["orders.$[order]."+field] = value
+	}
+	_, err = s.db.Collection(EntityTypeUser).UpdateMany(ctx,
+		bson.M{"orders._id": orderID},
+		bson.M{"$set": set},
+		options.Update().SetArrayFilters(options.ArrayFilters{
+			Filters: []interface{}{bson.M{"order._id": orderID}},
+		}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "updating user orders failed")
+	}
+	return userIDs, nil
+}
+
+// orderUserIDs возвращает hex ID пользователей, у которых есть копия заказа
+func (s *OrderService) orderUserIDs(ctx context.Context, orderID string) ([]string, error) {
+	cursor, err := s.db.Collection(EntityTypeUser).Find(ctx, bson.M{"orders._id": orderID},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding order users failed")
+	}
+	var users []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, errors.Wrap(err, "decoding order users failed")
+	}
+	ids := make([]string, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.ID.Hex())
+	}
+	return ids, nil
+}
+
+// publish рассылает событие заказа подписчикам и ставит его в очередь вебхуков ресторана.
+// Статус уже сохранен, поэтому сбой рассылки только логируется: клиенты увидят
+// актуальный статус при следующем запросе.
+func (s *OrderService) publish(ctx context.Context, event *models.OrderEvent, order *models.Order) {
+	if s.events != nil {
+		if err := s.events.Publish(ctx, event); err != nil {
+			log.Printf("Failed to publish order event: %v", err)
+		}
+	}
+	if s.webhookService != nil {
+		if err := s.webhookService.Enqueue(ctx, event, order); err != nil {
+			log.Printf("Failed to enqueue order webhooks: %v", err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	passwordResetsCollectionName = "password_resets"
+
+	// passwordResetTTL время жизни ссылки восстановления
+	passwordResetTTL = time.Hour
+	// passwordResetCooldown не дает засыпать почту повторными запросами
+	passwordResetCooldown = time.Minute
+	// minPasswordLength совпадает с ограничением при регистрации
+	minPasswordLength = 6
+)
+
+var (
+	ErrInvalidResetToken = errors.New("invalid or expired reset token")
+	ErrPasswordTooShort  = errors.New("password is too short")
+)
+
+// PasswordResetService структура сервиса восстановления пароля пользователя по почте.
+// В базе хранится только хэш токена; токен одноразовый.
+type PasswordResetService struct {
+	db       *mongo.Database
+	notifier *Notifier
+	resetURL string
+}
+
+// NewPasswordResetService создает новый экземпляр PasswordResetService.
+// Ссылка в письме строится из PASSWORD_RESET_URL, к ней добавляется параметр token.
+func NewPasswordResetService(client *mongo.Client, dbName string, notifier *Notifier) *PasswordResetService {
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = "http://localhost:3000/reset-password"
+	}
+	return &PasswordResetService{
+		db:       client.Database(dbName),
+		notifier: notifier,
+		resetURL: resetURL,
+	}
+}
+
+// EnsureIndexes создает индексы токенов восстановления
+func (s *PasswordResetService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(passwordResetsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return errors.Wrap(err, "creating password reset indexes failed")
+}
+
+// RequestReset отправляет ссылку восстановления на почту. Неизвестный адрес не считается ошибкой,
+// чтобы по ответу нельзя было проверить, зарегистрирован ли адрес.
+func (s *PasswordResetService) RequestReset(ctx context.Context, email string) error {
+	var user struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"email": email},
+		options.FindOne().SetProjection(bson.M{"_id": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "finding user failed")
+	}
+
+	now := time.Now()
+	recent, err := s.db.Collection(passwordResetsCollectionName).CountDocuments(ctx, bson.M{
+		"user_id":    user.ID,
+		"created_at": bson.M{"$gt": now.Add(-passwordResetCooldown)},
+	})
+	if err != nil {
+		return errors.Wrap(err, "checking recent resets failed")
+	}
+	if recent > 0 {
+		log.Printf("Skipping password reset for user %s: requested too often", user.ID.Hex())
+		return nil
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return errors.Wrap(err, "generating reset token failed")
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+	_, err = s.db.Collection(passwordResetsCollectionName).InsertOne(ctx, bson.M{
+		"token_hash": hashResetToken(token),
+		"user_id":    user.ID,
+		"created_at": now,
+		"expires_at": now.Add(passwordResetTTL),
+	})
+	if err != nil {
+		return errors.Wrap(err, "saving reset token failed")
+	}
+
+	link, err := url.Parse(s.resetURL)
+	if err != nil {
+		return errors.Wrap(err, "parsing PASSWORD_RESET_URL failed")
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	return s.notifier.Dispatch(ctx, []primitive.ObjectID{user.ID}, NotificationPasswordReset, "Восстановление пароля", map[string]string{
+		"link":            link.String(),
+		"expires_minutes": strconv.Itoa(int(passwordResetTTL.Minutes())),
+	})
+}
+
+// ResetPassword задает новый пароль по токену из письма. После смены пароля
+// остальные токены пользователя и его refresh-токен становятся недействительными.
+func (s *PasswordResetService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	if len(newPassword) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+
+	var reset struct {
+		UserID primitive.ObjectID `bson:"user_id"`
+	}
+	err := s.db.Collection(passwordResetsCollectionName).FindOneAndDelete(ctx, bson.M{
+		"token_hash": hashResetToken(token),
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&reset)
+	if err == mongo.ErrNoDocuments {
+		return ErrInvalidResetToken
+	}
+	if err != nil {
+		return errors.Wrap(err, "finding reset token failed")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.Wrap(err, "hashing new password failed")
+	}
+	result, err := s.db.Collection(EntityTypeUser).UpdateOne(ctx, bson.M{"_id": reset.UserID}, bson.M{
+		"$set":   bson.M{"password": string(hashedPassword)},
+		"$unset": bson.M{"refreshToken": ""},
+	})
+	if err != nil {
+		return errors.Wrap(err, "updating password failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrInvalidResetToken
+	}
+
+	if _, err := s.db.Collection(passwordResetsCollectionName).DeleteMany(ctx, bson.M{"user_id": reset.UserID}); err != nil {
+		log.Printf("Failed to delete reset tokens of user %s: %v", reset.UserID.Hex(), err)
+	}
+	return nil
+}
+
+// hashResetToken возвращает хэш токена для хранения в базе
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
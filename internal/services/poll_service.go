@@ -0,0 +1,517 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const pollsCollectionName = "polls"
+
+// JobPollClose тип задачи, закрывающей опрос в момент окончания голосования
+const JobPollClose = "poll.close"
+
+// Типы уведомлений об опросах
+const (
+	NotificationPollInvite = "poll_invite"
+	NotificationPollClosed = "poll_closed"
+)
+
+var (
+	ErrPollNotFound      = errors.New("poll not found")
+	ErrPollForbidden     = errors.New("only the poll creator can do this")
+	ErrPollClosed        = errors.New("poll is closed")
+	ErrPollNotClosed     = errors.New("poll is still open")
+	ErrPollNoWinner      = errors.New("poll has no winner")
+	ErrPollMeetupExists  = errors.New("meetup for this poll already exists")
+	ErrInvalidBallot     = errors.New("ranking must list distinct poll options")
+	ErrPollDeadlinePast  = errors.New("poll deadline must be in the future")
+	ErrPollVoterNotFound = errors.New("only friends can be invited to vote")
+)
+
+// PollMeetupRequest параметры встречи, создаваемой по итогам опроса
+type PollMeetupRequest struct {
+	Title       string
+	Description string
+	StartsAt    time.Time
+	Seats       int
+}
+
+// PollService структура сервиса опросов о выборе ресторана
+type PollService struct {
+	db                  *mongo.Database
+	friendService       *FriendService
+	meetupService       *MeetupService
+	notificationService *NotificationService
+	jobRunner           *JobRunner
+}
+
+// NewPollService создает новый экземпляр PollService
+func NewPollService(client *mongo.Client, dbName string, friendService *FriendService, meetupService *MeetupService, notificationService *NotificationService, jobRunner *JobRunner) *PollService {
+	return &PollService{
+		db:                  client.Database(dbName),
+		friendService:       friendService,
+		meetupService:       meetupService,
+		notificationService: notificationService,
+		jobRunner:           jobRunner,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции опросов
+func (s *PollService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(pollsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "creator_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "voter_ids", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating poll indexes failed")
+	}
+	return nil
+}
+
+// CreatePoll создает опрос по списку ресторанов и приглашает друзей проголосовать
+func (s *PollService) CreatePoll(ctx context.Context, creatorID primitive.ObjectID, title string, restaurantIDs, voterIDs []primitive.ObjectID, deadline time.Time) (*models.Poll, error) {
+	if !deadline.After(time.Now()) {
+		return nil, ErrPollDeadlinePast
+	}
+
+	pollOptions, err := s.loadOptions(ctx, restaurantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	voters := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{creatorID: true}
+	for _, voterID := range voterIDs {
+		if seen[voterID] {
+			continue
+		}
+		seen[voterID] = true
+		friends, err := s.friendService.AreFriends(ctx, creatorID, voterID)
+		if err != nil {
+			return nil, err
+		}
+		if !friends {
+			return nil, ErrPollVoterNotFound
+		}
+		voters = append(voters, voterID)
+	}
+
+	poll := &models.Poll{
+		ID:        primitive.NewObjectID(),
+		CreatorID: creatorID,
+		Title:     title,
+		Options:   pollOptions,
+		VoterIDs:  voters,
+		Ballots:   map[string]models.PollBallot{},
+		Deadline:  deadline,
+		Status:    models.PollStatusOpen,
+		CreatedAt: time.Now(),
+	}
+	if err := poll.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.Collection(pollsCollectionName).InsertOne(ctx, poll); err != nil {
+		return nil, errors.Wrap(err, "inserting poll failed")
+	}
+
+	// Опрос закрывается и при первом обращении после срока, задача лишь рассылает итоги вовремя
+	_, err = s.jobRunner.Enqueue(ctx, JobPollClose, models.PollCloseJobPayload{PollID: poll.ID}, EnqueueOptions{
+		RunAt:     deadline,
+		UniqueKey: JobPollClose + ":" + poll.ID.Hex(),
+	})
+	if err != nil {
+		log.Printf("Failed to schedule closing of poll %s: %v", poll.ID.Hex(), err)
+	}
+
+	s.notify(ctx, voters, NotificationPollInvite, "Голосование: "+poll.Title, poll)
+	return s.present(poll, creatorID), nil
+}
+
+// GetPoll возвращает опрос участнику, закрывая его, если срок голосования истек
+func (s *PollService) GetPoll(ctx context.Context, userID, pollID primitive.ObjectID) (*models.Poll, error) {
+	poll, err := s.findPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if !poll.IsParticipant(userID) {
+		return nil, ErrPollNotFound
+	}
+	if poll, err = s.closeIfExpired(ctx, poll); err != nil {
+		return nil, err
+	}
+	return s.present(poll, userID), nil
+}
+
+// ListUserPolls возвращает опросы, созданные пользователем или в которых он участвует
+func (s *PollService) ListUserPolls(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]models.Poll, error) {
+	cursor, err := s.db.Collection(pollsCollectionName).Find(ctx,
+		bson.M{"$or": bson.A{bson.M{"creator_id": userID}, bson.M{"voter_ids": userID}}},
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetSkip(int64((page-1)*limit)).
+			SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding polls failed")
+	}
+	var polls []models.Poll
+	if err := cursor.All(ctx, &polls); err != nil {
+		return nil, errors.Wrap(err, "decoding polls failed")
+	}
+
+	result := make([]models.Poll, 0, len(polls))
+	for i := range polls {
+		poll, err := s.closeIfExpired(ctx, &polls[i])
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *s.present(poll, userID))
+	}
+	return result, nil
+}
+
+// Vote сохраняет или заменяет бюллетень участника
+func (s *PollService) Vote(ctx context.Context, userID, pollID primitive.ObjectID, ranking []primitive.ObjectID) (*models.Poll, error) {
+	poll, err := s.findPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if !poll.IsParticipant(userID) {
+		return nil, ErrPollNotFound
+	}
+	if poll.Status != models.PollStatusOpen || !poll.Deadline.After(time.Now()) {
+		return nil, ErrPollClosed
+	}
+	if len(ranking) == 0 {
+		return nil, ErrInvalidBallot
+	}
+	seen := make(map[primitive.ObjectID]bool, len(ranking))
+	for _, restaurantID := range ranking {
+		if seen[restaurantID] || !poll.HasOption(restaurantID) {
+			return nil, ErrInvalidBallot
+		}
+		seen[restaurantID] = true
+	}
+
+	ballot := models.PollBallot{UserID: userID, Ranking: ranking, CastAt: time.Now()}
+	var updated models.Poll
+	err = s.db.Collection(pollsCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": pollID, "status": models.PollStatusOpen, "deadline": bson.M{"$gt": ballot.CastAt}},
+		bson.M{"$set": bson.M{"ballots." + userID.Hex(): ballot}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPollClosed
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "saving ballot failed")
+	}
+	return s.present(&updated, userID), nil
+}
+
+// ClosePoll досрочно закрывает опрос и подводит итоги
+func (s *PollService) ClosePoll(ctx context.Context, creatorID, pollID primitive.ObjectID) (*models.Poll, error) {
+	poll, err := s.findPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.CreatorID != creatorID {
+		return nil, ErrPollForbidden
+	}
+	if poll.Status != models.PollStatusOpen {
+		return nil, ErrPollClosed
+	}
+	if poll, err = s.close(ctx, poll); err != nil {
+		return nil, err
+	}
+	return s.present(poll, creatorID), nil
+}
+
+// CreateMeetupFromPoll создает встречу в ресторане-победителе и приглашает участников опроса
+func (s *PollService) CreateMeetupFromPoll(ctx context.Context, creatorID, pollID primitive.ObjectID, req PollMeetupRequest) (*models.Meetup, error) {
+	poll, err := s.findPoll(ctx, pollID)
+	if err != nil {
+		return nil, err
+	}
+	if poll.CreatorID != creatorID {
+		return nil, ErrPollForbidden
+	}
+	if poll, err = s.closeIfExpired(ctx, poll); err != nil {
+		return nil, err
+	}
+	if poll.Status != models.PollStatusClosed {
+		return nil, ErrPollNotClosed
+	}
+	if poll.WinnerID == nil {
+		return nil, ErrPollNoWinner
+	}
+	if poll.MeetupID != nil {
+		return nil, ErrPollMeetupExists
+	}
+
+	// Приглашаются только участники, оставшиеся друзьями автора
+	var invitees []primitive.ObjectID
+	for _, voterID := range poll.VoterIDs {
+		friends, err := s.friendService.AreFriends(ctx, creatorID, voterID)
+		if err != nil {
+			return nil, err
+		}
+		if friends {
+			invitees = append(invitees, voterID)
+		}
+	}
+
+	if req.Title == "" {
+		req.Title = poll.Title
+	}
+	if req.Seats == 0 {
+		req.Seats = len(invitees) + 1
+		if req.Seats < 2 {
+			req.Seats = 2
+		}
+	}
+	meetup := &models.Meetup{
+		OrganizerID:  creatorID,
+		RestaurantID: *poll.WinnerID,
+		Title:        req.Title,
+		Description:  req.Description,
+		StartsAt:     req.StartsAt,
+		Seats:        req.Seats,
+	}
+	if err := s.meetupService.CreateMeetup(ctx, meetup, invitees); err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Collection(pollsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": pollID, "meetup_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"meetup_id": meetup.ID}},
+	)
+	if err == nil && result.MatchedCount == 0 {
+		err = ErrPollMeetupExists
+	}
+	if err != nil {
+		// Встреча по опросу уже создана параллельным запросом - отменяем дубликат
+		if cancelErr := s.meetupService.CancelMeetup(ctx, creatorID, meetup.ID); cancelErr != nil {
+			log.Printf("Failed to cancel duplicate meetup %s: %v", meetup.ID.Hex(), cancelErr)
+		}
+		if err == ErrPollMeetupExists {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "linking meetup to poll failed")
+	}
+	return meetup, nil
+}
+
+// HandleCloseJob закрывает опрос по задаче poll.close. Опрос, уже закрытый или удаленный, пропускается.
+func (s *PollService) HandleCloseJob(ctx context.Context, job *models.Job) error {
+	var payload models.PollCloseJobPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return errors.Wrap(err, "decoding poll job failed")
+	}
+	poll, err := s.findPoll(ctx, payload.PollID)
+	if err == ErrPollNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = s.closeIfExpired(ctx, poll)
+	return err
+}
+
+// closeIfExpired закрывает открытый опрос с истекшим сроком голосования
+func (s *PollService) closeIfExpired(ctx context.Context, poll *models.Poll) (*models.Poll, error) {
+	if poll.Status != models.PollStatusOpen || poll.Deadline.After(time.Now()) {
+		return poll, nil
+	}
+	return s.close(ctx, poll)
+}
+
+// close подсчитывает голоса и закрывает опрос. Если опрос уже закрыт
+// параллельным запросом, возвращается сохраненный результат.
+func (s *PollService) close(ctx context.Context, poll *models.Poll) (*models.Poll, error) {
+	ballots := make([]models.PollBallot, 0, len(poll.Ballots))
+	for _, ballot := range poll.Ballots {
+		ballots = append(ballots, ballot)
+	}
+	winnerID, rounds := rankedChoiceWinner(poll.Options, ballots)
+
+	now := time.Now()
+	set := bson.M{"status": models.PollStatusClosed, "rounds": rounds, "closed_at": now}
+	if winnerID != nil {
+		set["winner_id"] = *winnerID
+	}
+	result, err := s.db.Collection(pollsCollectionName).UpdateOne(ctx,
+		bson.M{"_id": poll.ID, "status": models.PollStatusOpen},
+		bson.M{"$set": set},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "closing poll failed")
+	}
+	if result.MatchedCount == 0 {
+		return s.findPoll(ctx, poll.ID)
+	}
+
+	poll.Status = models.PollStatusClosed
+	poll.WinnerID = winnerID
+	poll.Rounds = rounds
+	poll.ClosedAt = &now
+	s.notify(ctx, append([]primitive.ObjectID{poll.CreatorID}, poll.VoterIDs...), NotificationPollClosed, "Итоги голосования: "+poll.Title, poll)
+	return poll, nil
+}
+
+// findPoll возвращает опрос по ID
+func (s *PollService) findPoll(ctx context.Context, pollID primitive.ObjectID) (*models.Poll, error) {
+	var poll models.Poll
+	err := s.db.Collection(pollsCollectionName).FindOne(ctx, bson.M{"_id": pollID}).Decode(&poll)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrPollNotFound
+		}
+		return nil, errors.Wrap(err, "finding poll failed")
+	}
+	return &poll, nil
+}
+
+// loadOptions проверяет рестораны-варианты и возвращает их в переданном порядке
+func (s *PollService) loadOptions(ctx context.Context, restaurantIDs []primitive.ObjectID) ([]models.PollOption, error) {
+	ids := uniqueObjectIDs(restaurantIDs)
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "banned": bson.M{"$ne": true}},
+		options.Find().SetProjection(bson.M{"name": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding poll restaurants failed")
+	}
+	var restaurants []struct {
+		ID   primitive.ObjectID `bson:"_id"`
+		Name string             `bson:"name"`
+	}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding poll restaurants failed")
+	}
+	names := make(map[primitive.ObjectID]string, len(restaurants))
+	for _, restaurant := range restaurants {
+		names[restaurant.ID] = restaurant.Name
+	}
+
+	pollOptions := make([]models.PollOption, 0, len(ids))
+	for _, id := range ids {
+		name, ok := names[id]
+		if !ok {
+			return nil, ErrRestaurantNotFound
+		}
+		pollOptions = append(pollOptions, models.PollOption{RestaurantID: id, Name: name})
+	}
+	return pollOptions, nil
+}
+
+// present скрывает бюллетени других участников и добавляет бюллетень текущего пользователя
+func (s *PollService) present(poll *models.Poll, userID primitive.ObjectID) *models.Poll {
+	poll.BallotCount = len(poll.Ballots)
+	if ballot, ok := poll.Ballots[userID.Hex()]; ok {
+		poll.MyRanking = ballot.Ranking
+	}
+	return poll
+}
+
+// notify отправляет уведомление об опросе; ошибка уведомления не отменяет основное действие
+func (s *PollService) notify(ctx context.Context, userIDs []primitive.ObjectID, notificationType, title string, poll *models.Poll) {
+	data := map[string]string{"poll_id": poll.ID.Hex(), "poll_title": poll.Title}
+	if poll.WinnerID != nil {
+		data["winner_id"] = poll.WinnerID.Hex()
+	}
+	if err := s.notificationService.Notify(ctx, userIDs, notificationType, title, data); err != nil {
+		log.Printf("Failed to send %s notifications: %v", notificationType, err)
+	}
+}
+
+// rankedChoiceWinner подсчитывает голоса методом мгновенного второго тура.
+// В каждом раунде бюллетень отдается высшему в нем еще не выбывшему варианту;
+// побеждает вариант с абсолютным большинством, иначе выбывает вариант с наименьшим
+// числом голосов. При равенстве выбывает вариант, стоящий в опросе позже.
+func rankedChoiceWinner(pollOptions []models.PollOption, ballots []models.PollBallot) (*primitive.ObjectID, []models.PollRound) {
+	active := make([]primitive.ObjectID, 0, len(pollOptions))
+	for _, option := range pollOptions {
+		active = append(active, option.RestaurantID)
+	}
+
+	var rounds []models.PollRound
+	for round := 1; len(active) > 0; round++ {
+		isActive := make(map[primitive.ObjectID]bool, len(active))
+		for _, id := range active {
+			isActive[id] = true
+		}
+		counts := make(map[primitive.ObjectID]int, len(active))
+		total := 0
+		for _, ballot := range ballots {
+			for _, id := range ballot.Ranking {
+				if isActive[id] {
+					counts[id]++
+					total++
+					break
+				}
+			}
+		}
+
+		current := models.PollRound{Round: round, Counts: make(map[string]int, len(active))}
+		for _, id := range active {
+			current.Counts[id.Hex()] = counts[id]
+		}
+		if total == 0 {
+			rounds = append(rounds, current)
+			return nil, rounds
+		}
+
+		leader, loser := active[0], active[len(active)-1]
+		for _, id := range active {
+			if counts[id] > counts[leader] {
+				leader = id
+			}
+		}
+		for i := len(active) - 1; i >= 0; i-- {
+			if counts[active[i]] < counts[loser] {
+				loser = active[i]
+			}
+		}
+		if counts[leader]*2 > total || len(active) == 1 || counts[leader] == counts[loser] {
+			// Большинство, последний оставшийся вариант или полное равенство - побеждает лидер
+			rounds = append(rounds, current)
+			return &leader, rounds
+		}
+
+		current.Eliminated = &loser
+		rounds = append(rounds, current)
+		remaining := active[:0:0]
+		for _, id := range active {
+			if id != loser {
+				remaining = append(remaining, id)
+			}
+		}
+		active = remaining
+	}
+	return nil, rounds
+}
+
+// uniqueObjectIDs возвращает ID без повторов, сохраняя порядок
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
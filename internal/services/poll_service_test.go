@@ -0,0 +1,150 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRankedChoiceWinner(t *testing.T) {
+	a, b, c := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	names := map[primitive.ObjectID]string{a: "A", b: "B", c: "C"}
+	options := []models.PollOption{{RestaurantID: a}, {RestaurantID: b}, {RestaurantID: c}}
+	ballots := func(rankings ...[]primitive.ObjectID) []models.PollBallot {
+		result := make([]models.PollBallot, 0, len(rankings))
+		for _, ranking := range rankings {
+			result = append(result, models.PollBallot{UserID: primitive.NewObjectID(), Ranking: ranking})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name           string
+		options        []models.PollOption
+		ballots        []models.PollBallot
+		want           string // пустая строка - победителя нет
+		wantRounds     int
+		wantEliminated []string
+	}{
+		{
+			name:       "majority in first round",
+			options:    options,
+			ballots:    ballots([]primitive.ObjectID{a}, []primitive.ObjectID{a}, []primitive.ObjectID{b}),
+			want:       "A",
+			wantRounds: 1,
+		},
+		{
+			name:    "transfer changes winner",
+			options: options,
+			ballots: ballots(
+				[]primitive.ObjectID{a}, []primitive.ObjectID{a},
+				[]primitive.ObjectID{b, c},
+				[]primitive.ObjectID{c}, []primitive.ObjectID{c},
+			),
+			want:           "C",
+			wantRounds:     2,
+			wantEliminated: []string{"B"},
+		},
+		{
+			name:    "tie for last eliminates later option",
+			options: options,
+			ballots: ballots(
+				[]primitive.ObjectID{a}, []primitive.ObjectID{a},
+				[]primitive.ObjectID{b},
+				[]primitive.ObjectID{c, b},
+			),
+			// C выбывает вместо B, затем A и B равны - побеждает A как стоящий раньше
+			want:           "A",
+			wantRounds:     2,
+			wantEliminated: []string{"C"},
+		},
+		{
+			name:    "tie for first settled by transfers",
+			options: options,
+			ballots: ballots(
+				[]primitive.ObjectID{a}, []primitive.ObjectID{a},
+				[]primitive.ObjectID{b}, []primitive.ObjectID{b},
+				[]primitive.ObjectID{c, b},
+			),
+			want:           "B",
+			wantRounds:     2,
+			wantEliminated: []string{"C"},
+		},
+		{
+			name:       "complete tie picks first option",
+			options:    options,
+			ballots:    ballots([]primitive.ObjectID{c}, []primitive.ObjectID{b}, []primitive.ObjectID{a}),
+			want:       "A",
+			wantRounds: 1,
+		},
+		{
+			name:    "exhausted ballots are not counted",
+			options: options,
+			ballots: ballots(
+				[]primitive.ObjectID{a}, []primitive.ObjectID{a},
+				[]primitive.ObjectID{b}, []primitive.ObjectID{b},
+				[]primitive.ObjectID{c},
+			),
+			// После выбывания C его бюллетень пуст, A и B равны - побеждает A
+			want:           "A",
+			wantRounds:     2,
+			wantEliminated: []string{"C"},
+		},
+		{
+			name:       "single option",
+			options:    options[:1],
+			ballots:    ballots([]primitive.ObjectID{a}),
+			want:       "A",
+			wantRounds: 1,
+		},
+		{
+			name:       "no ballots",
+			options:    options,
+			want:       "",
+			wantRounds: 1,
+		},
+		{
+			name:       "ballots for unknown options only",
+			options:    options,
+			ballots:    ballots([]primitive.ObjectID{primitive.NewObjectID()}),
+			want:       "",
+			wantRounds: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winner, rounds := rankedChoiceWinner(tt.options, tt.ballots)
+
+			got := ""
+			if winner != nil {
+				got = names[*winner]
+			}
+			if got != tt.want {
+				t.Errorf("winner = %q, want %q", got, tt.want)
+			}
+			if len(rounds) != tt.wantRounds {
+				t.Fatalf("rounds = %d, want %d", len(rounds), tt.wantRounds)
+			}
+
+			var eliminated []string
+			for i, round := range rounds {
+				if round.Round != i+1 {
+					t.Errorf("rounds[%d].Round = %d, want %d", i, round.Round, i+1)
+				}
+				if round.Eliminated != nil {
+					eliminated = append(eliminated, names[*round.Eliminated])
+				}
+			}
+			if len(eliminated) != len(tt.wantEliminated) {
+				t.Fatalf("eliminated = %v, want %v", eliminated, tt.wantEliminated)
+			}
+			for i := range eliminated {
+				if eliminated[i] != tt.wantEliminated[i] {
+					t.Errorf("eliminated = %v, want %v", eliminated, tt.wantEliminated)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,416 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Веса составляющих оценки рекомендации; сумма равна 1
+const (
+	recommendationCategoryWeight = 0.30
+	recommendationFriendsWeight  = 0.25
+	recommendationInterestWeight = 0.15
+	recommendationPriceWeight    = 0.10
+	recommendationQualityWeight  = 0.20
+)
+
+// Параметры байесовского сглаживания рейтинга: рестораны с малым числом отзывов
+// тянутся к среднему значению
+const (
+	recommendationPriorRating = 3.5
+	recommendationPriorCount  = 5
+)
+
+// recommendationCandidateLimit ограничивает число ресторанов, оцениваемых за один запрос
+const recommendationCandidateLimit = 500
+
+// RecommendationService структура сервиса персональных рекомендаций ресторанов.
+// Оценка строится на данных самого сервиса без внешних моделей.
+type RecommendationService struct {
+	db            *mongo.Database
+	friendService *FriendService
+}
+
+// NewRecommendationService создает новый экземпляр RecommendationService
+func NewRecommendationService(client *mongo.Client, dbName string, friendService *FriendService) *RecommendationService {
+	return &RecommendationService{
+		db:            client.Database(dbName),
+		friendService: friendService,
+	}
+}
+
+// tasteProfile описывает предпочтения пользователя, выведенные из его истории
+type tasteProfile struct {
+	known      map[primitive.ObjectID]bool // избранные, заказанные и оцененные рестораны
+	categories map[string]float64          // нормированная склонность к категориям, 0..1
+	interests  []string                    // слова из поля интересов
+	price      float64                     // типичный средний чек, 0 если неизвестен
+	friends    map[primitive.ObjectID]int  // число друзей, которым понравился ресторан
+}
+
+// Recommend возвращает рестораны, которые пользователь еще не пробовал, упорядоченные по оценке.
+// Учитываются избранное, история заказов, оценки пользователя, избранное друзей,
+// склонность к категориям, интересы и привычный уровень цен.
+func (s *RecommendationService) Recommend(ctx context.Context, userID primitive.ObjectID, limit int) ([]models.Recommendation, error) {
+	profile, err := s.buildProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.candidates(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	recommendations := make([]models.Recommendation, 0, len(candidates))
+	for _, restaurant := range candidates {
+		recommendations = append(recommendations, scoreRestaurant(profile, restaurant))
+	}
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+	return recommendations, nil
+}
+
+// buildProfile собирает профиль вкусов пользователя
+func (s *RecommendationService) buildProfile(ctx context.Context, userID primitive.ObjectID) (*tasteProfile, error) {
+	var user struct {
+		Interests string               `bson:"interests"`
+		Favorites []primitive.ObjectID `bson:"favorites"`
+		Orders    []struct {
+			RestaurantID string `bson:"restaurant_id"`
+		} `bson:"orders"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID}, options.FindOne().SetProjection(bson.M{
+		"interests":            1,
+		"favorites":            1,
+		"orders.restaurant_id": 1,
+	})).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrUserNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "finding user failed")
+	}
+
+	// Вес ресторана в профиле: избранное сильнее заказа, оценка сдвигает вес в обе стороны
+	weights := map[primitive.ObjectID]float64{}
+	for _, id := range user.Favorites {
+		weights[id] += 3
+	}
+	orders := map[primitive.ObjectID]int{}
+	for _, order := range user.Orders {
+		if id, err := primitive.ObjectIDFromHex(order.RestaurantID); err == nil {
+			orders[id]++
+		}
+	}
+	for id, count := range orders {
+		weights[id] += math.Min(float64(count), 5)
+	}
+
+	ratings, err := s.userRatings(ctx, []primitive.ObjectID{userID}, 1)
+	if err != nil {
+		return nil, err
+	}
+	for _, rating := range ratings {
+		weights[rating.RestaurantID] += float64(rating.Rating - 3)
+	}
+
+	profile := &tasteProfile{
+		known:      make(map[primitive.ObjectID]bool, len(weights)),
+		categories: map[string]float64{},
+		interests:  interestWords(user.Interests),
+		friends:    map[primitive.ObjectID]int{},
+	}
+	for id := range weights {
+		profile.known[id] = true
+	}
+
+	if len(weights) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(weights))
+		for id := range weights {
+			ids = append(ids, id)
+		}
+		known, err := s.findSummaries(ctx, bson.M{"_id": bson.M{"$in": ids}}, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var priceSum, priceWeight, maxCategory float64
+		for _, restaurant := range known {
+			weight := weights[restaurant.ID]
+			category := strings.ToLower(restaurant.Category)
+			profile.categories[category] += weight
+			maxCategory = math.Max(maxCategory, profile.categories[category])
+			if weight > 0 && restaurant.AveragePrice > 0 {
+				priceSum += weight * float64(restaurant.AveragePrice)
+				priceWeight += weight
+			}
+		}
+		for category, weight := range profile.categories {
+			if weight <= 0 || maxCategory <= 0 {
+				delete(profile.categories, category)
+				continue
+			}
+			profile.categories[category] = weight / maxCategory
+		}
+		if priceWeight > 0 {
+			profile.price = priceSum / priceWeight
+		}
+	}
+
+	if err := s.loadFriendLikes(ctx, userID, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// loadFriendLikes подсчитывает друзей, добавивших ресторан в избранное или оценивших его на 4-5
+func (s *RecommendationService) loadFriendLikes(ctx context.Context, userID primitive.ObjectID, profile *tasteProfile) error {
+	friendIDs, err := s.friendService.FriendIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if len(friendIDs) == 0 {
+		return nil
+	}
+
+	liked := map[primitive.ObjectID]map[primitive.ObjectID]bool{}
+	like := func(restaurantID, friendID primitive.ObjectID) {
+		if liked[restaurantID] == nil {
+			liked[restaurantID] = map[primitive.ObjectID]bool{}
+		}
+		liked[restaurantID][friendID] = true
+	}
+
+	cursor, err := s.db.Collection(EntityTypeUser).Find(ctx, bson.M{"_id": bson.M{"$in": friendIDs}},
+		options.Find().SetProjection(bson.M{"favorites": 1}),
+	)
+	if err != nil {
+		return errors.Wrap(err, "finding friends failed")
+	}
+	var friends []struct {
+		ID        primitive.ObjectID   `bson:"_id"`
+		Favorites []primitive.ObjectID `bson:"favorites"`
+	}
+	if err := cursor.All(ctx, &friends); err != nil {
+		return errors.Wrap(err, "decoding friends failed")
+	}
+	for _, friend := range friends {
+		for _, restaurantID := range friend.Favorites {
+			like(restaurantID, friend.ID)
+		}
+	}
+
+	ratings, err := s.userRatings(ctx, friendIDs, 4)
+	if err != nil {
+		return err
+	}
+	for _, rating := range ratings {
+		like(rating.RestaurantID, rating.UserID)
+	}
+
+	for restaurantID, users := range liked {
+		profile.friends[restaurantID] = len(users)
+	}
+	return nil
+}
+
+// userRatings возвращает одобренные отзывы пользователей с оценкой не ниже minRating
+func (s *RecommendationService) userRatings(ctx context.Context, userIDs []primitive.ObjectID, minRating int) ([]models.Review, error) {
+	cursor, err := s.db.Collection(reviewsCollectionName).Find(ctx, bson.M{
+		"user_id": bson.M{"$in": userIDs},
+		"status":  models.ReviewStatusApproved,
+		"rating":  bson.M{"$gte": minRating},
+	}, options.Find().SetProjection(bson.M{"user_id": 1, "restaurant_id": 1, "rating": 1}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding reviews failed")
+	}
+	var reviews []models.Review
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, errors.Wrap(err, "decoding reviews failed")
+	}
+	return reviews, nil
+}
+
+// candidates выбирает незнакомые пользователю рестораны: из любимых категорий,
+// понравившиеся друзьям и лучшие по рейтингу для разнообразия
+func (s *RecommendationService) candidates(ctx context.Context, profile *tasteProfile) ([]models.RestaurantSummary, error) {
+	known := make([]primitive.ObjectID, 0, len(profile.known))
+	for id := range profile.known {
+		known = append(known, id)
+	}
+	base := bson.M{"banned": bson.M{"$ne": true}, "_id": bson.M{"$nin": known}}
+
+	var filters []bson.M
+	if len(profile.categories) > 0 || len(profile.friends) > 0 {
+		categories := make([]string, 0, len(profile.categories))
+		for category := range profile.categories {
+			categories = append(categories, category)
+		}
+		friendLiked := make([]primitive.ObjectID, 0, len(profile.friends))
+		for id := range profile.friends {
+			if !profile.known[id] {
+				friendLiked = append(friendLiked, id)
+			}
+		}
+		filters = append(filters, bson.M{"$and": bson.A{base, bson.M{"$or": bson.A{
+			// Категории в профиле хранятся в нижнем регистре
+			bson.M{"category": bson.M{"$in": caseInsensitive(categories)}},
+			bson.M{"_id": bson.M{"$in": friendLiked}},
+		}}}})
+	}
+	filters = append(filters, base)
+
+	seen := map[primitive.ObjectID]bool{}
+	var candidates []models.RestaurantSummary
+	for _, filter := range filters {
+		found, err := s.findSummaries(ctx, filter, recommendationCandidateLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, restaurant := range found {
+			if seen[restaurant.ID] {
+				continue
+			}
+			seen[restaurant.ID] = true
+			candidates = append(candidates, restaurant)
+		}
+	}
+	return candidates, nil
+}
+
+// findSummaries загружает публичные данные ресторанов, лучшие по рейтингу первыми
+func (s *RecommendationService) findSummaries(ctx context.Context, filter bson.M, limit int64) ([]models.RestaurantSummary, error) {
+	opts := options.Find().
+		SetProjection(restaurantSummaryProjection).
+		SetSort(bson.D{{Key: "rating.average", Value: -1}, {Key: "_id", Value: 1}})
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding restaurants failed")
+	}
+	var restaurants []models.RestaurantSummary
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurants failed")
+	}
+	return restaurants, nil
+}
+
+// scoreRestaurant оценивает ресторан по профилю и формирует пояснения
+func scoreRestaurant(profile *tasteProfile, restaurant models.RestaurantSummary) models.Recommendation {
+	var reasons []string
+
+	category := profile.categories[strings.ToLower(restaurant.Category)]
+	if category >= 0.5 {
+		reasons = append(reasons, fmt.Sprintf("You often choose %s", restaurant.Category))
+	}
+
+	friends := profile.friends[restaurant.ID]
+	if friends == 1 {
+		reasons = append(reasons, "1 friend liked this")
+	} else if friends > 1 {
+		reasons = append(reasons, fmt.Sprintf("%d friends liked this", friends))
+	}
+
+	matches := interestMatches(profile.interests, restaurant)
+	interest := math.Min(float64(len(matches))/2, 1)
+	if len(matches) > 0 {
+		reasons = append(reasons, "Matches your interests: "+strings.Join(matches, ", "))
+	}
+
+	var price float64
+	if profile.price > 0 && restaurant.AveragePrice > 0 {
+		actual := float64(restaurant.AveragePrice)
+		price = 1 - math.Abs(actual-profile.price)/math.Max(actual, profile.price)
+		if price >= 0.8 {
+			reasons = append(reasons, "Fits your usual budget")
+		}
+	}
+
+	quality := 0.5
+	if restaurant.Rating != nil && restaurant.Rating.Count > 0 {
+		count := float64(restaurant.Rating.Count)
+		smoothed := (recommendationPriorRating*recommendationPriorCount + restaurant.Rating.Average*count) /
+			(recommendationPriorCount + count)
+		quality = (smoothed - 1) / 4
+		if restaurant.Rating.Average >= 4.5 && restaurant.Rating.Count >= recommendationPriorCount {
+			reasons = append(reasons, fmt.Sprintf("Highly rated (%.1f)", restaurant.Rating.Average))
+		}
+	}
+
+	score := recommendationCategoryWeight*category +
+		recommendationFriendsWeight*math.Min(float64(friends)/3, 1) +
+		recommendationInterestWeight*interest +
+		recommendationPriceWeight*price +
+		recommendationQualityWeight*quality
+
+	if reasons == nil {
+		reasons = []string{"Popular with other guests"}
+	}
+	return models.Recommendation{
+		Restaurant: restaurant,
+		Score:      math.Round(score*1000) / 1000,
+		Reasons:    reasons,
+	}
+}
+
+// interestWords разбивает поле интересов на слова длиной от трех букв
+func interestWords(interests string) []string {
+	words := strings.FieldsFunc(strings.ToLower(interests), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	result := make([]string, 0, len(words))
+	for _, word := range words {
+		if len([]rune(word)) >= 3 {
+			result = append(result, word)
+		}
+	}
+	return uniqueStrings(result)
+}
+
+// interestMatches возвращает интересы, встречающиеся в категории, диетических метках или описании ресторана
+func interestMatches(interests []string, restaurant models.RestaurantSummary) []string {
+	if len(interests) == 0 {
+		return nil
+	}
+	text := strings.ToLower(restaurant.Category + " " + strings.Join(restaurant.DietaryTags, " ") + " " + restaurant.Description)
+	words := map[string]bool{}
+	for _, word := range interestWords(text) {
+		words[word] = true
+	}
+
+	var matches []string
+	for _, interest := range interests {
+		if words[interest] {
+			matches = append(matches, interest)
+		}
+	}
+	return matches
+}
+
+// caseInsensitive строит регулярные выражения для сравнения строк без учета регистра
+func caseInsensitive(values []string) bson.A {
+	result := make(bson.A, 0, len(values))
+	for _, value := range values {
+		result = append(result, primitive.Regex{Pattern: "^" + regexp.QuoteMeta(value) + "$", Options: "i"})
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestScoreRestaurant(t *testing.T) {
+	id := primitive.NewObjectID()
+	profile := &tasteProfile{
+		categories: map[string]float64{"грузинская": 1, "пицца": 0.4},
+		interests:  interestWords("Хинкали, вегетарианская еда и вино"),
+		price:      1000,
+		friends:    map[primitive.ObjectID]int{id: 3},
+	}
+
+	tests := []struct {
+		name        string
+		profile     *tasteProfile
+		restaurant  models.RestaurantSummary
+		wantScore   float64
+		wantReasons []string
+	}{
+		{
+			name:        "no history falls back to neutral quality",
+			profile:     &tasteProfile{},
+			restaurant:  models.RestaurantSummary{ID: id, Category: "Грузинская"},
+			wantScore:   0.1,
+			wantReasons: []string{"Popular with other guests"},
+		},
+		{
+			name:    "every signal matches",
+			profile: profile,
+			restaurant: models.RestaurantSummary{
+				ID: id, Category: "Грузинская", AveragePrice: 1000,
+				DietaryTags: []string{"вегетарианская"}, Description: "Хинкали на дровах",
+				Rating: &models.RatingSummary{Average: 5, Count: 5},
+			},
+			// 0.3 + 0.25 + 0.15 + 0.1 + 0.2*(4.25-1)/4
+			wantScore: 0.963,
+			wantReasons: []string{
+				"You often choose Грузинская",
+				"3 friends liked this",
+				"Matches your interests: хинкали, вегетарианская",
+				"Fits your usual budget",
+				"Highly rated (5.0)",
+			},
+		},
+		{
+			name:    "few reviews are pulled to the prior",
+			profile: &tasteProfile{},
+			restaurant: models.RestaurantSummary{
+				ID: primitive.NewObjectID(), Rating: &models.RatingSummary{Average: 5, Count: 1},
+			},
+			// 0.2*((3.5*5+5)/6-1)/4
+			wantScore:   0.138,
+			wantReasons: []string{"Popular with other guests"},
+		},
+		{
+			name:    "weak category and distant price give no reasons",
+			profile: profile,
+			restaurant: models.RestaurantSummary{
+				ID: primitive.NewObjectID(), Category: "Пицца", AveragePrice: 2000,
+			},
+			// 0.3*0.4 + 0.1*0.5 + 0.2*0.5
+			wantScore:   0.27,
+			wantReasons: []string{"Popular with other guests"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := scoreRestaurant(tt.profile, tt.restaurant)
+			if got.Score != tt.wantScore {
+				t.Errorf("scoreRestaurant() score = %v, want %v", got.Score, tt.wantScore)
+			}
+			if !reflect.DeepEqual(got.Reasons, tt.wantReasons) {
+				t.Errorf("scoreRestaurant() reasons = %q, want %q", got.Reasons, tt.wantReasons)
+			}
+		})
+	}
+}
+
+func TestInterestWords(t *testing.T) {
+	got := interestWords("Суши, суши и ВИНО; jazz 24/7")
+	want := []string{"суши", "вино", "jazz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("interestWords() = %q, want %q", got, want)
+	}
+}
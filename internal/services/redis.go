@@ -58,3 +58,8 @@ func (r *RedisService) GetCachedEntity(entityID string, entity interface{}) erro
 
 	return nil
 }
+
+// InvalidateEntity удаляет сущность из кэша после ее изменения
+func (r *RedisService) InvalidateEntity(entityID string) error {
+	return r.Client.Del(ctx, entityID).Err()
+}
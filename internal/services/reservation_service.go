@@ -0,0 +1,561 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const reservationsCollectionName = "reservations"
+
+// LateCancellationWindow - отмена позже этого срока до начала считается поздней
+const LateCancellationWindow = 2 * time.Hour
+
+// reservationBookAttempts - число попыток занять место при одновременных бронированиях
+const reservationBookAttempts = 3
+
+// Типы уведомлений о бронированиях
+const (
+	NotificationReservationConfirmed = "reservation_confirmed"
+	NotificationReservationDeclined  = "reservation_declined"
+)
+
+var (
+	ErrReservationNotFound      = errors.New("reservation not found")
+	ErrReservationsDisabled     = errors.New("restaurant does not accept reservations")
+	ErrSlotUnavailable          = errors.New("no table available for this time and party size")
+	ErrReservationOutsideWindow = errors.New("reservation time is in the past or too far ahead")
+	ErrReservationClosed        = errors.New("restaurant is closed at this time")
+	ErrPartyTooLarge            = errors.New("party size exceeds restaurant limit")
+	ErrReservationState         = errors.New("reservation cannot change from its current status")
+	ErrReservationNotStarted    = errors.New("reservation has not started yet")
+	ErrInvalidDate              = errors.New("date must be in YYYY-MM-DD format")
+	ErrDuplicateTableID         = errors.New("table ids must be unique")
+)
+
+// activeReservationStatuses - статусы бронирований, занимающих места
+var activeReservationStatuses = bson.A{models.ReservationStatusPending, models.ReservationStatusConfirmed}
+
+// ReservationService структура сервиса бронирования столиков
+type ReservationService struct {
+	db                  *mongo.Database
+	notificationService *NotificationService
+}
+
+// NewReservationService создает новый экземпляр ReservationService
+func NewReservationService(client *mongo.Client, dbName string, notificationService *NotificationService) *ReservationService {
+	return &ReservationService{
+		db:                  client.Database(dbName),
+		notificationService: notificationService,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции бронирований
+func (s *ReservationService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(reservationsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "starts_at", Value: 1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "starts_at", Value: -1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating reservation indexes failed")
+	}
+	return nil
+}
+
+// SetSettings сохраняет настройки бронирования ресторана
+func (s *ReservationService) SetSettings(ctx context.Context, restaurantID primitive.ObjectID, settings *models.ReservationSettings) error {
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(settings.Tables))
+	for _, table := range settings.Tables {
+		if seen[table.ID] {
+			return ErrDuplicateTableID
+		}
+		seen[table.ID] = true
+	}
+
+	result, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID,
+		bson.M{"$set": bson.M{"reservationSettings": settings}})
+	if err != nil {
+		return errors.Wrap(err, "saving reservation settings failed")
+	}
+	if result.MatchedCount == 0 {
+		return ErrRestaurantNotFound
+	}
+	return nil
+}
+
+// Availability возвращает свободные для компании слоты на дату в часовом поясе ресторанов
+func (s *ReservationService) Availability(ctx context.Context, restaurantID primitive.ObjectID, date string, partySize int) ([]models.AvailabilitySlot, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, restaurantLocation())
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	restaurant, err := s.loadRestaurant(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	settings := restaurant.Reservations
+	if partySize < 1 || partySize > settings.MaxPartySize {
+		return nil, ErrPartyTooLarge
+	}
+
+	slot := time.Duration(settings.SlotMinutes) * time.Minute
+	step := time.Duration(settings.IntervalMinutes) * time.Minute
+	bookings, err := s.activeBookings(ctx, restaurantID, day, day.AddDate(0, 0, 1).Add(slot), primitive.NilObjectID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	horizon := now.AddDate(0, 0, settings.MaxDaysAhead)
+	slots := []models.AvailabilitySlot{}
+	for _, hours := range restaurant.OpeningHours {
+		if hours.Day != int(day.Weekday()) {
+			continue
+		}
+		open, closeAt, ok := openingInterval(day, hours)
+		if !ok {
+			continue
+		}
+		for start := open; !start.Add(slot).After(closeAt); start = start.Add(step) {
+			if start.Before(now) || start.After(horizon) {
+				continue
+			}
+			if _, ok := allocateSeats(settings, bookings, start, start.Add(slot), partySize); ok {
+				slots = append(slots, models.AvailabilitySlot{StartsAt: start, EndsAt: start.Add(slot)})
+			}
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool { return slots[i].StartsAt.Before(slots[j].StartsAt) })
+	return slots, nil
+}
+
+// CreateReservation бронирует стол. Бронирование ожидает подтверждения ресторана,
+// если в настройках не включено автоматическое подтверждение.
+func (s *ReservationService) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	if err := reservation.Validate(); err != nil {
+		return err
+	}
+	restaurant, err := s.loadRestaurant(ctx, reservation.RestaurantID)
+	if err != nil {
+		return err
+	}
+	settings := restaurant.Reservations
+	if reservation.PartySize > settings.MaxPartySize {
+		return ErrPartyTooLarge
+	}
+
+	now := time.Now()
+	if reservation.StartsAt.Before(now) || reservation.StartsAt.After(now.AddDate(0, 0, settings.MaxDaysAhead)) {
+		return ErrReservationOutsideWindow
+	}
+	reservation.EndsAt = reservation.StartsAt.Add(time.Duration(settings.SlotMinutes) * time.Minute)
+	if !withinOpeningHours(restaurant.OpeningHours, reservation.StartsAt, reservation.EndsAt) {
+		return ErrReservationClosed
+	}
+
+	reservation.Status = models.ReservationStatusPending
+	if settings.AutoConfirm {
+		reservation.Status = models.ReservationStatusConfirmed
+	}
+	reservation.CreatedAt = now
+	reservation.UpdatedAt = now
+
+	// Бронирование вставляется и затем сверяется со всеми остальными пересекающимися бронированиями.
+	// При конфликте одновременных запросов бронирование удаляется и попытка повторяется,
+	// поэтому места не могут быть заняты дважды.
+	collection := s.db.Collection(reservationsCollectionName)
+	for attempt := 0; attempt < reservationBookAttempts; attempt++ {
+		bookings, err := s.activeBookings(ctx, reservation.RestaurantID, reservation.StartsAt, reservation.EndsAt, primitive.NilObjectID)
+		if err != nil {
+			return err
+		}
+		tableIDs, ok := allocateSeats(settings, bookings, reservation.StartsAt, reservation.EndsAt, reservation.PartySize)
+		if !ok {
+			return ErrSlotUnavailable
+		}
+
+		reservation.ID = primitive.NewObjectID()
+		reservation.TableIDs = tableIDs
+		if _, err := collection.InsertOne(ctx, reservation); err != nil {
+			return errors.Wrap(err, "inserting reservation failed")
+		}
+
+		others, err := s.activeBookings(ctx, reservation.RestaurantID, reservation.StartsAt, reservation.EndsAt, reservation.ID)
+		if err != nil {
+			return err
+		}
+		if fitsAmong(settings, others, reservation) {
+			return nil
+		}
+		if _, err := collection.DeleteOne(ctx, bson.M{"_id": reservation.ID}); err != nil {
+			return errors.Wrap(err, "rolling back reservation failed")
+		}
+	}
+	return ErrSlotUnavailable
+}
+
+// ListUserReservations возвращает бронирования пользователя, начиная с ближайших
+func (s *ReservationService) ListUserReservations(ctx context.Context, userID primitive.ObjectID, page, limit int) ([]models.Reservation, error) {
+	return s.list(ctx, bson.M{"user_id": userID}, bson.D{{Key: "starts_at", Value: -1}}, page, limit)
+}
+
+// ListRestaurantReservations возвращает бронирования ресторана на дату со статистикой гостей
+func (s *ReservationService) ListRestaurantReservations(ctx context.Context, restaurantID primitive.ObjectID, date, status string) ([]models.Reservation, error) {
+	day, err := time.ParseInLocation("2006-01-02", date, restaurantLocation())
+	if err != nil {
+		return nil, ErrInvalidDate
+	}
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"starts_at":     bson.M{"$gte": day, "$lt": day.AddDate(0, 0, 1)},
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+	reservations, err := s.list(ctx, filter, bson.D{{Key: "starts_at", Value: 1}}, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]primitive.ObjectID, 0, len(reservations))
+	for _, reservation := range reservations {
+		userIDs = append(userIDs, reservation.UserID)
+	}
+	stats, err := s.guestStats(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range reservations {
+		if guest, ok := stats[reservations[i].UserID]; ok {
+			reservations[i].GuestStats = &guest
+		} else {
+			reservations[i].GuestStats = &models.ReservationStats{}
+		}
+	}
+	return reservations, nil
+}
+
+// CancelReservation отменяет бронирование пользователем и учитывает отмену в его статистике
+func (s *ReservationService) CancelReservation(ctx context.Context, userID, reservationID primitive.ObjectID) (*models.Reservation, error) {
+	reservation, err := s.transition(ctx, bson.M{"_id": reservationID, "user_id": userID}, activeReservationStatuses,
+		bson.M{"status": models.ReservationStatusCancelled},
+		func(r *models.Reservation) error {
+			if !r.StartsAt.After(time.Now()) {
+				return ErrReservationState
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+
+	counter := "reservationStats.cancellations"
+	if time.Until(reservation.StartsAt) < LateCancellationWindow {
+		counter = "reservationStats.lateCancellations"
+	}
+	s.incrementStats(ctx, userID, counter)
+	return reservation, nil
+}
+
+// ConfirmReservation подтверждает бронирование рестораном
+func (s *ReservationService) ConfirmReservation(ctx context.Context, restaurantID, reservationID primitive.ObjectID) (*models.Reservation, error) {
+	reservation, err := s.transition(ctx, bson.M{"_id": reservationID, "restaurant_id": restaurantID},
+		bson.A{models.ReservationStatusPending}, bson.M{"status": models.ReservationStatusConfirmed}, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, reservation, NotificationReservationConfirmed, "Бронирование подтверждено")
+	return reservation, nil
+}
+
+// DeclineReservation отклоняет бронирование рестораном с указанием причины
+func (s *ReservationService) DeclineReservation(ctx context.Context, restaurantID, reservationID primitive.ObjectID, reason string) (*models.Reservation, error) {
+	reservation, err := s.transition(ctx, bson.M{"_id": reservationID, "restaurant_id": restaurantID},
+		bson.A{models.ReservationStatusPending}, bson.M{"status": models.ReservationStatusDeclined, "decline_reason": reason}, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, reservation, NotificationReservationDeclined, "Бронирование отклонено")
+	return reservation, nil
+}
+
+// MarkNoShow отмечает, что гости не пришли; учитывается в статистике пользователя
+func (s *ReservationService) MarkNoShow(ctx context.Context, restaurantID, reservationID primitive.ObjectID) (*models.Reservation, error) {
+	return s.finish(ctx, restaurantID, reservationID, models.ReservationStatusNoShow, "reservationStats.noShows")
+}
+
+// MarkCompleted отмечает, что гости пришли
+func (s *ReservationService) MarkCompleted(ctx context.Context, restaurantID, reservationID primitive.ObjectID) (*models.Reservation, error) {
+	return s.finish(ctx, restaurantID, reservationID, models.ReservationStatusCompleted, "reservationStats.completed")
+}
+
+// finish завершает начавшееся подтвержденное бронирование и обновляет статистику гостя
+func (s *ReservationService) finish(ctx context.Context, restaurantID, reservationID primitive.ObjectID, status, counter string) (*models.Reservation, error) {
+	reservation, err := s.transition(ctx, bson.M{"_id": reservationID, "restaurant_id": restaurantID},
+		bson.A{models.ReservationStatusConfirmed}, bson.M{"status": status},
+		func(r *models.Reservation) error {
+			if r.StartsAt.After(time.Now()) {
+				return ErrReservationNotStarted
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	s.incrementStats(ctx, reservation.UserID, counter)
+	return reservation, nil
+}
+
+// transition атомарно меняет статус бронирования, если текущий статус входит в from.
+// check выполняет дополнительную проверку бронирования перед изменением.
+func (s *ReservationService) transition(ctx context.Context, filter bson.M, from bson.A, set bson.M, check func(*models.Reservation) error) (*models.Reservation, error) {
+	existing, err := s.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if check != nil {
+		if err := check(existing); err != nil {
+			return nil, err
+		}
+	}
+
+	filter["status"] = bson.M{"$in": from}
+	set["updated_at"] = time.Now()
+	var reservation models.Reservation
+	err = s.db.Collection(reservationsCollectionName).FindOneAndUpdate(ctx, filter, bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reservation)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationState
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating reservation failed")
+	}
+	return &reservation, nil
+}
+
+// find возвращает бронирование по фильтру
+func (s *ReservationService) find(ctx context.Context, filter bson.M) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := s.db.Collection(reservationsCollectionName).FindOne(ctx, filter).Decode(&reservation)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReservationNotFound
+		}
+		return nil, errors.Wrap(err, "finding reservation failed")
+	}
+	return &reservation, nil
+}
+
+// list возвращает бронирования по фильтру; limit 0 означает без ограничения
+func (s *ReservationService) list(ctx context.Context, filter bson.M, sortBy bson.D, page, limit int) ([]models.Reservation, error) {
+	findOptions := options.Find().SetSort(sortBy)
+	if limit > 0 {
+		findOptions.SetSkip(int64((page - 1) * limit)).SetLimit(int64(limit))
+	}
+	cursor, err := s.db.Collection(reservationsCollectionName).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding reservations failed")
+	}
+	reservations := []models.Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, errors.Wrap(err, "decoding reservations failed")
+	}
+	return reservations, nil
+}
+
+// activeBookings возвращает занимающие места бронирования, пересекающиеся с интервалом.
+// Бронирование с ID exclude не учитывается.
+func (s *ReservationService) activeBookings(ctx context.Context, restaurantID primitive.ObjectID, from, to time.Time, exclude primitive.ObjectID) ([]models.Reservation, error) {
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"status":        bson.M{"$in": activeReservationStatuses},
+		"starts_at":     bson.M{"$lt": to},
+		"ends_at":       bson.M{"$gt": from},
+	}
+	if !exclude.IsZero() {
+		filter["_id"] = bson.M{"$ne": exclude}
+	}
+	return s.list(ctx, filter, bson.D{{Key: "_id", Value: 1}}, 1, 0)
+}
+
+// loadRestaurant возвращает часы работы и настройки бронирования ресторана
+func (s *ReservationService) loadRestaurant(ctx context.Context, restaurantID primitive.ObjectID) (*models.Restaurant, error) {
+	var restaurant models.Restaurant
+	err := s.db.Collection(EntityTypeRestaurant).FindOne(ctx,
+		bson.M{"_id": restaurantID, "banned": bson.M{"$ne": true}},
+		options.FindOne().SetProjection(bson.M{"openingHours": 1, "reservationSettings": 1}),
+	).Decode(&restaurant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrRestaurantNotFound
+		}
+		return nil, errors.Wrap(err, "finding restaurant failed")
+	}
+	if restaurant.Reservations == nil || (len(restaurant.Reservations.Tables) == 0 && restaurant.Reservations.SeatCapacity == 0) {
+		return nil, ErrReservationsDisabled
+	}
+	return &restaurant, nil
+}
+
+// guestStats возвращает статистику бронирований пользователей
+func (s *ReservationService) guestStats(ctx context.Context, userIDs []primitive.ObjectID) (map[primitive.ObjectID]models.ReservationStats, error) {
+	cursor, err := s.db.Collection(EntityTypeUser).Find(ctx,
+		bson.M{"_id": bson.M{"$in": uniqueObjectIDs(userIDs)}},
+		options.Find().SetProjection(bson.M{"reservationStats": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding guest stats failed")
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, errors.Wrap(err, "decoding guest stats failed")
+	}
+	stats := make(map[primitive.ObjectID]models.ReservationStats, len(users))
+	for _, user := range users {
+		if user.Reservations != nil {
+			stats[user.ID] = *user.Reservations
+		}
+	}
+	return stats, nil
+}
+
+// incrementStats увеличивает счетчик статистики бронирований пользователя
+func (s *ReservationService) incrementStats(ctx context.Context, userID primitive.ObjectID, counter string) {
+	_, err := s.db.Collection(EntityTypeUser).UpdateByID(ctx, userID, bson.M{"$inc": bson.M{counter: 1}})
+	if err != nil {
+		log.Printf("Failed to update reservation stats for user %s: %v", userID.Hex(), err)
+	}
+}
+
+// notify уведомляет гостя об изменении бронирования
+func (s *ReservationService) notify(ctx context.Context, reservation *models.Reservation, notificationType, title string) {
+	err := s.notificationService.Notify(ctx, []primitive.ObjectID{reservation.UserID}, notificationType, title, map[string]string{
+		"reservation_id": reservation.ID.Hex(),
+		"restaurant_id":  reservation.RestaurantID.Hex(),
+		"starts_at":      reservation.StartsAt.Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("Failed to send %s notification: %v", notificationType, err)
+	}
+}
+
+// fitsAmong проверяет, что вставленное бронирование с назначенными ему столами
+// не конфликтует с остальными бронированиями
+func fitsAmong(settings *models.ReservationSettings, others []models.Reservation, reservation *models.Reservation) bool {
+	if len(settings.Tables) == 0 {
+		_, ok := allocateSeats(settings, others, reservation.StartsAt, reservation.EndsAt, reservation.PartySize)
+		return ok
+	}
+	for _, booking := range others {
+		if !booking.StartsAt.Before(reservation.EndsAt) || !booking.EndsAt.After(reservation.StartsAt) {
+			continue
+		}
+		for _, tableID := range booking.TableIDs {
+			for _, own := range reservation.TableIDs {
+				if tableID == own {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// allocateSeats подбирает места для компании среди пересекающихся бронирований.
+// При наличии столов выбирается самый маленький свободный стол, вмещающий компанию.
+func allocateSeats(settings *models.ReservationSettings, bookings []models.Reservation, start, end time.Time, partySize int) ([]string, bool) {
+	var overlapping []models.Reservation
+	for _, booking := range bookings {
+		if booking.StartsAt.Before(end) && booking.EndsAt.After(start) {
+			overlapping = append(overlapping, booking)
+		}
+	}
+
+	if len(settings.Tables) == 0 {
+		seated := 0
+		for _, booking := range overlapping {
+			seated += booking.PartySize
+		}
+		return nil, seated+partySize <= settings.SeatCapacity
+	}
+
+	used := map[string]bool{}
+	for _, booking := range overlapping {
+		for _, tableID := range booking.TableIDs {
+			used[tableID] = true
+		}
+	}
+	var best *models.Table
+	for i, table := range settings.Tables {
+		if used[table.ID] || table.Seats < partySize {
+			continue
+		}
+		if best == nil || table.Seats < best.Seats {
+			best = &settings.Tables[i]
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return []string{best.ID}, true
+}
+
+// withinOpeningHours проверяет, что визит целиком попадает в часы работы ресторана
+func withinOpeningHours(hours []models.OpeningHours, start, end time.Time) bool {
+	local := start.In(restaurantLocation())
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	for _, h := range hours {
+		if h.Day != int(day.Weekday()) {
+			continue
+		}
+		open, closeAt, ok := openingInterval(day, h)
+		if ok && !start.Before(open) && !end.After(closeAt) {
+			return true
+		}
+	}
+	return false
+}
+
+// openingInterval возвращает начало и конец работы ресторана в указанный день
+func openingInterval(day time.Time, hours models.OpeningHours) (time.Time, time.Time, bool) {
+	open, ok := clockMinutes(hours.Open)
+	if !ok {
+		return time.Time{}, time.Time{}, false
+	}
+	closeAt, ok := clockMinutes(hours.Close)
+	if !ok || closeAt <= open {
+		return time.Time{}, time.Time{}, false
+	}
+	return day.Add(time.Duration(open) * time.Minute), day.Add(time.Duration(closeAt) * time.Minute), true
+}
+
+// clockMinutes переводит время "HH:MM" в минуты от начала дня; допускается "24:00"
+func clockMinutes(clock string) (int, bool) {
+	parts := strings.Split(clock, ":")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, false
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil || h < 0 || m < 0 || m > 59 || h*60+m > 24*60 {
+		return 0, false
+	}
+	return h*60 + m, true
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"testing"
+	"time"
+)
+
+func TestAllocateSeats(t *testing.T) {
+	start := time.Date(2024, 5, 10, 19, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	booking := func(from, to time.Duration, partySize int, tableIDs ...string) models.Reservation {
+		return models.Reservation{StartsAt: start.Add(from), EndsAt: start.Add(to), PartySize: partySize, TableIDs: tableIDs}
+	}
+	capacity := &models.ReservationSettings{SeatCapacity: 10}
+	tables := &models.ReservationSettings{Tables: []models.Table{
+		{ID: "t6", Seats: 6},
+		{ID: "t2", Seats: 2},
+		{ID: "t4a", Seats: 4},
+		{ID: "t4b", Seats: 4},
+	}}
+
+	tests := []struct {
+		name      string
+		settings  *models.ReservationSettings
+		bookings  []models.Reservation
+		partySize int
+		want      []string
+		wantOK    bool
+	}{
+		{name: "capacity empty", settings: capacity, partySize: 10, wantOK: true},
+		{name: "capacity too large", settings: capacity, partySize: 11},
+		{
+			name:      "capacity filled exactly",
+			settings:  capacity,
+			bookings:  []models.Reservation{booking(-time.Hour, time.Hour, 4), booking(time.Hour, 3*time.Hour, 3)},
+			partySize: 3,
+			wantOK:    true,
+		},
+		{
+			name:      "capacity exceeded",
+			settings:  capacity,
+			bookings:  []models.Reservation{booking(-time.Hour, time.Hour, 4), booking(time.Hour, 3*time.Hour, 3)},
+			partySize: 4,
+		},
+		{
+			name:      "capacity ignores adjacent bookings",
+			settings:  capacity,
+			bookings:  []models.Reservation{booking(-2*time.Hour, 0, 10), booking(2*time.Hour, 4*time.Hour, 10)},
+			partySize: 10,
+			wantOK:    true,
+		},
+		{name: "smallest fitting table", settings: tables, partySize: 3, want: []string{"t4a"}, wantOK: true},
+		{name: "exact fit", settings: tables, partySize: 2, want: []string{"t2"}, wantOK: true},
+		{
+			name:      "skips occupied table",
+			settings:  tables,
+			bookings:  []models.Reservation{booking(time.Hour, 3*time.Hour, 4, "t4a")},
+			partySize: 4,
+			want:      []string{"t4b"},
+			wantOK:    true,
+		},
+		{
+			name:      "falls back to larger table",
+			settings:  tables,
+			bookings:  []models.Reservation{booking(0, 2*time.Hour, 4, "t4a"), booking(0, 2*time.Hour, 4, "t4b")},
+			partySize: 3,
+			want:      []string{"t6"},
+			wantOK:    true,
+		},
+		{
+			name:      "table free after previous visit",
+			settings:  tables,
+			bookings:  []models.Reservation{booking(-2*time.Hour, 0, 6, "t6")},
+			partySize: 5,
+			want:      []string{"t6"},
+			wantOK:    true,
+		},
+		{
+			name:      "no table large enough",
+			settings:  tables,
+			partySize: 7,
+		},
+		{
+			name:      "large table occupied",
+			settings:  tables,
+			bookings:  []models.Reservation{booking(-time.Hour, time.Hour, 2, "t6")},
+			partySize: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := allocateSeats(tt.settings, tt.bookings, start, end, tt.partySize)
+			if ok != tt.wantOK {
+				t.Fatalf("allocateSeats() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("allocateSeats() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("allocateSeats() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestFitsAmong(t *testing.T) {
+	start := time.Date(2024, 5, 10, 19, 0, 0, 0, time.UTC)
+	reservation := func(from time.Duration, partySize int, tableIDs ...string) models.Reservation {
+		return models.Reservation{
+			StartsAt:  start.Add(from),
+			EndsAt:    start.Add(from + 2*time.Hour),
+			PartySize: partySize,
+			TableIDs:  tableIDs,
+		}
+	}
+	capacity := &models.ReservationSettings{SeatCapacity: 8}
+	tables := &models.ReservationSettings{Tables: []models.Table{{ID: "t4", Seats: 4}, {ID: "t6", Seats: 6}}}
+
+	tests := []struct {
+		name        string
+		settings    *models.ReservationSettings
+		others      []models.Reservation
+		reservation models.Reservation
+		want        bool
+	}{
+		{
+			name:        "capacity within limit",
+			settings:    capacity,
+			others:      []models.Reservation{reservation(time.Hour, 4)},
+			reservation: reservation(0, 4),
+			want:        true,
+		},
+		{
+			name:        "capacity taken by concurrent booking",
+			settings:    capacity,
+			others:      []models.Reservation{reservation(time.Hour, 5)},
+			reservation: reservation(0, 4),
+		},
+		{
+			name:        "different tables",
+			settings:    tables,
+			others:      []models.Reservation{reservation(0, 6, "t6")},
+			reservation: reservation(0, 4, "t4"),
+			want:        true,
+		},
+		{
+			name:        "same table taken concurrently",
+			settings:    tables,
+			others:      []models.Reservation{reservation(time.Hour, 3, "t4")},
+			reservation: reservation(0, 4, "t4"),
+		},
+		{
+			name:        "same table in adjacent slot",
+			settings:    tables,
+			others:      []models.Reservation{reservation(2*time.Hour, 3, "t4"), reservation(-2*time.Hour, 3, "t4")},
+			reservation: reservation(0, 4, "t4"),
+			want:        true,
+		},
+		{
+			name:        "no others",
+			settings:    tables,
+			reservation: reservation(0, 4, "t4"),
+			want:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fitsAmong(tt.settings, tt.others, &tt.reservation); got != tt.want {
+				t.Errorf("fitsAmong() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Варианты сортировки результатов поиска ресторанов
+const (
+	RestaurantSortRating    = "rating"
+	RestaurantSortPriceAsc  = "price_asc"
+	RestaurantSortPriceDesc = "price_desc"
+	RestaurantSortName      = "name"
+	RestaurantSortNewest    = "newest"
+)
+
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// restaurantSummaryProjection перечисляет поля, которые можно отдавать в публичных списках.
+// Используется проекция включения, чтобы пароль и токены никогда не покидали базу.
+var restaurantSummaryProjection = bson.M{
+	"name":         1,
+	"description":  1,
+	"category":     1,
+	"averagePrice": 1,
+	"address":      1,
+	"location":     1,
+	"avatar":       1,
+	"avatarImage":  1,
+	"phone":        1,
+	"hours":        1,
+	"openingHours": 1,
+	"dietaryTags":  1,
+	"rating":       1,
+}
+
+// RestaurantSearchQuery параметры поиска ресторанов
+type RestaurantSearchQuery struct {
+	Categories  []string
+	MinPrice    int
+	MaxPrice    int
+	MinRating   float64
+	OpenNow     bool
+	DietaryTags []string // ресторан должен поддерживать все перечисленные теги
+	Sort        string
+	Cursor      string
+	Limit       int
+}
+
+// restaurantCursor позиция последнего элемента страницы
+type restaurantCursor struct {
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+// EnsureRestaurantIndexes создает индексы для поиска ресторанов
+func (s *EntityService) EnsureRestaurantIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(EntityTypeRestaurant).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "category", Value: 1}, {Key: "rating.average", Value: -1}, {Key: "_id", Value: -1}}},
+		{Keys: bson.D{{Key: "averagePrice", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "dietaryTags", Value: 1}}},
+		{Keys: bson.D{{Key: "openingHours.day", Value: 1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating restaurant indexes failed")
+	}
+	return nil
+}
+
+// SearchRestaurants возвращает страницу ресторанов, удовлетворяющих фильтрам
+func (s *EntityService) SearchRestaurants(ctx context.Context, query RestaurantSearchQuery) (*models.RestaurantSearchPage, error) {
+	field, direction := restaurantSortField(query.Sort)
+	filter := restaurantSearchFilter(query, time.Now())
+
+	if query.Cursor != "" {
+		after, err := restaurantCursorFilter(query.Cursor, field, direction)
+		if err != nil {
+			return nil, err
+		}
+		filter = bson.M{"$and": bson.A{filter, after}}
+	}
+
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if field != "_id" {
+		sort = bson.D{{Key: field, Value: direction}, {Key: "_id", Value: direction}}
+	}
+
+	// Запрашиваем на один элемент больше, чтобы понять, есть ли следующая страница
+	findOptions := options.Find().
+		SetProjection(restaurantSummaryProjection).
+		SetSort(sort).
+		SetLimit(int64(query.Limit + 1))
+
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "searching restaurants failed")
+	}
+	restaurants := []models.RestaurantSummary{}
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurants failed")
+	}
+
+	page := &models.RestaurantSearchPage{Restaurants: restaurants}
+	if len(restaurants) > query.Limit {
+		page.Restaurants = restaurants[:query.Limit]
+		last := page.Restaurants[query.Limit-1]
+		page.NextCursor, err = encodeRestaurantCursor(last, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// restaurantSearchFilter строит фильтр MongoDB по параметрам поиска
+func restaurantSearchFilter(query RestaurantSearchQuery, now time.Time) bson.M {
+	filter := bson.M{"banned": bson.M{"$ne": true}}
+
+	if len(query.Categories) > 0 {
+		filter["category"] = bson.M{"$in": query.Categories}
+	}
+
+	price := bson.M{}
+	if query.MinPrice > 0 {
+		price["$gte"] = query.MinPrice
+	}
+	if query.MaxPrice > 0 {
+		price["$lte"] = query.MaxPrice
+	}
+	if len(price) > 0 {
+		filter["averagePrice"] = price
+	}
+
+	if query.MinRating > 0 {
+		filter["rating.average"] = bson.M{"$gte": query.MinRating}
+	}
+
+	if len(query.DietaryTags) > 0 {
+		filter["dietaryTags"] = bson.M{"$all": query.DietaryTags}
+	}
+
+	if query.OpenNow {
+		local := now.In(restaurantLocation())
+		clock := local.Format("15:04")
+		filter["openingHours"] = bson.M{"$elemMatch": bson.M{
+			"day":   int(local.Weekday()),
+			"open":  bson.M{"$lte": clock},
+			"close": bson.M{"$gt": clock},
+		}}
+	}
+
+	return filter
+}
+
+// restaurantSortField возвращает поле и направление сортировки
+func restaurantSortField(sortBy string) (string, int) {
+	switch sortBy {
+	case RestaurantSortPriceAsc:
+		return "averagePrice", 1
+	case RestaurantSortPriceDesc:
+		return "averagePrice", -1
+	case RestaurantSortName:
+		return "name", 1
+	case RestaurantSortNewest:
+		return "_id", -1
+	default:
+		return "rating.average", -1
+	}
+}
+
+// restaurantCursorFilter строит условие "после курсора" для сортировки по полю и _id.
+// Документы без значения поля (null) идут первыми при сортировке по возрастанию и последними по убыванию.
+func restaurantCursorFilter(encoded, field string, direction int) (bson.M, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c restaurantCursor
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	lastID, err := primitive.ObjectIDFromHex(c.ID)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cmp := "$gt"
+	if direction < 0 {
+		cmp = "$lt"
+	}
+	if field == "_id" {
+		return bson.M{"_id": bson.M{cmp: lastID}}, nil
+	}
+
+	after := bson.A{bson.M{field: c.Value, "_id": bson.M{cmp: lastID}}}
+	switch {
+	case c.Value != nil:
+		after = append(after, bson.M{field: bson.M{cmp: c.Value}})
+		if direction < 0 {
+			after = append(after, bson.M{field: nil})
+		}
+	case direction > 0:
+		after = append(after, bson.M{field: bson.M{"$ne": nil}})
+	}
+	return bson.M{"$or": after}, nil
+}
+
+// encodeRestaurantCursor кодирует позицию ресторана в курсор
+func encodeRestaurantCursor(last models.RestaurantSummary, field string) (string, error) {
+	c := restaurantCursor{ID: last.ID.Hex()}
+	switch field {
+	case "averagePrice":
+		c.Value = last.AveragePrice
+	case "name":
+		c.Value = last.Name
+	case "rating.average":
+		if last.Rating != nil {
+			c.Value = last.Rating.Average
+		}
+	}
+
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return "", errors.Wrap(err, "encoding cursor failed")
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// restaurantLocation возвращает часовой пояс, в котором указаны часы работы ресторанов
+func restaurantLocation() *time.Location {
+	name := os.Getenv("RESTAURANT_TIMEZONE")
+	if name == "" {
+		name = "Europe/Moscow"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"encoding/base64"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRestaurantCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	rated := models.RestaurantSummary{ID: id, Name: "Пельменная", AveragePrice: 900, Rating: &models.RatingSummary{Average: 4.5}}
+	unrated := models.RestaurantSummary{ID: id, Name: "Новое место"}
+
+	tests := []struct {
+		name      string
+		last      models.RestaurantSummary
+		field     string
+		direction int
+		want      bson.M
+	}{
+		{
+			name: "price ascending",
+			last: rated, field: "averagePrice", direction: 1,
+			want: bson.M{"$or": bson.A{
+				bson.M{"averagePrice": float64(900), "_id": bson.M{"$gt": id}},
+				bson.M{"averagePrice": bson.M{"$gt": float64(900)}},
+			}},
+		},
+		{
+			name: "rating descending keeps unrated after rated",
+			last: rated, field: "rating.average", direction: -1,
+			want: bson.M{"$or": bson.A{
+				bson.M{"rating.average": 4.5, "_id": bson.M{"$lt": id}},
+				bson.M{"rating.average": bson.M{"$lt": 4.5}},
+				bson.M{"rating.average": nil},
+			}},
+		},
+		{
+			name: "rating descending from unrated stays among unrated",
+			last: unrated, field: "rating.average", direction: -1,
+			want: bson.M{"$or": bson.A{
+				bson.M{"rating.average": nil, "_id": bson.M{"$lt": id}},
+			}},
+		},
+		{
+			name: "rating ascending from unrated moves to rated",
+			last: unrated, field: "rating.average", direction: 1,
+			want: bson.M{"$or": bson.A{
+				bson.M{"rating.average": nil, "_id": bson.M{"$gt": id}},
+				bson.M{"rating.average": bson.M{"$ne": nil}},
+			}},
+		},
+		{
+			name: "name",
+			last: rated, field: "name", direction: 1,
+			want: bson.M{"$or": bson.A{
+				bson.M{"name": "Пельменная", "_id": bson.M{"$gt": id}},
+				bson.M{"name": bson.M{"$gt": "Пельменная"}},
+			}},
+		},
+		{
+			name: "newest uses id only",
+			last: rated, field: "_id", direction: -1,
+			want: bson.M{"_id": bson.M{"$lt": id}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoded, err := encodeRestaurantCursor(tt.last, tt.field)
+			if err != nil {
+				t.Fatalf("encodeRestaurantCursor() error = %v", err)
+			}
+			got, err := restaurantCursorFilter(encoded, tt.field, tt.direction)
+			if err != nil {
+				t.Fatalf("restaurantCursorFilter() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("restaurantCursorFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRestaurantCursorFilterRejectsInvalidCursors(t *testing.T) {
+	for name, encoded := range map[string]string{
+		"not base64": "!!!",
+		"not json":   base64.RawURLEncoding.EncodeToString([]byte("cursor")),
+		"bad id":     base64.RawURLEncoding.EncodeToString([]byte(`{"v":1,"id":"42"}`)),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := restaurantCursorFilter(encoded, "name", 1); err != ErrInvalidCursor {
+				t.Errorf("restaurantCursorFilter() error = %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
@@ -0,0 +1,626 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ReviewEditWindow - срок, в течение которого автор может изменить или удалить отзыв
+const ReviewEditWindow = 7 * 24 * time.Hour
+
+const reviewsCollectionName = "reviews"
+
+// reviewModerationProjection убирает из публичных отзывов флаги автопроверки и число жалоб
+var reviewModerationProjection = bson.M{"flags": 0, "report_count": 0}
+
+// JobRecomputeRatings тип периодической задачи, пересчитывающей рейтинги ресторанов по отзывам
+const JobRecomputeRatings = "reviews.recompute_ratings"
+
+// Варианты сортировки списка отзывов
+const (
+	ReviewSortNewest  = "newest"
+	ReviewSortOldest  = "oldest"
+	ReviewSortHighest = "highest"
+	ReviewSortLowest  = "lowest"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrReviewForbidden     = errors.New("not allowed to modify this review")
+	ErrReviewEditExpired   = errors.New("review edit window has expired")
+	ErrReviewAlreadyExists = errors.New("order has already been reviewed")
+	ErrOrderNotReviewable  = errors.New("no delivered order found for this restaurant")
+	ErrReviewChanged       = errors.New("review was changed by moderation, reload it and try again")
+)
+
+// ReviewService структура сервиса отзывов
+type ReviewService struct {
+	db       *mongo.Database
+	rules    ModerationRules
+	eventBus *EventBus
+}
+
+// NewReviewService создает новый экземпляр ReviewService
+func NewReviewService(client *mongo.Client, dbName string, rules ModerationRules, eventBus *EventBus) *ReviewService {
+	return &ReviewService{
+		db:       client.Database(dbName),
+		rules:    rules,
+		eventBus: eventBus,
+	}
+}
+
+// EnsureIndexes создает индексы коллекции отзывов
+func (s *ReviewService) EnsureIndexes(ctx context.Context) error {
+	// Отзыв был уникален по одному заказу, и участники группового заказа, получающие копию
+	// заказа с тем же ID, не могли оставить свои отзывы; старый индекс удаляется
+	if _, err := s.db.Collection(reviewsCollectionName).Indexes().DropOne(ctx, "order_id_1"); err != nil && !isIndexNotFound(err) {
+		return errors.Wrap(err, "dropping review order index failed")
+	}
+
+	_, err := s.db.Collection(reviewsCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// Один отзыв каждого участника на один заказ
+			Keys:    bson.D{{Key: "order_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "rating", Value: -1}}},
+		{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating review indexes failed")
+	}
+	return nil
+}
+
+// isIndexNotFound сообщает, что удаляемого индекса или самой коллекции нет
+func isIndexNotFound(err error) bool {
+	var commandErr mongo.CommandError
+	return errors.As(err, &commandErr) && (commandErr.Code == 26 || commandErr.Code == 27)
+}
+
+// CreateReview добавляет отзыв пользователя о ресторане по выполненному заказу.
+// Отзывы, не прошедшие автоматическую проверку, попадают в очередь модерации.
+func (s *ReviewService) CreateReview(ctx context.Context, review *models.Review) error {
+	if err := review.Validate(); err != nil {
+		return err
+	}
+
+	// Отзыв можно оставить только по доставленному заказу из этого ресторана
+	count, err := s.db.Collection(EntityTypeUser).CountDocuments(ctx, bson.M{
+		"_id": review.UserID,
+		"orders": bson.M{"$elemMatch": bson.M{
+			"_id":           review.OrderID,
+			"restaurant_id": review.RestaurantID.Hex(),
+			"status":        "delivered",
+		}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "checking order failed")
+	}
+	if count == 0 {
+		return ErrOrderNotReviewable
+	}
+
+	flags := s.rules.CheckText(review.Comment)
+	if s.rules.MaxReviewsPerDay > 0 {
+		recent, err := s.db.Collection(reviewsCollectionName).CountDocuments(ctx, bson.M{
+			"user_id":    review.UserID,
+			"created_at": bson.M{"$gte": time.Now().Add(-24 * time.Hour)},
+		})
+		if err != nil {
+			return errors.Wrap(err, "counting recent reviews failed")
+		}
+		if recent >= int64(s.rules.MaxReviewsPerDay) {
+			flags = append(flags, ModerationFlagRateLimit)
+		}
+	}
+
+	now := time.Now()
+	review.ID = primitive.NewObjectID()
+	review.Reply = nil
+	review.Flags = flags
+	review.ReportCount = 0
+	review.Status = models.ReviewStatusApproved
+	if len(flags) > 0 {
+		review.Status = models.ReviewStatusPending
+	}
+	review.CreatedAt = now
+	review.UpdatedAt = now
+
+	// Отзыв, пересчет рейтинга или запись аудита и событие сохраняются вместе
+	return s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.db.Collection(reviewsCollectionName).InsertOne(ctx, review); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return ErrReviewAlreadyExists
+			}
+			return errors.Wrap(err, "inserting review failed")
+		}
+
+		var err error
+		if review.Status == models.ReviewStatusPending {
+			err = writeModerationAudit(ctx, s.db, models.ModerationAuditEntry{
+				ReviewID: review.ID,
+				Action:   models.ModerationActionAutoFlag,
+				ToStatus: review.Status,
+				Reason:   strings.Join(flags, ","),
+			})
+		} else {
+			err = s.applyRatingDelta(ctx, review.RestaurantID, review.Rating, 0)
+		}
+		if err != nil {
+			return err
+		}
+
+		return s.eventBus.Record(ctx, models.DomainEventReviewPosted, review.ID.Hex(), models.ReviewPostedPayload{
+			ReviewID:     review.ID,
+			RestaurantID: review.RestaurantID,
+			UserID:       review.UserID,
+			Rating:       review.Rating,
+			Status:       review.Status,
+		})
+	})
+}
+
+// GetReview возвращает отзыв по ID
+func (s *ReviewService) GetReview(ctx context.Context, reviewID primitive.ObjectID) (*models.Review, error) {
+	var review models.Review
+	err := s.db.Collection(reviewsCollectionName).FindOne(ctx, bson.M{"_id": reviewID}).Decode(&review)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReviewNotFound
+		}
+		return nil, errors.Wrap(err, "finding review failed")
+	}
+	return &review, nil
+}
+
+// UpdateReview изменяет оценку и текст отзыва в пределах окна редактирования.
+// Если новый текст не проходит автоматическую проверку, отзыв возвращается в очередь модерации.
+func (s *ReviewService) UpdateReview(ctx context.Context, userID, reviewID primitive.ObjectID, rating int, comment string) (*models.Review, error) {
+	review, err := s.editableReview(ctx, userID, reviewID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldRating, oldStatus := review.Rating, review.Status
+	unchanged := unchangedReviewFilter(review)
+	review.Rating = rating
+	review.Comment = comment
+	review.UpdatedAt = time.Now()
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+	if flags := s.rules.CheckText(comment); len(flags) > 0 {
+		review.Flags = flags
+		review.Status = models.ReviewStatusPending
+	}
+
+	update := bson.M{"$set": bson.M{
+		"rating":     review.Rating,
+		"comment":    review.Comment,
+		"status":     review.Status,
+		"flags":      review.Flags,
+		"updated_at": review.UpdatedAt,
+	}}
+	// Отзыв, запись аудита и рейтинг ресторана меняются вместе
+	err = s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		result, err := s.db.Collection(reviewsCollectionName).UpdateOne(ctx, unchanged, update)
+		if err != nil {
+			return errors.Wrap(err, "updating review failed")
+		}
+		if result.MatchedCount == 0 {
+			return s.changedReviewError(ctx, reviewID)
+		}
+
+		if oldStatus != review.Status {
+			err := writeModerationAudit(ctx, s.db, models.ModerationAuditEntry{
+				ReviewID:   review.ID,
+				Action:     models.ModerationActionAutoFlag,
+				FromStatus: oldStatus,
+				ToStatus:   review.Status,
+				Reason:     strings.Join(review.Flags, ","),
+			})
+			if err != nil {
+				return err
+			}
+			if err := s.hideReviewPhotos(ctx, review.ID, review.Status); err != nil {
+				return err
+			}
+		}
+
+		return s.applyRatingChange(ctx, review.RestaurantID, oldRating, oldStatus, review.Rating, review.Status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// SetReviewStatus переводит отзыв в новый статус модерации, пересчитывает рейтинг ресторана
+// и записывает действие в журнал. Повторная установка того же статуса ничего не меняет.
+func (s *ReviewService) SetReviewStatus(ctx context.Context, reviewID primitive.ObjectID, status string, actorID primitive.ObjectID, action, reason string) (*models.Review, error) {
+	set := bson.M{"status": status, "updated_at": time.Now()}
+	if action == models.ModerationActionApprove {
+		// Одобрение модератором снимает накопленные жалобы, иначе следующая жалоба
+		// сразу вернула бы отзыв в очередь
+		set["report_count"] = 0
+	}
+
+	var before models.Review
+	changed := false
+	err := s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		err := s.db.Collection(reviewsCollectionName).FindOneAndUpdate(ctx,
+			bson.M{"_id": reviewID, "status": bson.M{"$ne": status}},
+			bson.M{"$set": set},
+			options.FindOneAndUpdate().SetReturnDocument(options.Before),
+		).Decode(&before)
+		if err == mongo.ErrNoDocuments {
+			// Отзыв отсутствует или уже находится в нужном статусе
+			changed = false
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "updating review status failed")
+		}
+		changed = true
+
+		err = writeModerationAudit(ctx, s.db, models.ModerationAuditEntry{
+			ReviewID:   reviewID,
+			ActorID:    actorID,
+			Action:     action,
+			FromStatus: before.Status,
+			ToStatus:   status,
+			Reason:     reason,
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.hideReviewPhotos(ctx, reviewID, status); err != nil {
+			return err
+		}
+
+		return s.applyRatingChange(ctx, before.RestaurantID, before.Rating, before.Status, before.Rating, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return s.GetReview(ctx, reviewID)
+	}
+
+	after := before
+	after.Status = status
+	if action == models.ModerationActionApprove {
+		after.ReportCount = 0
+	}
+	return &after, nil
+}
+
+// DeleteReview удаляет отзыв автора в пределах окна редактирования.
+// Фото отзыва сразу скрываются, а их файлы удаляет подписчик события review.deleted.
+func (s *ReviewService) DeleteReview(ctx context.Context, userID, reviewID primitive.ObjectID) error {
+	review, err := s.editableReview(ctx, userID, reviewID)
+	if err != nil {
+		return err
+	}
+
+	// Удаление отзыва, пересчет рейтинга и событие для очистки фото выполняются вместе
+	return s.eventBus.WithTransaction(ctx, func(ctx context.Context) error {
+		result, err := s.db.Collection(reviewsCollectionName).DeleteOne(ctx, unchangedReviewFilter(review))
+		if err != nil {
+			return errors.Wrap(err, "deleting review failed")
+		}
+		if result.DeletedCount == 0 {
+			return s.changedReviewError(ctx, reviewID)
+		}
+
+		if err := s.applyRatingChange(ctx, review.RestaurantID, review.Rating, review.Status, 0, models.ReviewStatusRejected); err != nil {
+			return err
+		}
+		if err := s.hideReviewPhotos(ctx, reviewID, models.ReviewStatusRejected); err != nil {
+			return err
+		}
+
+		return s.eventBus.Record(ctx, models.DomainEventReviewDeleted, reviewID.Hex(), models.ReviewDeletedPayload{
+			ReviewID:     reviewID,
+			RestaurantID: review.RestaurantID,
+			UserID:       review.UserID,
+		})
+	})
+}
+
+// ReplyToReview сохраняет ответ владельца ресторана на отзыв
+func (s *ReviewService) ReplyToReview(ctx context.Context, restaurantID, reviewID primitive.ObjectID, text string) (*models.Review, error) {
+	review, err := s.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.RestaurantID != restaurantID {
+		return nil, ErrReviewForbidden
+	}
+
+	review.Reply = &models.ReviewReply{Text: text, CreatedAt: time.Now()}
+	if err := review.Validate(); err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"$set": bson.M{"reply": review.Reply}}
+	if _, err := s.db.Collection(reviewsCollectionName).UpdateByID(ctx, reviewID, update); err != nil {
+		return nil, errors.Wrap(err, "saving reply failed")
+	}
+	// Состояние модерации ресторану не показывается
+	review.Flags = nil
+	review.ReportCount = 0
+	return review, nil
+}
+
+// ListRestaurantReviews возвращает страницу отзывов ресторана с указанной сортировкой
+func (s *ReviewService) ListRestaurantReviews(ctx context.Context, restaurantID primitive.ObjectID, sortBy string, page, limit int) (*models.ReviewPage, error) {
+	collection := s.db.Collection(reviewsCollectionName)
+	filter := bson.M{
+		"restaurant_id": restaurantID,
+		"status":        bson.M{"$nin": bson.A{models.ReviewStatusPending, models.ReviewStatusRejected}},
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting reviews failed")
+	}
+
+	findOptions := options.Find().
+		SetProjection(reviewModerationProjection).
+		SetSort(reviewSort(sortBy)).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding reviews failed")
+	}
+	reviews := []models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, errors.Wrap(err, "decoding reviews failed")
+	}
+
+	return &models.ReviewPage{Reviews: reviews, Total: total, Page: page, Limit: limit}, nil
+}
+
+// editableReview возвращает отзыв, если пользователь является его автором и окно редактирования не истекло
+func (s *ReviewService) editableReview(ctx context.Context, userID, reviewID primitive.ObjectID) (*models.Review, error) {
+	review, err := s.GetReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID != userID {
+		return nil, ErrReviewForbidden
+	}
+	if time.Since(review.CreatedAt) > ReviewEditWindow {
+		return nil, ErrReviewEditExpired
+	}
+	return review, nil
+}
+
+// unchangedReviewFilter отбирает отзыв, только если его статус и оценка остались такими,
+// какими были прочитаны: иначе правка автора затерла бы решение модератора, а рейтинг
+// ресторана пересчитался бы от устаревших значений
+func unchangedReviewFilter(review *models.Review) bson.M {
+	var status interface{} = review.Status
+	if review.Status == "" {
+		status = bson.M{"$in": bson.A{"", nil}}
+	}
+	return bson.M{"_id": review.ID, "status": status, "rating": review.Rating}
+}
+
+// changedReviewError объясняет, почему отзыв не нашелся по unchangedReviewFilter
+func (s *ReviewService) changedReviewError(ctx context.Context, reviewID primitive.ObjectID) error {
+	if _, err := s.GetReview(ctx, reviewID); err != nil {
+		return err
+	}
+	return ErrReviewChanged
+}
+
+// applyRatingChange учитывает в рейтинге ресторана только одобренные отзывы
+func (s *ReviewService) applyRatingChange(ctx context.Context, restaurantID primitive.ObjectID, oldRating int, oldStatus string, newRating int, newStatus string) error {
+	removed, added := 0, 0
+	if isReviewVisible(oldStatus) {
+		removed = oldRating
+	}
+	if isReviewVisible(newStatus) {
+		added = newRating
+	}
+	if removed == added {
+		return nil
+	}
+	return s.applyRatingDelta(ctx, restaurantID, added, removed)
+}
+
+// applyRatingDelta атомарно обновляет сумму, количество, гистограмму и среднюю оценку ресторана.
+// added - добавляемая оценка, removed - удаляемая (0 означает отсутствие).
+func (s *ReviewService) applyRatingDelta(ctx context.Context, restaurantID primitive.ObjectID, added, removed int) error {
+	countDelta := 0
+	set := bson.M{}
+	if added > 0 {
+		countDelta++
+		key := "rating.histogram." + strconv.Itoa(added)
+		set[key] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + key, 0}}, 1}}
+	}
+	if removed > 0 {
+		countDelta--
+		key := "rating.histogram." + strconv.Itoa(removed)
+		set[key] = bson.M{"$max": bson.A{0, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + key, 0}}, -1}}}}
+	}
+	set["rating.sum"] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating.sum", 0}}, added - removed}}
+	set["rating.count"] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating.count", 0}}, countDelta}}
+	set["rating.version"] = bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating.version", 0}}, 1}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: set}},
+		{{Key: "$set", Value: bson.M{"rating.average": bson.M{"$cond": bson.A{
+			bson.M{"$gt": bson.A{"$rating.count", 0}},
+			bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$rating.sum", "$rating.count"}}, 2}},
+			0,
+		}}}}},
+	}
+
+	_, err := s.db.Collection(EntityTypeRestaurant).UpdateByID(ctx, restaurantID, pipeline)
+	if err != nil {
+		return errors.Wrap(err, "updating restaurant rating failed")
+	}
+	return nil
+}
+
+// RecomputeRatings пересчитывает рейтинги ресторанов по опубликованным отзывам и
+// возвращает число обновленных ресторанов. Исправляет расхождения, которые могли накопиться
+// при инкрементальном обновлении рейтинга.
+func (s *ReviewService) RecomputeRatings(ctx context.Context) (int, error) {
+	reviewed, err := s.db.Collection(reviewsCollectionName).Distinct(ctx, "restaurant_id", visibleReviewsFilter())
+	if err != nil {
+		return 0, errors.Wrap(err, "finding reviewed restaurants failed")
+	}
+	// У ресторанов, чьи отзывы все удалены или скрыты, рейтинг обнуляется
+	rated, err := s.db.Collection(EntityTypeRestaurant).Distinct(ctx, "_id", bson.M{"rating.count": bson.M{"$gt": 0}})
+	if err != nil {
+		return 0, errors.Wrap(err, "finding rated restaurants failed")
+	}
+
+	seen := map[primitive.ObjectID]bool{}
+	updated := 0
+	for _, value := range append(reviewed, rated...) {
+		restaurantID, ok := value.(primitive.ObjectID)
+		if !ok || seen[restaurantID] {
+			continue
+		}
+		seen[restaurantID] = true
+
+		saved, err := s.recomputeRating(ctx, restaurantID)
+		if err != nil {
+			return updated, err
+		}
+		if saved {
+			updated++
+		}
+	}
+	return updated, nil
+}
+
+// recomputeRating пересчитывает рейтинг одного ресторана. Каждое изменение рейтинга увеличивает
+// rating.version, поэтому пересчет сохраняется, только если версия не изменилась с момента чтения:
+// иначе отзыв изменили во время подсчета, и ресторан пропускается до следующего запуска.
+func (s *ReviewService) recomputeRating(ctx context.Context, restaurantID primitive.ObjectID) (bool, error) {
+	restaurants := s.db.Collection(EntityTypeRestaurant)
+	var current struct {
+		Rating struct {
+			Version int64 `bson:"version"`
+		} `bson:"rating"`
+	}
+	err := restaurants.FindOne(ctx, bson.M{"_id": restaurantID},
+		options.FindOne().SetProjection(bson.M{"rating.version": 1}),
+	).Decode(&current)
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "finding restaurant rating failed")
+	}
+
+	filter := visibleReviewsFilter()
+	filter["restaurant_id"] = restaurantID
+	cursor, err := s.db.Collection(reviewsCollectionName).Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$group", Value: bson.M{"_id": "$rating", "count": bson.M{"$sum": 1}}}},
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "aggregating ratings failed")
+	}
+	var groups []struct {
+		Rating int `bson:"_id"`
+		Count  int `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return false, errors.Wrap(err, "decoding ratings failed")
+	}
+
+	summary := models.RatingSummary{Histogram: map[string]int{}, Version: current.Rating.Version + 1}
+	for _, group := range groups {
+		summary.Histogram[strconv.Itoa(group.Rating)] += group.Count
+		summary.Sum += group.Rating * group.Count
+		summary.Count += group.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = math.Round(float64(summary.Sum)/float64(summary.Count)*100) / 100
+	}
+
+	versionFilter := interface{}(current.Rating.Version)
+	if current.Rating.Version == 0 {
+		// Рейтинги, сохраненные до появления версии, поля version не имеют
+		versionFilter = bson.M{"$in": bson.A{0, nil}}
+	}
+	result, err := restaurants.UpdateOne(ctx,
+		bson.M{"_id": restaurantID, "rating.version": versionFilter},
+		bson.M{"$set": bson.M{"rating": summary}},
+	)
+	if err != nil {
+		return false, errors.Wrap(err, "updating restaurant rating failed")
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// visibleReviewsFilter отбирает публично показываемые отзывы, см. isReviewVisible
+func visibleReviewsFilter() bson.M {
+	return bson.M{"status": bson.M{"$in": bson.A{models.ReviewStatusApproved, "", nil}}}
+}
+
+// hideReviewPhotos скрывает фото отзыва из галереи, пока сам отзыв не показывается публично,
+// и возвращает их, когда отзыв снова одобрен. Собственный статус модерации фото не меняется.
+func (s *ReviewService) hideReviewPhotos(ctx context.Context, reviewID primitive.ObjectID, status string) error {
+	_, err := s.db.Collection(restaurantPhotosCollectionName).UpdateMany(ctx,
+		bson.M{"review_id": reviewID},
+		bson.M{"$set": bson.M{"review_hidden": !isReviewVisible(status)}},
+	)
+	if err != nil {
+		return errors.Wrap(err, "updating review photos visibility failed")
+	}
+	return nil
+}
+
+// HandleRecomputeRatingsJob пересчитывает рейтинги по задаче reviews.recompute_ratings
+func (s *ReviewService) HandleRecomputeRatingsJob(ctx context.Context, job *models.Job) error {
+	count, err := s.RecomputeRatings(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Ratings recomputed for %d restaurants", count)
+	return nil
+}
+
+// isReviewVisible сообщает, показывается ли отзыв публично.
+// Отзывы без статуса созданы до появления модерации и считаются одобренными.
+func isReviewVisible(status string) bool {
+	return status == "" || status == models.ReviewStatusApproved
+}
+
+// reviewSort возвращает порядок сортировки для списка отзывов
+func reviewSort(sortBy string) bson.D {
+	switch sortBy {
+	case ReviewSortOldest:
+		return bson.D{{Key: "created_at", Value: 1}}
+	case ReviewSortHighest:
+		return bson.D{{Key: "rating", Value: -1}, {Key: "created_at", Value: -1}}
+	case ReviewSortLowest:
+		return bson.D{{Key: "rating", Value: 1}, {Key: "created_at", Value: -1}}
+	default:
+		return bson.D{{Key: "created_at", Value: -1}}
+	}
+}
@@ -0,0 +1,377 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestRecomputeRating(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	restaurantID := primitive.NewObjectID()
+
+	tests := []struct {
+		name        string
+		restaurant  bson.D
+		groups      []bson.D
+		matched     int
+		want        bool
+		wantVersion interface{} // ожидаемое условие на версию в фильтре
+		wantRating  bson.M
+	}{
+		{
+			name:       "saves when version unchanged",
+			restaurant: bson.D{{Key: "_id", Value: restaurantID}, {Key: "rating", Value: bson.D{{Key: "version", Value: int64(7)}}}},
+			groups: []bson.D{
+				{{Key: "_id", Value: 5}, {Key: "count", Value: 2}},
+				{{Key: "_id", Value: 2}, {Key: "count", Value: 1}},
+			},
+			matched:     1,
+			want:        true,
+			wantVersion: int64(7),
+			wantRating:  bson.M{"average": 4.0, "count": int32(3), "sum": int32(12), "version": int64(8)},
+		},
+		{
+			name:        "skips when rating changed meanwhile",
+			restaurant:  bson.D{{Key: "_id", Value: restaurantID}, {Key: "rating", Value: bson.D{{Key: "version", Value: int64(7)}}}},
+			groups:      []bson.D{{{Key: "_id", Value: 4}, {Key: "count", Value: 1}}},
+			matched:     0,
+			wantVersion: int64(7),
+			wantRating:  bson.M{"average": 4.0, "count": int32(1), "sum": int32(4), "version": int64(8)},
+		},
+		{
+			name:        "resets rating without visible reviews",
+			restaurant:  bson.D{{Key: "_id", Value: restaurantID}},
+			matched:     1,
+			want:        true,
+			wantVersion: bson.M{"$in": bson.A{int32(0), nil}},
+			wantRating:  bson.M{"average": 0.0, "count": int32(0), "sum": int32(0), "version": int64(1)},
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &ReviewService{db: mt.DB}
+			mt.AddMockResponses(
+				mtest.CreateCursorResponse(0, "db.restaurants", mtest.FirstBatch, tt.restaurant),
+				mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, tt.groups...),
+				bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: tt.matched}, {Key: "nModified", Value: tt.matched}},
+			)
+
+			saved, err := s.recomputeRating(context.Background(), restaurantID)
+			if err != nil {
+				mt.Fatalf("recomputeRating() error: %v", err)
+			}
+			if saved != tt.want {
+				mt.Errorf("recomputeRating() = %v, want %v", saved, tt.want)
+			}
+
+			var update bson.Raw
+			for _, event := range mt.GetAllStartedEvents() {
+				if event.CommandName == "update" {
+					values, _ := event.Command.Lookup("updates").Array().Values()
+					update = values[0].Document()
+				}
+			}
+			if update == nil {
+				mt.Fatalf("rating was not saved")
+			}
+
+			var filter struct {
+				Version interface{} `bson:"rating.version"`
+			}
+			if err := bson.Unmarshal(update.Lookup("q").Document(), &filter); err != nil {
+				mt.Fatal(err)
+			}
+			if got, want := bsonString(mt, filter.Version), bsonString(mt, tt.wantVersion); got != want {
+				mt.Errorf("version filter = %s, want %s", got, want)
+			}
+
+			var set struct {
+				Rating bson.M `bson:"rating"`
+			}
+			if err := bson.Unmarshal(update.Lookup("u", "$set").Document(), &set); err != nil {
+				mt.Fatal(err)
+			}
+			for key, want := range tt.wantRating {
+				if got := set.Rating[key]; got != want {
+					mt.Errorf("rating.%s = %v (%T), want %v (%T)", key, got, got, want, want)
+				}
+			}
+		})
+	}
+}
+
+// bsonString приводит значение к расширенному JSON для сравнения в тестах
+func bsonString(t testing.TB, value interface{}) string {
+	data, err := bson.MarshalExtJSON(bson.M{"v": value}, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDeleteReviewRecordsCleanupEvent(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("delete", func(mt *mtest.T) {
+		s := &ReviewService{db: mt.DB, eventBus: &EventBus{db: mt.DB}}
+		userID, reviewID, restaurantID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+		updated := bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}}
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: reviewID},
+				{Key: "user_id", Value: userID},
+				{Key: "restaurant_id", Value: restaurantID},
+				{Key: "rating", Value: 4},
+				{Key: "created_at", Value: time.Now()},
+			}),
+			bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: 1}},
+			updated,
+			updated,
+			mtest.CreateSuccessResponse(),
+		)
+
+		if err := s.DeleteReview(context.Background(), userID, reviewID); err != nil {
+			t.Fatalf("DeleteReview() error = %v", err)
+		}
+
+		var hidden bool
+		var event bson.Raw
+		for _, started := range mt.GetAllStartedEvents() {
+			switch {
+			case started.CommandName == "update" && started.Command.Lookup("update").StringValue() == restaurantPhotosCollectionName:
+				hidden = started.Command.Lookup("updates").Array().Index(0).Value().Document().Lookup("u", "$set", "review_hidden").Boolean()
+			case started.CommandName == "insert" && started.Command.Lookup("insert").StringValue() == domainEventsCollectionName:
+				event = started.Command.Lookup("documents").Array().Index(0).Value().Document()
+			}
+		}
+		if !hidden {
+			t.Error("review photos were not hidden")
+		}
+		if event == nil {
+			t.Fatal("review.deleted event was not recorded")
+		}
+		if got := event.Lookup("type").StringValue(); got != models.DomainEventReviewDeleted {
+			t.Errorf("event type = %q, want %q", got, models.DomainEventReviewDeleted)
+		}
+		if got := event.Lookup("payload", "review_id").ObjectID(); got != reviewID {
+			t.Errorf("payload review_id = %s, want %s", got.Hex(), reviewID.Hex())
+		}
+	})
+}
+
+func TestReviewIndexesAllowGroupOrderParticipants(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("replaces order index", func(mt *mtest.T) {
+		s := &ReviewService{db: mt.DB}
+		mt.AddMockResponses(
+			mtest.CreateCommandErrorResponse(mtest.CommandError{Code: 27, Name: "IndexNotFound", Message: "index not found with name [order_id_1]"}),
+			mtest.CreateSuccessResponse(),
+		)
+
+		if err := s.EnsureIndexes(context.Background()); err != nil {
+			mt.Fatalf("EnsureIndexes() error = %v", err)
+		}
+
+		if got := mt.GetStartedEvent().Command.Lookup("index").StringValue(); got != "order_id_1" {
+			mt.Errorf("dropped index = %q, want order_id_1", got)
+		}
+		unique := mt.GetStartedEvent().Command.Lookup("indexes").Array().Index(0).Value().Document()
+		keys, _ := unique.Lookup("key").Document().Elements()
+		if len(keys) != 2 || keys[0].Key() != "order_id" || keys[1].Key() != "user_id" || !unique.Lookup("unique").Boolean() {
+			mt.Errorf("first index = %v, want unique {order_id, user_id}", unique)
+		}
+	})
+}
+
+func TestCreateReviewForGroupOrder(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	restaurantID := primitive.NewObjectID()
+	orderID := primitive.NewObjectID().Hex() // копия заказа у каждого участника с одним ID
+	host, guest := primitive.NewObjectID(), primitive.NewObjectID()
+
+	created := func() []bson.D {
+		return []bson.D{
+			mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateSuccessResponse(),
+			{{Key: "ok", Value: 1}, {Key: "n", Value: 1}, {Key: "nModified", Value: 1}},
+			mtest.CreateSuccessResponse(),
+		}
+	}
+
+	tests := []struct {
+		name      string
+		userID    primitive.ObjectID
+		responses []bson.D
+		wantErr   error
+	}{
+		{name: "host reviews", userID: host, responses: created()},
+		{name: "guest reviews the same order", userID: guest, responses: created()},
+		{
+			name:   "guest reviews twice",
+			userID: guest,
+			responses: []bson.D{
+				mtest.CreateCursorResponse(0, "db.users", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+				mtest.CreateWriteErrorsResponse(mtest.WriteError{Index: 0, Code: 11000, Message: "E11000 duplicate key error"}),
+			},
+			wantErr: ErrReviewAlreadyExists,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &ReviewService{db: mt.DB, eventBus: &EventBus{db: mt.DB}}
+			mt.AddMockResponses(tt.responses...)
+
+			review := &models.Review{RestaurantID: restaurantID, UserID: tt.userID, OrderID: orderID, Rating: 5}
+			if err := s.CreateReview(context.Background(), review); err != tt.wantErr {
+				mt.Fatalf("CreateReview() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReviewEditsRequireUnchangedReview(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	userID := primitive.NewObjectID()
+	stored := func(reviewID primitive.ObjectID, status string) bson.D {
+		return bson.D{
+			{Key: "_id", Value: reviewID},
+			{Key: "user_id", Value: userID},
+			{Key: "restaurant_id", Value: primitive.NewObjectID()},
+			{Key: "order_id", Value: "order-1"},
+			{Key: "rating", Value: 4},
+			{Key: "status", Value: status},
+			{Key: "created_at", Value: time.Now()},
+		}
+	}
+	written := func(n int) bson.D {
+		return bson.D{{Key: "ok", Value: 1}, {Key: "n", Value: n}, {Key: "nModified", Value: n}}
+	}
+
+	tests := []struct {
+		name       string
+		delete     bool
+		status     string
+		afterWrite func(review bson.D) []bson.D // ответы после неудачной записи
+		written    int
+		wantErr    error
+		wantStatus interface{}
+	}{
+		{name: "update unchanged", status: models.ReviewStatusApproved, written: 1, wantStatus: models.ReviewStatusApproved},
+		{name: "update legacy review", status: "", written: 1, wantStatus: bson.M{"$in": bson.A{"", nil}}},
+		{
+			name: "update after moderation", status: models.ReviewStatusApproved,
+			afterWrite: func(review bson.D) []bson.D {
+				return []bson.D{mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, review)}
+			},
+			wantErr: ErrReviewChanged, wantStatus: models.ReviewStatusApproved,
+		},
+		{
+			name: "update after deletion", status: models.ReviewStatusApproved,
+			afterWrite: func(bson.D) []bson.D {
+				return []bson.D{mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch)}
+			},
+			wantErr: ErrReviewNotFound, wantStatus: models.ReviewStatusApproved,
+		},
+		{name: "delete unchanged", delete: true, status: models.ReviewStatusApproved, written: 1, wantStatus: models.ReviewStatusApproved},
+		{
+			name: "delete after moderation", delete: true, status: models.ReviewStatusPending,
+			afterWrite: func(review bson.D) []bson.D {
+				return []bson.D{mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, review)}
+			},
+			wantErr: ErrReviewChanged, wantStatus: models.ReviewStatusPending,
+		},
+	}
+
+	for _, tt := range tests {
+		mt.Run(tt.name, func(mt *mtest.T) {
+			s := &ReviewService{db: mt.DB, eventBus: &EventBus{db: mt.DB}}
+			reviewID := primitive.NewObjectID()
+			review := stored(reviewID, tt.status)
+			responses := []bson.D{mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, review), written(tt.written)}
+			if tt.afterWrite != nil {
+				responses = append(responses, tt.afterWrite(review)...)
+			} else {
+				// рейтинг ресторана, фото отзыва и событие
+				responses = append(responses, written(1), written(1), mtest.CreateSuccessResponse())
+			}
+			mt.AddMockResponses(responses...)
+
+			var err error
+			if tt.delete {
+				err = s.DeleteReview(context.Background(), userID, reviewID)
+			} else {
+				_, err = s.UpdateReview(context.Background(), userID, reviewID, 2, "стало хуже")
+			}
+			if err != tt.wantErr {
+				mt.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			mt.GetStartedEvent() // чтение отзыва
+			write := mt.GetStartedEvent().Command
+			var filter bson.Raw
+			if tt.delete {
+				filter = write.Lookup("deletes").Array().Index(0).Value().Document().Lookup("q").Document()
+			} else {
+				filter = write.Lookup("updates").Array().Index(0).Value().Document().Lookup("q").Document()
+			}
+			if got := filter.Lookup("rating").AsInt64(); got != 4 {
+				mt.Errorf("filter rating = %d, want 4", got)
+			}
+			if got, want := bsonString(mt, filter.Lookup("status")), bsonString(mt, tt.wantStatus); got != want {
+				mt.Errorf("filter status = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestListRestaurantReviewsHidesModerationState(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+
+	mt.Run("public page", func(mt *mtest.T) {
+		s := &ReviewService{db: mt.DB}
+		restaurantID := primitive.NewObjectID()
+		mt.AddMockResponses(
+			mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, bson.D{{Key: "n", Value: 1}}),
+			mtest.CreateCursorResponse(0, "db.reviews", mtest.FirstBatch, bson.D{
+				{Key: "_id", Value: primitive.NewObjectID()},
+				{Key: "restaurant_id", Value: restaurantID},
+				{Key: "rating", Value: 5},
+				{Key: "status", Value: models.ReviewStatusApproved},
+			}),
+		)
+
+		page, err := s.ListRestaurantReviews(context.Background(), restaurantID, ReviewSortNewest, 1, 10)
+		if err != nil {
+			mt.Fatalf("ListRestaurantReviews() error = %v", err)
+		}
+
+		mt.GetStartedEvent() // подсчет
+		projection := mt.GetStartedEvent().Command.Lookup("projection").Document()
+		for _, field := range []string{"flags", "report_count"} {
+			if value, err := projection.LookupErr(field); err != nil || value.AsInt64() != 0 {
+				mt.Errorf("projection does not exclude %s: %v", field, projection)
+			}
+		}
+
+		data, err := json.Marshal(page)
+		if err != nil {
+			mt.Fatalf("json.Marshal() error = %v", err)
+		}
+		for _, field := range []string{`"flags"`, `"report_count"`} {
+			if strings.Contains(string(data), field) {
+				mt.Errorf("public page contains %s: %s", field, data)
+			}
+		}
+	})
+}
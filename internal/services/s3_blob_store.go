@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	s3Service         = "s3"
+	s3Algorithm       = "AWS4-HMAC-SHA256"
+	s3TimeFormat      = "20060102T150405Z"
+	s3DateFormat      = "20060102"
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	s3MaxPresignTTL   = 7 * 24 * time.Hour
+)
+
+// S3Config параметры подключения к S3-совместимому хранилищу (AWS S3, MinIO, Yandex Object Storage)
+type S3Config struct {
+	Endpoint        string // например https://s3.amazonaws.com или http://minio:9000
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	VirtualHosted   bool // адресация bucket.host вместо host/bucket
+}
+
+// S3BlobStore хранит файлы в S3-совместимом хранилище.
+// Запросы подписываются по AWS Signature Version 4, ссылки на чтение выдаются как presigned URL.
+type S3BlobStore struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+// NewS3BlobStore создает хранилище S3
+func NewS3BlobStore(config S3Config) (*S3BlobStore, error) {
+	if config.Endpoint == "" || config.Bucket == "" {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required for s3 media store")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required for s3 media store")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	endpoint, err := url.Parse(strings.TrimRight(config.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, errors.New("invalid S3_ENDPOINT")
+	}
+	return &S3BlobStore{
+		config:   config,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// objectURL возвращает адрес объекта без параметров запроса
+func (s *S3BlobStore) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	if s.config.VirtualHosted {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = u.Path + "/" + key
+	} else {
+		u.Path = u.Path + "/" + s.config.Bucket + "/" + key
+	}
+	u.RawPath = awsURIEncode(u.Path, false)
+	return &u
+}
+
+// Put загружает объект
+func (s *S3BlobStore) Put(ctx context.Context, key, contentType string, data []byte) error {
+	if !validBlobKey(key) {
+		return ErrInvalidBlobKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return errors.Wrap(err, "building s3 request failed")
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(data))
+	sum := sha256.Sum256(data)
+	return s.do(req, hex.EncodeToString(sum[:]), http.StatusOK)
+}
+
+// Delete удаляет объект; S3 не сообщает об отсутствии объекта при удалении
+func (s *S3BlobStore) Delete(ctx context.Context, key string) error {
+	if !validBlobKey(key) {
+		return ErrInvalidBlobKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return errors.Wrap(err, "building s3 request failed")
+	}
+	sum := sha256.Sum256(nil)
+	return s.do(req, hex.EncodeToString(sum[:]), http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// SignedURL возвращает presigned GET ссылку; S3 ограничивает срок действия семью днями
+func (s *S3BlobStore) SignedURL(key string, ttl time.Duration) (string, error) {
+	if !validBlobKey(key) {
+		return "", ErrInvalidBlobKey
+	}
+	if ttl > s3MaxPresignTTL {
+		ttl = s3MaxPresignTTL
+	}
+	return s.presign(key, ttl, time.Now().UTC()), nil
+}
+
+// presign строит presigned GET ссылку на момент now
+func (s *S3BlobStore) presign(key string, ttl time.Duration, now time.Time) string {
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", s.config.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(ttl.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		awsCanonicalQuery(query),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	u.RawQuery = awsCanonicalQuery(query)
+	return u.String()
+}
+
+// do подписывает и выполняет запрос, ожидая один из указанных статусов
+func (s *S3BlobStore) do(req *http.Request, payloadHash string, expected ...int) error {
+	s.signRequest(req, payloadHash, time.Now().UTC())
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "s3 request failed")
+	}
+	defer resp.Body.Close()
+	for _, status := range expected {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("s3 %s %s returned %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// signRequest добавляет к запросу заголовки подписи AWS Signature Version 4
+func (s *S3BlobStore) signRequest(req *http.Request, payloadHash string, now time.Time) {
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           now.Format(s3TimeFormat),
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		awsCanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, s.config.AccessKeyID, s.scope(now), signedHeaders, s.signature(now, canonicalRequest)))
+}
+
+// scope возвращает область действия подписи: дата/регион/сервис/aws4_request
+func (s *S3BlobStore) scope(now time.Time) string {
+	return now.Format(s3DateFormat) + "/" + s.config.Region + "/" + s3Service + "/aws4_request"
+}
+
+// signature вычисляет подпись канонического запроса ключом, производным от секрета, даты и региона
+func (s *S3BlobStore) signature(now time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		s3Algorithm,
+		now.Format(s3TimeFormat),
+		s.scope(now),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), []byte(now.Format(s3DateFormat)))
+	key = hmacSHA256(key, []byte(s.config.Region))
+	key = hmacSHA256(key, []byte(s3Service))
+	key = hmacSHA256(key, []byte("aws4_request"))
+	return hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+}
+
+// awsCanonicalQuery кодирует параметры запроса в порядке, требуемом подписью
+func awsCanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, awsURIEncode(key, true)+"="+awsURIEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// awsURIEncode кодирует строку по правилам SigV4: без кодирования остаются только
+// буквы, цифры и -_.~, а "/" - в зависимости от encodeSlash
+func awsURIEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
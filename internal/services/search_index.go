@@ -0,0 +1,150 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SearchIndex поисковый индекс по ресторанам и блюдам.
+// Позволяет заменить реализацию на MongoDB другим поисковым движком.
+type SearchIndex interface {
+	// IndexRestaurant добавляет или заменяет в индексе ресторан и все его блюда
+	IndexRestaurant(ctx context.Context, restaurant *models.Restaurant) error
+	// RemoveRestaurant удаляет из индекса ресторан и его блюда
+	RemoveRestaurant(ctx context.Context, restaurantID primitive.ObjectID) error
+	// Prune удаляет документы, проиндексированные раньше указанного времени
+	Prune(ctx context.Context, before time.Time) error
+	// Search возвращает совпадения, отсортированные по текстовой релевантности
+	Search(ctx context.Context, text string, kinds []string, limit int) ([]models.SearchHit, error)
+}
+
+// tokenize разбивает текст на нормализованные слова
+func tokenize(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// termMatchScore оценивает совпадение слова запроса со словом документа:
+// точное совпадение, совпадение по префиксу или с опечаткой
+func termMatchScore(query, term string) float64 {
+	switch {
+	case query == term:
+		return 1
+	case len([]rune(query)) >= 2 && strings.HasPrefix(term, query):
+		return 0.8
+	}
+
+	allowed := 1
+	if len([]rune(query)) > 6 {
+		allowed = 2
+	}
+	if len([]rune(query)) >= 4 && editDistance(query, term) <= allowed {
+		return 0.6
+	}
+	return 0
+}
+
+// editDistance вычисляет расстояние Дамерау-Левенштейна (с перестановкой соседних символов)
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// highlight выделяет в тексте слова, совпавшие с запросом, и обрезает его до фрагмента вокруг первого совпадения.
+// Возвращает пустую строку, если совпадений нет.
+func highlight(text string, queryTerms []string, maxRunes int) string {
+	runes := []rune(text)
+	var builder strings.Builder
+	first := -1
+
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) && !unicode.IsDigit(runes[i]) {
+			builder.WriteRune(runes[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j])) {
+			j++
+		}
+		word := string(runes[i:j])
+		normalized := strings.ReplaceAll(strings.ToLower(word), "ё", "е")
+
+		matched := false
+		for _, term := range queryTerms {
+			if termMatchScore(term, normalized) > 0 {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			if first < 0 {
+				first = i
+			}
+			builder.WriteString("<em>" + word + "</em>")
+		} else {
+			builder.WriteString(word)
+		}
+		i = j
+	}
+	if first < 0 {
+		return ""
+	}
+
+	result := builder.String()
+	if len(runes) <= maxRunes {
+		return result
+	}
+
+	// Фрагмент вокруг первого совпадения, без разрыва тегов выделения
+	start := first - maxRunes/4
+	if start < 0 {
+		start = 0
+	}
+	// Фрагмент начинается с начала слова
+	for start > 0 && (unicode.IsLetter(runes[start-1]) || unicode.IsDigit(runes[start-1])) {
+		start--
+	}
+	prefix := string(runes[:start])
+	fragment := []rune(strings.TrimPrefix(result, prefix))
+	if len(fragment) > maxRunes {
+		cut := maxRunes
+		if open := strings.LastIndex(string(fragment[:cut]), "<em>"); open >= 0 &&
+			!strings.Contains(string(fragment[:cut])[open:], "</em>") {
+			cut = len([]rune(string(fragment[:cut])[:open]))
+		}
+		fragment = append(fragment[:cut], []rune("…")...)
+	}
+	if start > 0 {
+		return "…" + string(fragment)
+	}
+	return string(fragment)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"борщ", "борщ", 0},
+		{"", "суп", 3},
+		{"борщ", "борш", 1},
+		{"борщ", "брощ", 1}, // перестановка соседних букв
+		{"пицца", "пица", 1},
+		{"pizza", "piza", 1},
+		{"ramen", "lemon", 3},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestTermMatchScore(t *testing.T) {
+	tests := []struct {
+		name        string
+		query, term string
+		want        float64
+	}{
+		{"exact", "борщ", "борщ", 1},
+		{"prefix", "бор", "борщ", 0.8},
+		{"one letter prefix", "б", "борщ", 0},
+		{"typo", "борш", "борщ", 0.6},
+		{"transposition", "брощ", "борщ", 0.6},
+		{"short query no typos", "суп", "сук", 0},
+		{"long query two typos", "карбанора", "карбонара", 0.6},
+		{"short query two typos", "бкрш", "борщ", 0},
+		{"unrelated", "пицца", "ролл", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := termMatchScore(tt.query, tt.term); got != tt.want {
+				t.Errorf("termMatchScore(%q, %q) = %v, want %v", tt.query, tt.term, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	long := strings.Repeat("очень ", 30) + "вкусный борщ со сметаной"
+	tests := []struct {
+		name     string
+		text     string
+		terms    []string
+		maxRunes int
+		want     string
+	}{
+		{"no match", "Паста карбонара", []string{"борщ"}, 120, ""},
+		{"single", "Украинский борщ", []string{"борщ"}, 120, "Украинский <em>борщ</em>"},
+		{"case and yo", "Ёжики в СЁМГЕ", []string{"семге"}, 120, "Ёжики в <em>СЁМГЕ</em>"},
+		{"typo", "Суп борщ", []string{"борш"}, 120, "Суп <em>борщ</em>"},
+		{"keeps punctuation", "Борщ, пампушки!", []string{"борщ", "пампушки"}, 120, "<em>Борщ</em>, <em>пампушки</em>!"},
+		{"fragment", long, []string{"борщ"}, 40, "…очень вкусный <em>борщ</em> со сметаной"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := highlight(tt.text, tt.terms, tt.maxRunes); got != tt.want {
+				t.Errorf("highlight() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTypoKeysFindPrefixTypos(t *testing.T) {
+	tests := []struct {
+		query, term string
+	}{
+		{"порщ", "борщ"},   // замена первой буквы
+		{"обрщ", "борщ"},   // перестановка первых букв
+		{"орщ", "борщ"},    // пропуск первой буквы
+		{"бборщ", "борщ"},  // лишняя буква
+		{"pizza", "pizza"}, // точное совпадение
+		{"lasagna", "lazagna"},
+	}
+	for _, tt := range tests {
+		if !sharesKey(typoKeys(tt.query), typoKeys(tt.term)) {
+			t.Errorf("typoKeys(%q) and typoKeys(%q) have no common key", tt.query, tt.term)
+		}
+	}
+
+	if sharesKey(typoKeys("борщ"), typoKeys("пицца")) {
+		t.Error("unrelated words share a typo key")
+	}
+	if keys := typoKeys("су"); keys != nil {
+		t.Errorf("typoKeys() for short word = %v, want nil", keys)
+	}
+}
+
+func sharesKey(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestRestaurantSearchDocuments(t *testing.T) {
+	restaurant := &models.Restaurant{
+		ID:   primitive.NewObjectID(),
+		Name: "Пельменная",
+		Menu: []models.MenuItem{
+			{ID: "a", Name: "Пельмени"},
+			{ID: "a", Name: "Вареники"},
+			{Name: "Компот"},
+		},
+	}
+	docs := restaurantSearchDocuments(restaurant, time.Now())
+	if len(docs) != 4 {
+		t.Fatalf("restaurantSearchDocuments() returned %d documents, want 4", len(docs))
+	}
+
+	ids := map[string]bool{}
+	for _, doc := range docs {
+		if ids[doc.ID] {
+			t.Errorf("duplicate document ID %q", doc.ID)
+		}
+		ids[doc.ID] = true
+	}
+	if docs[1].MenuItemID != "a" || docs[2].MenuItemID != "a" {
+		t.Errorf("menu item IDs = %q, %q, want both \"a\"", docs[1].MenuItemID, docs[2].MenuItemID)
+	}
+	if docs[0].Kind != models.SearchKindRestaurant || docs[3].Kind != models.SearchKindDish {
+		t.Errorf("unexpected document kinds %q, %q", docs[0].Kind, docs[3].Kind)
+	}
+}
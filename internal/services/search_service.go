@@ -0,0 +1,157 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// JobSearchReindex тип периодической задачи полной перестройки поискового индекса
+const JobSearchReindex = "search.reindex"
+
+// SearchQuery параметры полнотекстового поиска
+type SearchQuery struct {
+	Text   string
+	Kinds  []string         // restaurant, dish; пусто - все
+	Origin *models.GeoPoint // точка пользователя для учета расстояния
+	Limit  int
+}
+
+// SearchService структура сервиса поиска по ресторанам и блюдам.
+// Текстовую релевантность считает SearchIndex, сервис добавляет учет рейтинга и расстояния.
+type SearchService struct {
+	db    *mongo.Database
+	index SearchIndex
+}
+
+// NewSearchService создает новый экземпляр SearchService
+func NewSearchService(client *mongo.Client, dbName string, index SearchIndex) *SearchService {
+	return &SearchService{
+		db:    client.Database(dbName),
+		index: index,
+	}
+}
+
+// Search выполняет поиск и ранжирует результаты с учетом рейтинга ресторана и расстояния до него
+func (s *SearchService) Search(ctx context.Context, query SearchQuery) ([]models.SearchHit, error) {
+	// Берем запас кандидатов, так как порядок меняется после учета рейтинга и расстояния
+	hits, err := s.index.Search(ctx, query.Text, query.Kinds, query.Limit*3)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return hits, nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.RestaurantID)
+	}
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}},
+		options.Find().SetProjection(bson.M{"rating": 1, "location": 1}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading restaurants for ranking failed")
+	}
+	var restaurants []models.RestaurantSummary
+	if err := cursor.All(ctx, &restaurants); err != nil {
+		return nil, errors.Wrap(err, "decoding restaurants for ranking failed")
+	}
+	byID := make(map[primitive.ObjectID]models.RestaurantSummary, len(restaurants))
+	for _, restaurant := range restaurants {
+		byID[restaurant.ID] = restaurant
+	}
+
+	for i := range hits {
+		restaurant := byID[hits[i].RestaurantID]
+		boost := 1.0
+		if restaurant.Rating != nil {
+			hits[i].Rating = restaurant.Rating.Average
+			boost += restaurant.Rating.Average / 10
+		}
+		if query.Origin != nil && restaurant.Location != nil {
+			distance := DistanceMeters(*query.Origin, *restaurant.Location)
+			hits[i].DistanceM = &distance
+			// Ближние рестораны получают до двукратного усиления, дальние - меньше
+			boost *= 1 + 1/(1+distance/2000)
+		}
+		hits[i].Score *= boost
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if len(hits) > query.Limit {
+		hits = hits[:query.Limit]
+	}
+	return hits, nil
+}
+
+// ReindexRestaurant обновляет ресторан в поисковом индексе
+func (s *SearchService) ReindexRestaurant(ctx context.Context, restaurantID primitive.ObjectID) error {
+	var restaurant models.Restaurant
+	err := s.db.Collection(EntityTypeRestaurant).FindOne(ctx, bson.M{"_id": restaurantID}).Decode(&restaurant)
+	if err == mongo.ErrNoDocuments {
+		return s.index.RemoveRestaurant(ctx, restaurantID)
+	}
+	if err != nil {
+		return errors.Wrap(err, "finding restaurant failed")
+	}
+	return s.index.IndexRestaurant(ctx, &restaurant)
+}
+
+// HandleRestaurantRegistered добавляет в индекс ресторан из события restaurant.registered,
+// не дожидаясь периодической перестройки
+func (s *SearchService) HandleRestaurantRegistered(ctx context.Context, event *models.DomainEvent) error {
+	var payload models.EntityRegisteredPayload
+	if err := event.DecodePayload(&payload); err != nil {
+		return errors.Wrap(err, "decoding registration event failed")
+	}
+	return s.ReindexRestaurant(ctx, payload.EntityID)
+}
+
+// ReindexAll перестраивает индекс по всем ресторанам и возвращает их количество.
+// Документы удаленных ресторанов убираются из индекса.
+func (s *SearchService) ReindexAll(ctx context.Context) (int, error) {
+	startedAt := time.Now()
+	cursor, err := s.db.Collection(EntityTypeRestaurant).Find(ctx, bson.M{},
+		options.Find().SetProjection(bson.M{"name": 1, "description": 1, "category": 1, "menu": 1, "banned": 1}),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "finding restaurants failed")
+	}
+	defer cursor.Close(ctx)
+
+	count := 0
+	for cursor.Next(ctx) {
+		var restaurant models.Restaurant
+		if err := cursor.Decode(&restaurant); err != nil {
+			return count, errors.Wrap(err, "decoding restaurant failed")
+		}
+		if err := s.index.IndexRestaurant(ctx, &restaurant); err != nil {
+			return count, err
+		}
+		count++
+	}
+	if err := cursor.Err(); err != nil {
+		return count, errors.Wrap(err, "iterating restaurants failed")
+	}
+	return count, s.index.Prune(ctx, startedAt)
+}
+
+// HandleReindexJob перестраивает индекс по задаче search.reindex
+func (s *SearchService) HandleReindexJob(ctx context.Context, job *models.Job) error {
+	count, err := s.ReindexAll(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Search index rebuilt for %d restaurants", count)
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 type EntityService struct {
 	db             *mongo.Database
 	entityCollName string
+	eventBus       *EventBus
 }
 
 const (
@@ -35,7 +36,8 @@ var SecretKey []byte
 
 func init() {
 	// Load environment variables from .env file
-	if err := godotenv.Load(".env"); err != nil {
+	// Без файла .env переменные берутся из окружения процесса
+	if err := godotenv.Load(".env"); err != nil && !os.IsNotExist(err) {
 		log.Fatal("Error loading .env file")
 	}
 
@@ -50,10 +52,11 @@ func init() {
 }
 
 // NewEntityService создает новый экземпляр EntityService
-func NewEntityService(client *mongo.Client, dbName, entityCollName string) *EntityService {
+func NewEntityService(client *mongo.Client, dbName, entityCollName string, eventBus *EventBus) *EntityService {
 	return &EntityService{
 		db:             client.Database(dbName),
 		entityCollName: entityCollName,
+		eventBus:       eventBus,
 	}
 }
 
@@ -115,6 +118,11 @@ func (s *EntityService) UpdateEntity(ctx context.Context, entityID string, entit
 		return err // не удалось преобразовать entityID в ObjectID
 	}
 
+	// Роли меняются только сервером; пустое значение не попадает в $set
+	if roles := reflect.ValueOf(entity).Elem().FieldByName("Roles"); roles.IsValid() && roles.CanSet() {
+		roles.SetString("")
+	}
+
 	// Обновление данных сущности
 	filter := bson.M{"_id": id}
 	update := bson.M{"$set": entity}
@@ -239,6 +247,21 @@ func (s *EntityService) AddFavoriteRestaurant(ctx context.Context, userID primit
 	return nil
 }
 
+// RemoveFavoriteRestaurant удаляет ресторан из списка избранных пользователя
+func (s *EntityService) RemoveFavoriteRestaurant(ctx context.Context, userID primitive.ObjectID, restaurantID primitive.ObjectID) error {
+	collection := s.db.Collection("users")
+
+	filter := bson.M{"_id": userID}
+	update := bson.M{"$pull": bson.M{"favorites": restaurantID}}
+
+	_, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return errors.Wrap(err, "removing favorite restaurant failed")
+	}
+
+	return nil
+}
+
 // GetFavoriteRestaurants возвращает список избранных ресторанов пользователя
 func (s *EntityService) GetFavoriteRestaurants(ctx context.Context, userID primitive.ObjectID) ([]models.Restaurant, error) {
 	userCollection := s.db.Collection("users")
@@ -0,0 +1,307 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"log"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const waitlistCollectionName = "waitlist"
+
+// Параметры оценки времени ожидания
+const (
+	waitlistHistoryWindow = 14 * 24 * time.Hour // учитываются рассадки за последние две недели
+	waitlistHistorySize   = 100                 // не более стольких последних рассадок
+)
+
+// NotificationWaitlistReady тип уведомления о готовности стола
+const NotificationWaitlistReady = "waitlist_ready"
+
+var (
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrAlreadyInWaitlist     = errors.New("user is already in this waitlist")
+	ErrWaitlistEntryState    = errors.New("waitlist entry cannot change from its current status")
+)
+
+// WaitlistService структура сервиса листа ожидания ресторанов
+type WaitlistService struct {
+	db                     *mongo.Database
+	notificationService    *NotificationService
+	defaultMinutesPerParty int
+}
+
+// NewWaitlistService создает новый экземпляр WaitlistService.
+// Без истории рассадок на каждую компанию впереди закладывается WAITLIST_MINUTES_PER_PARTY минут.
+func NewWaitlistService(client *mongo.Client, dbName string, notificationService *NotificationService) *WaitlistService {
+	return &WaitlistService{
+		db:                     client.Database(dbName),
+		notificationService:    notificationService,
+		defaultMinutesPerParty: envInt("WAITLIST_MINUTES_PER_PARTY", 10),
+	}
+}
+
+// EnsureIndexes создает индексы листа ожидания.
+// Пользователь может стоять в очереди ресторана только один раз.
+func (s *WaitlistService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(waitlistCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "active", Value: 1}, {Key: "created_at", Value: 1}}},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "seated_at", Value: -1}}},
+		{
+			Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true).SetPartialFilterExpression(bson.M{
+				"active":  true,
+				"user_id": bson.M{"$exists": true},
+			}),
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating waitlist indexes failed")
+	}
+	return nil
+}
+
+// Join ставит пользователя в очередь ресторана
+func (s *WaitlistService) Join(ctx context.Context, userID, restaurantID primitive.ObjectID, partySize int) (*models.WaitlistEntry, error) {
+	var user struct {
+		Name    string `bson:"name"`
+		Surname string `bson:"surname"`
+		Phone   string `bson:"phone"`
+	}
+	err := s.db.Collection(EntityTypeUser).FindOne(ctx, bson.M{"_id": userID},
+		options.FindOne().SetProjection(bson.M{"name": 1, "surname": 1, "phone": 1}),
+	).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrUserNotFound
+		}
+		return nil, errors.Wrap(err, "finding user failed")
+	}
+
+	return s.add(ctx, &models.WaitlistEntry{
+		RestaurantID: restaurantID,
+		UserID:       &userID,
+		Name:         user.Name + " " + user.Surname,
+		Phone:        user.Phone,
+		PartySize:    partySize,
+	})
+}
+
+// AddWalkIn добавляет в очередь гостей, пришедших без приложения
+func (s *WaitlistService) AddWalkIn(ctx context.Context, restaurantID primitive.ObjectID, name, phone string, partySize int) (*models.WaitlistEntry, error) {
+	return s.add(ctx, &models.WaitlistEntry{
+		RestaurantID: restaurantID,
+		Name:         name,
+		Phone:        phone,
+		PartySize:    partySize,
+	})
+}
+
+// GetEntry возвращает запись пользователя с текущей позицией и оценкой ожидания
+func (s *WaitlistService) GetEntry(ctx context.Context, userID, entryID primitive.ObjectID) (*models.WaitlistEntry, error) {
+	entry, err := s.find(ctx, bson.M{"_id": entryID, "user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	if !entry.Active {
+		return entry, nil
+	}
+
+	ahead, err := s.db.Collection(waitlistCollectionName).CountDocuments(ctx, bson.M{
+		"restaurant_id": entry.RestaurantID,
+		"active":        true,
+		"created_at":    bson.M{"$lt": entry.CreatedAt},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "counting waitlist position failed")
+	}
+	perParty, err := s.minutesPerParty(ctx, entry.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	s.estimate(entry, int(ahead), perParty)
+	return entry, nil
+}
+
+// Queue возвращает текущую очередь ресторана в порядке постановки
+func (s *WaitlistService) Queue(ctx context.Context, restaurantID primitive.ObjectID) ([]models.WaitlistEntry, error) {
+	cursor, err := s.db.Collection(waitlistCollectionName).Find(ctx,
+		bson.M{"restaurant_id": restaurantID, "active": true},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding waitlist failed")
+	}
+	entries := []models.WaitlistEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, errors.Wrap(err, "decoding waitlist failed")
+	}
+
+	perParty, err := s.minutesPerParty(ctx, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range entries {
+		s.estimate(&entries[i], i, perParty)
+	}
+	return entries, nil
+}
+
+// NotifyReady сообщает гостям, что стол готов
+func (s *WaitlistService) NotifyReady(ctx context.Context, restaurantID, entryID primitive.ObjectID) (*models.WaitlistEntry, error) {
+	entry, err := s.transition(ctx, bson.M{"_id": entryID, "restaurant_id": restaurantID},
+		bson.A{models.WaitlistStatusWaiting}, models.WaitlistStatusNotified, "notified_at", true)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.UserID != nil {
+		err := s.notificationService.Notify(ctx, []primitive.ObjectID{*entry.UserID}, NotificationWaitlistReady, "Ваш стол готов",
+			map[string]string{"waitlist_id": entry.ID.Hex(), "restaurant_id": restaurantID.Hex()})
+		if err != nil {
+			log.Printf("Failed to send %s notification: %v", NotificationWaitlistReady, err)
+		}
+	}
+	return entry, nil
+}
+
+// Seat отмечает, что гостей посадили за стол
+func (s *WaitlistService) Seat(ctx context.Context, restaurantID, entryID primitive.ObjectID) (*models.WaitlistEntry, error) {
+	return s.transition(ctx, bson.M{"_id": entryID, "restaurant_id": restaurantID},
+		bson.A{models.WaitlistStatusWaiting, models.WaitlistStatusNotified}, models.WaitlistStatusSeated, "seated_at", false)
+}
+
+// MarkLeft отмечает, что гости ушли из очереди ресторана
+func (s *WaitlistService) MarkLeft(ctx context.Context, restaurantID, entryID primitive.ObjectID) (*models.WaitlistEntry, error) {
+	return s.transition(ctx, bson.M{"_id": entryID, "restaurant_id": restaurantID},
+		bson.A{models.WaitlistStatusWaiting, models.WaitlistStatusNotified}, models.WaitlistStatusLeft, "left_at", false)
+}
+
+// Leave удаляет пользователя из очереди по его просьбе
+func (s *WaitlistService) Leave(ctx context.Context, userID, entryID primitive.ObjectID) (*models.WaitlistEntry, error) {
+	return s.transition(ctx, bson.M{"_id": entryID, "user_id": userID},
+		bson.A{models.WaitlistStatusWaiting, models.WaitlistStatusNotified}, models.WaitlistStatusLeft, "left_at", false)
+}
+
+// add вычисляет позицию и оценку ожидания и сохраняет запись
+func (s *WaitlistService) add(ctx context.Context, entry *models.WaitlistEntry) (*models.WaitlistEntry, error) {
+	if err := entry.Validate(); err != nil {
+		return nil, err
+	}
+	if err := ensureRestaurantExists(ctx, s.db, entry.RestaurantID); err != nil {
+		return nil, err
+	}
+
+	ahead, err := s.db.Collection(waitlistCollectionName).CountDocuments(ctx,
+		bson.M{"restaurant_id": entry.RestaurantID, "active": true})
+	if err != nil {
+		return nil, errors.Wrap(err, "counting waitlist failed")
+	}
+	perParty, err := s.minutesPerParty(ctx, entry.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.ID = primitive.NewObjectID()
+	entry.Status = models.WaitlistStatusWaiting
+	entry.Active = true
+	entry.PositionAtJoin = int(ahead)
+	entry.CreatedAt = time.Now()
+	s.estimate(entry, int(ahead), perParty)
+	entry.QuotedWaitMin = entry.EstimatedWaitMin
+
+	if _, err := s.db.Collection(waitlistCollectionName).InsertOne(ctx, entry); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrAlreadyInWaitlist
+		}
+		return nil, errors.Wrap(err, "inserting waitlist entry failed")
+	}
+	return entry, nil
+}
+
+// transition атомарно переводит запись в новый статус и проставляет время перехода
+func (s *WaitlistService) transition(ctx context.Context, filter bson.M, from bson.A, to, timeField string, active bool) (*models.WaitlistEntry, error) {
+	if _, err := s.find(ctx, filter); err != nil {
+		return nil, err
+	}
+
+	filter["status"] = bson.M{"$in": from}
+	var entry models.WaitlistEntry
+	err := s.db.Collection(waitlistCollectionName).FindOneAndUpdate(ctx, filter,
+		bson.M{"$set": bson.M{"status": to, "active": active, timeField: time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&entry)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWaitlistEntryState
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating waitlist entry failed")
+	}
+	return &entry, nil
+}
+
+// find возвращает запись листа ожидания по фильтру
+func (s *WaitlistService) find(ctx context.Context, filter bson.M) (*models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	err := s.db.Collection(waitlistCollectionName).FindOne(ctx, filter).Decode(&entry)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWaitlistEntryNotFound
+		}
+		return nil, errors.Wrap(err, "finding waitlist entry failed")
+	}
+	return &entry, nil
+}
+
+// minutesPerParty оценивает, сколько минут занимает обслуживание одной компании в очереди.
+// Оценка строится по недавним рассадкам: время ожидания делится на число компаний,
+// стоявших впереди в момент постановки, плюс сама компания.
+func (s *WaitlistService) minutesPerParty(ctx context.Context, restaurantID primitive.ObjectID) (float64, error) {
+	cursor, err := s.db.Collection(waitlistCollectionName).Find(ctx, bson.M{
+		"restaurant_id": restaurantID,
+		"status":        models.WaitlistStatusSeated,
+		"seated_at":     bson.M{"$gte": time.Now().Add(-waitlistHistoryWindow)},
+	}, options.Find().
+		SetSort(bson.D{{Key: "seated_at", Value: -1}}).
+		SetLimit(waitlistHistorySize).
+		SetProjection(bson.M{"created_at": 1, "notified_at": 1, "seated_at": 1, "position_at_join": 1}),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "loading waitlist history failed")
+	}
+	var history []models.WaitlistEntry
+	if err := cursor.All(ctx, &history); err != nil {
+		return 0, errors.Wrap(err, "decoding waitlist history failed")
+	}
+	if len(history) == 0 {
+		return float64(s.defaultMinutesPerParty), nil
+	}
+
+	total := 0.0
+	for _, entry := range history {
+		// Ожидание заканчивается приглашением к столу; если его не было - рассадкой
+		end := *entry.SeatedAt
+		if entry.NotifiedAt != nil {
+			end = *entry.NotifiedAt
+		}
+		total += end.Sub(entry.CreatedAt).Minutes() / float64(entry.PositionAtJoin+1)
+	}
+	return total / float64(len(history)), nil
+}
+
+// estimate заполняет позицию и оценку ожидания записи по числу компаний впереди
+func (s *WaitlistService) estimate(entry *models.WaitlistEntry, ahead int, perParty float64) {
+	entry.Position = ahead + 1
+	if entry.Status == models.WaitlistStatusNotified {
+		entry.EstimatedWaitMin = 0
+		return
+	}
+	entry.EstimatedWaitMin = int(math.Ceil(float64(ahead+1) * perParty))
+}
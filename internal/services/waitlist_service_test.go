@@ -0,0 +1,77 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/integration/mtest"
+)
+
+func TestWaitlistEstimate(t *testing.T) {
+	s := &WaitlistService{}
+
+	tests := []struct {
+		name     string
+		status   string
+		ahead    int
+		perParty float64
+		wantPos  int
+		wantWait int
+	}{
+		{name: "first in line", status: models.WaitlistStatusWaiting, ahead: 0, perParty: 10, wantPos: 1, wantWait: 10},
+		{name: "rounds up", status: models.WaitlistStatusWaiting, ahead: 2, perParty: 7.2, wantPos: 3, wantWait: 22},
+		{name: "notified waits no longer", status: models.WaitlistStatusNotified, ahead: 3, perParty: 10, wantPos: 4, wantWait: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &models.WaitlistEntry{Status: tt.status, EstimatedWaitMin: 99}
+			s.estimate(entry, tt.ahead, tt.perParty)
+			if entry.Position != tt.wantPos || entry.EstimatedWaitMin != tt.wantWait {
+				t.Errorf("estimate() = position %d, wait %d; want %d, %d", entry.Position, entry.EstimatedWaitMin, tt.wantPos, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestWaitlistMinutesPerParty(t *testing.T) {
+	mt := mtest.New(t, mtest.NewOptions().ClientType(mtest.Mock))
+	restaurantID := primitive.NewObjectID()
+	created := time.Date(2024, 5, 1, 19, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return created.Add(time.Duration(minutes) * time.Minute) }
+
+	mt.Run("no history uses default", func(mt *mtest.T) {
+		s := &WaitlistService{db: mt.DB, defaultMinutesPerParty: 12}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.waitlist", mtest.FirstBatch))
+
+		got, err := s.minutesPerParty(context.Background(), restaurantID)
+		if err != nil {
+			mt.Fatalf("minutesPerParty() error = %v", err)
+		}
+		if got != 12 {
+			mt.Errorf("minutesPerParty() = %v, want 12", got)
+		}
+	})
+
+	mt.Run("averages wait per party ahead", func(mt *mtest.T) {
+		s := &WaitlistService{db: mt.DB, defaultMinutesPerParty: 12}
+		mt.AddMockResponses(mtest.CreateCursorResponse(0, "db.waitlist", mtest.FirstBatch,
+			// 20 минут до рассадки при одной компании впереди - 10 минут на компанию
+			bson.D{{Key: "created_at", Value: created}, {Key: "seated_at", Value: at(20)}, {Key: "position_at_join", Value: 1}},
+			// приглашение через 6 минут первым в очереди считается концом ожидания
+			bson.D{{Key: "created_at", Value: created}, {Key: "notified_at", Value: at(6)}, {Key: "seated_at", Value: at(15)}, {Key: "position_at_join", Value: 0}},
+		))
+
+		got, err := s.minutesPerParty(context.Background(), restaurantID)
+		if err != nil {
+			mt.Fatalf("minutesPerParty() error = %v", err)
+		}
+		if got != 8 {
+			mt.Errorf("minutesPerParty() = %v, want 8", got)
+		}
+	})
+}
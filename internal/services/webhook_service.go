@@ -0,0 +1,715 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	webhooksCollectionName          = "webhooks"
+	webhookDeliveriesCollectionName = "webhook_deliveries"
+
+	// maxWebhooksPerRestaurant ограничивает число подписок ресторана
+	maxWebhooksPerRestaurant = 10
+	// webhookDeliveredRetention сколько хранится лог доставленных событий
+	webhookDeliveredRetention = 14 * 24 * time.Hour
+	// webhookDeadRetention сколько хранятся недоставленные события
+	webhookDeadRetention = 30 * 24 * time.Hour
+	// webhookHistoryLimit сколько последних попыток хранится в доставке
+	webhookHistoryLimit = 20
+	// webhookLease на сколько доставка закрепляется за отправителем
+	webhookLease = time.Minute
+	// webhookBatch сколько доставок отправляется за один тик
+	webhookBatch = 100
+	// webhookResponseLimit сколько байт тела ответа сохраняется в лог
+	webhookResponseLimit = 512
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookLimitReached     = errors.New("webhook limit reached")
+	ErrInvalidWebhookURL       = errors.New("webhook url must be https")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrAddressNotPublic        = errors.New("address is not public")
+)
+
+// WebhookService структура сервиса вебхуков ресторанов. События заказов сохраняются
+// в очередь webhook_deliveries и доставляются не менее одного раза: с повторами
+// по экспоненте, после исчерпания попыток доставка попадает в список недоставленных.
+type WebhookService struct {
+	db          *mongo.Database
+	client      *http.Client
+	maxAttempts int
+	retryBase   time.Duration
+	retryMax    time.Duration
+	allowHTTP   bool
+	concurrency int
+}
+
+// NewWebhookService создает новый экземпляр WebhookService.
+// WEBHOOK_ALLOW_INSECURE=true разрешает http и внутренние адреса для локальной разработки,
+// WEBHOOK_CONCURRENCY задает число одновременных доставок на реплике (по умолчанию 8).
+func NewWebhookService(client *mongo.Client, dbName string) *WebhookService {
+	insecure := os.Getenv("WEBHOOK_ALLOW_INSECURE") == "true"
+	return &WebhookService{
+		db:          client.Database(dbName),
+		client:      newPublicHTTPClient(insecure),
+		maxAttempts: envInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		retryBase:   time.Duration(envInt("WEBHOOK_RETRY_BASE_SECONDS", 30)) * time.Second,
+		retryMax:    6 * time.Hour,
+		allowHTTP:   insecure,
+		concurrency: max(envInt("WEBHOOK_CONCURRENCY", 8), 1),
+	}
+}
+
+// newPublicHTTPClient создает клиент для URL, заданных пользователями (вебхуки, push-подписки):
+// он не ходит по редиректам и, если не разрешено, не подключается к внутренним адресам,
+// чтобы через такой URL нельзя было достучаться до нашей сети
+func newPublicHTTPClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if allowPrivate {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return ErrAddressNotPublic
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: 10 * time.Second,
+			MaxIdleConnsPerHost:   4,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// nonPublicNetworks диапазоны специального назначения (RFC 6890 и реестры IANA), к которым
+// не подключается клиент для пользовательских URL. IPv4-mapped IPv6 адреса сверяются с диапазонами IPv4.
+var nonPublicNetworks = parseCIDRs(
+	"0.0.0.0/8",       // "этот" хост и сеть
+	"10.0.0.0/8",      // частная сеть
+	"100.64.0.0/10",   // CGNAT
+	"127.0.0.0/8",     // loopback
+	"169.254.0.0/16",  // link-local, в том числе метаданные облака
+	"172.16.0.0/12",   // частная сеть
+	"192.0.0.0/24",    // IETF protocol assignments
+	"192.0.2.0/24",    // документация TEST-NET-1
+	"192.88.99.0/24",  // 6to4 relay anycast
+	"192.168.0.0/16",  // частная сеть
+	"198.18.0.0/15",   // тестирование производительности
+	"198.51.100.0/24", // документация TEST-NET-2
+	"203.0.113.0/24",  // документация TEST-NET-3
+	"224.0.0.0/4",     // multicast
+	"240.0.0.0/4",     // зарезервировано и broadcast
+	"::/128",          // unspecified
+	"::1/128",         // loopback
+	"64:ff9b::/96",    // NAT64, ведет на IPv4 адреса
+	"64:ff9b:1::/48",  // локальный NAT64
+	"100::/64",        // discard-only
+	"2001::/23",       // IETF protocol assignments, в том числе Teredo
+	"2001:db8::/32",   // документация
+	"2002::/16",       // 6to4, ведет на IPv4 адреса
+	"fc00::/7",        // unique local
+	"fe80::/10",       // link-local
+	"fec0::/10",       // site-local
+	"ff00::/8",        // multicast
+)
+
+// parseCIDRs разбирает список диапазонов; ошибка в списке — ошибка программиста
+func parseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isPublicIP проверяет, что адрес не входит ни в один диапазон специального назначения
+func isPublicIP(ip net.IP) bool {
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// EnsureIndexes создает индексы вебхуков и очереди доставок
+func (s *WebhookService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.db.Collection(webhooksCollectionName).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "restaurant_id", Value: 1}},
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating webhook indexes failed")
+	}
+	_, err = s.db.Collection(webhookDeliveriesCollectionName).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			// Повторная обработка того же события заказа не ставит его в очередь дважды
+			Keys:    bson.D{{Key: "webhook_id", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "restaurant_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{
+			Keys:    bson.D{{Key: "delivered_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookDeliveredRetention.Seconds())),
+		},
+		{
+			Keys:    bson.D{{Key: "dead_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(int32(webhookDeadRetention.Seconds())),
+		},
+	})
+	return errors.Wrap(err, "creating webhook delivery indexes failed")
+}
+
+// Create создает вебхук ресторана. Если секрет не передан, он генерируется.
+func (s *WebhookService) Create(ctx context.Context, restaurantID primitive.ObjectID, webhook *models.Webhook) (*models.Webhook, error) {
+	if webhook.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		webhook.Secret = secret
+	}
+	if err := s.check(webhook); err != nil {
+		return nil, err
+	}
+
+	count, err := s.db.Collection(webhooksCollectionName).CountDocuments(ctx, bson.M{"restaurant_id": restaurantID})
+	if err != nil {
+		return nil, errors.Wrap(err, "counting webhooks failed")
+	}
+	if count >= maxWebhooksPerRestaurant {
+		return nil, ErrWebhookLimitReached
+	}
+
+	now := time.Now()
+	webhook.ID = primitive.NewObjectID()
+	webhook.RestaurantID = restaurantID
+	webhook.CreatedAt = now
+	webhook.UpdatedAt = now
+	if _, err := s.db.Collection(webhooksCollectionName).InsertOne(ctx, webhook); err != nil {
+		return nil, errors.Wrap(err, "inserting webhook failed")
+	}
+	return webhook, nil
+}
+
+// List возвращает вебхуки ресторана без секретов
+func (s *WebhookService) List(ctx context.Context, restaurantID primitive.ObjectID) ([]models.Webhook, error) {
+	cursor, err := s.db.Collection(webhooksCollectionName).Find(ctx, bson.M{"restaurant_id": restaurantID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}))
+	if err != nil {
+		return nil, errors.Wrap(err, "finding webhooks failed")
+	}
+	webhooks := []models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, errors.Wrap(err, "decoding webhooks failed")
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// Get возвращает вебхук ресторана без секрета
+func (s *WebhookService) Get(ctx context.Context, restaurantID, webhookID primitive.ObjectID) (*models.Webhook, error) {
+	webhook, err := s.load(ctx, bson.M{"_id": webhookID, "restaurant_id": restaurantID})
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// Update изменяет адрес, события, описание и активность вебхука; секрет меняется через RotateSecret
+func (s *WebhookService) Update(ctx context.Context, restaurantID, webhookID primitive.ObjectID, webhook *models.Webhook) (*models.Webhook, error) {
+	webhook.Secret = ""
+	if err := s.check(webhook); err != nil {
+		return nil, err
+	}
+
+	var updated models.Webhook
+	err := s.db.Collection(webhooksCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": webhookID, "restaurant_id": restaurantID},
+		bson.M{"$set": bson.M{
+			"url":         webhook.URL,
+			"events":      webhook.Events,
+			"description": webhook.Description,
+			"active":      webhook.Active,
+			"updated_at":  time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "updating webhook failed")
+	}
+	updated.Secret = ""
+	return &updated, nil
+}
+
+// RotateSecret выдает вебхуку новый секрет; старые подписи перестают проходить проверку сразу
+func (s *WebhookService) RotateSecret(ctx context.Context, restaurantID, webhookID primitive.ObjectID) (*models.Webhook, error) {
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var updated models.Webhook
+	err = s.db.Collection(webhooksCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": webhookID, "restaurant_id": restaurantID},
+		bson.M{"$set": bson.M{"secret": secret, "updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "rotating webhook secret failed")
+	}
+	return &updated, nil
+}
+
+// Delete удаляет вебхук; его недоставленные события переходят в список недоставленных
+func (s *WebhookService) Delete(ctx context.Context, restaurantID, webhookID primitive.ObjectID) error {
+	result, err := s.db.Collection(webhooksCollectionName).DeleteOne(ctx, bson.M{"_id": webhookID, "restaurant_id": restaurantID})
+	if err != nil {
+		return errors.Wrap(err, "deleting webhook failed")
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+
+	now := time.Now()
+	_, err = s.db.Collection(webhookDeliveriesCollectionName).UpdateMany(ctx,
+		bson.M{"webhook_id": webhookID, "status": bson.M{"$in": bson.A{models.WebhookDeliveryPending, models.WebhookDeliverySending}}},
+		bson.M{
+			"$set":   bson.M{"status": models.WebhookDeliveryDead, "dead_at": now, "last_error": "webhook deleted"},
+			"$unset": bson.M{"locked_until": ""},
+		},
+	)
+	return errors.Wrap(err, "closing webhook deliveries failed")
+}
+
+// Enqueue ставит событие заказа в очередь для всех активных вебхуков ресторана,
+// подписанных на его тип. order может быть nil, если заказ не загружался.
+func (s *WebhookService) Enqueue(ctx context.Context, event *models.OrderEvent, order *models.Order) error {
+	cursor, err := s.db.Collection(webhooksCollectionName).Find(ctx, bson.M{
+		"restaurant_id": event.RestaurantID,
+		"active":        true,
+		"events":        event.Type,
+	})
+	if err != nil {
+		return errors.Wrap(err, "finding webhooks failed")
+	}
+	var webhooks []models.Webhook
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return errors.Wrap(err, "decoding webhooks failed")
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	envelope := models.WebhookEnvelope{
+		ID:        webhookEventID(event),
+		Type:      event.Type,
+		CreatedAt: now,
+		Data: models.WebhookOrderData{
+			OrderID:        event.OrderID,
+			Status:         event.Status,
+			PreviousStatus: event.PreviousStatus,
+			At:             event.At,
+			Order:          order,
+		},
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return errors.Wrap(err, "encoding webhook payload failed")
+	}
+
+	docs := make([]interface{}, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		docs = append(docs, models.WebhookDelivery{
+			ID:            primitive.NewObjectID(),
+			WebhookID:     webhook.ID,
+			RestaurantID:  webhook.RestaurantID,
+			EventID:       envelope.ID,
+			EventType:     envelope.Type,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			History:       []models.WebhookAttempt{},
+			CreatedAt:     now,
+		})
+	}
+	_, err = s.db.Collection(webhookDeliveriesCollectionName).InsertMany(ctx, docs, options.InsertMany().SetOrdered(false))
+	if err != nil && !onlyDuplicateKeys(err) {
+		return errors.Wrap(err, "enqueueing webhook deliveries failed")
+	}
+	return nil
+}
+
+// webhookEventID возвращает ID события для получателей вебхука. ID выводится из самого события
+// заказа, поэтому повторная обработка события (например, повторная доставка order.placed)
+// дает тот же ID, и получатель может отбросить дубль.
+func webhookEventID(event *models.OrderEvent) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		event.Type,
+		event.OrderID,
+		event.Status,
+		event.PreviousStatus,
+		strconv.FormatInt(event.At.UnixMilli(), 10),
+	}, "|")))
+	return hex.EncodeToString(sum[:12])
+}
+
+// onlyDuplicateKeys проверяет, что неупорядоченная вставка отклонила только дубли
+func onlyDuplicateKeys(err error) bool {
+	var bulkErr mongo.BulkWriteException
+	if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+		return false
+	}
+	for _, writeErr := range bulkErr.WriteErrors {
+		if !mongo.IsDuplicateKeyError(writeErr) {
+			return false
+		}
+	}
+	return true
+}
+
+// SendTest сразу отправляет вебхуку тестовое событие и возвращает результат попытки.
+// Тестовое событие не повторяется, но попадает в лог доставок.
+func (s *WebhookService) SendTest(ctx context.Context, restaurantID, webhookID primitive.ObjectID) (*models.WebhookDelivery, error) {
+	webhook, err := s.load(ctx, bson.M{"_id": webhookID, "restaurant_id": restaurantID})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	envelope := models.WebhookEnvelope{
+		ID:        primitive.NewObjectID().Hex(),
+		Type:      models.WebhookEventTest,
+		CreatedAt: now,
+		Data:      map[string]string{"webhook_id": webhook.ID.Hex(), "message": "test event"},
+	}
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding webhook payload failed")
+	}
+
+	delivery := &models.WebhookDelivery{
+		ID:            primitive.NewObjectID(),
+		WebhookID:     webhook.ID,
+		RestaurantID:  restaurantID,
+		EventID:       envelope.ID,
+		EventType:     envelope.Type,
+		Payload:       string(payload),
+		Attempts:      1,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	attempt := s.attempt(ctx, webhook, delivery)
+	delivery.History = []models.WebhookAttempt{attempt}
+	if attempt.Error == "" {
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &attempt.At
+	} else {
+		delivery.Status = models.WebhookDeliveryDead
+		delivery.DeadAt = &attempt.At
+		delivery.LastError = attempt.Error
+	}
+
+	if _, err := s.db.Collection(webhookDeliveriesCollectionName).InsertOne(ctx, delivery); err != nil {
+		return nil, errors.Wrap(err, "saving test delivery failed")
+	}
+	return delivery, nil
+}
+
+// Deliveries возвращает лог доставок ресторана, новые первыми. Пустой webhookID
+// не фильтрует по вебхуку, пустой status — по статусу.
+func (s *WebhookService) Deliveries(ctx context.Context, restaurantID primitive.ObjectID, webhookID *primitive.ObjectID, status string, page, limit int) ([]models.WebhookDelivery, error) {
+	filter := bson.M{"restaurant_id": restaurantID}
+	if webhookID != nil {
+		filter["webhook_id"] = *webhookID
+	}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	cursor, err := s.db.Collection(webhookDeliveriesCollectionName).Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*limit)).
+		SetLimit(int64(limit)),
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "finding webhook deliveries failed")
+	}
+	deliveries := []models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, errors.Wrap(err, "decoding webhook deliveries failed")
+	}
+	return deliveries, nil
+}
+
+// Redeliver возвращает недоставленное событие в очередь с новым счетчиком попыток
+func (s *WebhookService) Redeliver(ctx context.Context, restaurantID, deliveryID primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := s.db.Collection(webhookDeliveriesCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"_id": deliveryID, "restaurant_id": restaurantID, "status": models.WebhookDeliveryDead},
+		bson.M{
+			"$set":   bson.M{"status": models.WebhookDeliveryPending, "attempts": 0, "next_attempt_at": time.Now()},
+			"$unset": bson.M{"dead_at": ""},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "requeueing webhook delivery failed")
+	}
+	return &delivery, nil
+}
+
+// RunDeliveries периодически отправляет события из очереди, пока не будет отменен ctx
+func (s *WebhookService) RunDeliveries(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.deliverBatch(ctx)
+		}
+	}
+}
+
+// deliverBatch отправляет до webhookBatch готовых доставок силами concurrency отправителей,
+// чтобы медленный получатель не задерживал остальные доставки
+func (s *WebhookService) deliverBatch(ctx context.Context) {
+	var claimed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < s.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for claimed.Add(1) <= webhookBatch && s.sendNext(ctx) {
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// sendNext забирает и отправляет одну готовую доставку; false, если отправлять нечего
+func (s *WebhookService) sendNext(ctx context.Context) bool {
+	now := time.Now()
+	var delivery models.WebhookDelivery
+	err := s.db.Collection(webhookDeliveriesCollectionName).FindOneAndUpdate(ctx,
+		bson.M{"$or": bson.A{
+			bson.M{"status": models.WebhookDeliveryPending, "next_attempt_at": bson.M{"$lte": now}},
+			bson.M{"status": models.WebhookDeliverySending, "locked_until": bson.M{"$lte": now}},
+		}},
+		bson.M{
+			"$set": bson.M{"status": models.WebhookDeliverySending, "locked_until": now.Add(webhookLease)},
+			"$inc": bson.M{"attempts": 1},
+		},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "next_attempt_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&delivery)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			log.Printf("Failed to claim webhook delivery: %v", err)
+		}
+		return false
+	}
+
+	webhook, err := s.load(ctx, bson.M{"_id": delivery.WebhookID})
+	var attempt models.WebhookAttempt
+	switch {
+	case err != nil:
+		attempt = models.WebhookAttempt{At: time.Now(), Error: err.Error()}
+	case !webhook.Active:
+		attempt = models.WebhookAttempt{At: time.Now(), Error: "webhook is disabled"}
+	default:
+		attempt = s.attempt(ctx, webhook, &delivery)
+	}
+	// Без вебхука повторять нечего: доставка сразу уходит в недоставленные
+	final := err == ErrWebhookNotFound || (err == nil && !webhook.Active) || attempt.StatusCode == http.StatusGone
+	if attempt.StatusCode == http.StatusGone {
+		s.deactivate(ctx, webhook.ID)
+	}
+
+	if err := s.complete(ctx, &delivery, attempt, final); err != nil {
+		log.Printf("Failed to update webhook delivery %s: %v", delivery.ID.Hex(), err)
+	}
+	return true
+}
+
+// attempt выполняет одну подписанную попытку доставки
+func (s *WebhookService) attempt(ctx context.Context, webhook *models.Webhook, delivery *models.WebhookDelivery) models.WebhookAttempt {
+	started := time.Now()
+	attempt := models.WebhookAttempt{At: started}
+
+	timestamp := strconv.FormatInt(started.Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FoodWebhooks/1.0")
+	req.Header.Set("X-Webhook-Id", delivery.EventID)
+	req.Header.Set("X-Webhook-Delivery", delivery.ID.Hex())
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Signature", "t="+timestamp+",v1="+SignWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		attempt.DurationMs = time.Since(started).Milliseconds()
+		return attempt
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+
+	attempt.StatusCode = resp.StatusCode
+	attempt.Response = strings.ToValidUTF8(string(body), "")
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = "unexpected status " + strconv.Itoa(resp.StatusCode)
+	}
+	attempt.DurationMs = time.Since(started).Milliseconds()
+	return attempt
+}
+
+// complete сохраняет результат попытки: успех, повтор по расписанию или список недоставленных
+func (s *WebhookService) complete(ctx context.Context, delivery *models.WebhookDelivery, attempt models.WebhookAttempt, final bool) error {
+	set := bson.M{}
+	switch {
+	case attempt.Error == "":
+		set["status"] = models.WebhookDeliveryDelivered
+		set["delivered_at"] = attempt.At
+	case final || delivery.Attempts >= s.maxAttempts:
+		set["status"] = models.WebhookDeliveryDead
+		set["dead_at"] = attempt.At
+		set["last_error"] = attempt.Error
+	default:
+		set["status"] = models.WebhookDeliveryPending
+		set["next_attempt_at"] = time.Now().Add(retryDelay(s.retryBase, s.retryMax, delivery.Attempts))
+		set["last_error"] = attempt.Error
+	}
+
+	_, err := s.db.Collection(webhookDeliveriesCollectionName).UpdateOne(ctx,
+		bson.M{"_id": delivery.ID, "status": models.WebhookDeliverySending},
+		bson.M{
+			"$set":   set,
+			"$unset": bson.M{"locked_until": ""},
+			"$push":  bson.M{"history": bson.M{"$each": bson.A{attempt}, "$slice": -webhookHistoryLimit}},
+		},
+	)
+	return errors.Wrap(err, "saving webhook attempt failed")
+}
+
+// deactivate отключает вебхук, получатель которого ответил 410 Gone
+func (s *WebhookService) deactivate(ctx context.Context, webhookID primitive.ObjectID) {
+	_, err := s.db.Collection(webhooksCollectionName).UpdateOne(ctx, bson.M{"_id": webhookID},
+		bson.M{"$set": bson.M{"active": false, "updated_at": time.Now()}})
+	if err != nil {
+		log.Printf("Failed to deactivate webhook %s: %v", webhookID.Hex(), err)
+	}
+}
+
+// check проверяет вебхук перед сохранением
+func (s *WebhookService) check(webhook *models.Webhook) error {
+	if err := webhook.Validate(); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if parsed.Scheme != "https" && !(s.allowHTTP && parsed.Scheme == "http") {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+// load возвращает вебхук по фильтру
+func (s *WebhookService) load(ctx context.Context, filter bson.M) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := s.db.Collection(webhooksCollectionName).FindOne(ctx, filter).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, errors.Wrap(err, "finding webhook failed")
+	}
+	return &webhook, nil
+}
+
+// SignWebhookPayload возвращает HMAC-SHA256 от "timestamp.payload" в hex.
+// Получатель считает ту же подпись своим секретом и сравнивает с v1 из X-Webhook-Signature;
+// timestamp позволяет отбросить старые перехваченные запросы.
+func SignWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// newWebhookSecret генерирует секрет вебхука
+func newWebhookSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errors.Wrap(err, "generating webhook secret failed")
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(raw), nil
+}
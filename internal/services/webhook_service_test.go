@@ -0,0 +1,230 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestIsPublicIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "8.8.8.8", want: true},
+		{ip: "100.63.255.255", want: true},
+		{ip: "100.128.0.0", want: true},
+		{ip: "198.20.0.1", want: true},
+		{ip: "2606:4700:4700::1111", want: true},
+		{ip: "0.0.0.0"},
+		{ip: "0.1.2.3"},
+		{ip: "10.1.2.3"},
+		{ip: "100.64.0.1"},
+		{ip: "100.127.255.254"},
+		{ip: "127.0.0.1"},
+		{ip: "169.254.169.254"},
+		{ip: "172.31.255.255"},
+		{ip: "192.0.0.170"},
+		{ip: "192.168.1.1"},
+		{ip: "198.18.0.1"},
+		{ip: "198.19.255.255"},
+		{ip: "203.0.113.5"},
+		{ip: "224.0.0.1"},
+		{ip: "255.255.255.255"},
+		{ip: "::"},
+		{ip: "::1"},
+		{ip: "::ffff:127.0.0.1"},
+		{ip: "::ffff:100.64.0.1"},
+		{ip: "64:ff9b::a00:1"},
+		{ip: "2001:0:4136:e378::1"},
+		{ip: "2002:a00:1::1"},
+		{ip: "fd00::1"},
+		{ip: "fe80::1"},
+		{ip: "ff02::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := isPublicIP(net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("isPublicIP(%s) = %v, want %v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookEventID(t *testing.T) {
+	at := time.Date(2024, 5, 10, 19, 0, 0, 123456789, time.UTC)
+	base := models.OrderEvent{
+		ID:             42,
+		Type:           models.OrderEventStatusChanged,
+		OrderID:        "order-1",
+		RestaurantID:   primitive.NewObjectID(),
+		Status:         models.OrderStatusConfirmed,
+		PreviousStatus: models.OrderStatusPending,
+		At:             at,
+	}
+	want := webhookEventID(&base)
+	if len(want) != 24 {
+		t.Fatalf("webhookEventID() = %q, want 24 hex characters", want)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(event *models.OrderEvent)
+		same   bool
+	}{
+		{name: "same event", modify: func(event *models.OrderEvent) {}, same: true},
+		{name: "different stream id", modify: func(event *models.OrderEvent) { event.ID = 43 }, same: true},
+		{name: "time stored with millisecond precision", modify: func(event *models.OrderEvent) { event.At = at.Truncate(time.Millisecond) }, same: true},
+		{name: "different order", modify: func(event *models.OrderEvent) { event.OrderID = "order-2" }},
+		{name: "different status", modify: func(event *models.OrderEvent) { event.Status = models.OrderStatusCancelled }},
+		{name: "different type", modify: func(event *models.OrderEvent) { event.Type = models.OrderEventDelivery }},
+		{name: "later transition", modify: func(event *models.OrderEvent) { event.At = at.Add(time.Second) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := base
+			tt.modify(&event)
+			if got := webhookEventID(&event); (got == want) != tt.same {
+				t.Errorf("webhookEventID() = %q, base %q, want same = %v", got, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestOnlyDuplicateKeys(t *testing.T) {
+	duplicate := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 11000, Message: "E11000 duplicate key error"}}
+	other := mongo.BulkWriteError{WriteError: mongo.WriteError{Code: 121, Message: "document failed validation"}}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "duplicates only", err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, duplicate}}, want: true},
+		{name: "mixed errors", err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{duplicate, other}}},
+		{name: "write concern", err: mongo.BulkWriteException{WriteConcernError: &mongo.WriteConcernError{Code: 64}}},
+		{name: "not a bulk error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := onlyDuplicateKeys(tt.err); got != tt.want {
+				t.Errorf("onlyDuplicateKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   string
+		want      string
+	}{
+		{
+			name:      "order event",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			payload:   `{"id":"evt_1","type":"order_created"}`,
+			want:      "a9a76bb6f8da8150320b30f1a4759ff290ece9f7b8de44ca7e3175ba6d3b45bb",
+		},
+		{
+			name:      "empty payload",
+			secret:    "whsec_test",
+			timestamp: "1700000000",
+			want:      "5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc",
+		},
+		{
+			name:      "empty secret",
+			timestamp: "1700000000",
+			payload:   "{}",
+			want:      "a9dc44c8eda3de70e9cbf3e488895f1abc26acb1461d3124a3cb886af35251cf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhookPayload(tt.secret, tt.timestamp, tt.payload); got != tt.want {
+				t.Errorf("SignWebhookPayload() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Разделитель не дает сдвинуть цифры метки времени в тело
+	if SignWebhookPayload("s", "17", "00.{}") == SignWebhookPayload("s", "1700", ".{}") {
+		t.Errorf("signature does not separate timestamp and payload")
+	}
+}
+
+func TestWebhookAttemptSignsRequest(t *testing.T) {
+	secret := "whsec_test"
+	payload := `{"id":"evt_1","type":"order_created"}`
+	var header http.Header
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s := &WebhookService{client: newPublicHTTPClient(true)}
+	delivery := &models.WebhookDelivery{ID: primitive.NewObjectID(), EventID: "evt_1", EventType: models.OrderEventCreated, Payload: payload}
+	attempt := s.attempt(context.Background(), &models.Webhook{URL: server.URL, Secret: secret}, delivery)
+	if attempt.Error != "" || attempt.StatusCode != http.StatusNoContent {
+		t.Fatalf("attempt = %+v, want delivered", attempt)
+	}
+	if string(body) != payload {
+		t.Errorf("body = %s, want %s", body, payload)
+	}
+	if header.Get("X-Webhook-Id") != "evt_1" || header.Get("X-Webhook-Delivery") != delivery.ID.Hex() {
+		t.Errorf("unexpected webhook headers: %v", header)
+	}
+
+	var timestamp, signature string
+	for _, part := range strings.Split(header.Get("X-Webhook-Signature"), ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp != strconv.FormatInt(attempt.At.Unix(), 10) {
+		t.Errorf("signature timestamp = %q, want %d", timestamp, attempt.At.Unix())
+	}
+	if signature != SignWebhookPayload(secret, timestamp, payload) {
+		t.Errorf("signature %q does not verify", signature)
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	s := &WebhookService{client: newPublicHTTPClient(false)}
+	delivery := &models.WebhookDelivery{ID: primitive.NewObjectID(), Payload: "{}"}
+	attempt := s.attempt(context.Background(), &models.Webhook{URL: server.URL, Secret: "s"}, delivery)
+	if attempt.StatusCode != 0 || !strings.Contains(attempt.Error, ErrAddressNotPublic.Error()) {
+		t.Fatalf("attempt = %+v, want refused connection", attempt)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+// webPushRecordSize размер записи aes128gcm; сообщение всегда помещается в одну запись
+const webPushRecordSize = 4096
+
+// WebPushChannel отправляет web-push сообщения с шифрованием aes128gcm (RFC 8291)
+// и авторизацией сервера приложения по VAPID (RFC 8292)
+type WebPushChannel struct {
+	publicKey  string // base64url несжатой точки P-256, ее же получает браузер в applicationServerKey
+	privateKey *ecdsa.PrivateKey
+	subject    string
+	client     *http.Client
+}
+
+// NewWebPushChannel создает push-канал из ключей VAPID в base64url
+func NewWebPushChannel(publicKey, privateKey, subject string) (*WebPushChannel, error) {
+	if publicKey == "" || privateKey == "" || subject == "" {
+		return nil, errors.New("VAPID_PUBLIC_KEY, VAPID_PRIVATE_KEY and VAPID_SUBJECT are required for webpush driver")
+	}
+	rawPrivate, err := decodeBase64URL(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding VAPID private key failed")
+	}
+	ecdhKey, err := ecdh.P256().NewPrivateKey(rawPrivate)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing VAPID private key failed")
+	}
+	point := ecdhKey.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(point) != strings.TrimRight(publicKey, "=") {
+		return nil, errors.New("VAPID public key does not match private key")
+	}
+
+	return &WebPushChannel{
+		publicKey: base64.RawURLEncoding.EncodeToString(point),
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(point[1:33]),
+				Y:     new(big.Int).SetBytes(point[33:65]),
+			},
+			D: new(big.Int).SetBytes(rawPrivate),
+		},
+		subject: subject,
+		// Endpoint приходит от браузера пользователя, поэтому внутренние адреса и редиректы запрещены
+		client: newPublicHTTPClient(false),
+	}, nil
+}
+
+// PublicKey возвращает ключ сервера приложения для подписки в браузере
+func (c *WebPushChannel) PublicKey() string {
+	return c.publicKey
+}
+
+// Send шифрует и отправляет сообщение в push-сервис браузера
+func (c *WebPushChannel) Send(ctx context.Context, msg *models.OutboxMessage) error {
+	if msg.PushKeys == nil {
+		return permanent(errors.New("push subscription keys are missing"))
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"title": msg.Subject,
+		"body":  msg.Body,
+		"type":  msg.Type,
+		"data":  msg.Data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "encoding push payload failed")
+	}
+	body, err := encryptWebPush(payload, msg.PushKeys)
+	if err != nil {
+		return permanent(err)
+	}
+	authorization, err := c.vapidAuthorization(msg.To)
+	if err != nil {
+		return permanent(err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, msg.To, bytes.NewReader(body))
+	if err != nil {
+		return permanent(errors.Wrap(err, "building push request failed"))
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", "86400")
+	req.Header.Set("Urgency", "normal")
+	req.Header.Set("Authorization", authorization)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrAddressNotPublic) {
+			return permanent(err)
+		}
+		return errors.Wrap(err, "push request failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone {
+		return ErrPushSubscriptionGone
+	}
+	return deliveryStatusError("push service", resp)
+}
+
+// vapidAuthorization подписывает JWT для origin push-сервиса
+func (c *WebPushChannel) vapidAuthorization(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return "", errors.New("push endpoint must be an https URL")
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": c.subject,
+	}).SignedString(c.privateKey)
+	if err != nil {
+		return "", errors.Wrap(err, "signing VAPID token failed")
+	}
+	return "vapid t=" + token + ", k=" + c.publicKey, nil
+}
+
+// encryptWebPush шифрует сообщение для подписки по схеме aes128gcm из RFC 8291
+func encryptWebPush(plaintext []byte, keys *models.PushKeys) ([]byte, error) {
+	rawReceiver, err := decodeBase64URL(keys.P256dh)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding p256dh failed")
+	}
+	receiver, err := ecdh.P256().NewPublicKey(rawReceiver)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing p256dh failed")
+	}
+	authSecret, err := decodeBase64URL(keys.Auth)
+	if err != nil || len(authSecret) != 16 {
+		return nil, errors.New("auth secret must be 16 bytes")
+	}
+	if len(plaintext)+1+aes.BlockSize > webPushRecordSize {
+		return nil, errors.New("push payload is too large")
+	}
+
+	ephemeral, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "generating ephemeral key failed")
+	}
+	shared, err := ephemeral.ECDH(receiver)
+	if err != nil {
+		return nil, errors.Wrap(err, "computing shared secret failed")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errors.Wrap(err, "generating salt failed")
+	}
+	senderPublic := ephemeral.PublicKey().Bytes()
+
+	keyInfo := append([]byte("WebPush: info\x00"), rawReceiver...)
+	keyInfo = append(keyInfo, senderPublic...)
+	ikm := hkdfExpand(hmacSHA256(authSecret, shared), keyInfo, 32)
+	prk := hmacSHA256(salt, ikm)
+	cek := hkdfExpand(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdfExpand(prk, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating cipher failed")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating gcm failed")
+	}
+	// 0x02 отмечает последнюю запись, дополнение не используется
+	record := gcm.Seal(nil, nonce, append(plaintext, 0x02), nil)
+
+	header := make([]byte, 0, 16+4+1+len(senderPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(senderPublic)))
+	header = append(header, senderPublic...)
+	return append(header, record...), nil
+}
+
+// hmacSHA256 вычисляет HMAC-SHA-256, он же шаг extract из HKDF
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// hkdfExpand шаг expand из HKDF для длины не больше одного блока SHA-256
+func hkdfExpand(prk, info []byte, length int) []byte {
+	return hmacSHA256(prk, append(append([]byte{}, info...), 0x01))[:length]
+}
+
+// decodeBase64URL декодирует base64url с выравниванием или без него
+func decodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
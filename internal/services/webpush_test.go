@@ -0,0 +1,60 @@
+package services
+
+import (
+	"awesomeProject/internal/models"
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestWebPushRejectsPrivateEndpoints(t *testing.T) {
+	vapid, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel, err := NewWebPushChannel(
+		base64.RawURLEncoding.EncodeToString(vapid.PublicKey().Bytes()),
+		base64.RawURLEncoding.EncodeToString(vapid.Bytes()),
+		"mailto:push@example.com",
+	)
+	if err != nil {
+		t.Fatalf("NewWebPushChannel() error: %v", err)
+	}
+	browser, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	_, _ = rand.Read(auth)
+	keys := &models.PushKeys{
+		P256dh: base64.RawURLEncoding.EncodeToString(browser.PublicKey().Bytes()),
+		Auth:   base64.RawURLEncoding.EncodeToString(auth),
+	}
+
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	err = channel.Send(context.Background(), &models.OutboxMessage{
+		Type:     NotificationFriendRequest,
+		To:       server.URL + "/push/1",
+		Subject:  "Title",
+		PushKeys: keys,
+	})
+	if errors.Cause(err) != ErrPermanentDelivery || !strings.Contains(err.Error(), ErrAddressNotPublic.Error()) {
+		t.Fatalf("Send() error = %v, want permanent %v", err, ErrAddressNotPublic)
+	}
+	if requests != 0 {
+		t.Fatalf("push service received %d requests", requests)
+	}
+}